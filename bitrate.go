@@ -0,0 +1,158 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains EstimateBitrates, which measures the average bitrate of
+// each track.
+package matroska
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// EstimateBitrates returns the average bitrate of each track, in bits per
+// second, measured over the whole file.
+//
+// The size of the blocks of each track, as stored in the file, is summed and
+// divided by the Duration of the SegmentInfo, or, if the file declares none,
+// by the time spanned by the blocks. This reads every block of the file; see
+// EstimateBitratesSampled for a faster estimate. The read position is
+// restored afterwards.
+//
+// Example:
+//
+//	bitrates, err := demuxer.EstimateBitrates()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for track, bitrate := range bitrates {
+//	    fmt.Printf("Track %d: %.0f kb/s\n", track, bitrate/1000)
+//	}
+//
+// Returns:
+//   - map[uint64]float64: The bitrate of each track, indexed by track number.
+//     Tracks without blocks have a bitrate of 0.
+//   - error: An error if the demuxer avoids seeks, no duration is known, or a
+//     packet could not be read.
+func (d *Demuxer) EstimateBitrates() (map[uint64]float64, error) {
+	d.lock()
+	defer d.unlock()
+	return d.parser.estimateBitrates(0)
+}
+
+// EstimateBitratesSampled returns the average bitrate of each track, in bits
+// per second, measured over the first clusters of the file.
+//
+// Only the blocks of the first maxClusters clusters are read, and their size
+// is divided by the time they span, from the earliest start time to the
+// latest end time. This is much faster than EstimateBitrates on large files,
+// but less accurate for variable bitrate streams. If the file has no more
+// than maxClusters clusters, the result is that of EstimateBitrates.
+//
+// Example:
+//
+//	bitrates, err := demuxer.EstimateBitratesSampled(20)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - maxClusters: The number of clusters to read, which must be positive.
+//
+// Returns:
+//   - map[uint64]float64: The bitrate of each track, indexed by track number.
+//   - error: An error if maxClusters is not positive, the demuxer avoids
+//     seeks, the sampled blocks span no time, or a packet could not be read.
+func (d *Demuxer) EstimateBitratesSampled(maxClusters int) (map[uint64]float64, error) {
+	if maxClusters <= 0 {
+		return nil, fmt.Errorf("invalid number of clusters: %d", maxClusters)
+	}
+	d.lock()
+	defer d.unlock()
+	return d.parser.estimateBitrates(maxClusters)
+}
+
+// estimateBitrates reads the blocks from the start of the segment and divides
+// the size of the blocks of each track by the duration. See
+// Demuxer.EstimateBitrates.
+//
+// Parameters:
+//   - maxClusters: The number of clusters to read, or 0 to read them all.
+//
+// Returns:
+//   - map[uint64]float64: The bitrate of each track in bits per second.
+//   - error: An error if the bitrates could not be estimated.
+func (mp *MatroskaParser) estimateBitrates(maxClusters int) (bitrates map[uint64]float64, err error) {
+	if mp.avoidSeeks {
+		return nil, fmt.Errorf("cannot estimate bitrates without seeking")
+	}
+
+	restore := mp.saveReadState()
+	defer func() {
+		if errRestore := restore(); errRestore != nil && err == nil {
+			err = errRestore
+		}
+	}()
+
+	mp.currentTrackMask = 0
+	mp.resetClusterState()
+	mp.segmentIndex = 0
+	if _, err = mp.reader.Seek(int64(mp.segmentPos), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint64]uint64)
+	var start, end uint64
+	var clusterPos uint64
+	clusters := 0
+	found, complete := false, true
+	for {
+		packet, errReadPacket := mp.readPacket(context.Background())
+		if errReadPacket == io.EOF {
+			break
+		}
+		if errReadPacket != nil {
+			return nil, fmt.Errorf("failed to read packet: %w", errReadPacket)
+		}
+		if clusters == 0 || packet.ClusterPos != clusterPos {
+			clusterPos = packet.ClusterPos
+			clusters++
+		}
+		if maxClusters > 0 && clusters > maxClusters {
+			packet.Release()
+			complete = false
+			break
+		}
+
+		totals[packet.Track] += uint64(len(packet.Data))
+		if !found || packet.StartTime < start {
+			start = packet.StartTime
+		}
+		if packet.StartTime > end {
+			end = packet.StartTime
+		}
+		if packet.EndTime > end {
+			end = packet.EndTime
+		}
+		found = true
+		packet.Release()
+	}
+
+	// The declared duration only covers the whole of a single segment
+	duration := end - start
+	if complete && len(mp.segments) <= 1 && mp.fileInfo != nil && mp.fileInfo.Duration > 0 {
+		duration = uint64(mp.fileInfo.DurationNanos())
+	}
+	if duration == 0 {
+		return nil, fmt.Errorf("cannot estimate bitrates without a duration")
+	}
+
+	seconds := float64(duration) / 1e9
+	bitrates = make(map[uint64]float64, len(mp.tracks))
+	for _, track := range mp.tracks {
+		bitrates[track.Number] = 0
+	}
+	for track, total := range totals {
+		bitrates[track] = float64(total) * 8 / seconds
+	}
+	return bitrates, nil
+}
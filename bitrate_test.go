@@ -0,0 +1,97 @@
+package matroska
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDemuxer_EstimateBitrates(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+		{Type: TypeAudio, CodecID: "A_OPUS"},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"},
+	}
+	// Two GOPs of five frames, each keyframe starting a new cluster, with
+	// 500 bytes of video and 50 bytes of audio per frame
+	var packets []*Packet
+	for frame := uint64(0); frame < 10; frame++ {
+		timecode := frame * 40000000
+		flags := uint32(0)
+		if frame%5 == 0 {
+			flags = KF
+		}
+		packets = append(packets,
+			&Packet{Track: 1, StartTime: timecode, Data: make([]byte, 500), Flags: flags},
+			&Packet{Track: 2, StartTime: timecode, Data: make([]byte, 50), Flags: KF},
+		)
+	}
+
+	checkBitrates := func(t *testing.T, bitrates map[uint64]float64, expected map[uint64]float64) {
+		t.Helper()
+		if len(bitrates) != len(expected) {
+			t.Fatalf("Expected bitrates for %d tracks, got %v", len(expected), bitrates)
+		}
+		for track, want := range expected {
+			if got, ok := bitrates[track]; !ok || math.Abs(got-want) > 0.01 {
+				t.Errorf("Track %d: expected %.2f b/s, got %.2f", track, want, got)
+			}
+		}
+	}
+
+	t.Run("Full scan", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		bitrates, err := demuxer.EstimateBitrates()
+		if err != nil {
+			t.Fatalf("EstimateBitrates() failed: %v", err)
+		}
+		// The Muxer declares the start time of the last packet as the duration
+		checkBitrates(t, bitrates, map[uint64]float64{
+			1: 10 * 500 * 8 / 0.36,
+			2: 10 * 50 * 8 / 0.36,
+			3: 0,
+		})
+
+		// The read position is restored
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if packet.Track != 1 || packet.StartTime != 0 {
+			t.Errorf("Expected the first packet, got track %d at %d", packet.Track, packet.StartTime)
+		}
+	})
+
+	t.Run("Sampled", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		bitrates, err := demuxer.EstimateBitratesSampled(1)
+		if err != nil {
+			t.Fatalf("EstimateBitratesSampled() failed: %v", err)
+		}
+		// The first cluster spans from 0 to the end of its last video frame
+		checkBitrates(t, bitrates, map[uint64]float64{
+			1: 5 * 500 * 8 / 0.2,
+			2: 5 * 50 * 8 / 0.2,
+			3: 0,
+		})
+	})
+
+	t.Run("Sample covering the file", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		sampled, err := demuxer.EstimateBitratesSampled(10)
+		if err != nil {
+			t.Fatalf("EstimateBitratesSampled() failed: %v", err)
+		}
+		full, err := demuxer.EstimateBitrates()
+		if err != nil {
+			t.Fatalf("EstimateBitrates() failed: %v", err)
+		}
+		checkBitrates(t, sampled, full)
+	})
+
+	t.Run("Invalid number of clusters", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if _, err := demuxer.EstimateBitratesSampled(0); err == nil {
+			t.Error("Expected an error for 0 clusters")
+		}
+	})
+}
@@ -0,0 +1,693 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains helpers for converting codec-specific data stored in
+// Matroska tracks into formats expected by decoders and raw elementary streams.
+package matroska
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// annexBStartCode is the 4-byte start code that precedes NAL units in Annex B streams.
+var annexBStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// AVCNALLengthSize returns the size in bytes of the NAL unit length fields used by
+// the frames of an H.264 track, as declared by its AVCDecoderConfigurationRecord.
+//
+// Matroska stores H.264 frames in AVCC format, where each NAL unit is prefixed
+// by a big-endian length field. The size of that field is declared in the low
+// two bits of the fifth byte of the configuration record, which is the
+// CodecPrivate of "V_MPEG4/ISO/AVC" tracks.
+//
+// Parameters:
+//   - config: The AVCDecoderConfigurationRecord (the track's CodecPrivate).
+//
+// Returns:
+//   - int: The NAL unit length size (1, 2 or 4), or 4 if the record is too short.
+func AVCNALLengthSize(config []byte) int {
+	if len(config) < 5 {
+		return 4
+	}
+	return int(config[4]&0x03) + 1
+}
+
+// AVCCToAnnexB converts length-prefixed (AVCC) NAL units to Annex B format.
+//
+// AVCC format prefixes each NAL unit with a big-endian length of nalLengthSize
+// bytes, while Annex B format separates NAL units with start codes. This
+// function replaces every length prefix with a 4-byte start code (0x00000001).
+// It works for both H.264 and H.265 frames, which use the same framing.
+//
+// If the last NAL unit is truncated, it is dropped from the output.
+//
+// Example:
+//
+//	nalLengthSize := matroska.AVCNALLengthSize(trackInfo.CodecPrivate)
+//	annexB := matroska.AVCCToAnnexB(packet.Data, nalLengthSize)
+//
+// Parameters:
+//   - data: The frame data with length-prefixed NAL units.
+//   - nalLengthSize: The size in bytes of each length prefix (1 to 4).
+//
+// Returns:
+//   - []byte: The frame data with start-code-separated NAL units, or nil if
+//     nalLengthSize is out of range.
+func AVCCToAnnexB(data []byte, nalLengthSize int) []byte {
+	if nalLengthSize < 1 || nalLengthSize > 4 {
+		return nil
+	}
+
+	result := make([]byte, 0, len(data)+len(data)/8)
+	pos := 0
+	for pos+nalLengthSize <= len(data) {
+		length := 0
+		for i := 0; i < nalLengthSize; i++ {
+			length = length<<8 | int(data[pos+i])
+		}
+		pos += nalLengthSize
+
+		if length > len(data)-pos {
+			break
+		}
+
+		result = append(result, annexBStartCode...)
+		result = append(result, data[pos:pos+length]...)
+		pos += length
+	}
+
+	return result
+}
+
+// AVCDecoderConfigToAnnexB extracts the parameter sets of an H.264 track in Annex B format.
+//
+// The CodecPrivate of "V_MPEG4/ISO/AVC" tracks is an AVCDecoderConfigurationRecord
+// (ISO/IEC 14496-15), which contains the SPS (Sequence Parameter Set) and PPS
+// (Picture Parameter Set) NAL units a decoder needs before the first frame:
+//   - Byte 0: Configuration version (always 1).
+//   - Byte 1: AVC profile indication.
+//   - Byte 2: Profile compatibility.
+//   - Byte 3: AVC level indication.
+//   - Byte 4: NAL unit length size minus one (lower 2 bits).
+//   - Byte 5: Number of SPS NAL units (lower 5 bits).
+//   - Following: SPS data (each with 2-byte length prefix).
+//   - Following: Number of PPS NAL units.
+//   - Following: PPS data (each with 2-byte length prefix).
+//
+// Each returned NAL unit is prefixed with a 4-byte start code (0x00000001), so
+// the parameter sets can be written directly in front of the first frame of a
+// raw H.264 elementary stream. Parameter sets that are truncated are skipped.
+//
+// Parameters:
+//   - config: The AVCDecoderConfigurationRecord (the track's CodecPrivate).
+//
+// Returns:
+//   - sps: The sequence parameter sets in Annex B format.
+//   - pps: The picture parameter sets in Annex B format.
+func AVCDecoderConfigToAnnexB(config []byte) (sps, pps [][]byte) {
+	if len(config) < 6 {
+		return nil, nil
+	}
+
+	pos := 5
+	numSPS := int(config[pos] & 0x1F)
+	pos++
+	sps, pos = readParameterSets(config, pos, numSPS)
+
+	if pos >= len(config) {
+		return sps, nil
+	}
+	numPPS := int(config[pos])
+	pos++
+	pps, _ = readParameterSets(config, pos, numPPS)
+
+	return sps, pps
+}
+
+// HEVCNALLengthSize returns the size in bytes of the NAL unit length fields used by
+// the frames of an H.265 track, as declared by its HEVCDecoderConfigurationRecord.
+//
+// The size is declared in the low two bits of the 22nd byte of the
+// configuration record, which is the CodecPrivate of "V_MPEGH/ISO/HEVC" tracks.
+//
+// Parameters:
+//   - config: The HEVCDecoderConfigurationRecord (the track's CodecPrivate).
+//
+// Returns:
+//   - int: The NAL unit length size (1, 2 or 4), or 4 if the record is too short.
+func HEVCNALLengthSize(config []byte) int {
+	if len(config) < 22 {
+		return 4
+	}
+	return int(config[21]&0x03) + 1
+}
+
+// HEVCDecoderConfigToAnnexB extracts the parameter sets of an H.265 track in Annex B format.
+//
+// The CodecPrivate of "V_MPEGH/ISO/HEVC" tracks is an HEVCDecoderConfigurationRecord
+// (hvcC, ISO/IEC 14496-15). After a 22-byte fixed header and a 1-byte array
+// count, it holds arrays of NAL units grouped by type, typically the VPS, SPS
+// and PPS, as well as SEI messages:
+//   - Byte 0: NAL unit type (lower 6 bits).
+//   - Bytes 1-2: Number of NAL units in the array.
+//   - Following: NAL unit data (each with 2-byte length prefix).
+//
+// Each returned NAL unit is prefixed with a 4-byte start code (0x00000001), in
+// the order they appear in the record, so they can be written directly in front
+// of the first frame of a raw H.265 elementary stream. Parsing stops at the
+// first truncated array or NAL unit.
+//
+// Parameters:
+//   - config: The HEVCDecoderConfigurationRecord (the track's CodecPrivate).
+//
+// Returns:
+//   - [][]byte: The parameter set NAL units in Annex B format.
+func HEVCDecoderConfigToAnnexB(config []byte) [][]byte {
+	if len(config) < 23 {
+		return nil
+	}
+
+	numArrays := int(config[22])
+	pos := 23
+
+	var nalUnits [][]byte
+	for i := 0; i < numArrays && pos+3 <= len(config); i++ {
+		numNalus := int(config[pos+1])<<8 | int(config[pos+2])
+		pos += 3
+
+		var sets [][]byte
+		sets, pos = readParameterSets(config, pos, numNalus)
+		nalUnits = append(nalUnits, sets...)
+		if len(sets) < numNalus {
+			break
+		}
+	}
+
+	return nalUnits
+}
+
+// annexBConverter converts the frames of an H.264 or H.265 track from AVCC to
+// Annex B format as they are read, and prepends the track's parameter sets to
+// the first keyframe.
+type annexBConverter struct {
+	nalLengthSize    int
+	parameterSets    []byte
+	parameterSetSent bool
+}
+
+// newAnnexBConverter creates an Annex B converter for a track.
+//
+// Parameters:
+//   - track: The track whose frames will be converted.
+//
+// Returns:
+//   - *annexBConverter: The converter, or nil if the track's codec is not H.264 or H.265.
+func newAnnexBConverter(track *TrackInfo) *annexBConverter {
+	var nalUnits [][]byte
+	converter := &annexBConverter{}
+
+	switch track.CodecID {
+	case "V_MPEG4/ISO/AVC":
+		sps, pps := AVCDecoderConfigToAnnexB(track.CodecPrivate)
+		nalUnits = append(sps, pps...)
+		converter.nalLengthSize = AVCNALLengthSize(track.CodecPrivate)
+	case "V_MPEGH/ISO/HEVC":
+		nalUnits = HEVCDecoderConfigToAnnexB(track.CodecPrivate)
+		converter.nalLengthSize = HEVCNALLengthSize(track.CodecPrivate)
+	default:
+		return nil
+	}
+
+	for _, nal := range nalUnits {
+		converter.parameterSets = append(converter.parameterSets, nal...)
+	}
+	return converter
+}
+
+// convert converts a packet's data to Annex B format in place.
+//
+// Parameters:
+//   - packet: The packet to convert.
+func (c *annexBConverter) convert(packet *Packet) {
+	data := AVCCToAnnexB(packet.Data, c.nalLengthSize)
+	if !c.parameterSetSent && packet.Flags&KF != 0 {
+		data = append(append([]byte{}, c.parameterSets...), data...)
+		c.parameterSetSent = true
+	}
+	packet.Data = data
+}
+
+// readParameterSets reads count parameter sets, each prefixed by a 2-byte
+// big-endian length, starting at pos, and returns them with Annex B start codes.
+//
+// Parameters:
+//   - data: The buffer containing the parameter sets.
+//   - pos: The offset of the first parameter set's length field.
+//   - count: The number of parameter sets to read.
+//
+// Returns:
+//   - [][]byte: The parameter sets with start codes.
+//   - int: The offset just past the last parameter set that was read.
+func readParameterSets(data []byte, pos int, count int) ([][]byte, int) {
+	var sets [][]byte
+	for i := 0; i < count && pos+2 <= len(data); i++ {
+		length := int(data[pos])<<8 | int(data[pos+1])
+		pos += 2
+		if pos+length > len(data) {
+			pos = len(data)
+			break
+		}
+
+		nal := make([]byte, 0, len(annexBStartCode)+length)
+		nal = append(nal, annexBStartCode...)
+		nal = append(nal, data[pos:pos+length]...)
+		sets = append(sets, nal)
+		pos += length
+	}
+	return sets, pos
+}
+
+// SplitVorbisHeaders splits the CodecPrivate of a Vorbis track into its three
+// setup packets.
+//
+// The CodecPrivate of "A_VORBIS" tracks packs the identification, comment and
+// setup headers that libvorbis needs before the first audio packet into a single
+// blob using Xiph lacing: a byte holding the number of packets minus one (always
+// 2), the Xiph-encoded sizes of the first two packets, then the packets.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - id: The identification header.
+//   - comment: The comment header.
+//   - setup: The setup header.
+//   - err: An error if the CodecPrivate is not a valid three-packet Xiph lace.
+func SplitVorbisHeaders(codecPrivate []byte) (id, comment, setup []byte, err error) {
+	if len(codecPrivate) < 1 {
+		return nil, nil, nil, fmt.Errorf("vorbis codec private is empty")
+	}
+	if codecPrivate[0] != 2 {
+		return nil, nil, nil, fmt.Errorf("vorbis codec private has %d packets, expected 3", int(codecPrivate[0])+1)
+	}
+
+	data := codecPrivate[1:]
+	sizes, offset, err := parseXiphLacing(data, 3)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid vorbis codec private: %w", err)
+	}
+
+	id = data[offset : offset+sizes[0]]
+	offset += sizes[0]
+	comment = data[offset : offset+sizes[1]]
+	offset += sizes[1]
+	setup = data[offset:]
+
+	return id, comment, setup, nil
+}
+
+// ParseFLACStreamInfo parses the STREAMINFO block from the CodecPrivate of a FLAC track.
+//
+// The CodecPrivate of "A_FLAC" tracks holds a native FLAC header: the "fLaC"
+// marker followed by metadata blocks, the first of which must be STREAMINFO.
+// Each metadata block starts with a 4-byte header holding a last-block flag,
+// the block type and the 24-bit length of the block body. This function
+// validates the marker and the structure of every metadata block, and decodes
+// the STREAMINFO block.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - *FLACStreamInfo: The decoded STREAMINFO block.
+//   - error: An error if the CodecPrivate is not a valid FLAC header.
+func ParseFLACStreamInfo(codecPrivate []byte) (*FLACStreamInfo, error) {
+	if len(codecPrivate) < 4 || string(codecPrivate[:4]) != "fLaC" {
+		return nil, fmt.Errorf("flac codec private is missing the fLaC marker")
+	}
+
+	var streamInfo *FLACStreamInfo
+	pos := 4
+	for blockIndex := 0; ; blockIndex++ {
+		if pos+4 > len(codecPrivate) {
+			return nil, fmt.Errorf("flac metadata block %d header is truncated", blockIndex)
+		}
+		last := codecPrivate[pos]&0x80 != 0
+		blockType := codecPrivate[pos] & 0x7F
+		length := int(codecPrivate[pos+1])<<16 | int(codecPrivate[pos+2])<<8 | int(codecPrivate[pos+3])
+		pos += 4
+
+		if pos+length > len(codecPrivate) {
+			return nil, fmt.Errorf("flac metadata block %d is truncated", blockIndex)
+		}
+
+		if blockIndex == 0 {
+			if blockType != 0 {
+				return nil, fmt.Errorf("first flac metadata block has type %d, expected STREAMINFO", blockType)
+			}
+			if length != 34 {
+				return nil, fmt.Errorf("flac STREAMINFO block has %d bytes, expected 34", length)
+			}
+			streamInfo = decodeFLACStreamInfo(codecPrivate[pos : pos+length])
+		}
+
+		pos += length
+		if last {
+			break
+		}
+	}
+
+	return streamInfo, nil
+}
+
+// decodeFLACStreamInfo decodes the 34-byte body of a FLAC STREAMINFO block.
+//
+// Parameters:
+//   - block: The STREAMINFO block body.
+//
+// Returns:
+//   - *FLACStreamInfo: The decoded stream parameters.
+func decodeFLACStreamInfo(block []byte) *FLACStreamInfo {
+	info := &FLACStreamInfo{
+		MinBlockSize: uint16(block[0])<<8 | uint16(block[1]),
+		MaxBlockSize: uint16(block[2])<<8 | uint16(block[3]),
+		MinFrameSize: uint32(block[4])<<16 | uint32(block[5])<<8 | uint32(block[6]),
+		MaxFrameSize: uint32(block[7])<<16 | uint32(block[8])<<8 | uint32(block[9]),
+		Raw:          append([]byte{}, block...),
+	}
+
+	// Sample rate (20 bits), channels - 1 (3 bits), bits per sample - 1 (5 bits),
+	// total samples (36 bits)
+	packed := binary.BigEndian.Uint64(block[10:18])
+	info.SampleRate = uint32(packed >> 44)
+	info.Channels = uint8(packed>>41&0x07) + 1
+	info.BitsPerSample = uint8(packed>>36&0x1F) + 1
+	info.TotalSamples = packed & 0xFFFFFFFFF
+	copy(info.MD5[:], block[18:34])
+
+	return info
+}
+
+// ADTSHeader builds the 7-byte ADTS header for a raw AAC frame of an AAC track.
+//
+// Matroska stores AAC frames without ADTS framing, and the CodecPrivate of
+// "A_AAC" tracks is an AudioSpecificConfig (ISO/IEC 14496-3) describing the
+// stream. This function derives the profile, sampling frequency index and
+// channel configuration from the AudioSpecificConfig and builds an ADTS header
+// without CRC. Writing the header in front of every frame produces a playable
+// .aac file.
+//
+// Only the AAC Main, LC, SSR and LTP object types can be described by an ADTS
+// header, and the sampling frequency must be one of the standard frequencies.
+//
+// Example:
+//
+//	header, err := matroska.ADTSHeader(trackInfo.CodecPrivate, len(packet.Data))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_, _ = out.Write(header)
+//	_, _ = out.Write(packet.Data)
+//
+// Parameters:
+//   - codecPrivate: The AudioSpecificConfig (the track's CodecPrivate).
+//   - frameLen: The length of the raw AAC frame in bytes.
+//
+// Returns:
+//   - []byte: The 7-byte ADTS header.
+//   - error: An error if the config is invalid or cannot be described by ADTS.
+func ADTSHeader(codecPrivate []byte, frameLen int) ([]byte, error) {
+	if len(codecPrivate) < 2 {
+		return nil, fmt.Errorf("aac audio specific config is too short: %d bytes", len(codecPrivate))
+	}
+
+	objectType := codecPrivate[0] >> 3
+	freqIndex := (codecPrivate[0]&0x07)<<1 | codecPrivate[1]>>7
+	channelConfig := (codecPrivate[1] >> 3) & 0x0F
+
+	if objectType < 1 || objectType > 4 {
+		return nil, fmt.Errorf("%w: aac object type %d cannot be described by an adts header", ErrUnsupportedCodec, objectType)
+	}
+	if freqIndex > 12 {
+		return nil, fmt.Errorf("aac sampling frequency index %d cannot be described by an adts header", freqIndex)
+	}
+
+	fullLen := frameLen + 7
+	if frameLen < 0 || fullLen > 0x1FFF {
+		return nil, fmt.Errorf("aac frame length %d does not fit in an adts header", frameLen)
+	}
+
+	profile := objectType - 1
+	return []byte{
+		0xFF,
+		0xF1, // MPEG-4, layer 0, no CRC
+		profile<<6 | freqIndex<<2 | channelConfig>>2,
+		(channelConfig&0x03)<<6 | byte(fullLen>>11),
+		byte(fullLen >> 3),
+		byte(fullLen&0x07)<<5 | 0x1F,
+		0xFC,
+	}, nil
+}
+
+// ParseOpusHead parses the Opus identification header from the CodecPrivate of an Opus track.
+//
+// The CodecPrivate of "A_OPUS" tracks is an OpusHead packet (RFC 7845): the
+// "OpusHead" magic signature, the version, channel count, pre-skip, input sample
+// rate, output gain and channel mapping family, followed by the channel mapping
+// table when the mapping family is not 0. Multi-byte fields are little-endian.
+//
+// The pre-skip is also available from the track's CodecDelay, and seeking
+// requires decoding and discarding SeekPreRoll nanoseconds of audio.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - *OpusHead: The decoded identification header.
+//   - error: An error if the CodecPrivate is not a valid OpusHead.
+func ParseOpusHead(codecPrivate []byte) (*OpusHead, error) {
+	if len(codecPrivate) < 19 {
+		return nil, fmt.Errorf("opus head is too short: %d bytes", len(codecPrivate))
+	}
+	if string(codecPrivate[:8]) != "OpusHead" {
+		return nil, fmt.Errorf("opus head is missing the OpusHead signature")
+	}
+
+	head := &OpusHead{
+		Version:              codecPrivate[8],
+		Channels:             codecPrivate[9],
+		PreSkip:              binary.LittleEndian.Uint16(codecPrivate[10:12]),
+		InputSampleRate:      binary.LittleEndian.Uint32(codecPrivate[12:16]),
+		OutputGain:           int16(binary.LittleEndian.Uint16(codecPrivate[16:18])),
+		ChannelMappingFamily: codecPrivate[18],
+	}
+
+	if head.Version>>4 != 0 {
+		return nil, fmt.Errorf("%w: opus head version %d", ErrUnsupportedCodec, head.Version)
+	}
+	if head.Channels == 0 {
+		return nil, fmt.Errorf("opus head has no channels")
+	}
+
+	if head.ChannelMappingFamily != 0 {
+		if len(codecPrivate) < 21+int(head.Channels) {
+			return nil, fmt.Errorf("opus head channel mapping table is truncated")
+		}
+		head.StreamCount = codecPrivate[19]
+		head.CoupledCount = codecPrivate[20]
+		head.ChannelMapping = append([]byte{}, codecPrivate[21:21+int(head.Channels)]...)
+	}
+
+	return head, nil
+}
+
+// ParseAV1Config parses the AV1 codec configuration record from the CodecPrivate of an AV1 track.
+//
+// The CodecPrivate of "V_AV1" tracks is an AV1CodecConfigurationRecord (av1C,
+// from the AV1 ISOBMFF binding):
+//   - Byte 0: Marker (upper bit, always 1) and version (lower 7 bits, always 1).
+//   - Byte 1: seq_profile (upper 3 bits) and seq_level_idx_0 (lower 5 bits).
+//   - Byte 2: seq_tier_0, high_bitdepth, twelve_bit, mono_chrome,
+//     chroma_subsampling_x and chroma_subsampling_y (one bit each, from the
+//     upper bit), then chroma_sample_position (lower 2 bits).
+//   - Byte 3: Reserved (upper 3 bits), initial_presentation_delay_present and
+//     initial_presentation_delay_minus_one (lower 4 bits).
+//   - Following: The configuration OBUs, such as the sequence header.
+//
+// The returned ConfigOBUs share memory with codecPrivate.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - *AV1Config: The decoded configuration record.
+//   - error: An error if the CodecPrivate is too short or its marker bit is not
+//     set, or an error wrapping ErrUnsupportedCodec for a version other than 1.
+func ParseAV1Config(codecPrivate []byte) (*AV1Config, error) {
+	if len(codecPrivate) < 4 {
+		return nil, fmt.Errorf("av1 configuration record is too short: %d bytes", len(codecPrivate))
+	}
+	if codecPrivate[0]&0x80 == 0 {
+		return nil, fmt.Errorf("av1 configuration record is missing its marker bit")
+	}
+
+	config := &AV1Config{
+		Version:                         codecPrivate[0] & 0x7F,
+		SeqProfile:                      codecPrivate[1] >> 5,
+		SeqLevelIdx0:                    codecPrivate[1] & 0x1F,
+		SeqTier0:                        codecPrivate[2] >> 7,
+		HighBitdepth:                    codecPrivate[2]&0x40 != 0,
+		TwelveBit:                       codecPrivate[2]&0x20 != 0,
+		Monochrome:                      codecPrivate[2]&0x10 != 0,
+		ChromaSubsamplingX:              codecPrivate[2]&0x08 != 0,
+		ChromaSubsamplingY:              codecPrivate[2]&0x04 != 0,
+		ChromaSamplePosition:            codecPrivate[2] & 0x03,
+		InitialPresentationDelayPresent: codecPrivate[3]&0x10 != 0,
+		ConfigOBUs:                      codecPrivate[4:],
+	}
+	if config.Version != 1 {
+		return nil, fmt.Errorf("%w: av1 configuration record version %d", ErrUnsupportedCodec, config.Version)
+	}
+	if config.InitialPresentationDelayPresent {
+		config.InitialPresentationDelayMinusOne = codecPrivate[3] & 0x0F
+	}
+
+	return config, nil
+}
+
+// The IDs of the features in the CodecPrivate of VP9 tracks.
+const (
+	vp9FeatureProfile           = 1
+	vp9FeatureLevel             = 2
+	vp9FeatureBitDepth          = 3
+	vp9FeatureChromaSubsampling = 4
+)
+
+// ParseVP9Config parses the codec features from the CodecPrivate of a VP9 track.
+//
+// The CodecPrivate of "V_VP9" tracks, when present, is a list of features, each
+// made of a 1-byte ID, a 1-byte length and its value:
+//   - ID 1: Profile (1 byte, 0 to 3).
+//   - ID 2: Level (1 byte).
+//   - ID 3: Bit depth (1 byte, 8, 10 or 12).
+//   - ID 4: Chroma subsampling (1 byte, 0 to 3).
+//
+// Features with other IDs are skipped. Most VP9 tracks have no CodecPrivate,
+// in which case the profile and bit depth must be read from the first frame.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - *VP9Config: The decoded features.
+//   - error: An error if the CodecPrivate is empty, a feature is truncated, or
+//     a feature has an invalid length or value.
+func ParseVP9Config(codecPrivate []byte) (*VP9Config, error) {
+	if len(codecPrivate) == 0 {
+		return nil, fmt.Errorf("vp9 codec private is empty")
+	}
+
+	config := &VP9Config{}
+	for pos := 0; pos < len(codecPrivate); {
+		if pos+2 > len(codecPrivate) {
+			return nil, fmt.Errorf("vp9 feature at offset %d is truncated", pos)
+		}
+		id, length := codecPrivate[pos], int(codecPrivate[pos+1])
+		pos += 2
+		if pos+length > len(codecPrivate) {
+			return nil, fmt.Errorf("vp9 feature %d is truncated: %d bytes, %d remaining", id, length, len(codecPrivate)-pos)
+		}
+		value := codecPrivate[pos : pos+length]
+		pos += length
+
+		if id < vp9FeatureProfile || id > vp9FeatureChromaSubsampling {
+			continue
+		}
+		if length != 1 {
+			return nil, fmt.Errorf("vp9 feature %d has an invalid length of %d bytes", id, length)
+		}
+		switch id {
+		case vp9FeatureProfile:
+			if value[0] > 3 {
+				return nil, fmt.Errorf("invalid vp9 profile %d", value[0])
+			}
+			config.Profile, config.HasProfile = value[0], true
+		case vp9FeatureLevel:
+			config.Level = value[0]
+		case vp9FeatureBitDepth:
+			if value[0] != 8 && value[0] != 10 && value[0] != 12 {
+				return nil, fmt.Errorf("invalid vp9 bit depth %d", value[0])
+			}
+			config.BitDepth = value[0]
+		case vp9FeatureChromaSubsampling:
+			if value[0] > 3 {
+				return nil, fmt.Errorf("invalid vp9 chroma subsampling %d", value[0])
+			}
+			config.ChromaSubsampling, config.HasChromaSubsampling = value[0], true
+		}
+	}
+
+	return config, nil
+}
+
+// ParsePGSSegments splits the data of a block of a PGS subtitle track into its segments.
+//
+// The blocks of "S_HDMV/PGS" tracks hold the segments of a display set, each
+// made of a 1-byte type, a 2-byte big-endian size and its payload. Unlike in
+// .sup files, the segments have no "PG" header, as their timestamps are those
+// of the block. The payloads of the returned segments share memory with data.
+//
+// To write a .sup file, prefix each segment with "PG", the presentation and
+// decoding timestamps as 32-bit big-endian values in 90 kHz units, the type,
+// and the 2-byte size:
+//
+//	segments, err := matroska.ParsePGSSegments(packet.Data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	pts := uint32(packet.StartTime * 9 / 100000)
+//	for _, segment := range segments {
+//	    header := []byte{'P', 'G', 0, 0, 0, 0, 0, 0, 0, 0, segment.Type, 0, 0}
+//	    binary.BigEndian.PutUint32(header[2:6], pts)
+//	    binary.BigEndian.PutUint16(header[11:13], uint16(len(segment.Data)))
+//	    _, _ = out.Write(header)
+//	    _, _ = out.Write(segment.Data)
+//	}
+//
+// Parameters:
+//   - data: The data of a PGS block.
+//
+// Returns:
+//   - []PGSSegment: The segments, in the order they are stored.
+//   - error: An error if a segment header or payload is truncated.
+func ParsePGSSegments(data []byte) ([]PGSSegment, error) {
+	var segments []PGSSegment
+	for pos := 0; pos < len(data); {
+		if len(data)-pos < 3 {
+			return nil, fmt.Errorf("pgs segment header at offset %d is truncated", pos)
+		}
+		segmentType := data[pos]
+		size := int(binary.BigEndian.Uint16(data[pos+1 : pos+3]))
+		pos += 3
+		if len(data)-pos < size {
+			return nil, fmt.Errorf("pgs segment of type 0x%02X at offset %d is truncated: %d of %d bytes", segmentType, pos-3, len(data)-pos, size)
+		}
+		segments = append(segments, PGSSegment{Type: segmentType, Data: data[pos : pos+size]})
+		pos += size
+	}
+	return segments, nil
+}
+
+// swapPCMEndianness reverses the byte order of every sample of PCM data in
+// place, converting big-endian samples to little-endian and back. A trailing
+// partial sample is left untouched.
+//
+// Parameters:
+//   - data: The PCM samples.
+//   - sampleSize: The size in bytes of a sample (2, 3 or 4).
+func swapPCMEndianness(data []byte, sampleSize int) {
+	for i := 0; i+sampleSize <= len(data); i += sampleSize {
+		for j, k := i, i+sampleSize-1; j < k; j, k = j+1, k-1 {
+			data[j], data[k] = data[k], data[j]
+		}
+	}
+}
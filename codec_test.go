@@ -0,0 +1,634 @@
+package matroska
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// avcConfig is an AVCDecoderConfigurationRecord with one SPS and one PPS,
+// declaring 4-byte NAL unit lengths.
+var avcConfig = []byte{
+	0x01, 0x64, 0x00, 0x1F, 0xFF, // version, profile, compatibility, level, length size 4
+	0xE1,                               // 1 SPS
+	0x00, 0x04, 0x67, 0x64, 0x00, 0x1F, // SPS
+	0x01,                         // 1 PPS
+	0x00, 0x03, 0x68, 0xEB, 0xE3, // PPS
+}
+
+// TestAVCNALLengthSize tests reading the NAL unit length size from an AVC config.
+func TestAVCNALLengthSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		want   int
+	}{
+		{"Four bytes", avcConfig, 4},
+		{"Two bytes", []byte{0x01, 0x64, 0x00, 0x1F, 0xFD}, 2},
+		{"One byte", []byte{0x01, 0x64, 0x00, 0x1F, 0xFC}, 1},
+		{"Too short", []byte{0x01, 0x64}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AVCNALLengthSize(tt.config); got != tt.want {
+				t.Errorf("AVCNALLengthSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAVCCToAnnexB tests converting length-prefixed NAL units to Annex B format.
+func TestAVCCToAnnexB(t *testing.T) {
+	t.Run("Four-byte lengths", func(t *testing.T) {
+		data := []byte{
+			0x00, 0x00, 0x00, 0x02, 0x09, 0xF0, // AUD
+			0x00, 0x00, 0x00, 0x03, 0x65, 0x88, 0x84, // IDR slice
+		}
+		want := []byte{
+			0x00, 0x00, 0x00, 0x01, 0x09, 0xF0,
+			0x00, 0x00, 0x00, 0x01, 0x65, 0x88, 0x84,
+		}
+		if got := AVCCToAnnexB(data, 4); !bytes.Equal(got, want) {
+			t.Errorf("AVCCToAnnexB() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("Two-byte lengths", func(t *testing.T) {
+		data := []byte{0x00, 0x02, 0x09, 0xF0, 0x00, 0x01, 0x41}
+		want := []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xF0, 0x00, 0x00, 0x00, 0x01, 0x41}
+		if got := AVCCToAnnexB(data, 2); !bytes.Equal(got, want) {
+			t.Errorf("AVCCToAnnexB() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("Truncated last NAL unit", func(t *testing.T) {
+		data := []byte{0x00, 0x00, 0x00, 0x01, 0x41, 0x00, 0x00, 0x00, 0x09, 0x01}
+		want := []byte{0x00, 0x00, 0x00, 0x01, 0x41}
+		if got := AVCCToAnnexB(data, 4); !bytes.Equal(got, want) {
+			t.Errorf("AVCCToAnnexB() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("Invalid length size", func(t *testing.T) {
+		if got := AVCCToAnnexB([]byte{0x00, 0x01, 0x41}, 0); got != nil {
+			t.Errorf("AVCCToAnnexB() with size 0 = %x, want nil", got)
+		}
+		if got := AVCCToAnnexB([]byte{0x00, 0x01, 0x41}, 5); got != nil {
+			t.Errorf("AVCCToAnnexB() with size 5 = %x, want nil", got)
+		}
+	})
+}
+
+// TestAVCDecoderConfigToAnnexB tests extracting SPS and PPS from an AVC config.
+func TestAVCDecoderConfigToAnnexB(t *testing.T) {
+	t.Run("Valid config", func(t *testing.T) {
+		sps, pps := AVCDecoderConfigToAnnexB(avcConfig)
+		if len(sps) != 1 || !bytes.Equal(sps[0], []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x64, 0x00, 0x1F}) {
+			t.Errorf("Unexpected SPS: %x", sps)
+		}
+		if len(pps) != 1 || !bytes.Equal(pps[0], []byte{0x00, 0x00, 0x00, 0x01, 0x68, 0xEB, 0xE3}) {
+			t.Errorf("Unexpected PPS: %x", pps)
+		}
+	})
+
+	t.Run("Truncated PPS", func(t *testing.T) {
+		sps, pps := AVCDecoderConfigToAnnexB(avcConfig[:len(avcConfig)-1])
+		if len(sps) != 1 {
+			t.Errorf("Expected 1 SPS, got %d", len(sps))
+		}
+		if len(pps) != 0 {
+			t.Errorf("Expected no PPS, got %x", pps)
+		}
+	})
+
+	t.Run("Too short", func(t *testing.T) {
+		sps, pps := AVCDecoderConfigToAnnexB([]byte{0x01, 0x64})
+		if sps != nil || pps != nil {
+			t.Errorf("Expected nil parameter sets, got %x, %x", sps, pps)
+		}
+	})
+}
+
+// hevcConfig builds an HEVCDecoderConfigurationRecord with a VPS, SPS and PPS,
+// declaring 4-byte NAL unit lengths.
+func hevcConfig() []byte {
+	buf := new(bytes.Buffer)
+	header := make([]byte, 22)
+	header[0] = 0x01  // configurationVersion
+	header[21] = 0x0F // constantFrameRate, numTemporalLayers, temporalIdNested, lengthSizeMinusOne=3
+	buf.Write(header)
+	buf.WriteByte(3) // numOfArrays
+
+	arrays := []struct {
+		nalType byte
+		nal     []byte
+	}{
+		{32, []byte{0x40, 0x01, 0x0C}},
+		{33, []byte{0x42, 0x01, 0x01, 0x01}},
+		{34, []byte{0x44, 0x01, 0xC1}},
+	}
+	for _, a := range arrays {
+		buf.WriteByte(0x80 | a.nalType) // array_completeness, NAL_unit_type
+		buf.Write([]byte{0x00, 0x01})   // numNalus
+		buf.Write([]byte{0x00, byte(len(a.nal))})
+		buf.Write(a.nal)
+	}
+	return buf.Bytes()
+}
+
+// TestHEVCNALLengthSize tests reading the NAL unit length size from an HEVC config.
+func TestHEVCNALLengthSize(t *testing.T) {
+	if got := HEVCNALLengthSize(hevcConfig()); got != 4 {
+		t.Errorf("HEVCNALLengthSize() = %d, want 4", got)
+	}
+	if got := HEVCNALLengthSize([]byte{0x01}); got != 4 {
+		t.Errorf("HEVCNALLengthSize() with short config = %d, want 4", got)
+	}
+}
+
+// TestHEVCDecoderConfigToAnnexB tests extracting VPS, SPS and PPS from an HEVC config.
+func TestHEVCDecoderConfigToAnnexB(t *testing.T) {
+	t.Run("Valid config", func(t *testing.T) {
+		nalUnits := HEVCDecoderConfigToAnnexB(hevcConfig())
+		want := [][]byte{
+			{0x00, 0x00, 0x00, 0x01, 0x40, 0x01, 0x0C},
+			{0x00, 0x00, 0x00, 0x01, 0x42, 0x01, 0x01, 0x01},
+			{0x00, 0x00, 0x00, 0x01, 0x44, 0x01, 0xC1},
+		}
+		if len(nalUnits) != len(want) {
+			t.Fatalf("Expected %d NAL units, got %d", len(want), len(nalUnits))
+		}
+		for i := range want {
+			if !bytes.Equal(nalUnits[i], want[i]) {
+				t.Errorf("NAL unit %d = %x, want %x", i, nalUnits[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Truncated config", func(t *testing.T) {
+		config := hevcConfig()
+		nalUnits := HEVCDecoderConfigToAnnexB(config[:len(config)-2])
+		if len(nalUnits) != 2 {
+			t.Errorf("Expected 2 NAL units, got %d", len(nalUnits))
+		}
+	})
+
+	t.Run("Too short", func(t *testing.T) {
+		if nalUnits := HEVCDecoderConfigToAnnexB(make([]byte, 10)); nalUnits != nil {
+			t.Errorf("Expected nil NAL units, got %x", nalUnits)
+		}
+	})
+}
+
+// TestSplitVorbisHeaders tests splitting a Vorbis CodecPrivate into its three headers.
+func TestSplitVorbisHeaders(t *testing.T) {
+	// Identification header (30 bytes, as in every Vorbis stream)
+	idHeader := []byte{
+		0x01, 'v', 'o', 'r', 'b', 'i', 's',
+		0x00, 0x00, 0x00, 0x00, // vorbis_version
+		0x02,                   // audio_channels
+		0x44, 0xAC, 0x00, 0x00, // audio_sample_rate 44100
+		0x00, 0x00, 0x00, 0x00, // bitrate_maximum
+		0x00, 0xF4, 0x01, 0x00, // bitrate_nominal
+		0x00, 0x00, 0x00, 0x00, // bitrate_minimum
+		0xB8, // blocksize_0 and blocksize_1
+		0x01, // framing_flag
+	}
+	// Comment header with a vendor string
+	vendor := "Xiph.Org libVorbis I 20200704 (Reducing Environment)"
+	commentHeader := []byte{0x03, 'v', 'o', 'r', 'b', 'i', 's', byte(len(vendor)), 0x00, 0x00, 0x00}
+	commentHeader = append(commentHeader, vendor...)
+	commentHeader = append(commentHeader, 0x00, 0x00, 0x00, 0x00, 0x01)
+	// Setup header; as the last packet its size is implied by the remaining data
+	setupHeader := append([]byte{0x05, 'v', 'o', 'r', 'b', 'i', 's'}, bytes.Repeat([]byte{0x42}, 600)...)
+
+	codecPrivate := []byte{0x02, byte(len(idHeader)), byte(len(commentHeader))}
+	codecPrivate = append(codecPrivate, idHeader...)
+	codecPrivate = append(codecPrivate, commentHeader...)
+	codecPrivate = append(codecPrivate, setupHeader...)
+
+	t.Run("Valid headers", func(t *testing.T) {
+		id, comment, setup, err := SplitVorbisHeaders(codecPrivate)
+		if err != nil {
+			t.Fatalf("SplitVorbisHeaders() failed: %v", err)
+		}
+		if !bytes.Equal(id, idHeader) {
+			t.Errorf("Identification header = %x, want %x", id, idHeader)
+		}
+		if !bytes.Equal(comment, commentHeader) {
+			t.Errorf("Comment header = %x, want %x", comment, commentHeader)
+		}
+		if !bytes.Equal(setup, setupHeader) {
+			t.Errorf("Setup header has %d bytes, want %d", len(setup), len(setupHeader))
+		}
+	})
+
+	t.Run("Size larger than 255", func(t *testing.T) {
+		longComment := bytes.Repeat([]byte{0x03}, 300)
+		data := []byte{0x02, 0x02, 0xFF, 0x2D, 0xAA, 0xBB}
+		data = append(data, longComment...)
+		data = append(data, 0xCC)
+
+		id, comment, setup, err := SplitVorbisHeaders(data)
+		if err != nil {
+			t.Fatalf("SplitVorbisHeaders() failed: %v", err)
+		}
+		if !bytes.Equal(id, []byte{0xAA, 0xBB}) || len(comment) != 300 || !bytes.Equal(setup, []byte{0xCC}) {
+			t.Errorf("Unexpected split: id=%x comment=%d bytes setup=%x", id, len(comment), setup)
+		}
+	})
+
+	t.Run("Wrong packet count", func(t *testing.T) {
+		if _, _, _, err := SplitVorbisHeaders([]byte{0x01, 0x01, 0xAA, 0xBB}); err == nil {
+			t.Error("Expected error for two-packet lace")
+		}
+	})
+
+	t.Run("Sizes exceed data", func(t *testing.T) {
+		if _, _, _, err := SplitVorbisHeaders([]byte{0x02, 0x10, 0x10, 0xAA}); err == nil {
+			t.Error("Expected error for truncated headers")
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if _, _, _, err := SplitVorbisHeaders(nil); err == nil {
+			t.Error("Expected error for empty codec private")
+		}
+	})
+}
+
+// flacCodecPrivate builds a FLAC header with a STREAMINFO block for 44.1 kHz,
+// 16-bit stereo audio, followed by an empty PADDING block.
+func flacCodecPrivate() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("fLaC")
+	buf.Write([]byte{0x00, 0x00, 0x00, 0x22}) // STREAMINFO, not last, 34 bytes
+	buf.Write([]byte{0x10, 0x00, 0x10, 0x00}) // block sizes 4096/4096
+	buf.Write([]byte{0x00, 0x00, 0x0E})       // min frame size 14
+	buf.Write([]byte{0x00, 0x3A, 0x5C})       // max frame size 14940
+	// 44100 Hz (20 bits), 2 channels (3 bits), 16 bits (5 bits), 2646000 samples (36 bits)
+	packed := uint64(44100)<<44 | uint64(1)<<41 | uint64(15)<<36 | 2646000
+	_ = binary.Write(buf, binary.BigEndian, packed)
+	buf.Write(bytes.Repeat([]byte{0xAB}, 16)) // MD5
+	buf.Write([]byte{0x81, 0x00, 0x00, 0x00}) // PADDING, last, 0 bytes
+	return buf.Bytes()
+}
+
+// TestParseFLACStreamInfo tests parsing the STREAMINFO block of a FLAC CodecPrivate.
+func TestParseFLACStreamInfo(t *testing.T) {
+	t.Run("Valid header", func(t *testing.T) {
+		info, err := ParseFLACStreamInfo(flacCodecPrivate())
+		if err != nil {
+			t.Fatalf("ParseFLACStreamInfo() failed: %v", err)
+		}
+		if info.MinBlockSize != 4096 || info.MaxBlockSize != 4096 {
+			t.Errorf("Unexpected block sizes %d/%d", info.MinBlockSize, info.MaxBlockSize)
+		}
+		if info.MinFrameSize != 14 || info.MaxFrameSize != 14940 {
+			t.Errorf("Unexpected frame sizes %d/%d", info.MinFrameSize, info.MaxFrameSize)
+		}
+		if info.SampleRate != 44100 {
+			t.Errorf("Expected SampleRate 44100, got %d", info.SampleRate)
+		}
+		if info.Channels != 2 {
+			t.Errorf("Expected Channels 2, got %d", info.Channels)
+		}
+		if info.BitsPerSample != 16 {
+			t.Errorf("Expected BitsPerSample 16, got %d", info.BitsPerSample)
+		}
+		if info.TotalSamples != 2646000 {
+			t.Errorf("Expected TotalSamples 2646000, got %d", info.TotalSamples)
+		}
+		if info.MD5[0] != 0xAB || info.MD5[15] != 0xAB {
+			t.Errorf("Unexpected MD5 %x", info.MD5)
+		}
+		if !bytes.Equal(info.Raw, flacCodecPrivate()[8:42]) {
+			t.Errorf("Raw STREAMINFO block does not match the codec private")
+		}
+	})
+
+	t.Run("Missing marker", func(t *testing.T) {
+		data := flacCodecPrivate()
+		data[0] = 'X'
+		if _, err := ParseFLACStreamInfo(data); err == nil {
+			t.Error("Expected error for missing fLaC marker")
+		}
+	})
+
+	t.Run("First block not STREAMINFO", func(t *testing.T) {
+		data := flacCodecPrivate()
+		data[4] = 0x04
+		if _, err := ParseFLACStreamInfo(data); err == nil {
+			t.Error("Expected error for non-STREAMINFO first block")
+		}
+	})
+
+	t.Run("Wrong STREAMINFO length", func(t *testing.T) {
+		data := flacCodecPrivate()
+		data[7] = 0x20
+		if _, err := ParseFLACStreamInfo(data); err == nil {
+			t.Error("Expected error for wrong STREAMINFO length")
+		}
+	})
+
+	t.Run("Truncated metadata block", func(t *testing.T) {
+		data := flacCodecPrivate()
+		if _, err := ParseFLACStreamInfo(data[:len(data)-2]); err == nil {
+			t.Error("Expected error for truncated metadata block header")
+		}
+		if _, err := ParseFLACStreamInfo(data[:20]); err == nil {
+			t.Error("Expected error for truncated STREAMINFO")
+		}
+	})
+}
+
+// TestADTSHeader tests building ADTS headers from an AudioSpecificConfig.
+func TestADTSHeader(t *testing.T) {
+	t.Run("AAC LC 44.1 kHz stereo", func(t *testing.T) {
+		// AudioSpecificConfig 0x1210: object type 2 (LC), frequency index 4, 2 channels
+		header, err := ADTSHeader([]byte{0x12, 0x10}, 371)
+		if err != nil {
+			t.Fatalf("ADTSHeader() failed: %v", err)
+		}
+		want := []byte{0xFF, 0xF1, 0x50, 0x80, 0x2F, 0x5F, 0xFC}
+		if !bytes.Equal(header, want) {
+			t.Errorf("ADTSHeader() = %x, want %x", header, want)
+		}
+	})
+
+	t.Run("AAC LC 48 kHz 5.1", func(t *testing.T) {
+		// AudioSpecificConfig 0x11B0: object type 2 (LC), frequency index 3, 6 channels
+		header, err := ADTSHeader([]byte{0x11, 0xB0}, 1000)
+		if err != nil {
+			t.Fatalf("ADTSHeader() failed: %v", err)
+		}
+		want := []byte{0xFF, 0xF1, 0x4D, 0x80, 0x7D, 0xFF, 0xFC}
+		if !bytes.Equal(header, want) {
+			t.Errorf("ADTSHeader() = %x, want %x", header, want)
+		}
+	})
+
+	t.Run("Unsupported object type", func(t *testing.T) {
+		// HE-AAC (SBR, object type 5)
+		if _, err := ADTSHeader([]byte{0x2B, 0x92}, 100); !errors.Is(err, ErrUnsupportedCodec) {
+			t.Errorf("Expected ErrUnsupportedCodec for object type 5, got %v", err)
+		}
+	})
+
+	t.Run("Explicit sampling frequency", func(t *testing.T) {
+		if _, err := ADTSHeader([]byte{0x17, 0x80, 0x00, 0x00, 0x00}, 100); err == nil {
+			t.Error("Expected error for explicit sampling frequency")
+		}
+	})
+
+	t.Run("Frame too large", func(t *testing.T) {
+		if _, err := ADTSHeader([]byte{0x12, 0x10}, 8190); err == nil {
+			t.Error("Expected error for frame too large")
+		}
+	})
+
+	t.Run("Config too short", func(t *testing.T) {
+		if _, err := ADTSHeader([]byte{0x12}, 100); err == nil {
+			t.Error("Expected error for short config")
+		}
+	})
+}
+
+// TestParseOpusHead tests parsing the Opus identification header.
+func TestParseOpusHead(t *testing.T) {
+	stereoHead := []byte{
+		'O', 'p', 'u', 's', 'H', 'e', 'a', 'd',
+		0x01,       // version
+		0x02,       // channels
+		0x38, 0x01, // pre-skip 312
+		0x80, 0xBB, 0x00, 0x00, // input sample rate 48000
+		0x00, 0x00, // output gain
+		0x00, // mapping family 0
+	}
+
+	t.Run("Stereo", func(t *testing.T) {
+		head, err := ParseOpusHead(stereoHead)
+		if err != nil {
+			t.Fatalf("ParseOpusHead() failed: %v", err)
+		}
+		if head.Version != 1 || head.Channels != 2 || head.PreSkip != 312 || head.InputSampleRate != 48000 {
+			t.Errorf("Unexpected head: %+v", head)
+		}
+		if head.ChannelMapping != nil {
+			t.Errorf("Expected no channel mapping, got %v", head.ChannelMapping)
+		}
+		// The pre-skip matches the CodecDelay written by common muxers
+		if head.PreSkipNanos() != 6500000 {
+			t.Errorf("Expected pre-skip of 6500000 ns, got %d", head.PreSkipNanos())
+		}
+	})
+
+	t.Run("Surround with channel mapping", func(t *testing.T) {
+		head := append([]byte{}, stereoHead[:18]...)
+		head[9] = 6                           // channels
+		head[16], head[17] = 0x00, 0xFF       // output gain -256 (-1 dB)
+		head = append(head, 0x01, 0x04, 0x02) // family 1, 4 streams, 2 coupled
+		head = append(head, 0, 4, 1, 2, 3, 5)
+
+		parsed, err := ParseOpusHead(head)
+		if err != nil {
+			t.Fatalf("ParseOpusHead() failed: %v", err)
+		}
+		if parsed.ChannelMappingFamily != 1 || parsed.StreamCount != 4 || parsed.CoupledCount != 2 {
+			t.Errorf("Unexpected mapping: %+v", parsed)
+		}
+		if !bytes.Equal(parsed.ChannelMapping, []byte{0, 4, 1, 2, 3, 5}) {
+			t.Errorf("Unexpected channel mapping %v", parsed.ChannelMapping)
+		}
+		if parsed.OutputGain != -256 {
+			t.Errorf("Expected OutputGain -256, got %d", parsed.OutputGain)
+		}
+	})
+
+	t.Run("Truncated channel mapping", func(t *testing.T) {
+		head := append([]byte{}, stereoHead[:18]...)
+		head = append(head, 0x01, 0x01, 0x01, 0x00)
+		if _, err := ParseOpusHead(head); err == nil {
+			t.Error("Expected error for truncated channel mapping")
+		}
+	})
+
+	t.Run("Bad signature", func(t *testing.T) {
+		head := append([]byte{}, stereoHead...)
+		head[0] = 'X'
+		if _, err := ParseOpusHead(head); err == nil {
+			t.Error("Expected error for bad signature")
+		}
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		head := append([]byte{}, stereoHead...)
+		head[8] = 0x10
+		if _, err := ParseOpusHead(head); err == nil {
+			t.Error("Expected error for unsupported version")
+		}
+	})
+
+	t.Run("Too short", func(t *testing.T) {
+		if _, err := ParseOpusHead(stereoHead[:10]); err == nil {
+			t.Error("Expected error for short head")
+		}
+	})
+}
+
+// TestParseAV1Config tests parsing the AV1 codec configuration record.
+func TestParseAV1Config(t *testing.T) {
+	// A 10-bit 4:2:0 Main profile stream at level 5.1 (13), with a sequence header OBU
+	sequenceHeader := []byte{0x0A, 0x0B, 0x00, 0x00, 0x00, 0x2C, 0xCF, 0x7F, 0x0D, 0xBF, 0xFF, 0x38, 0x18}
+	record := append([]byte{
+		0x81, // marker, version 1
+		0x0D, // seq_profile 0, seq_level_idx_0 13
+		0x4C, // high_bitdepth, subsampling x and y, chroma_sample_position 0
+		0x1A, // initial_presentation_delay_present, minus one 10
+	}, sequenceHeader...)
+
+	t.Run("Valid record", func(t *testing.T) {
+		config, err := ParseAV1Config(record)
+		if err != nil {
+			t.Fatalf("ParseAV1Config() failed: %v", err)
+		}
+		if config.Version != 1 || config.SeqProfile != 0 || config.SeqLevelIdx0 != 13 || config.SeqTier0 != 0 {
+			t.Errorf("Unexpected profile and level: %+v", config)
+		}
+		if !config.ChromaSubsamplingX || !config.ChromaSubsamplingY || config.Monochrome || config.BitDepth() != 10 {
+			t.Errorf("Unexpected color configuration: %+v", config)
+		}
+		if !config.InitialPresentationDelayPresent || config.InitialPresentationDelayMinusOne != 10 {
+			t.Errorf("Unexpected initial presentation delay: %+v", config)
+		}
+		if !bytes.Equal(config.ConfigOBUs, sequenceHeader) {
+			t.Errorf("Expected the sequence header OBU, got %X", config.ConfigOBUs)
+		}
+	})
+
+	t.Run("High profile at the high tier", func(t *testing.T) {
+		config, err := ParseAV1Config([]byte{0x81, 0x28, 0xE0, 0x00})
+		if err != nil {
+			t.Fatalf("ParseAV1Config() failed: %v", err)
+		}
+		if config.SeqProfile != 1 || config.SeqLevelIdx0 != 8 || config.SeqTier0 != 1 || config.BitDepth() != 12 {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+		if config.InitialPresentationDelayPresent || len(config.ConfigOBUs) != 0 {
+			t.Errorf("Expected no initial presentation delay or OBUs, got %+v", config)
+		}
+	})
+
+	t.Run("Invalid records", func(t *testing.T) {
+		if _, err := ParseAV1Config(record[:3]); err == nil {
+			t.Error("Expected an error for a truncated record")
+		}
+		if _, err := ParseAV1Config([]byte{0x01, 0x0D, 0x4C, 0x00}); err == nil {
+			t.Error("Expected an error for a record without its marker bit")
+		}
+		if _, err := ParseAV1Config([]byte{0x82, 0x0D, 0x4C, 0x00}); !errors.Is(err, ErrUnsupportedCodec) {
+			t.Errorf("Expected ErrUnsupportedCodec for version 2, got %v", err)
+		}
+	})
+}
+
+// TestParseVP9Config tests parsing the codec features of VP9 tracks.
+func TestParseVP9Config(t *testing.T) {
+	t.Run("All features", func(t *testing.T) {
+		config, err := ParseVP9Config([]byte{
+			0x01, 0x01, 0x02, // profile 2
+			0x02, 0x01, 0x29, // level 4.1
+			0x03, 0x01, 0x0A, // bit depth 10
+			0x04, 0x01, 0x01, // 4:2:0 colocated
+		})
+		if err != nil {
+			t.Fatalf("ParseVP9Config() failed: %v", err)
+		}
+		want := VP9Config{Profile: 2, HasProfile: true, Level: 41, BitDepth: 10, ChromaSubsampling: 1, HasChromaSubsampling: true}
+		if *config != want {
+			t.Errorf("Expected %+v, got %+v", want, *config)
+		}
+	})
+
+	t.Run("Partial features", func(t *testing.T) {
+		// Profile 0 only, and an unknown feature that is skipped
+		config, err := ParseVP9Config([]byte{0x07, 0x02, 0xAA, 0xBB, 0x01, 0x01, 0x00})
+		if err != nil {
+			t.Fatalf("ParseVP9Config() failed: %v", err)
+		}
+		if !config.HasProfile || config.Profile != 0 || config.Level != 0 || config.BitDepth != 0 || config.HasChromaSubsampling {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"Truncated header", []byte{0x01}},
+		{"Truncated value", []byte{0x02, 0x02, 0x29}},
+		{"Invalid length", []byte{0x01, 0x02, 0x00, 0x00}},
+		{"Invalid profile", []byte{0x01, 0x01, 0x04}},
+		{"Invalid bit depth", []byte{0x03, 0x01, 0x09}},
+		{"Invalid chroma subsampling", []byte{0x04, 0x01, 0x04}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseVP9Config(tt.data); err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+// TestParsePGSSegments tests splitting a PGS block into its segments.
+func TestParsePGSSegments(t *testing.T) {
+	t.Run("Display set", func(t *testing.T) {
+		data := []byte{
+			0x16, 0x00, 0x03, 0x07, 0x80, 0x04, // PCS
+			0x17, 0x00, 0x02, 0x01, 0x00, // WDS
+			0x14, 0x00, 0x01, 0xAA, // PDS
+			0x80, 0x00, 0x00, // END
+		}
+		segments, err := ParsePGSSegments(data)
+		if err != nil {
+			t.Fatalf("ParsePGSSegments() failed: %v", err)
+		}
+		want := []PGSSegment{
+			{Type: PGSPresentationComposition, Data: []byte{0x07, 0x80, 0x04}},
+			{Type: PGSWindowDefinition, Data: []byte{0x01, 0x00}},
+			{Type: PGSPaletteDefinition, Data: []byte{0xAA}},
+			{Type: PGSEndOfDisplaySet, Data: []byte{}},
+		}
+		if len(segments) != len(want) {
+			t.Fatalf("Expected %d segments, got %d", len(want), len(segments))
+		}
+		for i := range want {
+			if segments[i].Type != want[i].Type || !bytes.Equal(segments[i].Data, want[i].Data) {
+				t.Errorf("Segment %d = {0x%02X %x}, want {0x%02X %x}", i, segments[i].Type, segments[i].Data, want[i].Type, want[i].Data)
+			}
+		}
+	})
+
+	t.Run("Empty block", func(t *testing.T) {
+		segments, err := ParsePGSSegments(nil)
+		if err != nil || len(segments) != 0 {
+			t.Errorf("ParsePGSSegments(nil) = %v, %v, want no segments", segments, err)
+		}
+	})
+
+	t.Run("Truncated header", func(t *testing.T) {
+		if _, err := ParsePGSSegments([]byte{0x80, 0x00, 0x00, 0x15, 0x00}); err == nil {
+			t.Error("Expected error for truncated segment header")
+		}
+	})
+
+	t.Run("Truncated payload", func(t *testing.T) {
+		if _, err := ParsePGSSegments([]byte{0x15, 0x00, 0x04, 0x01, 0x02}); err == nil {
+			t.Error("Expected error for truncated segment payload")
+		}
+	})
+}
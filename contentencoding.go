@@ -0,0 +1,235 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the logic that undoes the content encodings (compression
+// and encryption) declared by a track's ContentEncodings element.
+package matroska
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// frameEncodings returns the content encodings of a track that apply to its
+// frames, in the order in which they must be undone.
+//
+// The Matroska specification requires a demuxer to start with the encoding
+// that has the highest ContentEncodingOrder and work its way down to the one
+// with the lowest.
+//
+// Parameters:
+//   - track: The track whose encodings should be returned.
+//
+// Returns:
+//   - []ContentEncoding: The frame encodings, highest order first.
+func frameEncodings(track *TrackInfo) []ContentEncoding {
+	var encodings []ContentEncoding
+	for _, encoding := range track.ContentEncodings {
+		if encoding.Scope&ContentEncodingScopeFrames != 0 {
+			encodings = append(encodings, encoding)
+		}
+	}
+	sort.SliceStable(encodings, func(i, j int) bool {
+		return encodings[i].Order > encodings[j].Order
+	})
+	return encodings
+}
+
+// decodeFrame undoes the content encodings of a track on a frame's data.
+//
+// Header stripping is undone by prepending the stripped bytes stored in the
+// encoding's ContentCompSettings, and zlib compression by inflating the data.
+// Compression is left in place when decompression has been disabled with
+// SetDecompression. Encrypted frames are decrypted when a key has been set for
+// the track with SetDecryptionKey, and left encrypted otherwise. Frames using
+// other encodings are returned unchanged.
+//
+// Parameters:
+//   - track: The track the frame belongs to.
+//   - data: The frame data as stored in the file.
+//
+// Returns:
+//   - []byte: The decoded frame data.
+//   - error: An error if the frame could not be decoded.
+func (mp *MatroskaParser) decodeFrame(track *TrackInfo, data []byte) ([]byte, error) {
+	for _, encoding := range frameEncodings(track) {
+		if encoding.Type == ContentEncodingTypeEncryption && encoding.Encryption != nil {
+			key, ok := mp.decryptionKeys[track.Number]
+			if !ok {
+				continue
+			}
+			decrypted, err := decryptFrame(encoding.Encryption, key, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt frame of track %d: %w", track.Number, err)
+			}
+			data = decrypted
+			continue
+		}
+		if encoding.Type != ContentEncodingTypeCompression || encoding.Compression == nil || mp.noDecompression {
+			continue
+		}
+		switch encoding.Compression.Algo {
+		case CompPrepend:
+			decoded := make([]byte, 0, len(encoding.Compression.Settings)+len(data))
+			decoded = append(decoded, encoding.Compression.Settings...)
+			data = append(decoded, data...)
+		case CompZlib:
+			decoded, err := inflate(data, mp.reader.maxElementSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to inflate frame of track %d: %w", track.Number, err)
+			}
+			data = decoded
+		}
+	}
+	return data, nil
+}
+
+// checkDecodable checks that decodeFrame undoes every frame encoding of a
+// track, so that the frames read from it can be written without their
+// encodings, as Remux does.
+//
+// Parameters:
+//   - track: The track to check.
+//
+// Returns:
+//   - error: An error if the track is encrypted and no decryption key has
+//     been set for it, or is compressed with an unsupported algorithm.
+func (mp *MatroskaParser) checkDecodable(track *TrackInfo) error {
+	for _, encoding := range frameEncodings(track) {
+		switch encoding.Type {
+		case ContentEncodingTypeEncryption:
+			if _, ok := mp.decryptionKeys[track.Number]; !ok || encoding.Encryption == nil {
+				return fmt.Errorf("track %d is encrypted and no decryption key is set", track.Number)
+			}
+		case ContentEncodingTypeCompression:
+			if encoding.Compression != nil && encoding.Compression.Algo != CompZlib && encoding.Compression.Algo != CompPrepend {
+				return fmt.Errorf("track %d uses the unsupported compression algorithm %d", track.Number, encoding.Compression.Algo)
+			}
+		}
+	}
+	return nil
+}
+
+// inflate decompresses zlib-compressed data. The decompressed data is
+// subject to the same limit as elements read into memory, so that a small
+// frame cannot inflate to an arbitrarily large allocation.
+//
+// Parameters:
+//   - data: The zlib stream.
+//   - maxSize: The maximum size of the decompressed data, or 0 for no limit.
+//
+// Returns:
+//   - []byte: The decompressed data.
+//   - error: An error if the stream is invalid or truncated, or one wrapping
+//     ErrElementTooLarge if it decompresses to more than maxSize bytes.
+func inflate(data []byte, maxSize uint64) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	if maxSize == 0 || maxSize >= math.MaxInt64 {
+		return io.ReadAll(zr)
+	}
+	decoded, err := io.ReadAll(io.LimitReader(zr, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(decoded)) > maxSize {
+		return nil, fmt.Errorf("%w: decompressed data exceeds the limit of %d bytes", ErrElementTooLarge, maxSize)
+	}
+	return decoded, nil
+}
+
+// WebM encrypted frame signal byte flags
+const (
+	webmSignalEncrypted   = 0x01
+	webmSignalPartitioned = 0x02
+	webmIVSize            = 8
+)
+
+// decryptFrame decrypts a frame encrypted as described by the WebM encryption
+// specification.
+//
+// Every frame starts with a signal byte. If its encrypted bit is clear, the
+// rest of the frame is unencrypted and returned as is. Otherwise, an 8-byte IV
+// follows, which forms the upper half of the initial AES-CTR counter block.
+// If the partitioned bit is also set, the IV is followed by a partition count
+// and that many 4-byte big-endian offsets, which split the rest of the frame
+// into alternating clear and encrypted partitions, starting with a clear one.
+// The encrypted partitions are decrypted as one continuous AES-CTR stream.
+//
+// Parameters:
+//   - encryption: The encryption settings of the track.
+//   - key: The AES key, 16, 24 or 32 bytes long.
+//   - data: The encrypted frame, including the signal byte.
+//
+// Returns:
+//   - []byte: The decrypted frame data, without the signal byte and IV.
+//   - error: An error if the encryption is unsupported, the key is invalid,
+//     or the frame is truncated.
+func decryptFrame(encryption *ContentEncryption, key []byte, data []byte) ([]byte, error) {
+	if encryption.Algo != EncAlgoAES || encryption.AESCipherMode != AESCipherModeCTR {
+		return nil, fmt.Errorf("unsupported encryption algorithm %d with cipher mode %d", encryption.Algo, encryption.AESCipherMode)
+	}
+	if len(data) < 1 {
+		return nil, fmt.Errorf("frame is missing its signal byte")
+	}
+
+	signal := data[0]
+	if signal&webmSignalEncrypted == 0 {
+		return data[1:], nil
+	}
+	if len(data) < 1+webmIVSize {
+		return nil, fmt.Errorf("frame is too short for its IV")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, data[1:1+webmIVSize])
+	stream := cipher.NewCTR(block, counter)
+	payload := data[1+webmIVSize:]
+
+	if signal&webmSignalPartitioned == 0 {
+		decrypted := make([]byte, len(payload))
+		stream.XORKeyStream(decrypted, payload)
+		return decrypted, nil
+	}
+
+	if len(payload) < 1 {
+		return nil, fmt.Errorf("frame is missing its partition count")
+	}
+	count := int(payload[0])
+	if len(payload) < 1+count*4 {
+		return nil, fmt.Errorf("frame is too short for %d partition offsets", count)
+	}
+	offsets := make([]int, 0, count+2)
+	offsets = append(offsets, 0)
+	for i := 0; i < count; i++ {
+		offsets = append(offsets, int(binary.BigEndian.Uint32(payload[1+i*4:])))
+	}
+	payload = payload[1+count*4:]
+	offsets = append(offsets, len(payload))
+
+	decrypted := make([]byte, len(payload))
+	for i := 0; i+1 < len(offsets); i++ {
+		start, end := offsets[i], offsets[i+1]
+		if start > end || end > len(payload) {
+			return nil, fmt.Errorf("invalid partition offset %d", end)
+		}
+		if i%2 == 0 {
+			copy(decrypted[start:end], payload[start:end])
+		} else {
+			stream.XORKeyStream(decrypted[start:end], payload[start:end])
+		}
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,168 @@
+package matroska
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	t.Run("Encodings undone from highest order", func(t *testing.T) {
+		track := &TrackInfo{
+			ContentEncodings: []ContentEncoding{
+				{Order: 0, Scope: ContentEncodingScopeFrames, Compression: &ContentCompression{Algo: CompPrepend, Settings: []byte{0x01}}},
+				{Order: 1, Scope: ContentEncodingScopeFrames, Compression: &ContentCompression{Algo: CompPrepend, Settings: []byte{0x02}}},
+			},
+		}
+		parser := &MatroskaParser{}
+		got, err := parser.decodeFrame(track, []byte{0x03})
+		if err != nil {
+			t.Fatalf("decodeFrame() failed: %v", err)
+		}
+		want := []byte{0x01, 0x02, 0x03}
+		if !bytes.Equal(got, want) {
+			t.Errorf("decodeFrame() = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("CodecPrivate scope is ignored", func(t *testing.T) {
+		track := &TrackInfo{
+			ContentEncodings: []ContentEncoding{
+				{Scope: ContentEncodingScopeCodecPrivate, Compression: &ContentCompression{Algo: CompPrepend, Settings: []byte{0x01}}},
+			},
+		}
+		parser := &MatroskaParser{}
+		got, err := parser.decodeFrame(track, []byte{0x03})
+		if err != nil {
+			t.Fatalf("decodeFrame() failed: %v", err)
+		}
+		if !bytes.Equal(got, []byte{0x03}) {
+			t.Errorf("decodeFrame() = %x, want 03", got)
+		}
+	})
+
+	t.Run("Inflated size is limited", func(t *testing.T) {
+		deflated := new(bytes.Buffer)
+		zw := zlib.NewWriter(deflated)
+		_, _ = zw.Write(make([]byte, 1<<20))
+		_ = zw.Close()
+
+		track := &TrackInfo{
+			ContentEncodings: []ContentEncoding{
+				{Scope: ContentEncodingScopeFrames, Compression: &ContentCompression{Algo: CompZlib}},
+			},
+		}
+		parser := &MatroskaParser{reader: &EBMLReader{maxElementSize: 1 << 16}}
+		if _, err := parser.decodeFrame(track, deflated.Bytes()); !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("decodeFrame() error = %v, want %v", err, ErrElementTooLarge)
+		}
+
+		parser.reader.maxElementSize = 1 << 20
+		got, err := parser.decodeFrame(track, deflated.Bytes())
+		if err != nil {
+			t.Fatalf("decodeFrame() failed: %v", err)
+		}
+		if len(got) != 1<<20 {
+			t.Errorf("decodeFrame() returned %d bytes, want %d", len(got), 1<<20)
+		}
+	})
+}
+
+// encryptWebMFrame encrypts data with AES-CTR as described by the WebM
+// encryption specification, returning the signal byte, IV and ciphertext.
+func encryptWebMFrame(t *testing.T, key, iv, data []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher() failed: %v", err)
+	}
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, iv)
+	ciphertext := make([]byte, len(data))
+	cipher.NewCTR(block, counter).XORKeyStream(ciphertext, data)
+
+	frame := append([]byte{webmSignalEncrypted}, iv...)
+	return append(frame, ciphertext...)
+}
+
+func TestDecryptFrame(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	encryption := &ContentEncryption{Algo: EncAlgoAES, AESCipherMode: AESCipherModeCTR}
+
+	t.Run("Encrypted frame", func(t *testing.T) {
+		plaintext := []byte("an encrypted frame spanning more than one AES block")
+		got, err := decryptFrame(encryption, key, encryptWebMFrame(t, key, iv, plaintext))
+		if err != nil {
+			t.Fatalf("decryptFrame() failed: %v", err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("decryptFrame() = %q, want %q", got, plaintext)
+		}
+	})
+
+	t.Run("Known ciphertext", func(t *testing.T) {
+		frame := []byte{
+			0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+			0x4B, 0xD6, 0xA9, 0x54, 0x04,
+		}
+		got, err := decryptFrame(encryption, key, frame)
+		if err != nil {
+			t.Fatalf("decryptFrame() failed: %v", err)
+		}
+		if !bytes.Equal(got, []byte("frame")) {
+			t.Errorf("decryptFrame() = %q, want %q", got, "frame")
+		}
+	})
+
+	t.Run("Unencrypted frame", func(t *testing.T) {
+		got, err := decryptFrame(encryption, key, []byte{0x00, 0xAA, 0xBB})
+		if err != nil {
+			t.Fatalf("decryptFrame() failed: %v", err)
+		}
+		if !bytes.Equal(got, []byte{0xAA, 0xBB}) {
+			t.Errorf("decryptFrame() = %x, want aabb", got)
+		}
+	})
+
+	t.Run("Partitioned frame", func(t *testing.T) {
+		clear1, secret, clear2 := []byte("head"), []byte("secret"), []byte("tail")
+		encrypted := encryptWebMFrame(t, key, iv, secret)[1+webmIVSize:]
+
+		frame := append([]byte{webmSignalEncrypted | webmSignalPartitioned}, iv...)
+		frame = append(frame, 2, 0, 0, 0, 4, 0, 0, 0, 10)
+		frame = append(frame, clear1...)
+		frame = append(frame, encrypted...)
+		frame = append(frame, clear2...)
+
+		got, err := decryptFrame(encryption, key, frame)
+		if err != nil {
+			t.Fatalf("decryptFrame() failed: %v", err)
+		}
+		if want := []byte("headsecrettail"); !bytes.Equal(got, want) {
+			t.Errorf("decryptFrame() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Error cases", func(t *testing.T) {
+		cases := []struct {
+			name       string
+			encryption *ContentEncryption
+			key        []byte
+			data       []byte
+		}{
+			{"Unsupported algorithm", &ContentEncryption{Algo: 1}, key, []byte{0x00}},
+			{"Empty frame", encryption, key, nil},
+			{"Truncated IV", encryption, key, []byte{0x01, 0x01, 0x02}},
+			{"Invalid key", encryption, []byte{0x01}, encryptWebMFrame(t, key, iv, []byte{0x01})},
+			{"Invalid partition offset", encryption, key, append(append([]byte{0x03}, iv...), 1, 0, 0, 0, 9, 0xAA)},
+		}
+		for _, c := range cases {
+			if _, err := decryptFrame(c.encryption, c.key, c.data); err == nil {
+				t.Errorf("%s: expected an error", c.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,261 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains Dump, which prints the structure of a file in the style of mkvinfo.
+package matroska
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dumpWriter writes the indented lines of a dump, and keeps the first write error.
+type dumpWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line writes a line at the given depth, with the mkvinfo tree prefix.
+//
+// Parameters:
+//   - depth: The depth of the line in the tree, 0 for top-level elements.
+//   - format: The format of the line, as for fmt.Printf.
+//   - args: The arguments of the format.
+func (dw *dumpWriter) line(depth int, format string, args ...interface{}) {
+	if dw.err != nil {
+		return
+	}
+	prefix := "+ "
+	if depth > 0 {
+		prefix = "|" + strings.Repeat(" ", depth-1) + "+ "
+	}
+	_, dw.err = fmt.Fprintf(dw.w, prefix+format+"\n", args...)
+}
+
+// Dump writes a human-readable tree of the structure of the file to w, in the
+// style of mkvinfo.
+//
+// The tree shows the EBML header, the segment information, the key fields of
+// each track, summaries of the chapters, tags and attachments, and the number
+// of cue points and clusters. Clusters are counted by reading the headers of
+// the top-level elements of the segment and seeking past their data, so no
+// cluster data is read. The number of clusters is not shown when the demuxer
+// avoids seeks, and the read position is restored afterwards, so Dump can be
+// called at any time.
+//
+// Example:
+//
+//	if err := demuxer.Dump(os.Stdout); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - w: The destination of the dump.
+//
+// Returns:
+//   - error: An error if the clusters could not be counted, or the dump could
+//     not be written.
+func (d *Demuxer) Dump(w io.Writer) error {
+	d.lock()
+	defer d.unlock()
+	mp := d.parser
+	dw := &dumpWriter{w: w}
+
+	if header := mp.header; header != nil {
+		dw.line(0, "EBML head")
+		dw.line(1, "EBML version: %d", header.Version)
+		dw.line(1, "EBML read version: %d", header.ReadVersion)
+		dw.line(1, "Maximum EBML ID length: %d", header.MaxIDLength)
+		dw.line(1, "Maximum EBML size length: %d", header.MaxSizeLength)
+		dw.line(1, "Document type: %s", header.DocType)
+		dw.line(1, "Document type version: %d", header.DocTypeVersion)
+		dw.line(1, "Document type read version: %d", header.DocTypeReadVersion)
+	}
+
+	if mp.segment != nil && mp.segment.Size == (1<<(7*8))-1 {
+		dw.line(0, "Segment: size unknown")
+	} else if mp.segment != nil {
+		dw.line(0, "Segment: size %d", mp.segment.Size)
+	}
+
+	if info := mp.fileInfo; info != nil {
+		dw.line(1, "Segment information")
+		if info.Title != "" {
+			dw.line(2, "Title: %s", info.Title)
+		}
+		dw.line(2, "Timestamp scale: %d", info.TimecodeScale)
+		if info.Duration > 0 {
+			dw.line(2, "Duration: %s", formatDumpTime(uint64(info.DurationNanos())))
+		}
+		if info.DateUTCValid {
+			dw.line(2, "Date: %s UTC", info.DateUTCTime().Format("2006-01-02 15:04:05"))
+		}
+		dw.line(2, "Multiplexing application: %s", info.MuxingApp)
+		dw.line(2, "Writing application: %s", info.WritingApp)
+		if info.UID != [16]byte{} {
+			dw.line(2, "Segment UID: %x", info.UID[:])
+		}
+	}
+
+	dw.line(1, "Tracks")
+	for i, track := range mp.tracks {
+		dw.line(2, "Track")
+		dw.line(3, "Track number: %d (track index: %d)", track.Number, i)
+		dw.line(3, "Track UID: %d", track.UID)
+		dw.line(3, "Track type: %s", track.Type)
+		dw.line(3, "Codec ID: %s", track.CodecID)
+		if len(track.CodecPrivate) > 0 {
+			dw.line(3, "Codec's private data: size %d", len(track.CodecPrivate))
+		}
+		if track.Name != "" {
+			dw.line(3, "Name: %s", track.Name)
+		}
+		if track.Language != "" {
+			dw.line(3, "Language: %s", track.Language)
+		}
+		if track.LanguageIETF != "" {
+			dw.line(3, "Language (IETF BCP 47): %s", track.LanguageIETF)
+		}
+		dw.line(3, "\"Enabled\" flag: %d", boolToUInt(track.Enabled))
+		dw.line(3, "\"Default track\" flag: %d", boolToUInt(track.Default))
+		if track.Forced {
+			dw.line(3, "\"Forced display\" flag: 1")
+		}
+		if track.DefaultDuration > 0 {
+			dw.line(3, "Default duration: %s", formatDumpTime(track.DefaultDuration))
+		}
+		if len(track.ContentEncodings) > 0 {
+			dw.line(3, "Content encodings: %d", len(track.ContentEncodings))
+		}
+
+		switch track.Type {
+		case TypeVideo:
+			dw.line(3, "Video track")
+			dw.line(4, "Pixel width: %d", track.Video.PixelWidth)
+			dw.line(4, "Pixel height: %d", track.Video.PixelHeight)
+			if track.Video.DisplayWidth > 0 && track.Video.DisplayHeight > 0 {
+				dw.line(4, "Display width: %d", track.Video.DisplayWidth)
+				dw.line(4, "Display height: %d", track.Video.DisplayHeight)
+			}
+			if track.Video.Interlaced {
+				dw.line(4, "Interlaced: 1")
+			}
+		case TypeAudio:
+			dw.line(3, "Audio track")
+			dw.line(4, "Sampling frequency: %g", track.Audio.SamplingFreq)
+			if track.Audio.OutputSamplingFreq > 0 && track.Audio.OutputSamplingFreq != track.Audio.SamplingFreq {
+				dw.line(4, "Output sampling frequency: %g", track.Audio.OutputSamplingFreq)
+			}
+			dw.line(4, "Channels: %d", track.Audio.Channels)
+			if track.Audio.BitDepth > 0 {
+				dw.line(4, "Bit depth: %d", track.Audio.BitDepth)
+			}
+		}
+	}
+
+	if len(mp.chapters) > 0 {
+		dw.line(1, "Chapters: %d", len(mp.chapters))
+		dumpChapters(dw, mp.chapters, 2)
+	}
+	if len(mp.tags) > 0 {
+		dw.line(1, "Tags: %d", len(mp.tags))
+		for _, tag := range mp.tags {
+			names := make([]string, 0, len(tag.SimpleTags))
+			for _, simpleTag := range tag.SimpleTags {
+				names = append(names, simpleTag.Name)
+			}
+			dw.line(2, "Tag: %d targets, simple tags: %s", len(tag.Targets), strings.Join(names, ", "))
+		}
+	}
+	if len(mp.attachments) > 0 {
+		dw.line(1, "Attachments: %d", len(mp.attachments))
+		for _, attachment := range mp.attachments {
+			dw.line(2, "Attached file: %s (%s, %d bytes)", attachment.Name, attachment.MimeType, attachment.Length)
+		}
+	}
+	dw.line(1, "Cues: %d cue points", len(mp.cues))
+
+	if !mp.avoidSeeks {
+		clusters, complete, err := mp.countClusters()
+		if err != nil {
+			return fmt.Errorf("failed to count clusters: %w", err)
+		}
+		if complete {
+			dw.line(1, "Clusters: %d", clusters)
+		} else {
+			dw.line(1, "Clusters: at least %d", clusters)
+		}
+	}
+
+	return dw.err
+}
+
+// dumpChapters writes the lines of chapters, and of their nested chapters.
+//
+// Parameters:
+//   - dw: The writer of the dump.
+//   - chapters: The chapters to write.
+//   - depth: The depth of the chapter lines.
+func dumpChapters(dw *dumpWriter, chapters []*Chapter, depth int) {
+	for _, chapter := range chapters {
+		name := ""
+		if len(chapter.Display) > 0 {
+			name = chapter.Display[0].String
+		}
+		dw.line(depth, "Chapter: %s - %s %q", formatDumpTime(chapter.Start), formatDumpTime(chapter.End), name)
+		dumpChapters(dw, chapter.Children, depth+1)
+	}
+}
+
+// countClusters counts the Cluster elements of the segment by reading the
+// headers of its top-level elements, seeking past their data. The read
+// position is restored afterwards.
+//
+// Returns:
+//   - int: The number of clusters found.
+//   - bool: False if the scan stopped at an element of unknown size, which
+//     cannot be skipped, so that more clusters may follow.
+//   - error: An error if the input could not be read or seeked.
+func (mp *MatroskaParser) countClusters() (count int, complete bool, err error) {
+	currentPos := mp.reader.Position()
+	defer func() {
+		if _, errSeek := mp.reader.Seek(currentPos, io.SeekStart); errSeek != nil && err == nil {
+			err = fmt.Errorf("failed to restore position: %w", errSeek)
+		}
+	}()
+
+	if _, err = mp.reader.Seek(int64(mp.segmentPos), io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	segmentEnd := mp.segmentPos + mp.segment.Size
+	for mp.reader.Position() < int64(segmentEnd) {
+		id, size, errReadHeader := mp.reader.ReadElementHeader()
+		if errReadHeader != nil {
+			if errReadHeader == io.EOF {
+				break
+			}
+			return count, false, errReadHeader
+		}
+		if id == IDCluster {
+			count++
+		}
+		if size == (1<<(7*8))-1 {
+			return count, false, nil
+		}
+		if _, err = mp.reader.Seek(int64(size), io.SeekCurrent); err != nil {
+			return count, false, err
+		}
+	}
+	return count, true, nil
+}
+
+// formatDumpTime formats a time in nanoseconds as HH:MM:SS.nnnnnnnnn.
+//
+// Parameters:
+//   - ns: The time in nanoseconds.
+//
+// Returns:
+//   - string: The formatted time, for example "01:01:01.123000000".
+func formatDumpTime(ns uint64) string {
+	seconds := ns / 1000000000
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", seconds/3600, seconds/60%60, seconds%60, ns%1000000000)
+}
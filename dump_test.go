@@ -0,0 +1,74 @@
+package matroska
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDemuxer_Dump(t *testing.T) {
+	video := &TrackInfo{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}
+	video.Video.PixelWidth = 640
+	video.Video.PixelHeight = 360
+	audio := &TrackInfo{Type: TypeAudio, CodecID: "A_OPUS", CodecPrivate: []byte("OpusHead"), Language: "eng"}
+	audio.Audio.SamplingFreq = 48000
+	audio.Audio.Channels = 2
+
+	var packets []*Packet
+	for i := uint64(0); i < 3; i++ {
+		packets = append(packets,
+			&Packet{Track: 1, StartTime: i * 1000000000, Data: []byte{byte(i)}, Flags: KF},
+			&Packet{Track: 2, StartTime: i * 1000000000, Data: []byte{0xA0 + byte(i)}, Flags: KF},
+		)
+	}
+	demuxer := createMuxedFile(t, []*TrackInfo{video, audio}, packets)
+	demuxer.parser.chapters = []*Chapter{
+		{Start: 0, End: 2000000000, Display: []ChapterDisplay{{String: "Intro"}}},
+	}
+	demuxer.parser.tags = []*Tag{
+		{SimpleTags: []SimpleTag{{Name: "TITLE"}, {Name: "ARTIST"}}},
+	}
+
+	var out bytes.Buffer
+	if err := demuxer.Dump(&out); err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	dump := out.String()
+
+	for _, line := range []string{
+		"+ EBML head\n",
+		"|+ Document type: matroska\n",
+		"+ Segment: size ",
+		"|+ Segment information\n",
+		"| + Timestamp scale: 1000000\n",
+		"| + Duration: 00:00:02.000000000\n",
+		"| + Multiplexing application: matroska-go\n",
+		"|+ Tracks\n",
+		"|  + Track number: 1 (track index: 0)\n",
+		"|  + Track type: video\n",
+		"|  + Codec ID: V_VP9\n",
+		"|  + Default duration: 00:00:00.040000000\n",
+		"|   + Pixel width: 640\n",
+		"|  + Track type: audio\n",
+		"|  + Codec's private data: size 8\n",
+		"|  + Language: eng\n",
+		"|   + Sampling frequency: 48000\n",
+		"|   + Channels: 2\n",
+		"|+ Chapters: 1\n",
+		"| + Chapter: 00:00:00.000000000 - 00:00:02.000000000 \"Intro\"\n",
+		"|+ Tags: 1\n",
+		"| + Tag: 0 targets, simple tags: TITLE, ARTIST\n",
+		"|+ Cues: 3 cue points\n",
+		"|+ Clusters: 3\n",
+	} {
+		if !strings.Contains(dump, line) {
+			t.Errorf("Dump() output is missing %q:\n%s", line, dump)
+		}
+	}
+
+	// Counting the clusters must not move the read position
+	packet, err := demuxer.ReadPacket()
+	if err != nil || packet.Track != 1 || packet.StartTime != 0 {
+		t.Errorf("ReadPacket() after Dump() = %+v, %v, want the first packet", packet, err)
+	}
+}
@@ -0,0 +1,232 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains ComputeDuration, which measures the duration of a file
+// from its last cluster.
+package matroska
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// clusterSearchWindow is the size of the first window at the end of the
+// segment searched for the last cluster. It is doubled until a cluster is found.
+const clusterSearchWindow = 1 << 20
+
+// clusterIDBytes is the encoded ID of the Cluster element.
+var clusterIDBytes = []byte{0x1F, 0x43, 0xB6, 0x75}
+
+// ComputeDuration returns the duration of the file, measured from the end of
+// its last block.
+//
+// Many files omit the Duration of the SegmentInfo, and files whose muxing was
+// interrupted may declare a wrong one. ComputeDuration finds the last cluster
+// of the segment, from the cues or by searching backwards from the end of the
+// segment for a Cluster ID, and reads the blocks from there to the end of the
+// file. The duration is the largest end time of these blocks, which is their
+// timestamp plus their BlockDuration or their track's DefaultDuration. A
+// truncated last cluster is read up to where it is cut. In a file with several
+// segments, the last cluster of the last segment is used, and the duration
+// includes its TimeOffset, see Segments.
+//
+// Only the end of the file is read, and the read position is restored
+// afterwards. The result is cached, so later calls return immediately. When
+// the demuxer avoids seeks, the Duration of the SegmentInfo is returned
+// instead, if there is one.
+//
+// Example:
+//
+//	duration, err := demuxer.ComputeDuration()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Duration: %s\n", duration)
+//
+// Returns:
+//   - time.Duration: The duration of the file.
+//   - error: An error if the demuxer avoids seeks and the file declares no
+//     duration, if no block is found, or if the input could not be read.
+func (d *Demuxer) ComputeDuration() (time.Duration, error) {
+	d.lock()
+	defer d.unlock()
+	return d.parser.computeDuration()
+}
+
+// computeDuration measures the duration of the file from its last cluster,
+// and caches it. See Demuxer.ComputeDuration.
+//
+// Returns:
+//   - time.Duration: The duration of the file.
+//   - error: An error if the duration could not be computed.
+func (mp *MatroskaParser) computeDuration() (duration time.Duration, err error) {
+	if mp.durationComputed {
+		return mp.computedDuration, nil
+	}
+	if mp.avoidSeeks {
+		if mp.fileInfo != nil && mp.fileInfo.Duration > 0 {
+			return mp.fileInfo.DurationNanos(), nil
+		}
+		return 0, fmt.Errorf("cannot compute the duration without seeking")
+	}
+
+	// Save the read state, which is changed by readPacket
+	restore := mp.saveReadState()
+	defer func() {
+		if errRestore := restore(); errRestore != nil && err == nil {
+			err = errRestore
+		}
+	}()
+
+	start, err := mp.lastClusterPosition()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = mp.reader.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	mp.segmentIndex = mp.segmentAt(uint64(start))
+
+	mp.currentTrackMask = 0
+	var end uint64
+	found := false
+	for {
+		packet, errReadPacket := mp.readPacket(context.Background())
+		if errReadPacket != nil {
+			// The end of the file, or a truncated last cluster
+			break
+		}
+		found = true
+		if packet.EndTime > end {
+			end = packet.EndTime
+		}
+		if packet.StartTime > end {
+			end = packet.StartTime
+		}
+		packet.Release()
+	}
+	if !found {
+		return 0, fmt.Errorf("no block found in the last cluster")
+	}
+
+	mp.computedDuration = time.Duration(end)
+	mp.durationComputed = true
+	return mp.computedDuration, nil
+}
+
+// lastClusterPosition returns the position of a cluster from which the last
+// cluster of the last segment can be reached by reading forward.
+//
+// The last cluster referenced by the cues is used if there are cues and the
+// file has a single segment. Otherwise, the end of the last segment is
+// searched backwards with findClusterBefore.
+//
+// Returns:
+//   - int64: The position of the Cluster element.
+//   - error: An error if no cluster is found or the input could not be read.
+func (mp *MatroskaParser) lastClusterPosition() (int64, error) {
+	var cuePos uint64
+	for _, cue := range mp.cues {
+		if cue.Position > cuePos {
+			cuePos = cue.Position
+		}
+	}
+	if cuePos > 0 && len(mp.segments) <= 1 {
+		return int64(mp.segmentPos + cuePos), nil
+	}
+
+	segment := mp.segment
+	if len(mp.segments) > 0 {
+		segment = mp.segments[len(mp.segments)-1]
+	}
+	fileEnd, err := mp.reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	end := fileEnd
+	if segment.Size != (1<<(7*8))-1 && int64(segment.Position+segment.Size) < end {
+		end = int64(segment.Position + segment.Size)
+	}
+	position, found, err := mp.findClusterBefore(end, int64(segment.Position))
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("no cluster found in the segment")
+	}
+	return position, nil
+}
+
+// findClusterBefore searches backwards from end for the ID of a Cluster whose
+// size is consistent with end, in windows that are doubled until one is found
+// or limit is reached.
+//
+// Parameters:
+//   - end: The position before which the cluster must start.
+//   - limit: The position before which the search stops.
+//
+// Returns:
+//   - int64: The position of the Cluster element.
+//   - bool: True if a cluster was found.
+//   - error: An error if the input could not be read.
+func (mp *MatroskaParser) findClusterBefore(end, limit int64) (int64, bool, error) {
+	for window := int64(clusterSearchWindow); ; window *= 2 {
+		start := end - window
+		if start < limit {
+			start = limit
+		}
+		if start >= end {
+			return 0, false, nil
+		}
+		if _, err := mp.reader.Seek(start, io.SeekStart); err != nil {
+			return 0, false, err
+		}
+		data, err := mp.reader.readData(uint64(end - start))
+		if err != nil {
+			return 0, false, err
+		}
+
+		for i := bytes.LastIndex(data, clusterIDBytes); i >= 0; i = bytes.LastIndex(data[:i], clusterIDBytes) {
+			if isClusterHeader(data[i+len(clusterIDBytes):], end-start-int64(i)-int64(len(clusterIDBytes))) {
+				return start + int64(i), true, nil
+			}
+		}
+		if start == limit {
+			return 0, false, nil
+		}
+	}
+}
+
+// isClusterHeader reports whether data, which follows a Cluster ID, starts
+// with a valid element size that fits in the remaining bytes of the segment.
+// A cluster cut by the end of the file is accepted, as long as it has a
+// Timestamp child, which is always first.
+//
+// Parameters:
+//   - data: The bytes following the Cluster ID.
+//   - remaining: The number of bytes from the start of data to the end of the segment.
+//
+// Returns:
+//   - bool: True if data looks like the start of a cluster.
+func isClusterHeader(data []byte, remaining int64) bool {
+	if len(data) == 0 || data[0] == 0 {
+		return false
+	}
+	length := 1
+	for data[0]&(0x80>>uint(length-1)) == 0 {
+		length++
+	}
+	if len(data) < length+1 {
+		return false
+	}
+	size := uint64(data[0] & (0xFF >> uint(length)))
+	for _, b := range data[1:length] {
+		size = size<<8 | uint64(b)
+	}
+	unknown := size == (1<<(7*uint(length)))-1
+	if !unknown && size > uint64(remaining-int64(length)) {
+		return false
+	}
+	return data[length] == IDTimestamp
+}
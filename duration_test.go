@@ -0,0 +1,114 @@
+package matroska
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDemuxer_ComputeDuration(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"},
+	}
+	// Timestamps more than 32767 ms apart start new clusters
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		{Track: 1, StartTime: 40000000000, Data: []byte{0x02}, Flags: KF},
+		{Track: 2, StartTime: 60000000000, EndTime: 65000000000, Data: []byte("Hi")},
+		{Track: 1, StartTime: 80000000000, Data: []byte{0x03}, Flags: KF},
+		{Track: 1, StartTime: 80040000000, Data: []byte{0x04}},
+	}
+	// The end of the last video frame, with the DefaultDuration
+	expected := 80080000000 * time.Nanosecond
+
+	t.Run("With cues", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if len(demuxer.parser.cues) == 0 {
+			t.Fatal("Expected the muxed file to have cues")
+		}
+		duration, err := demuxer.ComputeDuration()
+		if err != nil {
+			t.Fatalf("ComputeDuration() failed: %v", err)
+		}
+		if duration != expected {
+			t.Errorf("Expected duration %v, got %v", expected, duration)
+		}
+	})
+
+	t.Run("Without cues", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		demuxer.parser.cues = nil
+		duration, err := demuxer.ComputeDuration()
+		if err != nil {
+			t.Fatalf("ComputeDuration() failed: %v", err)
+		}
+		if duration != expected {
+			t.Errorf("Expected duration %v, got %v", expected, duration)
+		}
+	})
+
+	t.Run("Preserves the read position", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		first, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if _, err = demuxer.ComputeDuration(); err != nil {
+			t.Fatalf("ComputeDuration() failed: %v", err)
+		}
+
+		var count int
+		for {
+			packet, errReadPacket := demuxer.ReadPacket()
+			if errReadPacket == io.EOF {
+				break
+			}
+			if errReadPacket != nil {
+				t.Fatalf("ReadPacket() failed: %v", errReadPacket)
+			}
+			if count == 0 && packet.StartTime != 40000000000 {
+				t.Errorf("Expected the packet after %d, got one at %d", first.StartTime, packet.StartTime)
+			}
+			count++
+		}
+		if count != len(packets)-1 {
+			t.Errorf("Expected %d remaining packets, got %d", len(packets)-1, count)
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if _, err := demuxer.ComputeDuration(); err != nil {
+			t.Fatalf("ComputeDuration() failed: %v", err)
+		}
+		demuxer.parser.cues = nil
+		demuxer.parser.segmentPos = 1 << 40
+		duration, err := demuxer.ComputeDuration()
+		if err != nil {
+			t.Fatalf("Second ComputeDuration() failed: %v", err)
+		}
+		if duration != expected {
+			t.Errorf("Expected cached duration %v, got %v", expected, duration)
+		}
+	})
+
+	t.Run("Avoiding seeks", func(t *testing.T) {
+		demuxer := &Demuxer{parser: &MatroskaParser{
+			avoidSeeks: true,
+			fileInfo:   &SegmentInfo{Duration: 2500, TimecodeScale: 1000000},
+		}}
+		duration, err := demuxer.ComputeDuration()
+		if err != nil {
+			t.Fatalf("ComputeDuration() failed: %v", err)
+		}
+		if duration != 2500*time.Millisecond {
+			t.Errorf("Expected duration %v, got %v", 2500*time.Millisecond, duration)
+		}
+
+		demuxer.parser.fileInfo.Duration = 0
+		if _, err = demuxer.ComputeDuration(); err == nil {
+			t.Error("Expected an error without a declared duration")
+		}
+	})
+}
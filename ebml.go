@@ -35,6 +35,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"math"
 )
@@ -54,6 +55,10 @@ const (
 	IDEBMLDocTypeVersion     = 0x4287     // The version of the document type
 	IDEBMLDocTypeReadVersion = 0x4285     // The minimum version of the document type parser needed to read this file
 
+	// Global elements, which may appear inside any master element
+	IDVoid  = 0xEC // Padding whose data is ignored, used to reserve space for later changes
+	IDCRC32 = 0xBF // The CRC-32 of the data following it in its parent master element
+
 	// Segment elements
 	IDSegment = 0x18538067 // The root element that contains all other top-level elements
 
@@ -81,38 +86,131 @@ const (
 	IDWritingApp       = 0x5741     // The name of the application used to write the file
 
 	// Track elements
-	IDTracks     = 0x1654AE6B // A top-level element containing all track entries
-	IDTrackEntry = 0xAE       // A single track entry containing information about a track
-	IDTrackNum   = 0xD7       // The track number as used in the Block header
-	IDTrackUID   = 0x73C5     // A unique identifier for the track
-	IDTrackType  = 0x83       // The type of the track (video, audio, etc.)
-	IDTrackName  = 0x536E     // The name of the track
-	IDLanguage   = 0x22B59C   // The language of the track
-	IDCodecID    = 0x86       // The ID of the codec used for this track
-	IDCodecPriv  = 0x63A2     // Private data specific to the codec
-	IDCodecName  = 0x258688   // The name of the codec used for this track
-	IDVideo      = 0xE0       // Video settings specific to this track
-	IDAudio      = 0xE1       // Audio settings specific to this track
+	IDTracks           = 0x1654AE6B // A top-level element containing all track entries
+	IDTrackEntry       = 0xAE       // A single track entry containing information about a track
+	IDTrackNum         = 0xD7       // The track number as used in the Block header
+	IDTrackUID         = 0x73C5     // A unique identifier for the track
+	IDTrackType        = 0x83       // The type of the track (video, audio, etc.)
+	IDFlagEnabled      = 0xB9       // Set if the track is usable
+	IDFlagDefault      = 0x88       // Set if the track is eligible for automatic selection by the player
+	IDFlagForced       = 0x55AA     // Set if the track must be played regardless of user preferences
+	IDFlagLacing       = 0x9C       // Set if the track may contain blocks using lacing
+	IDMaxBlockAddID    = 0x55EE     // The maximum BlockAddID of the BlockAdditions of the track
+	IDTrackOverlay     = 0x6FAB     // A track to play when the data of this track is not available
+	IDDefaultDuration  = 0x23E383   // The number of nanoseconds a frame lasts
+	IDCodecDelay       = 0x56AA     // The codec-built-in delay in nanoseconds
+	IDSeekPreRoll      = 0x56BB     // The duration in nanoseconds of data to decode and discard after a seek
+	IDTrackName        = 0x536E     // The name of the track
+	IDLanguage         = 0x22B59C   // The language of the track
+	IDLanguageIETF     = 0x22B59D   // The BCP 47 language of the track
+	IDCodecID          = 0x86       // The ID of the codec used for this track
+	IDCodecPriv        = 0x63A2     // Private data specific to the codec
+	IDCodecName        = 0x258688   // The name of the codec used for this track
+	IDCodecSettings    = 0x3A9697   // A description of the codec settings used for this track
+	IDCodecInfoURL     = 0x3B4040   // A URL giving information about the codec
+	IDCodecDownloadURL = 0x26B240   // A URL to download the codec from
+	IDVideo            = 0xE0       // Video settings specific to this track
+	IDAudio            = 0xE1       // Audio settings specific to this track
+	IDContentEncodings = 0x6D80     // Settings for the content encodings used in this track
+	IDTrackOperation   = 0xE2       // The operation building this virtual track from other tracks
+
+	// TrackEntry element describing interlaced video, parsed into the video settings
+	IDDefaultDecodedFieldDuration = 0x234E7A // The number of nanoseconds a decoded field lasts
+
+	// TrackOperation elements
+	IDTrackCombinePlanes = 0xE3 // The video planes combined into this track
+	IDTrackPlane         = 0xE4 // A video plane to combine
+	IDTrackPlaneUID      = 0xE5 // The UID of the track used as a plane
+	IDTrackPlaneType     = 0xE6 // The kind of plane the track is used for
+	IDTrackJoinBlocks    = 0xE9 // The tracks whose blocks are joined into this track
+	IDTrackJoinUID       = 0xED // The UID of a track whose blocks are joined
 
 	// Video elements
-	IDFlagInterlaced = 0x9A   // Flag indicating whether the video is interlaced
-	IDPixelWidth     = 0xB0   // The width of the encoded video frames in pixels
-	IDPixelHeight    = 0xBA   // The height of the encoded video frames in pixels
-	IDDisplayWidth   = 0x54B0 // The width of the video frames when displayed
-	IDDisplayHeight  = 0x54BA // The height of the video frames when displayed
+	IDFlagInterlaced  = 0x9A     // Flag indicating whether the video is interlaced
+	IDFieldOrder      = 0x9D     // The field ordering of interlaced video
+	IDPixelWidth      = 0xB0     // The width of the encoded video frames in pixels
+	IDPixelHeight     = 0xBA     // The height of the encoded video frames in pixels
+	IDDisplayWidth    = 0x54B0   // The width of the video frames when displayed
+	IDDisplayHeight   = 0x54BA   // The height of the video frames when displayed
+	IDDisplayUnit     = 0x54B2   // How DisplayWidth and DisplayHeight are interpreted
+	IDAspectRatioType = 0x54B3   // How the aspect ratio may be changed during playback
+	IDPixelCropBottom = 0x54AA   // The number of pixels to remove at the bottom of the image
+	IDPixelCropTop    = 0x54BB   // The number of pixels to remove at the top of the image
+	IDPixelCropLeft   = 0x54CC   // The number of pixels to remove on the left of the image
+	IDPixelCropRight  = 0x54DD   // The number of pixels to remove on the right of the image
+	IDColourSpace     = 0x2EB524 // The FourCC of the pixel layout of uncompressed video
+	IDColour          = 0x55B0   // Settings describing the colour format
+	IDProjection      = 0x7670   // Settings describing the video projection
+
+	// Projection elements
+	IDProjectionType      = 0x7671 // The projection used for the video
+	IDProjectionPrivate   = 0x7672 // Private data that only applies to a specific projection
+	IDProjectionPoseYaw   = 0x7673 // The yaw rotation of the projection
+	IDProjectionPosePitch = 0x7674 // The pitch rotation of the projection
+	IDProjectionPoseRoll  = 0x7675 // The roll rotation of the projection
+
+	// Colour elements
+	IDMatrixCoefficients      = 0x55B1 // The matrix coefficients of the video
+	IDBitsPerChannel          = 0x55B2 // The number of decoded bits per colour channel
+	IDChromaSubsamplingHorz   = 0x55B3 // The horizontal chroma subsampling
+	IDChromaSubsamplingVert   = 0x55B4 // The vertical chroma subsampling
+	IDCbSubsamplingHorz       = 0x55B5 // The horizontal Cb subsampling
+	IDCbSubsamplingVert       = 0x55B6 // The vertical Cb subsampling
+	IDChromaSitingHorz        = 0x55B7 // How chroma is sited horizontally
+	IDChromaSitingVert        = 0x55B8 // How chroma is sited vertically
+	IDRange                   = 0x55B9 // The clipping of the colour ranges
+	IDTransferCharacteristics = 0x55BA // The transfer characteristics of the video
+	IDPrimaries               = 0x55BB // The colour primaries of the video
+	IDMaxCLL                  = 0x55BC // Maximum content light level in candelas per square meter
+	IDMaxFALL                 = 0x55BD // Maximum frame-average light level in candelas per square meter
+	IDMasteringMetadata       = 0x55D0 // SMPTE 2086 mastering display metadata
+
+	// MasteringMetadata elements
+	IDPrimaryRChromaticityX   = 0x55D1 // Red X chromaticity coordinate
+	IDPrimaryRChromaticityY   = 0x55D2 // Red Y chromaticity coordinate
+	IDPrimaryGChromaticityX   = 0x55D3 // Green X chromaticity coordinate
+	IDPrimaryGChromaticityY   = 0x55D4 // Green Y chromaticity coordinate
+	IDPrimaryBChromaticityX   = 0x55D5 // Blue X chromaticity coordinate
+	IDPrimaryBChromaticityY   = 0x55D6 // Blue Y chromaticity coordinate
+	IDWhitePointChromaticityX = 0x55D7 // White point X chromaticity coordinate
+	IDWhitePointChromaticityY = 0x55D8 // White point Y chromaticity coordinate
+	IDLuminanceMax            = 0x55D9 // Maximum luminance in candelas per square meter
+	IDLuminanceMin            = 0x55DA // Minimum luminance in candelas per square meter
+
+	// ContentEncoding elements
+	IDContentEncoding       = 0x6240 // A content encoding (compression or encryption) of the track
+	IDContentEncodingOrder  = 0x5031 // The order in which the content encoding was applied
+	IDContentEncodingScope  = 0x5032 // The parts of the track the content encoding applies to
+	IDContentEncodingType   = 0x5033 // The type of the content encoding (compression or encryption)
+	IDContentCompression    = 0x5034 // The compression settings of the content encoding
+	IDContentCompAlgo       = 0x4254 // The compression algorithm
+	IDContentCompSettings   = 0x4255 // Settings specific to the compression algorithm
+	IDContentEncryption     = 0x5035 // The encryption settings of the content encoding
+	IDContentEncAlgo        = 0x47E1 // The encryption algorithm
+	IDContentEncKeyID       = 0x47E2 // The ID of the encryption key
+	IDContentEncAESSettings = 0x47E7 // Settings specific to AES encryption
+	IDAESSettingsCipherMode = 0x47E8 // The AES cipher mode
 
 	// Audio elements
 	IDSamplingFrequency       = 0xB5   // The sampling frequency of the audio in Hz
 	IDOutputSamplingFrequency = 0x78B5 // The output sampling frequency of the audio in Hz
 	IDChannels                = 0x9F   // The number of audio channels
 	IDBitDepth                = 0x6264 // The number of bits per audio sample
+	IDEmphasis                = 0x52F1 // The emphasis applied on the audio samples
 
 	// Cluster elements
-	IDCluster     = 0x1F43B675 // A cluster contains blocks of data for a specific timestamp
-	IDTimestamp   = 0xE7       // The timestamp of the cluster
-	IDSimpleBlock = 0xA3       // A block containing raw data without additional metadata
-	IDBlockGroup  = 0xA0       // A group of blocks with additional metadata
-	IDBlock       = 0xA1       // A block containing raw data
+	IDCluster         = 0x1F43B675 // A cluster contains blocks of data for a specific timestamp
+	IDTimestamp       = 0xE7       // The timestamp of the cluster
+	IDClusterPosition = 0xA7       // The segment-relative position of the cluster
+	IDPrevSize        = 0xAB       // The size of the previous cluster in bytes
+	IDSilentTracks    = 0x5854     // The list of tracks that are not used in the cluster
+	IDSilentTrackNum  = 0x58D7     // The number of a track that is not used in the cluster
+	IDSimpleBlock     = 0xA3       // A block containing raw data without additional metadata
+	IDBlockGroup      = 0xA0       // A group of blocks with additional metadata
+	IDBlock           = 0xA1       // A block containing raw data
+	IDBlockDuration   = 0x9B       // The duration of the block in timestamp units
+	IDReferenceBlock  = 0xFB       // The timestamp of a block referenced by this one, relative to it
+	IDDiscardPadding  = 0x75A2     // The duration in nanoseconds of the silent data at the end of the block
 
 	// Cues elements
 	IDCues             = 0x1C53BB6B // A top-level element containing all cue points
@@ -176,6 +274,13 @@ const (
 	IDFileUID         = 0x46AE     // Unique ID representing the file
 )
 
+// DefaultMaxElementSize is the default maximum size, in bytes, of an element that
+// is read into memory. Larger declared sizes are rejected with ErrElementTooLarge
+// before anything is allocated, so that a corrupt or crafted size cannot exhaust
+// memory. Master elements that are parsed incrementally, such as the Segment and
+// Cluster elements, are not subject to this limit.
+const DefaultMaxElementSize = 256 << 20
+
 // EBMLElement represents an EBML element with its ID, size, and data.
 //
 // An EBML element is the basic building block of EBML files. Each element consists of:
@@ -213,14 +318,19 @@ type EBMLElement struct {
 //
 //	fmt.Printf("Element ID: 0x%X, Size: %d\n", element.ID, element.Size)
 type EBMLReader struct {
-	r   io.ReadSeeker // The underlying reader for the EBML data
-	pos int64         // The current position in the stream
+	r              io.ReadSeeker // The underlying reader for the EBML data
+	pos            int64         // The current position in the stream
+	maxElementSize uint64        // The maximum size of an element read into memory, or 0 for no limit
+	scratch        [1]byte       // The buffer of the bytes read by readVInt, which avoids an allocation per byte
 }
 
 // NewEBMLReader creates a new EBML reader from an io.ReadSeeker.
 //
 // This function initializes a new EBMLReader with the provided io.ReadSeeker.
 // The reader is used to read EBML data from a stream, such as a file or network connection.
+// Reads from r are buffered, so that parsing element headers byte by byte does
+// not read from r each time. The reader reads ahead of its position, so r must
+// not be used directly while the EBMLReader is in use.
 //
 // Parameters:
 //   - r: An io.ReadSeeker that provides the EBML data stream
@@ -238,7 +348,68 @@ type EBMLReader struct {
 //
 //	reader := NewEBMLReader(file)
 func NewEBMLReader(r io.ReadSeeker) *EBMLReader {
-	return &EBMLReader{r: r}
+	return &EBMLReader{r: newBufferedReadSeeker(r), maxElementSize: DefaultMaxElementSize}
+}
+
+// SetMaxElementSize sets the maximum size, in bytes, of an element that is read
+// into memory. A size of 0 removes the limit. The default is DefaultMaxElementSize.
+//
+// Parameters:
+//   - size: The maximum element size.
+func (er *EBMLReader) SetMaxElementSize(size uint64) {
+	er.maxElementSize = size
+}
+
+// readData reads the size bytes of data of an element whose header has just
+// been read, after checking size against the maximum element size.
+//
+// Parameters:
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - []byte: The element data.
+//   - error: An error wrapping ErrElementTooLarge if size exceeds the maximum
+//     element size, or the error returned by the underlying reader.
+func (er *EBMLReader) readData(size uint64) ([]byte, error) {
+	if er.maxElementSize > 0 && size > er.maxElementSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrElementTooLarge, size, er.maxElementSize)
+	}
+
+	data := make([]byte, size)
+	n, err := io.ReadFull(er.r, data)
+	er.pos += int64(n)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readDataInto reads the data of an element whose header has just been read,
+// like readData, reusing buf if its capacity is large enough.
+//
+// Parameters:
+//   - buf: The buffer to reuse, which may be nil.
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - []byte: The element data, in buf or in a new slice.
+//   - error: An error wrapping ErrElementTooLarge if size exceeds the maximum
+//     element size, or the error returned by the underlying reader.
+func (er *EBMLReader) readDataInto(buf []byte, size uint64) ([]byte, error) {
+	if er.maxElementSize > 0 && size > er.maxElementSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrElementTooLarge, size, er.maxElementSize)
+	}
+
+	if uint64(cap(buf)) < size {
+		buf = make([]byte, size)
+	}
+	data := buf[:size]
+	n, err := io.ReadFull(er.r, data)
+	er.pos += int64(n)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
 // ReadVInt reads a variable-length integer from the stream.
@@ -279,11 +450,11 @@ func (er *EBMLReader) ReadVIntID() (uint64, error) {
 //   - The value of the variable-length integer
 //   - An error if the read operation failed or the VINT is invalid
 func (er *EBMLReader) readVInt(keepLengthMarker bool) (uint64, error) {
-	var b [1]byte
+	b := er.scratch[:]
 
 	// Skip any 0x00 padding bytes to resync to the next element/header
 	for {
-		if _, err := er.r.Read(b[:]); err != nil {
+		if _, err := er.r.Read(b); err != nil {
 			return 0, err
 		}
 		er.pos++
@@ -335,9 +506,12 @@ func (er *EBMLReader) readVInt(keepLengthMarker bool) (uint64, error) {
 		result = uint64(firstByte & (lengthMask - 1))
 	}
 
-	// Read remaining bytes
+	// Read remaining bytes; running out of data here means the VINT is truncated
 	for i := 1; i < length; i++ {
-		if _, err := er.r.Read(b[:]); err != nil {
+		if _, err := er.r.Read(b); err != nil {
+			if err == io.EOF {
+				return 0, io.ErrUnexpectedEOF
+			}
 			return 0, err
 		}
 		er.pos++
@@ -355,7 +529,8 @@ func (er *EBMLReader) readVInt(keepLengthMarker bool) (uint64, error) {
 //
 // Returns:
 //   - A pointer to the EBMLElement that was read
-//   - An error if the read operation failed or the element is invalid
+//   - An error if the read operation failed or the element is invalid, or
+//     ErrUnknownSizeUnsupported if the element has an unknown size
 //
 // Example usage:
 //
@@ -388,17 +563,13 @@ func (er *EBMLReader) ReadElement() (*EBMLElement, error) {
 
 	// Check for unknown size marker
 	if size == (1<<(7*8))-1 {
-		return nil, fmt.Errorf("unknown size elements not supported")
+		return nil, ErrUnknownSizeUnsupported
 	}
 
 	// Read element data
-	data := make([]byte, size)
-	if size > 0 {
-		n, errReadFull := io.ReadFull(er.r, data)
-		if errReadFull != nil {
-			return nil, fmt.Errorf("failed to read element data: %w", errReadFull)
-		}
-		er.pos += int64(n)
+	data, err := er.readData(size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read element data: %w", err)
 	}
 
 	return &EBMLElement{
@@ -611,6 +782,32 @@ func (er *EBMLReader) ReadElementHeader() (uint32, uint64, error) {
 	return uint32(id), size, nil
 }
 
+// checkCRC32 validates the data of a master element against its CRC-32 child.
+//
+// A CRC-32 element, when present, must be the first child of its parent. It
+// holds the IEEE CRC-32 of all the data that follows it in the parent, stored
+// as a little-endian 32-bit integer. Data that does not start with a CRC-32
+// element is considered valid.
+//
+// Parameters:
+//   - id: The ID of the master element, reported in the error.
+//   - position: The position of the element's data, reported in the error.
+//   - data: The data of the master element.
+//
+// Returns:
+//   - error: A *CRCError if the checksum does not match, or nil.
+func checkCRC32(id uint32, position int64, data []byte) error {
+	if len(data) < 6 || data[0] != IDCRC32 || data[1] != 0x84 {
+		return nil
+	}
+	expected := binary.LittleEndian.Uint32(data[2:6])
+	actual := crc32.ChecksumIEEE(data[6:])
+	if expected != actual {
+		return &CRCError{ElementID: id, Position: position, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
 // EBMLHeader represents the EBML header containing metadata about the file.
 //
 // The EBML header is the first element in an EBML file and contains information
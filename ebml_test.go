@@ -85,6 +85,20 @@ func TestReadVInt(t *testing.T) {
 	}
 }
 
+// TestReadVInt_Truncated checks that a VINT cut off after its first byte is
+// reported as io.ErrUnexpectedEOF rather than a clean io.EOF.
+func TestReadVInt_Truncated(t *testing.T) {
+	reader := NewEBMLReader(bytes.NewReader([]byte{0x10, 0x00}))
+	if _, err := reader.ReadVInt(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	reader = NewEBMLReader(bytes.NewReader([]byte{}))
+	if _, err := reader.ReadVInt(); err != io.EOF {
+		t.Errorf("Expected io.EOF for empty input, got %v", err)
+	}
+}
+
 // TestEBMLElementRead_Types tests the type reading methods of EBMLElement.
 func TestEBMLElementRead_Types(t *testing.T) {
 	t.Run("ReadUInt", func(t *testing.T) {
@@ -562,11 +576,36 @@ func TestEBMLReader_ReadElement_Advanced(t *testing.T) {
 		}
 	})
 
+	t.Run("Element larger than the maximum size", func(t *testing.T) {
+		// 8-byte size VINT claiming 0x00FFFFFFFFFFFFFE bytes
+		r := bytes.NewReader([]byte{0x42, 0x86, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE})
+		reader := NewEBMLReader(r)
+		_, err := reader.ReadElement()
+		if !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("Expected ErrElementTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Custom maximum size", func(t *testing.T) {
+		data := []byte{0x42, 0x86, 0x84, 0x01, 0x02, 0x03, 0x04}
+		reader := NewEBMLReader(bytes.NewReader(data))
+		reader.SetMaxElementSize(3)
+		if _, err := reader.ReadElement(); !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("Expected ErrElementTooLarge, got %v", err)
+		}
+
+		reader = NewEBMLReader(bytes.NewReader(data))
+		reader.SetMaxElementSize(4)
+		if _, err := reader.ReadElement(); err != nil {
+			t.Errorf("ReadElement() at the limit failed: %v", err)
+		}
+	})
+
 	t.Run("Unknown size element", func(t *testing.T) {
 		r := bytes.NewReader([]byte{0x42, 0x86, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
 		reader := NewEBMLReader(r)
 		_, err := reader.ReadElement()
-		if err == nil || err.Error() != "unknown size elements not supported" {
+		if !errors.Is(err, ErrUnknownSizeUnsupported) {
 			t.Errorf("Expected unknown size error, got %v", err)
 		}
 	})
@@ -0,0 +1,349 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the EBMLWriter, which encodes EBML elements and is the
+// counterpart of the EBMLReader.
+package matroska
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// unknownSizeVInt is an 8-byte VINT with all value bits set, which marks an
+// element size as unknown. It reserves space for a size that is back-patched.
+var unknownSizeVInt = []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+// maxVIntValue is the largest value that can be encoded as a VINT. Larger values
+// would need all the value bits of an 8-byte VINT, which mark an unknown size.
+const maxVIntValue = 1<<56 - 2
+
+// EBMLWriter provides methods for writing EBML data to a stream.
+//
+// EBMLWriter mirrors EBMLReader: it writes element headers, variable-length
+// integers and elements holding the different EBML data types. Master elements
+// whose size is not known in advance can be written with StartMasterElement and
+// EndMasterElement, which back-patch the size once the children are written;
+// this requires the underlying writer to implement io.Seeker.
+//
+// Example usage:
+//
+//	writer := matroska.NewEBMLWriter(file)
+//	sizePos, err := writer.StartMasterElement(matroska.IDTracks)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// Write the children of the Tracks element...
+//	if err = writer.EndMasterElement(sizePos); err != nil {
+//	    log.Fatal(err)
+//	}
+type EBMLWriter struct {
+	w   io.Writer // The underlying writer for the EBML data
+	pos int64     // The current position in the stream
+}
+
+// NewEBMLWriter creates a new EBML writer for an io.Writer.
+//
+// If the writer also implements io.Seeker, positions reported by the writer
+// are absolute positions in the stream; otherwise they are relative to the
+// point where the EBMLWriter was created.
+//
+// Parameters:
+//   - w: An io.Writer that receives the EBML data
+//
+// Returns:
+//   - A pointer to the newly created EBMLWriter
+func NewEBMLWriter(w io.Writer) *EBMLWriter {
+	ew := &EBMLWriter{w: w}
+	if seeker, ok := w.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			ew.pos = pos
+		}
+	}
+	return ew
+}
+
+// Position returns the current position in the stream.
+//
+// Returns:
+//   - The current position in the stream as a byte offset
+func (ew *EBMLWriter) Position() int64 {
+	return ew.pos
+}
+
+// Write writes raw data to the stream, implementing io.Writer.
+//
+// Parameters:
+//   - p: The data to write
+//
+// Returns:
+//   - The number of bytes written
+//   - An error if the write failed
+func (ew *EBMLWriter) Write(p []byte) (int, error) {
+	n, err := ew.w.Write(p)
+	ew.pos += int64(n)
+	return n, err
+}
+
+// WriteVInt writes a variable-length integer, using the smallest length that
+// can hold the value.
+//
+// Parameters:
+//   - v: The value to write, at most 2^56-2
+//
+// Returns:
+//   - An error if the value is too large or the write failed
+func (ew *EBMLWriter) WriteVInt(v uint64) error {
+	if v > maxVIntValue {
+		return fmt.Errorf("value %d is too large for a VINT", v)
+	}
+	_, err := ew.Write(encodeVInt(v))
+	return err
+}
+
+// WriteElementHeader writes the ID and size of an element. The element data
+// must be written next, for example with Write.
+//
+// Parameters:
+//   - id: The element ID, including its length marker
+//   - size: The size of the element data
+//
+// Returns:
+//   - An error if the size is too large or the write failed
+func (ew *EBMLWriter) WriteElementHeader(id uint32, size uint64) error {
+	if _, err := ew.Write(encodeElementID(id)); err != nil {
+		return err
+	}
+	return ew.WriteVInt(size)
+}
+
+// WriteBytes writes a binary element.
+//
+// Parameters:
+//   - id: The element ID
+//   - data: The element data
+//
+// Returns:
+//   - An error if the write failed
+func (ew *EBMLWriter) WriteBytes(id uint32, data []byte) error {
+	if err := ew.WriteElementHeader(id, uint64(len(data))); err != nil {
+		return err
+	}
+	_, err := ew.Write(data)
+	return err
+}
+
+// WriteUInt writes an unsigned integer element, using as few bytes as possible.
+//
+// Parameters:
+//   - id: The element ID
+//   - v: The value
+//
+// Returns:
+//   - An error if the write failed
+func (ew *EBMLWriter) WriteUInt(id uint32, v uint64) error {
+	return ew.WriteBytes(id, encodeUInt(v))
+}
+
+// WriteInt writes a signed integer element, using as few bytes as possible.
+//
+// Parameters:
+//   - id: The element ID
+//   - v: The value
+//
+// Returns:
+//   - An error if the write failed
+func (ew *EBMLWriter) WriteInt(id uint32, v int64) error {
+	return ew.WriteBytes(id, encodeInt(v))
+}
+
+// WriteFloat writes an 8-byte float element.
+//
+// Parameters:
+//   - id: The element ID
+//   - v: The value
+//
+// Returns:
+//   - An error if the write failed
+func (ew *EBMLWriter) WriteFloat(id uint32, v float64) error {
+	return ew.WriteBytes(id, encodeFloat(v))
+}
+
+// WriteString writes a string element.
+//
+// Parameters:
+//   - id: The element ID
+//   - s: The value
+//
+// Returns:
+//   - An error if the write failed
+func (ew *EBMLWriter) WriteString(id uint32, s string) error {
+	return ew.WriteBytes(id, []byte(s))
+}
+
+// StartMasterElement writes the header of a master element whose size is not
+// known yet. The size is written as an 8-byte unknown size, which is replaced
+// by the actual size when EndMasterElement is called.
+//
+// Parameters:
+//   - id: The element ID
+//
+// Returns:
+//   - The position of the element size, to be passed to EndMasterElement
+//   - An error if the write failed
+func (ew *EBMLWriter) StartMasterElement(id uint32) (int64, error) {
+	if _, err := ew.Write(encodeElementID(id)); err != nil {
+		return 0, err
+	}
+	sizePos := ew.pos
+	if _, err := ew.Write(unknownSizeVInt); err != nil {
+		return 0, err
+	}
+	return sizePos, nil
+}
+
+// EndMasterElement back-patches the size of a master element started with
+// StartMasterElement, so that it covers everything written since. The writer
+// is left at the end of the stream.
+//
+// Parameters:
+//   - sizePos: The position returned by StartMasterElement
+//
+// Returns:
+//   - An error if the underlying writer cannot seek or the write failed
+func (ew *EBMLWriter) EndMasterElement(sizePos int64) error {
+	size := uint64(ew.pos - sizePos - int64(len(unknownSizeVInt)))
+	if size > maxVIntValue {
+		return fmt.Errorf("master element size %d is too large", size)
+	}
+	data := make([]byte, len(unknownSizeVInt))
+	binary.BigEndian.PutUint64(data, size)
+	data[0] = 0x01
+	return ew.WriteAt(data, sizePos)
+}
+
+// WriteAt overwrites data at an earlier position of the stream, then returns
+// to the current position. The underlying writer must implement io.Seeker.
+//
+// Parameters:
+//   - data: The data to write
+//   - pos: The position to write at
+//
+// Returns:
+//   - An error if the underlying writer cannot seek or the write failed
+func (ew *EBMLWriter) WriteAt(data []byte, pos int64) error {
+	seeker, ok := ew.w.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("back-patching requires an io.Seeker")
+	}
+	if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := ew.w.Write(data); err != nil {
+		return err
+	}
+	_, err := seeker.Seek(ew.pos, io.SeekStart)
+	return err
+}
+
+// encodeVInt encodes a value as an EBML variable-length integer, using the
+// smallest length that can hold it. The value with all bits set is reserved
+// for unknown sizes at every length, so it is encoded with one more byte.
+//
+// Parameters:
+//   - v: The value to encode, at most 2^56-2.
+//
+// Returns:
+//   - []byte: The encoded VINT.
+func encodeVInt(v uint64) []byte {
+	length := 1
+	for length < 8 && v >= (1<<(7*uint(length)))-1 {
+		length++
+	}
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = byte(v)
+		v >>= 8
+	}
+	buf[0] |= 0x80 >> uint(length-1)
+	return buf
+}
+
+// encodeElementID encodes an element ID, which already contains its length
+// marker, in as many bytes as it needs.
+//
+// Parameters:
+//   - id: The element ID.
+//
+// Returns:
+//   - []byte: The encoded ID.
+func encodeElementID(id uint32) []byte {
+	switch {
+	case id > 0xFFFFFF:
+		return []byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+	case id > 0xFFFF:
+		return []byte{byte(id >> 16), byte(id >> 8), byte(id)}
+	case id > 0xFF:
+		return []byte{byte(id >> 8), byte(id)}
+	default:
+		return []byte{byte(id)}
+	}
+}
+
+// encodeUInt encodes an unsigned integer in as few big-endian bytes as possible.
+func encodeUInt(v uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, v)
+	i := 0
+	for i < 7 && data[i] == 0 {
+		i++
+	}
+	return data[i:]
+}
+
+// encodeInt encodes a signed integer in as few big-endian two's complement
+// bytes as possible.
+func encodeInt(v int64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(v))
+	i := 0
+	for i < 7 && ((data[i] == 0x00 && data[i+1]&0x80 == 0) || (data[i] == 0xFF && data[i+1]&0x80 != 0)) {
+		i++
+	}
+	return data[i:]
+}
+
+// encodeFloat encodes a float as 8 big-endian bytes.
+func encodeFloat(v float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(v))
+	return data
+}
+
+// putElement writes an element with the given ID and data to an in-memory buffer.
+func putElement(buf *bytes.Buffer, id uint32, data []byte) {
+	buf.Write(encodeElementID(id))
+	buf.Write(encodeVInt(uint64(len(data))))
+	buf.Write(data)
+}
+
+// putUIntElement writes an unsigned integer element to an in-memory buffer.
+func putUIntElement(buf *bytes.Buffer, id uint32, v uint64) {
+	putElement(buf, id, encodeUInt(v))
+}
+
+// putIntElement writes a signed integer element to an in-memory buffer.
+func putIntElement(buf *bytes.Buffer, id uint32, v int64) {
+	putElement(buf, id, encodeInt(v))
+}
+
+// putFloatElement writes an 8-byte float element to an in-memory buffer.
+func putFloatElement(buf *bytes.Buffer, id uint32, v float64) {
+	putElement(buf, id, encodeFloat(v))
+}
+
+// putStringElement writes a string element to an in-memory buffer.
+func putStringElement(buf *bytes.Buffer, id uint32, s string) {
+	putElement(buf, id, []byte(s))
+}
@@ -0,0 +1,158 @@
+package matroska
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEBMLWriter_WriteVInt(t *testing.T) {
+	testCases := []struct {
+		value    uint64
+		expected []byte
+	}{
+		{0, []byte{0x80}},
+		{126, []byte{0xFE}},
+		{127, []byte{0x40, 0x7F}},
+		{16382, []byte{0x7F, 0xFE}},
+		{16383, []byte{0x20, 0x3F, 0xFF}},
+		{1<<21 - 2, []byte{0x3F, 0xFF, 0xFE}},
+		{1<<56 - 2, []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}},
+	}
+	for _, tc := range testCases {
+		buf := new(bytes.Buffer)
+		writer := NewEBMLWriter(buf)
+		if err := writer.WriteVInt(tc.value); err != nil {
+			t.Fatalf("WriteVInt(%d) failed: %v", tc.value, err)
+		}
+		if !bytes.Equal(buf.Bytes(), tc.expected) {
+			t.Errorf("WriteVInt(%d) = %x, want %x", tc.value, buf.Bytes(), tc.expected)
+		}
+		if writer.Position() != int64(len(tc.expected)) {
+			t.Errorf("Position() = %d, want %d", writer.Position(), len(tc.expected))
+		}
+
+		value, err := NewEBMLReader(bytes.NewReader(buf.Bytes())).ReadVInt()
+		if err != nil || value != tc.value {
+			t.Errorf("Reading back %d = %d, %v", tc.value, value, err)
+		}
+	}
+
+	if err := NewEBMLWriter(new(bytes.Buffer)).WriteVInt(1<<56 - 1); err == nil {
+		t.Error("Expected an error for a value too large for a VINT")
+	}
+}
+
+func TestEBMLWriter_Elements(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer := NewEBMLWriter(buf)
+	if err := writer.WriteUInt(IDTrackNum, 300); err != nil {
+		t.Fatalf("WriteUInt() failed: %v", err)
+	}
+	if err := writer.WriteInt(IDReferenceBlock, -129); err != nil {
+		t.Fatalf("WriteInt() failed: %v", err)
+	}
+	if err := writer.WriteFloat(IDSamplingFrequency, 48000); err != nil {
+		t.Fatalf("WriteFloat() failed: %v", err)
+	}
+	if err := writer.WriteString(IDCodecID, "A_OPUS"); err != nil {
+		t.Fatalf("WriteString() failed: %v", err)
+	}
+	if err := writer.WriteBytes(IDCodecPriv, []byte{0x01, 0x02}); err != nil {
+		t.Fatalf("WriteBytes() failed: %v", err)
+	}
+
+	reader := NewEBMLReader(bytes.NewReader(buf.Bytes()))
+	element, _ := reader.ReadElement()
+	if element.ID != IDTrackNum || element.Size != 2 || element.ReadUInt() != 300 {
+		t.Errorf("Unexpected uint element: %+v", element)
+	}
+	element, _ = reader.ReadElement()
+	if element.ID != IDReferenceBlock || element.Size != 2 || element.ReadInt() != -129 {
+		t.Errorf("Unexpected int element: %+v", element)
+	}
+	element, _ = reader.ReadElement()
+	if element.ID != IDSamplingFrequency || element.ReadFloat() != 48000 {
+		t.Errorf("Unexpected float element: %+v", element)
+	}
+	element, _ = reader.ReadElement()
+	if element.ID != IDCodecID || element.ReadString() != "A_OPUS" {
+		t.Errorf("Unexpected string element: %+v", element)
+	}
+	element, _ = reader.ReadElement()
+	if element.ID != IDCodecPriv || !bytes.Equal(element.ReadBytes(), []byte{0x01, 0x02}) {
+		t.Errorf("Unexpected binary element: %+v", element)
+	}
+}
+
+func TestEncodeInt(t *testing.T) {
+	testCases := []struct {
+		value    int64
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0xFF}},
+		{127, []byte{0x7F}},
+		{128, []byte{0x00, 0x80}},
+		{-128, []byte{0x80}},
+		{-129, []byte{0xFF, 0x7F}},
+		{math.MinInt64, []byte{0x80, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tc := range testCases {
+		if got := encodeInt(tc.value); !bytes.Equal(got, tc.expected) {
+			t.Errorf("encodeInt(%d) = %x, want %x", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestEBMLWriter_MasterElement(t *testing.T) {
+	t.Run("Back-patched size", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "master.ebml"))
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		defer file.Close()
+
+		writer := NewEBMLWriter(file)
+		sizePos, err := writer.StartMasterElement(IDTracks)
+		if err != nil {
+			t.Fatalf("StartMasterElement() failed: %v", err)
+		}
+		_ = writer.WriteUInt(IDTrackNum, 1)
+		_ = writer.WriteString(IDCodecID, "V_VP9")
+		if err = writer.EndMasterElement(sizePos); err != nil {
+			t.Fatalf("EndMasterElement() failed: %v", err)
+		}
+		_ = writer.WriteUInt(IDTrackNum, 2)
+
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		reader := NewEBMLReader(bytes.NewReader(data))
+		element, err := reader.ReadElement()
+		if err != nil {
+			t.Fatalf("ReadElement() failed: %v", err)
+		}
+		if element.ID != IDTracks || element.Size != 10 {
+			t.Errorf("Unexpected master element: ID 0x%X, size %d", element.ID, element.Size)
+		}
+		element, err = reader.ReadElement()
+		if err != nil || element.ID != IDTrackNum || element.ReadUInt() != 2 {
+			t.Errorf("Unexpected element after the master element: %+v, %v", element, err)
+		}
+	})
+
+	t.Run("Non-seekable writer", func(t *testing.T) {
+		writer := NewEBMLWriter(&bytes.Buffer{})
+		sizePos, err := writer.StartMasterElement(IDTracks)
+		if err != nil {
+			t.Fatalf("StartMasterElement() failed: %v", err)
+		}
+		if err = writer.EndMasterElement(sizePos); err == nil {
+			t.Error("Expected an error back-patching a non-seekable writer")
+		}
+	})
+}
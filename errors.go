@@ -0,0 +1,75 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the sentinel errors returned by the parser, which callers
+// can test for with errors.Is.
+package matroska
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors
+//
+// These errors are returned, usually wrapped with more context, for common
+// failure modes. Use errors.Is to test for them:
+//
+//	demuxer, err := matroska.NewDemuxer(file)
+//	if errors.Is(err, matroska.ErrUnsupportedDocType) {
+//	    // Not a Matroska or WebM file
+//	}
+var (
+	// ErrUnsupportedDocType is returned when the EBML header declares a document
+	// type other than "matroska" or "webm".
+	ErrUnsupportedDocType = errors.New("unsupported document type")
+	// ErrUnsupportedDocTypeVersion is returned when the EBML header declares a
+	// DocTypeReadVersion higher than MaxDocTypeReadVersion, meaning that the file
+	// cannot be read correctly by this parser. See WithBestEffortDocTypeVersion.
+	ErrUnsupportedDocTypeVersion = errors.New("unsupported document type version")
+	// ErrInvalidWebM is returned when WebM validation is enabled and a WebM
+	// file uses a codec that WebM does not allow. See WithStrictWebM.
+	ErrInvalidWebM = errors.New("invalid webm file")
+	// ErrTruncatedBlock is returned when a Block or SimpleBlock is too short for
+	// its header, lacing or declared size. When the input ends within the data
+	// of a block, the error also wraps io.ErrUnexpectedEOF, while ReadPacket
+	// returns io.EOF itself only when the input ends between elements.
+	ErrTruncatedBlock = errors.New("truncated block")
+	// ErrUnknownSizeUnsupported is returned when an element with an unknown size
+	// is read into memory, which requires its size to be known.
+	ErrUnknownSizeUnsupported = errors.New("unknown size elements not supported")
+	// ErrElementTooLarge is returned when an element declares a size larger
+	// than the maximum element size, before its data is allocated.
+	// See WithMaxElementSize.
+	ErrElementTooLarge = errors.New("element too large")
+	// ErrCRCMismatch is returned, wrapped in a *CRCError, when CRC validation is
+	// enabled and an element's data does not match its CRC-32.
+	// See WithCRCValidation.
+	ErrCRCMismatch = errors.New("crc-32 mismatch")
+	// ErrUnsupportedCodec is returned by the codec helpers when the codec data
+	// describes a codec variant or version they cannot handle.
+	ErrUnsupportedCodec = errors.New("unsupported codec")
+)
+
+// CRCError is returned when CRC validation is enabled and the data of a master
+// element does not match the checksum stored in its CRC-32 child. It wraps
+// ErrCRCMismatch.
+type CRCError struct {
+	// ElementID is the ID of the master element that failed validation.
+	ElementID uint32
+	// Position is the position of the element's data in the input stream.
+	Position int64
+	// Expected is the checksum stored in the CRC-32 element.
+	Expected uint32
+	// Actual is the checksum computed over the element's data.
+	Actual uint32
+}
+
+// Error implements the error interface.
+func (e *CRCError) Error() string {
+	return fmt.Sprintf("%v in element 0x%X at position %d: expected 0x%08X, got 0x%08X",
+		ErrCRCMismatch, e.ElementID, e.Position, e.Expected, e.Actual)
+}
+
+// Unwrap returns ErrCRCMismatch, so that errors.Is(err, ErrCRCMismatch) works.
+func (e *CRCError) Unwrap() error {
+	return ErrCRCMismatch
+}
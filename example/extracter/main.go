@@ -22,264 +22,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/luispater/matroska-go"
 )
 
-// formatSRTEntry formats a subtitle packet into SRT (SubRip Text) format.
-//
-// SRT format has the following structure:
-//
-//	1
-//	00:00:01,000 --> 00:00:04,000
-//	Subtitle text here
-//
-// Parameters:
-//
-//	index int: The sequence number of the subtitle entry.
-//	packet *matroska.Packet: The Matroska packet containing subtitle data with timing information.
-//
-// Returns:
-//
-//	string: A string containing the formatted SRT entry with proper timing and text.
-//
-// The function handles:
-//   - Converting Matroska timestamps (in milliseconds) to SRT time format.
-//   - Cleaning subtitle text by converting CRLF to LF.
-//   - Ensuring empty subtitles are represented with a space character.
-func formatSRTEntry(index int, packet *matroska.Packet) string {
-	// Matroska timestamps are already in milliseconds (with TimecodeScale=1000000)
-	startMs := packet.StartTime
-	endMs := packet.EndTime
-
-	startTime := formatSRTTime(startMs)
-	endTime := formatSRTTime(endMs)
-
-	// Clean subtitle text - convert CRLF to LF to match reference
-	text := strings.ReplaceAll(string(packet.Data), "\r\n", "\n")
-	if text == "" {
-		text = " " // Empty subtitle
-	}
-
-	return fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index, startTime, endTime, text)
-}
-
-// formatSRTTime converts milliseconds to SRT time format (HH:MM:SS,mmm).
-//
-// SRT time format uses hours:minutes:seconds,milliseconds with comma as separator
-// for milliseconds, unlike many other formats that use a period.
-//
-// Parameters:
-//
-//	ms uint64: Time duration in nanoseconds.
-//
-// Returns:
-//
-//	string: A string formatted as "HH:MM:SS,mmm" where:
-//	  HH - Hours (zero-padded to 2 digits)
-//	  MM - Minutes (zero-padded to 2 digits)
-//	  SS - Seconds (zero-padded to 2 digits)
-//	  mmm - Milliseconds (zero-padded to 3 digits)
-//
-// Example:
-//
-//	formatSRTTime(3661123) returns "01:01:01,123".
-func formatSRTTime(ns uint64) string {
-	hours := ns / 3600000000000
-	ns %= 3600000000000
-	minutes := ns / 60000000000
-	ns %= 60000000000
-	seconds := ns / 1000000000
-	milliseconds := ns % 1000000000 / 1000000
-
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", hours, minutes, seconds, milliseconds)
-}
-
-// Global variables
-var firstAUDSeen = false
-var videoCodecPrivateWritten = false
-var videoCodecPrivate []byte
-
-// convertAVCCToAnnexB converts video data from AVCC format to Annex B format.
-//
-// AVCC format uses length-prefixed NAL units (4-byte big-endian length before each NAL unit),
-// while Annex B format uses start codes (0x00000001 or 0x000001) to separate NAL units.
-// This conversion is necessary for compatibility with many video players and tools.
-//
-// The function handles both H.264 and H.265 video formats, automatically detecting
-// the codec type and applying appropriate conversion rules:
-//   - H.264: Uses 4-byte start codes for all NAL units.
-//   - H.265: Uses 4-byte start codes for VPS, SPS, PPS, and the first AUD;
-//     uses 3-byte start codes for other NAL units.
-//
-// Parameters:
-//
-//	data []byte: Video data in AVCC format with length-prefixed NAL units.
-//
-// Returns:
-//
-//	[]byte: Video data converted to Annex B format with appropriate start codes.
-//
-// The function uses global state (firstAUDSeen) to track whether the first AUD
-// (Access Unit Delimiter) has been processed, which affects start code selection
-// for H.265 streams.
-func convertAVCCToAnnexB(data []byte) []byte {
-	var result []byte
-	pos := 0
-	nalCount := 0
-
-	for pos < len(data)-4 {
-		// Read NAL unit length (4 bytes, big endian)
-		length := uint32(data[pos])<<24 | uint32(data[pos+1])<<16 | uint32(data[pos+2])<<8 | uint32(data[pos+3])
-		pos += 4
-
-		// Add NAL unit data with start code
-		if pos+int(length) <= len(data) {
-			nalData := data[pos : pos+int(length)]
-
-			// Check NAL unit type to decide start code length
-			use4ByteStartCode := false
-			if len(nalData) > 0 {
-				// Detect if this is H.264 or H.265 based on NAL unit structure
-				firstByte := nalData[0]
-
-				// H.265: NAL unit type is in bits 6-1 (>> 1 & 0x3F)
-				// H.264: NAL unit type is in bits 4-0 (& 0x1F)
-
-				// Check if this looks like H.265 (has layer_id and temporal_id fields)
-				if len(nalData) >= 2 {
-					// H.265 has a specific pattern - check common H.265 NAL types
-					isH265 := (firstByte&0x81) == 0x40 || // VPS/SPS/PPS pattern
-						(firstByte&0x81) == 0x42 ||
-						(firstByte&0x81) == 0x44 ||
-						(firstByte&0x81) == 0x46 ||
-						(firstByte&0x81) == 0x4E // Common H.265 patterns
-
-					if isH265 {
-						// H.265 logic
-						nalType := (firstByte >> 1) & 0x3F
-						if nalType == 32 || nalType == 33 || nalType == 34 { // VPS, SPS, PPS
-							use4ByteStartCode = true
-						} else if nalType == 35 { // AUD
-							if !firstAUDSeen {
-								use4ByteStartCode = true
-								firstAUDSeen = true
-							}
-						}
-					} else {
-						// H.264 logic - based on analysis, H.264 uses 4-byte start codes for all NAL units
-						use4ByteStartCode = true
-					}
-				}
-			}
-
-			// Add appropriate start code
-			if use4ByteStartCode {
-				result = append(result, 0x00, 0x00, 0x00, 0x01)
-			} else {
-				result = append(result, 0x00, 0x00, 0x01)
-			}
-
-			result = append(result, nalData...)
-			pos += int(length)
-		} else {
-			// Handle truncated data
-			result = append(result, 0x00, 0x00, 0x01)
-			result = append(result, data[pos:]...)
-			break
-		}
-
-		nalCount++
-	}
-
-	return result
-}
-
-// convertAVCCConfigToAnnexB converts AVCC configuration data to Annex B format.
-//
-// AVCC configuration (also known as AVCDecoderConfigurationRecord) contains
-// codec initialization data including SPS (Sequence Parameter Set) and PPS
-// (Picture Parameter Set) NAL units. This function extracts these NAL units
-// and converts them from AVCC's length-prefixed format to Annex B's start code format.
-//
-// The AVCC configuration format:
-//   - Byte 0: Configuration version (always 1).
-//   - Byte 1: AVC profile indication.
-//   - Byte 2: Profile compatibility.
-//   - Byte 3: AVC level indication.
-//   - Byte 4: NAL unit length size minus one (usually 3, meaning 4-byte lengths).
-//   - Byte 5: Number of SPS NAL units (lower 5 bits).
-//   - Following: SPS data (each with 2-byte length prefix).
-//   - Following: Number of PPS NAL units.
-//   - Following: PPS data (each with 2-byte length prefix).
-//
-// Parameters:
-//
-//	config []byte: AVCC configuration data containing SPS and PPS NAL units.
-//
-// Returns:
-//
-//	[]byte: SPS and PPS NAL units in Annex B format with 4-byte start codes (0x00000001).
-//
-// The function returns an empty byte slice if the configuration data is invalid
-// or too short to contain valid SPS/PPS information.
-func convertAVCCConfigToAnnexB(config []byte) []byte {
-	var result []byte
-
-	if len(config) < 6 {
-		return result
-	}
-
-	// Parse AVCC configuration record
-	// Skip first 5 bytes (version, profile, compatibility, level, nal_length_size)
-	pos := 5
-
-	// Number of SPS
-	if pos >= len(config) {
-		return result
-	}
-	numSPS := config[pos] & 0x1F
-	pos++
-
-	// Extract SPS
-	for i := 0; i < int(numSPS) && pos+1 < len(config); i++ {
-		// SPS length (2 bytes, big endian)
-		spsLength := uint16(config[pos])<<8 | uint16(config[pos+1])
-		pos += 2
-
-		if pos+int(spsLength) <= len(config) {
-			// Add 4-byte start code + SPS data
-			result = append(result, 0x00, 0x00, 0x00, 0x01)
-			result = append(result, config[pos:pos+int(spsLength)]...)
-			pos += int(spsLength)
-		}
-	}
-
-	// Number of PPS
-	if pos >= len(config) {
-		return result
-	}
-	numPPS := config[pos]
-	pos++
-
-	// Extract PPS
-	for i := 0; i < int(numPPS) && pos+1 < len(config); i++ {
-		// PPS length (2 bytes, big endian)
-		ppsLength := uint16(config[pos])<<8 | uint16(config[pos+1])
-		pos += 2
-
-		if pos+int(ppsLength) <= len(config) {
-			// Add 4-byte start code + PPS data
-			result = append(result, 0x00, 0x00, 0x00, 0x01)
-			result = append(result, config[pos:pos+int(ppsLength)]...)
-			pos += int(ppsLength)
-		}
-	}
-
-	return result
-}
-
 // main demonstrates a complete workflow for extracting tracks from a Matroska file.
 //
 // This function shows how to:
@@ -291,23 +37,13 @@ func convertAVCCConfigToAnnexB(config []byte) []byte {
 //   - Validate output by comparing with reference files.
 //
 // The function processes three types of tracks:
-//   - Video tracks: Convert from AVCC to Annex B format, write codec private data.
+//   - Video tracks: Converted from AVCC to Annex B format by the demuxer.
 //   - Audio tracks: Write raw data without conversion.
-//   - Subtitle tracks: Convert to SRT format with proper timing.
-//
-// Global variables are used to track state during processing:
-//   - firstAUDSeen bool: Tracks whether the first AUD has been processed for H.265.
-//   - videoCodecPrivateWritten bool: Tracks whether video codec private data has been written.
-//   - videoCodecPrivate []byte: Stores the video codec private data for writing.
+//   - Subtitle tracks: Exported in SRT format by the demuxer with ExportSRT.
 //
 // The function includes progress reporting and validation against reference files
 // to demonstrate the accuracy of the extraction process.
 func main() {
-	// Reset global state for new file
-	firstAUDSeen = false
-	videoCodecPrivateWritten = false
-	videoCodecPrivate = nil
-
 	if len(os.Args) < 2 {
 		fmt.Printf("Usage: %s <mkv-file>\n", os.Args)
 		return
@@ -349,7 +85,7 @@ func main() {
 	}
 
 	fmt.Printf("File: %s\n", filepath.Base(inputFile))
-	fmt.Printf("Duration: %d\n", fileInfo.Duration)
+	fmt.Printf("Duration: %f\n", fileInfo.Duration)
 	fmt.Printf("Timecode Scale: %d\n", fileInfo.TimecodeScale)
 
 	// Demonstrate new features: Tags, Attachments, Chapters, Cues
@@ -491,9 +227,8 @@ func main() {
 
 	fmt.Printf("Number of tracks: %d\n", numTracks)
 
-	// Create mapping from track number to track index and output files
-	trackNumberToIndex := make(map[uint8]uint)
-	trackFiles := make([]*os.File, numTracks)
+	// Create the output files, keyed by track number
+	trackFiles := make(map[uint64]*os.File, numTracks)
 	defer func() {
 		for _, f := range trackFiles {
 			if f != nil {
@@ -510,15 +245,12 @@ func main() {
 			continue
 		}
 
-		fmt.Printf("Track %d: Type=%d, Codec=%s, Number=%d\n",
+		fmt.Printf("Track %d: Type=%s, Codec=%s, Number=%d\n",
 			i, trackInfo.Type, trackInfo.CodecID, trackInfo.Number)
 
-		// Map track number to index
-		trackNumberToIndex[trackInfo.Number] = i
-
-		// Save video codec private data
-		if trackInfo.Type == 1 && len(trackInfo.CodecPrivate) > 0 {
-			videoCodecPrivate = trackInfo.CodecPrivate
+		// Let the demuxer convert H.264/H.265 video to Annex B format
+		if trackInfo.Type == matroska.TypeVideo {
+			demuxer.SetAnnexBConversion(i, true)
 		}
 
 		// Create output file for this track
@@ -530,17 +262,15 @@ func main() {
 		}
 
 		// Add BOM for subtitle files
-		if trackInfo.Type == 17 {
+		if trackInfo.Type == matroska.TypeSubtitle {
 			_, _ = trackFile.Write([]byte{0xEF, 0xBB, 0xBF}) // UTF-8 BOM
 		}
 
-		trackFiles[i] = trackFile
+		trackFiles[trackInfo.Number] = trackFile
 	}
 
 	// Read and write packets
 	packetCount := 0
-	trackPacketCounts := make([]int, numTracks)
-	subtitleCounters := make([]int, numTracks) // For SRT numbering
 
 	for {
 		packet, errReadPacket := demuxer.ReadPacket()
@@ -558,46 +288,29 @@ func main() {
 		}
 
 		// Write packet data to corresponding track file
-		if trackIndex, exists := trackNumberToIndex[packet.Track]; exists && trackFiles[trackIndex] != nil {
-			// Check if this is a subtitle track
-			trackInfo, _ := demuxer.GetTrackInfo(trackIndex)
-			if trackInfo.Type == 17 { // Subtitle track
-				// Convert to SRT format
-				subtitleCounters[trackIndex]++
-				srtEntry := formatSRTEntry(subtitleCounters[trackIndex], packet)
-				_, err = trackFiles[trackIndex].WriteString(srtEntry)
-				if err != nil {
-					fmt.Printf("Error writing subtitle data for track %d: %v\n", packet.Track, err)
-					continue
-				}
-			} else if trackInfo.Type == 1 { // Video track
-				// Write codec private data (SPS/PPS) at the beginning
-				if !videoCodecPrivateWritten && len(videoCodecPrivate) > 0 {
-					codecPrivateAnnexB := convertAVCCConfigToAnnexB(videoCodecPrivate)
-					_, err = trackFiles[trackIndex].Write(codecPrivateAnnexB)
-					if err != nil {
-						fmt.Printf("Error writing codec private data for track %d: %v\n", packet.Track, err)
-						continue
+		if trackFile := trackFiles[packet.Track]; trackFile != nil {
+			// Subtitle tracks are exported in SRT format below
+			trackInfo, errGetTrack := demuxer.GetTrackByNumber(packet.Track)
+			if errGetTrack == nil && trackInfo.Type != matroska.TypeSubtitle {
+				// Prepend an ADTS header to AAC frames so the output is playable
+				if trackInfo.CodecID == "A_AAC" {
+					header, errADTSHeader := matroska.ADTSHeader(trackInfo.CodecPrivate, len(packet.Data))
+					if errADTSHeader == nil {
+						_, err = trackFile.Write(header)
+						if err != nil {
+							fmt.Printf("Error writing ADTS header for track %d: %v\n", packet.Track, err)
+							continue
+						}
 					}
-					videoCodecPrivateWritten = true
 				}
 
-				// Convert AVCC format to Annex B format
-				annexBData := convertAVCCToAnnexB(packet.Data)
-				_, err = trackFiles[trackIndex].Write(annexBData)
-				if err != nil {
-					fmt.Printf("Error writing video data for track %d: %v\n", packet.Track, err)
-					continue
-				}
-			} else {
-				// Write raw data for audio tracks
-				_, err = trackFiles[trackIndex].Write(packet.Data)
+				// Write raw data for audio tracks and Annex B data for video tracks
+				_, err = trackFile.Write(packet.Data)
 				if err != nil {
 					fmt.Printf("Error writing packet data for track %d: %v\n", packet.Track, err)
 					continue
 				}
 			}
-			trackPacketCounts[trackIndex]++
 		}
 	}
 
@@ -605,8 +318,27 @@ func main() {
 	fmt.Printf("Total packets processed: %d\n", packetCount)
 
 	// Print packet counts per track
-	for i := 0; i < len(trackPacketCounts); i++ {
-		fmt.Printf("Track %d: %d packets\n", i, trackPacketCounts[i])
+	stats := demuxer.Stats()
+	for i := uint(0); i < numTracks; i++ {
+		trackInfo, _ := demuxer.GetTrackInfo(i)
+		if trackStats, ok := stats[trackInfo.Number]; ok {
+			fmt.Printf("Track %d: %d packets, %d bytes, %d keyframes\n", i, trackStats.PacketCount, trackStats.TotalBytes, trackStats.KeyframeCount)
+		} else {
+			fmt.Printf("Track %d: 0 packets\n", i)
+		}
+	}
+
+	// Export the subtitle tracks in SRT format, each from the start of the file
+	for i := uint(0); i < numTracks; i++ {
+		trackInfo, _ := demuxer.GetTrackInfo(i)
+		trackFile := trackFiles[trackInfo.Number]
+		if trackInfo.Type != matroska.TypeSubtitle || trackFile == nil {
+			continue
+		}
+		demuxer.Seek(0, 0)
+		if err = demuxer.ExportSRT(trackFile, i); err != nil {
+			fmt.Printf("Error exporting subtitles of track %d: %v\n", i, err)
+		}
 	}
 
 	// Compare with reference files
@@ -629,15 +361,7 @@ func main() {
 		}
 
 		trackInfo, _ := demuxer.GetTrackInfo(i)
-		trackType := "Unknown"
-		switch trackInfo.Type {
-		case 1:
-			trackType = "Video"
-		case 2:
-			trackType = "Audio"
-		case 17:
-			trackType = "Subtitle"
-		}
+		trackType := trackInfo.Type.String()
 
 		if outputStat.Size() == refStat.Size() {
 			fmt.Printf("Track %d (%s): ✓ Size matches (%d bytes)\n", i, trackType, outputStat.Size())
@@ -0,0 +1,149 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains ExtractTrack, which writes a single track to a raw stream.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// ExtractTrack writes the packets of a single track to w, where track is less
+// than what is returned by GetNumTracks.
+//
+// Packets are read from the current position until the end of the file, with
+// the other tracks masked so that their frames are skipped. The frame data is
+// written as is, unless a conversion is enabled in opts:
+//   - AnnexB converts H.264 and H.265 frames to Annex B format, as
+//     SetAnnexBConversion does for the duration of the call.
+//   - ADTS prepends an ADTS header to every AAC frame.
+//   - SRT formats "S_TEXT/UTF8" subtitles as numbered SubRip entries. Every
+//     subtitle must have an end time, see ExportSRT.
+//
+// ReadPacket does not split the frames of laced blocks, so ExtractTrack fails
+// on the first laced block of the track rather than write it as one frame.
+//
+// Example:
+//
+//	out, err := os.Create("video.h264")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer out.Close()
+//	if err = demuxer.ExtractTrack(out, 0, matroska.ExtractOptions{AnnexB: true}); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - w: The destination of the track's data.
+//   - track: The index of the track to extract.
+//   - opts: The conversions to apply to the track's data.
+//
+// Returns:
+//   - error: An error if the track index is invalid, a packet could not be
+//     read or converted, a block is laced, a subtitle has no end time, or the
+//     data could not be written.
+func (d *Demuxer) ExtractTrack(w io.Writer, track uint, opts ExtractOptions) error {
+	d.lock()
+	defer d.unlock()
+	return d.extractTrack(w, track, opts)
+}
+
+// extractTrack writes a single track like ExtractTrack, for the methods that
+// already hold the lock of the demuxer.
+//
+// Parameters:
+//   - w: The writer the track's data is written to.
+//   - track: The index of the track to extract.
+//   - opts: The conversions to apply to the track's data.
+//
+// Returns:
+//   - error: An error if the track could not be extracted.
+func (d *Demuxer) extractTrack(w io.Writer, track uint, opts ExtractOptions) error {
+	trackInfo, err := d.trackInfo(track)
+	if err != nil {
+		return err
+	}
+
+	if opts.AnnexB {
+		if _, ok := d.parser.annexB[trackInfo.Number]; !ok {
+			d.parser.SetAnnexBConversion(track, true)
+			defer d.parser.SetAnnexBConversion(track, false)
+		}
+	}
+	adts := opts.ADTS && trackInfo.CodecID == "A_AAC"
+	srt := opts.SRT && trackInfo.CodecID == "S_TEXT/UTF8"
+
+	index := 0
+	return d.readTrackPackets(trackInfo.Number, func(packet *Packet) error {
+		if err = checkUnlaced(packet); err != nil {
+			return err
+		}
+		switch {
+		case srt:
+			index++
+			if packet.EndTime <= packet.StartTime {
+				return fmt.Errorf("subtitle %d has no end time, as the track has no BlockDuration or DefaultDuration", index)
+			}
+			_, err = io.WriteString(w, formatSRTEntry(index, packet))
+		case adts:
+			header, errADTSHeader := ADTSHeader(trackInfo.CodecPrivate, len(packet.Data))
+			if errADTSHeader != nil {
+				return errADTSHeader
+			}
+			if _, err = w.Write(header); err == nil {
+				_, err = w.Write(packet.Data)
+			}
+		default:
+			_, err = w.Write(packet.Data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write track data: %w", err)
+		}
+		return nil
+	})
+}
+
+// readTrackPackets reads the packets of a single track from the current
+// position until the end of the file, masking the other tracks, and passes
+// them to fn. The caller must hold the lock of the demuxer.
+//
+// Parameters:
+//   - trackNum: The number of the track to read.
+//   - fn: The function called with each packet of the track.
+//
+// Returns:
+//   - error: An error if a packet could not be read, or the first error
+//     returned by fn.
+func (d *Demuxer) readTrackPackets(trackNum uint64, fn func(packet *Packet) error) error {
+	// Tracks numbered above 64 cannot be masked and are filtered below instead
+	var mask uint64
+	if trackNum >= 1 && trackNum <= 64 {
+		mask = ^(uint64(1) << (trackNum - 1))
+	}
+
+	// The tracks are written with their times in nanoseconds
+	raw := d.parser.rawTimestamps
+	d.parser.rawTimestamps = false
+	defer func() {
+		d.parser.rawTimestamps = raw
+	}()
+
+	for {
+		packet, err := d.parser.ReadPacketMask(mask)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read packet: %w", err)
+		}
+		if packet.Track != trackNum {
+			packet.Release()
+			continue
+		}
+		err = fn(packet)
+		packet.Release()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createMuxedFile writes tracks and packets to a temporary Matroska file with
+// the Muxer, and returns a Demuxer reading it. The setup functions are called
+// after the tracks are added, before the header is written.
+func createMuxedFile(t testing.TB, tracks []*TrackInfo, packets []*Packet, setup ...func(muxer *Muxer)) *Demuxer {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "muxed.mkv"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+
+	muxer := NewMuxer(file)
+	for _, track := range tracks {
+		if _, err = muxer.AddTrack(track); err != nil {
+			t.Fatalf("AddTrack() failed: %v", err)
+		}
+	}
+	for _, fn := range setup {
+		fn(muxer)
+	}
+	if err = muxer.WriteHeader(); err != nil {
+		t.Fatalf("WriteHeader() failed: %v", err)
+	}
+	for _, packet := range packets {
+		if err = muxer.WritePacket(packet); err != nil {
+			t.Fatalf("WritePacket() failed: %v", err)
+		}
+	}
+	if err = muxer.Finalize(); err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(file)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	return demuxer
+}
+
+func TestDemuxer_ExtractTrack(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_MPEG4/ISO/AVC", CodecPrivate: avcConfig},
+		{Type: TypeAudio, CodecID: "A_AAC", CodecPrivate: []byte{0x12, 0x10}},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"},
+	}
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x00, 0x00, 0x00, 0x02, 0x65, 0x88}, Flags: KF},
+		{Track: 2, StartTime: 0, Data: []byte{0x21, 0x22}, Flags: KF},
+		{Track: 3, StartTime: 0, EndTime: 1500000000, Data: []byte("Hello\r\nWorld")},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x00, 0x00, 0x00, 0x01, 0x41}},
+		{Track: 2, StartTime: 40000000, Data: []byte{0x23}, Flags: KF},
+		{Track: 3, StartTime: 3723004000000, EndTime: 3725000000000, Data: []byte("Bye")},
+	}
+
+	testCases := []struct {
+		name     string
+		track    uint
+		opts     ExtractOptions
+		expected []byte
+	}{
+		{
+			name:     "Raw video",
+			track:    0,
+			expected: []byte{0x00, 0x00, 0x00, 0x02, 0x65, 0x88, 0x00, 0x00, 0x00, 0x01, 0x41},
+		},
+		{
+			name:  "Annex B video",
+			track: 0,
+			opts:  ExtractOptions{AnnexB: true},
+			expected: []byte{
+				0x00, 0x00, 0x00, 0x01, 0x67, 0x64, 0x00, 0x1F, // SPS
+				0x00, 0x00, 0x00, 0x01, 0x68, 0xEB, 0xE3, // PPS
+				0x00, 0x00, 0x00, 0x01, 0x65, 0x88,
+				0x00, 0x00, 0x00, 0x01, 0x41,
+			},
+		},
+		{
+			name:  "ADTS audio",
+			track: 1,
+			opts:  ExtractOptions{ADTS: true, AnnexB: true, SRT: true},
+			expected: []byte{
+				0xFF, 0xF1, 0x50, 0x80, 0x01, 0x3F, 0xFC, 0x21, 0x22,
+				0xFF, 0xF1, 0x50, 0x80, 0x01, 0x1F, 0xFC, 0x23,
+			},
+		},
+		{
+			name:     "SRT subtitles",
+			track:    2,
+			opts:     ExtractOptions{SRT: true},
+			expected: []byte("1\n00:00:00,000 --> 00:00:01,500\nHello\nWorld\n\n2\n01:02:03,004 --> 01:02:05,000\nBye\n\n"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			demuxer := createMuxedFile(t, tracks, packets)
+			var out bytes.Buffer
+			if err := demuxer.ExtractTrack(&out, tc.track, tc.opts); err != nil {
+				t.Fatalf("ExtractTrack() failed: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), tc.expected) {
+				t.Errorf("ExtractTrack() wrote %q, want %q", out.Bytes(), tc.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid track", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if err := demuxer.ExtractTrack(io.Discard, 3, ExtractOptions{}); err == nil {
+			t.Error("Expected an error for an invalid track index")
+		}
+	})
+
+	t.Run("Laced block", func(t *testing.T) {
+		trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_VORBIS", "Audio", "und")
+		// Three Xiph-laced frames: "aa", "bbb" and "cccc"
+		laced := []byte{0x81, 0x00, 0x00, 0x82, 0x02, 0x02, 0x03}
+		laced = append(laced, "aabbbcccc"...)
+		demuxer, err := NewDemuxer(bytes.NewReader(createMockMatroskaFileWithTrack(trackEntry, laced)))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if err = demuxer.ExtractTrack(io.Discard, 0, ExtractOptions{}); err == nil {
+			t.Error("Expected an error for a laced block")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the registry of handlers for the elements of the segment
+// that the parser does not parse itself.
+package matroska
+
+import "fmt"
+
+// ElementHandler is called with an element of the segment that the parser does
+// not parse itself. See MatroskaParser.RegisterHandler.
+//
+// Parameters:
+//   - element: The element, with its data.
+//
+// Returns:
+//   - error: An error that stops the parsing of the file.
+type ElementHandler func(element *EBMLElement) error
+
+// RegisterHandler registers a function to call with the top-level elements of
+// the segment with the given ID.
+//
+// The parser skips the children of the segment that it does not know, such as
+// experimental or application-specific elements. When a handler is registered
+// for their ID, the element is read into memory, within the maximum element
+// size, and passed to the handler instead. Handlers cannot replace the parsing
+// of the elements the parser knows (SegmentInfo, Tracks, Cues, Chapters, Tags,
+// Attachments, SeekHead, Void and Cluster). A nil function removes the handler.
+//
+// The children of the segment are parsed when the file is opened, so handlers
+// must be registered with WithElementHandler to be called for them.
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - fn: The function called with each element with this ID.
+func (mp *MatroskaParser) RegisterHandler(id uint32, fn func(*EBMLElement) error) {
+	if fn == nil {
+		delete(mp.handlers, id)
+		return
+	}
+	if mp.handlers == nil {
+		mp.handlers = make(map[uint32]ElementHandler)
+	}
+	mp.handlers[id] = fn
+}
+
+// handleElement passes an element whose header has just been read to the
+// handler registered for its ID, if there is one.
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - bool: True if a handler was called, in which case the element's data
+//     has been read.
+//   - error: An error if the data could not be read, or the error returned by
+//     the handler.
+func (mp *MatroskaParser) handleElement(id uint32, size uint64) (bool, error) {
+	handler, ok := mp.handlers[id]
+	if !ok {
+		return false, nil
+	}
+	data, err := mp.reader.readData(size)
+	if err != nil {
+		return true, fmt.Errorf("failed to read element 0x%X: %w", id, err)
+	}
+	if err = handler(&EBMLElement{ID: id, Size: size, Data: data}); err != nil {
+		return true, fmt.Errorf("element handler for 0x%X: %w", id, err)
+	}
+	return true, nil
+}
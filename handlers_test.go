@@ -0,0 +1,75 @@
+package matroska
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// customElementID is the ID of an element unknown to the parser.
+const customElementID = 0x1F1F1F1F
+
+// createCustomElementFile returns a file with an unknown top-level element
+// between the Tracks and the single cluster.
+func createCustomElementFile(t *testing.T) []byte {
+	t.Helper()
+	return buildTestFile(createMinimalEBMLHeader(), nil,
+		mockInfoElement(nil),
+		mockTracksElement(t, TypeVideo, "V_TEST"),
+		segmentElement{id: customElementID, data: []byte("custom data")},
+		mockFrameCluster(0),
+	).data
+}
+
+func TestRegisterHandler(t *testing.T) {
+	data := createCustomElementFile(t)
+
+	t.Run("Handler called", func(t *testing.T) {
+		var elements []*EBMLElement
+		demuxer, err := NewDemuxer(bytes.NewReader(data), WithElementHandler(customElementID, func(element *EBMLElement) error {
+			elements = append(elements, element)
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if len(elements) != 1 {
+			t.Fatalf("Expected the handler to be called once, got %d", len(elements))
+		}
+		if elements[0].ID != customElementID || string(elements[0].Data) != "custom data" {
+			t.Errorf("Expected the custom element, got ID 0x%X and data %q", elements[0].ID, elements[0].Data)
+		}
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if string(packet.Data) != "frame" {
+			t.Errorf("Expected the packet after the custom element, got %q", packet.Data)
+		}
+	})
+
+	t.Run("Handler error", func(t *testing.T) {
+		errHandler := errors.New("handler failed")
+		_, err := NewDemuxer(bytes.NewReader(data), WithElementHandler(customElementID, func(*EBMLElement) error {
+			return errHandler
+		}))
+		if !errors.Is(err, errHandler) {
+			t.Errorf("Expected the handler error, got %v", err)
+		}
+	})
+
+	t.Run("Removed handler", func(t *testing.T) {
+		called := false
+		handler := func(*EBMLElement) error {
+			called = true
+			return nil
+		}
+		if _, err := NewDemuxer(bytes.NewReader(data), WithElementHandler(customElementID, handler), WithElementHandler(customElementID, nil)); err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if called {
+			t.Error("Expected a removed handler not to be called")
+		}
+	})
+}
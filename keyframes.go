@@ -0,0 +1,183 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains BuildKeyframeIndex, which lists the keyframes of a track.
+package matroska
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// BuildKeyframeIndex returns the position and time of every keyframe of a
+// track, where track is less than what is returned by GetNumTracks.
+//
+// Cues usually reference one keyframe per cluster at most, and sometimes only
+// those of the video track. The index lists all of them, which allows seeking
+// to an exact keyframe or picking frames for thumbnails. Building it reads the
+// blocks of the whole file, so the index is cached and later calls for the
+// same track return immediately. The read position is restored afterwards.
+//
+// Example:
+//
+//	index, err := demuxer.BuildKeyframeIndex(0)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, keyframe := range index {
+//	    fmt.Printf("Keyframe at %d ns, offset %d\n", keyframe.Timecode, keyframe.FilePos)
+//	}
+//
+// Parameters:
+//   - track: The index of the track.
+//
+// Returns:
+//   - []KeyframePoint: The keyframes of the track, in file order.
+//   - error: An error if the track index is invalid, the demuxer avoids seeks,
+//     or a packet could not be read.
+func (d *Demuxer) BuildKeyframeIndex(track uint) ([]KeyframePoint, error) {
+	d.lock()
+	defer d.unlock()
+	trackInfo, err := d.trackInfo(track)
+	if err != nil {
+		return nil, err
+	}
+	return d.parser.buildKeyframeIndex(trackInfo.Number)
+}
+
+// buildKeyframeIndex reads all the blocks of a track from the start of the
+// segment, and caches the position and time of its keyframes. See
+// Demuxer.BuildKeyframeIndex.
+//
+// Parameters:
+//   - trackNum: The number of the track.
+//
+// Returns:
+//   - []KeyframePoint: A copy of the keyframes of the track.
+//   - error: An error if the demuxer avoids seeks or a packet could not be read.
+func (mp *MatroskaParser) buildKeyframeIndex(trackNum uint64) (index []KeyframePoint, err error) {
+	if cached, ok := mp.keyframeIndex[trackNum]; ok {
+		return append([]KeyframePoint(nil), cached...), nil
+	}
+	if mp.avoidSeeks {
+		return nil, fmt.Errorf("cannot build a keyframe index without seeking")
+	}
+
+	restore := mp.saveReadState()
+	defer func() {
+		if errRestore := restore(); errRestore != nil && err == nil {
+			err = errRestore
+		}
+	}()
+
+	// Tracks numbered above 64 cannot be masked and are filtered below instead
+	mp.currentTrackMask = 0
+	if trackNum >= 1 && trackNum <= 64 {
+		mp.currentTrackMask = ^(uint64(1) << (trackNum - 1))
+	}
+	mp.resetClusterState()
+	mp.segmentIndex = 0
+	if _, err = mp.reader.Seek(int64(mp.segmentPos), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	index = []KeyframePoint{}
+	for {
+		packet, errReadPacket := mp.readPacket(context.Background())
+		if errReadPacket == io.EOF {
+			break
+		}
+		if errReadPacket != nil {
+			return nil, fmt.Errorf("failed to read packet: %w", errReadPacket)
+		}
+		if packet.Track == trackNum && packet.Flags&KF != 0 {
+			index = append(index, KeyframePoint{Timecode: packet.StartTime, FilePos: packet.FilePos})
+		}
+		packet.Release()
+	}
+
+	if mp.keyframeIndex == nil {
+		mp.keyframeIndex = make(map[uint64][]KeyframePoint)
+	}
+	mp.keyframeIndex[trackNum] = index
+	return append([]KeyframePoint(nil), index...), nil
+}
+
+// NearestKeyframe returns the time and position of the last keyframe of a
+// track at or before a timecode, where track is less than what is returned by
+// GetNumTracks. Decoding must start at a keyframe, so this is where a seek to
+// timecode should start reading.
+//
+// The keyframe index of the track is used, see BuildKeyframeIndex, and built
+// on the first call. When the demuxer avoids seeks, the cues of the track are
+// used instead, and the position is that of the cluster containing the
+// keyframe.
+//
+// Example:
+//
+//	timecode, position, err := demuxer.NearestKeyframe(0, 90*uint64(time.Second))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Start decoding at %d ns, offset %d\n", timecode, position)
+//
+// Parameters:
+//   - track: The index of the track.
+//   - timecode: The target time in nanoseconds.
+//
+// Returns:
+//   - uint64: The time of the keyframe in nanoseconds.
+//   - uint64: The position in the file of the keyframe's block, or of its
+//     cluster when the demuxer avoids seeks.
+//   - error: An error if the track index is invalid, the keyframes could not be
+//     listed, or timecode precedes the first keyframe.
+func (d *Demuxer) NearestKeyframe(track uint, timecode uint64) (uint64, uint64, error) {
+	d.lock()
+	defer d.unlock()
+	trackInfo, err := d.trackInfo(track)
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.parser.nearestKeyframe(trackInfo.Number, timecode)
+}
+
+// nearestKeyframe finds the last keyframe of a track at or before a
+// timecode. See Demuxer.NearestKeyframe.
+//
+// Parameters:
+//   - trackNum: The number of the track.
+//   - timecode: The target time in nanoseconds.
+//
+// Returns:
+//   - uint64: The time of the keyframe in nanoseconds.
+//   - uint64: The position in the file of the keyframe.
+//   - error: An error if no keyframe is at or before timecode.
+func (mp *MatroskaParser) nearestKeyframe(trackNum uint64, timecode uint64) (uint64, uint64, error) {
+	var keyframes []KeyframePoint
+	if mp.avoidSeeks {
+		for _, cue := range mp.cues {
+			if cue.Track == trackNum {
+				keyframes = append(keyframes, KeyframePoint{Timecode: cue.Time, FilePos: mp.segmentPos + cue.Position})
+			}
+		}
+	} else {
+		index, err := mp.buildKeyframeIndex(trackNum)
+		if err != nil {
+			return 0, 0, err
+		}
+		keyframes = index
+	}
+
+	// Keyframes are not always in time order, so every one is considered
+	found := false
+	var nearest KeyframePoint
+	for _, keyframe := range keyframes {
+		if keyframe.Timecode <= timecode && (!found || keyframe.Timecode >= nearest.Timecode) {
+			nearest = keyframe
+			found = true
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("no keyframe of track %d at or before %d", trackNum, timecode)
+	}
+	return nearest.Timecode, nearest.FilePos, nil
+}
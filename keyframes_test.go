@@ -0,0 +1,226 @@
+package matroska
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestDemuxer_BuildKeyframeIndex(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+		{Type: TypeAudio, CodecID: "A_OPUS", DefaultDuration: 20000000},
+	}
+	// Four GOPs of five frames, each keyframe starting a new cluster, with
+	// audio keyframes that must not be listed for the video track
+	var packets []*Packet
+	var expected []uint64
+	for frame := uint64(0); frame < 20; frame++ {
+		timecode := frame * 40000000
+		flags := uint32(0)
+		if frame%5 == 0 {
+			flags = KF
+			expected = append(expected, timecode)
+		}
+		packets = append(packets,
+			&Packet{Track: 1, StartTime: timecode, Data: []byte{byte(frame)}, Flags: flags},
+			&Packet{Track: 2, StartTime: timecode, Data: []byte{0xA0, byte(frame)}, Flags: KF},
+		)
+	}
+
+	t.Run("Lists every keyframe", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		index, err := demuxer.BuildKeyframeIndex(0)
+		if err != nil {
+			t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+		}
+		if len(index) != len(expected) {
+			t.Fatalf("Expected %d keyframes, got %d", len(expected), len(index))
+		}
+		for i, keyframe := range index {
+			if keyframe.Timecode != expected[i] {
+				t.Errorf("Keyframe %d: expected timecode %d, got %d", i, expected[i], keyframe.Timecode)
+			}
+			if i > 0 && keyframe.FilePos <= index[i-1].FilePos {
+				t.Errorf("Keyframe %d: expected a position after %d, got %d", i, index[i-1].FilePos, keyframe.FilePos)
+			}
+		}
+
+		// The positions point at the blocks: the track number, the relative
+		// timestamp, the flags and the frame data
+		file := demuxer.reader.(*os.File)
+		for i, keyframe := range index {
+			data := make([]byte, 5)
+			if _, err = file.ReadAt(data, int64(keyframe.FilePos)); err != nil {
+				t.Fatalf("ReadAt() failed: %v", err)
+			}
+			if data[0] != 0x81 || data[4] != byte(i*5) {
+				t.Errorf("Keyframe %d: expected the block of frame %d at its position, got % X", i, i*5, data)
+			}
+		}
+	})
+
+	t.Run("BlockGroups", func(t *testing.T) {
+		// Packets shorter than the DefaultDuration are written as BlockGroups,
+		// with a ReferenceBlock for the inter frames
+		var groups []*Packet
+		for _, packet := range packets {
+			group := *packet
+			group.EndTime = group.StartTime + 20000000
+			groups = append(groups, &group)
+		}
+		demuxer := createMuxedFile(t, tracks, groups)
+		index, err := demuxer.BuildKeyframeIndex(0)
+		if err != nil {
+			t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+		}
+		if len(index) != len(expected) {
+			t.Fatalf("Expected %d keyframes, got %d", len(expected), len(index))
+		}
+		for i, keyframe := range index {
+			if keyframe.Timecode != expected[i] {
+				t.Errorf("Keyframe %d: expected timecode %d, got %d", i, expected[i], keyframe.Timecode)
+			}
+		}
+
+		for i := 0; i < 4; i++ {
+			packet, errReadPacket := demuxer.ReadPacket()
+			if errReadPacket != nil {
+				t.Fatalf("ReadPacket() failed: %v", errReadPacket)
+			}
+			if packet.Track == 1 && (packet.Flags&KF != 0) != (groups[i].Flags&KF != 0) {
+				t.Errorf("Packet %d: KF = %v, want %v", i, packet.Flags&KF != 0, groups[i].Flags&KF != 0)
+			}
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if _, err := demuxer.BuildKeyframeIndex(1); err != nil {
+			t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+		}
+		if _, ok := demuxer.parser.keyframeIndex[2]; !ok {
+			t.Fatal("Expected the index of track 2 to be cached")
+		}
+		demuxer.parser.keyframeIndex[2] = []KeyframePoint{{Timecode: 7}}
+		index, err := demuxer.BuildKeyframeIndex(1)
+		if err != nil {
+			t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+		}
+		if len(index) != 1 || index[0].Timecode != 7 {
+			t.Errorf("Expected the cached index, got %v", index)
+		}
+	})
+
+	t.Run("Preserves the read position", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		for i := 0; i < 3; i++ {
+			if _, err := demuxer.ReadPacket(); err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		if _, err := demuxer.BuildKeyframeIndex(0); err != nil {
+			t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+		}
+
+		count := 3
+		for {
+			_, err := demuxer.ReadPacket()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+			count++
+		}
+		if count != len(packets) {
+			t.Errorf("Expected %d packets, got %d", len(packets), count)
+		}
+	})
+
+	t.Run("Invalid track", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		if _, err := demuxer.BuildKeyframeIndex(2); err == nil {
+			t.Error("Expected an error for an invalid track index")
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		file := createMuxedFile(t, tracks, packets).reader.(*os.File)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		demuxer, err := NewStreamingDemuxer(file)
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		if _, err = demuxer.BuildKeyframeIndex(0); err == nil {
+			t.Error("Expected an error without seeking")
+		}
+	})
+}
+
+func TestDemuxer_NearestKeyframe(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}}
+	// Keyframes at 1 s, 2 s and 3 s
+	var packets []*Packet
+	for frame := uint64(0); frame < 75; frame++ {
+		flags := uint32(0)
+		if frame%25 == 0 {
+			flags = KF
+		}
+		packets = append(packets, &Packet{Track: 1, StartTime: 1000000000 + frame*40000000, Data: []byte{byte(frame)}, Flags: flags})
+	}
+	demuxer := createMuxedFile(t, tracks, packets)
+	index, err := demuxer.BuildKeyframeIndex(0)
+	if err != nil {
+		t.Fatalf("BuildKeyframeIndex() failed: %v", err)
+	}
+	if len(index) != 3 {
+		t.Fatalf("Expected 3 keyframes, got %d", len(index))
+	}
+
+	tests := []struct {
+		name     string
+		timecode uint64
+		expected int
+	}{
+		{"At the first keyframe", 1000000000, 0},
+		{"Between keyframes", 2500000000, 1},
+		{"At a keyframe", 2000000000, 1},
+		{"After the last keyframe", 9000000000, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timecode, position, errNearest := demuxer.NearestKeyframe(0, tt.timecode)
+			if errNearest != nil {
+				t.Fatalf("NearestKeyframe() failed: %v", errNearest)
+			}
+			if timecode != index[tt.expected].Timecode || position != index[tt.expected].FilePos {
+				t.Errorf("Expected keyframe %v, got {%d %d}", index[tt.expected], timecode, position)
+			}
+		})
+	}
+
+	t.Run("Before the first keyframe", func(t *testing.T) {
+		if _, _, errNearest := demuxer.NearestKeyframe(0, 999999999); errNearest == nil {
+			t.Error("Expected an error before the first keyframe")
+		}
+	})
+
+	t.Run("Cues when streaming", func(t *testing.T) {
+		demuxer.parser.avoidSeeks = true
+		defer func() { demuxer.parser.avoidSeeks = false }()
+		timecode, position, errNearest := demuxer.NearestKeyframe(0, 2500000000)
+		if errNearest != nil {
+			t.Fatalf("NearestKeyframe() failed: %v", errNearest)
+		}
+		if timecode != 2000000000 {
+			t.Errorf("Expected the keyframe at 2000000000, got %d", timecode)
+		}
+		if position >= index[1].FilePos || position <= index[0].FilePos {
+			t.Errorf("Expected the position of the cluster of the keyframe, got %d", position)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the identification of segments by their UID and the
+// navigation between linked segments, which split a presentation into several
+// files played back one after the other.
+package matroska
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// linkedExtensions are the extensions of the files considered by
+// OpenLinkedChain.
+var linkedExtensions = map[string]bool{
+	".mkv":  true,
+	".mka":  true,
+	".mks":  true,
+	".mk3d": true,
+	".webm": true,
+}
+
+// LinkedSegments returns the filenames of the segments to play back before and
+// after this one, as declared by its SegmentInfo.
+//
+// The filenames are hints written by the muxer; the segments are identified by
+// the PrevUID and NextUID fields of the SegmentInfo, which OpenLinkedChain
+// uses to find them.
+//
+// Example:
+//
+//	prev, next := demuxer.LinkedSegments()
+//	if next != "" {
+//	    fmt.Printf("Continues in %s\n", next)
+//	}
+//
+// Returns:
+//   - prev: The filename of the previous segment, or an empty string.
+//   - next: The filename of the next segment, or an empty string.
+func (d *Demuxer) LinkedSegments() (prev, next string) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	info := d.parser.GetFileInfo()
+	if info == nil {
+		return "", ""
+	}
+	return info.PrevFilename, info.NextFilename
+}
+
+// SegmentUID returns the UID of the segment, which identifies it among the
+// linked segments and between copies of the same recording.
+//
+// Example:
+//
+//	uid := demuxer.SegmentUID()
+//	fmt.Printf("Segment %x\n", uid)
+//
+// Returns:
+//   - [16]byte: The SegmentUID of the SegmentInfo, or zero if the file
+//     declares none.
+func (d *Demuxer) SegmentUID() [16]byte {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	info := d.parser.GetFileInfo()
+	if info == nil {
+		return [16]byte{}
+	}
+	return info.UID
+}
+
+// SameSegment reports whether two demuxers read the same segment, as
+// identified by its SegmentUID, such as a recording that was copied or
+// appended to another file. Segments without a UID are never the same, as
+// nothing identifies them.
+//
+// Example:
+//
+//	if matroska.SameSegment(first, second) {
+//	    fmt.Println("Skipping duplicate recording")
+//	}
+//
+// Parameters:
+//   - a, b: The demuxers to compare, which may be nil.
+//
+// Returns:
+//   - bool: True if both segments have the same non-zero SegmentUID.
+func SameSegment(a, b *Demuxer) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	uid := a.SegmentUID()
+	return uid != [16]byte{} && uid == b.SegmentUID()
+}
+
+// OpenLinkedChain opens the linked segments of a directory, in playback order.
+//
+// Every Matroska or WebM file of the directory (.mkv, .mka, .mks, .mk3d and
+// .webm) is opened, and the files are chained by matching the PrevUID and
+// NextUID of their SegmentInfo with the UID of the other files. Files that
+// cannot be parsed, and files that are not linked to another file of the
+// directory, are ignored. The linked files must form a single chain.
+//
+// The caller must close the returned demuxers, which also closes their files.
+//
+// Example:
+//
+//	demuxers, err := matroska.OpenLinkedChain("/videos/movie")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, demuxer := range demuxers {
+//	    defer demuxer.Close()
+//	}
+//
+// Parameters:
+//   - dir: The directory containing the files.
+//   - opts: The options configuring the demuxers, such as WithDecompression.
+//
+// Returns:
+//   - []*Demuxer: The demuxers of the linked files, from the first segment to
+//     the last.
+//   - error: An error if the directory could not be read, if no files are
+//     linked, or if the links form several chains or a loop.
+func OpenLinkedChain(dir string, opts ...Option) ([]*Demuxer, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	// Open every file with a segment UID, keeping them in name order
+	var demuxers []*Demuxer
+	byUID := make(map[[16]byte]*Demuxer)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !linkedExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		file, errOpen := os.Open(filepath.Join(dir, entry.Name()))
+		if errOpen != nil {
+			closeDemuxers(demuxers)
+			return nil, fmt.Errorf("failed to open %s: %w", entry.Name(), errOpen)
+		}
+		demuxer, errDemuxer := NewDemuxer(file, opts...)
+		if errDemuxer != nil {
+			_ = file.Close()
+			continue
+		}
+		info := demuxer.parser.GetFileInfo()
+		if info == nil || info.UID == [16]byte{} || byUID[info.UID] != nil {
+			_ = demuxer.Close()
+			continue
+		}
+		byUID[info.UID] = demuxer
+		demuxers = append(demuxers, demuxer)
+	}
+
+	// A link may be declared by either of the two segments
+	next := make(map[*Demuxer]*Demuxer)
+	prev := make(map[*Demuxer]*Demuxer)
+	link := func(from, to *Demuxer) error {
+		if from == to {
+			return fmt.Errorf("segment %x is linked to itself", from.parser.GetFileInfo().UID)
+		}
+		if (next[from] != nil && next[from] != to) || (prev[to] != nil && prev[to] != from) {
+			return fmt.Errorf("segment %x has several links", from.parser.GetFileInfo().UID)
+		}
+		next[from] = to
+		prev[to] = from
+		return nil
+	}
+	for _, demuxer := range demuxers {
+		info := demuxer.parser.GetFileInfo()
+		if to := byUID[info.NextUID]; to != nil && info.NextUID != [16]byte{} {
+			if err = link(demuxer, to); err != nil {
+				closeDemuxers(demuxers)
+				return nil, err
+			}
+		}
+		if from := byUID[info.PrevUID]; from != nil && info.PrevUID != [16]byte{} {
+			if err = link(from, demuxer); err != nil {
+				closeDemuxers(demuxers)
+				return nil, err
+			}
+		}
+	}
+
+	// The chain starts at the only linked segment without a previous one
+	var first *Demuxer
+	for _, demuxer := range demuxers {
+		if next[demuxer] == nil || prev[demuxer] != nil {
+			continue
+		}
+		if first != nil {
+			closeDemuxers(demuxers)
+			return nil, fmt.Errorf("directory %s contains several chains of linked segments", dir)
+		}
+		first = demuxer
+	}
+	if first == nil {
+		closeDemuxers(demuxers)
+		if len(next) > 0 {
+			return nil, fmt.Errorf("linked segments of directory %s form a loop", dir)
+		}
+		return nil, fmt.Errorf("directory %s contains no linked segments", dir)
+	}
+
+	var chain []*Demuxer
+	inChain := make(map[*Demuxer]bool)
+	for demuxer := first; demuxer != nil; demuxer = next[demuxer] {
+		chain = append(chain, demuxer)
+		inChain[demuxer] = true
+	}
+	if len(chain) <= len(next) {
+		// The other links form a loop apart from the chain
+		closeDemuxers(demuxers)
+		return nil, fmt.Errorf("directory %s contains several chains of linked segments", dir)
+	}
+	for _, demuxer := range demuxers {
+		if !inChain[demuxer] {
+			_ = demuxer.Close()
+		}
+	}
+	return chain, nil
+}
+
+// closeDemuxers closes the given demuxers, ignoring errors.
+//
+// Parameters:
+//   - demuxers: The demuxers to close.
+func closeDemuxers(demuxers []*Demuxer) {
+	for _, demuxer := range demuxers {
+		_ = demuxer.Close()
+	}
+}
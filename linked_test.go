@@ -0,0 +1,150 @@
+package matroska
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createLinkedFile writes a file to dir whose SegmentInfo has the given UIDs,
+// a zero UID leaving the element out, and the given next filename.
+func createLinkedFile(t *testing.T, dir, name string, uid, prevUID, nextUID byte, nextFilename string) {
+	t.Helper()
+	var info bytes.Buffer
+	for _, field := range []struct {
+		id  uint32
+		uid byte
+	}{{IDSegmentUID, uid}, {IDPrevUID, prevUID}, {IDNextUID, nextUID}} {
+		if field.uid != 0 {
+			putElement(&info, field.id, bytes.Repeat([]byte{field.uid}, 16))
+		}
+	}
+	if nextFilename != "" {
+		putStringElement(&info, IDNextFilename, nextFilename)
+	}
+	f := buildTestFile(createMinimalEBMLHeader(), nil,
+		mockInfoElement(info.Bytes()),
+		mockTracksElement(t, TypeVideo, "V_VP9"),
+	)
+	if err := os.WriteFile(filepath.Join(dir, name), f.data, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+}
+
+func TestOpenLinkedChain(t *testing.T) {
+	t.Run("Two files", func(t *testing.T) {
+		dir := t.TempDir()
+		// The name order is the opposite of the playback order
+		createLinkedFile(t, dir, "a.mkv", 2, 1, 0, "")
+		createLinkedFile(t, dir, "b.mkv", 1, 0, 2, "a.mkv")
+		createLinkedFile(t, dir, "other.mkv", 3, 0, 0, "")
+		if err := os.WriteFile(filepath.Join(dir, "notes.mkv"), []byte("not matroska"), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+
+		demuxers, err := OpenLinkedChain(dir)
+		if err != nil {
+			t.Fatalf("OpenLinkedChain() failed: %v", err)
+		}
+		defer closeDemuxers(demuxers)
+		if len(demuxers) != 2 {
+			t.Fatalf("Expected 2 linked files, got %d", len(demuxers))
+		}
+		for i, uid := range []byte{1, 2} {
+			info, _ := demuxers[i].GetFileInfo()
+			if info.UID[0] != uid {
+				t.Errorf("File %d: expected segment UID %x, got %x", i, uid, info.UID)
+			}
+		}
+
+		prev, next := demuxers[0].LinkedSegments()
+		if prev != "" || next != "a.mkv" {
+			t.Errorf("Expected linked segments (\"\", \"a.mkv\"), got (%q, %q)", prev, next)
+		}
+		prev, next = demuxers[1].LinkedSegments()
+		if prev != "" || next != "" {
+			t.Errorf("Expected no linked filenames, got (%q, %q)", prev, next)
+		}
+	})
+
+	t.Run("No links", func(t *testing.T) {
+		dir := t.TempDir()
+		createLinkedFile(t, dir, "a.mkv", 1, 0, 0, "")
+		if _, err := OpenLinkedChain(dir); err == nil {
+			t.Error("Expected an error for a directory without linked files")
+		}
+	})
+
+	t.Run("Several chains", func(t *testing.T) {
+		dir := t.TempDir()
+		createLinkedFile(t, dir, "a.mkv", 1, 0, 2, "")
+		createLinkedFile(t, dir, "b.mkv", 2, 0, 0, "")
+		createLinkedFile(t, dir, "c.mkv", 3, 0, 4, "")
+		createLinkedFile(t, dir, "d.mkv", 4, 0, 0, "")
+		if _, err := OpenLinkedChain(dir); err == nil {
+			t.Error("Expected an error for a directory with two chains")
+		}
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		dir := t.TempDir()
+		createLinkedFile(t, dir, "a.mkv", 1, 0, 2, "")
+		createLinkedFile(t, dir, "b.mkv", 2, 0, 1, "")
+		if _, err := OpenLinkedChain(dir); err == nil {
+			t.Error("Expected an error for segments linked in a loop")
+		}
+	})
+}
+
+func TestSameSegment(t *testing.T) {
+	dir := t.TempDir()
+	createLinkedFile(t, dir, "recording.mkv", 1, 0, 0, "")
+	createLinkedFile(t, dir, "copy.mkv", 1, 0, 0, "")
+	createLinkedFile(t, dir, "other.mkv", 2, 0, 0, "")
+	createLinkedFile(t, dir, "no-uid.mkv", 0, 0, 0, "")
+	createLinkedFile(t, dir, "no-uid-copy.mkv", 0, 0, 0, "")
+
+	open := func(name string) *Demuxer {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+		demuxer, err := NewDemuxer(file)
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = demuxer.Close() })
+		return demuxer
+	}
+	recording, recordingCopy, other := open("recording.mkv"), open("copy.mkv"), open("other.mkv")
+	noUID, noUIDCopy := open("no-uid.mkv"), open("no-uid-copy.mkv")
+
+	var expected [16]byte
+	copy(expected[:], bytes.Repeat([]byte{1}, 16))
+	if uid := recording.SegmentUID(); uid != expected {
+		t.Errorf("Expected SegmentUID %x, got %x", expected, uid)
+	}
+	if uid := noUID.SegmentUID(); uid != [16]byte{} {
+		t.Errorf("Expected a zero SegmentUID, got %x", uid)
+	}
+
+	tests := []struct {
+		name     string
+		a, b     *Demuxer
+		expected bool
+	}{
+		{"Copy", recording, recordingCopy, true},
+		{"Itself", recording, recording, true},
+		{"Other segment", recording, other, false},
+		{"No UID", noUID, noUIDCopy, false},
+		{"Nil", recording, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := SameSegment(tt.a, tt.b); same != tt.expected {
+				t.Errorf("Expected SameSegment() %v, got %v", tt.expected, same)
+			}
+		})
+	}
+}
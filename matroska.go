@@ -58,8 +58,10 @@
 package matroska
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sync"
 )
 
 // Demuxer is a Matroska demuxer using pure Go implementation.
@@ -71,9 +73,52 @@ import (
 //
 // For seekable inputs, use NewDemuxer. For non-seekable streams (like network streams),
 // use NewStreamingDemuxer.
+//
+// A Demuxer reads packets sequentially, so ReadPacket and the other methods
+// that read packets, seek or change settings must not be called from several
+// goroutines expecting a meaningful order; they are serialized by an internal
+// lock. The metadata accessors, such as GetTrackInfo, GetFileInfo, GetChapters
+// and Stats, can be called concurrently with each other and with a goroutine
+// reading packets, including one waiting for more data with WithFollow, as
+// can Close. The TrackInfo and other structures they return are shared and
+// must not be modified; use GetTrackInfoCopy for a modifiable copy.
 type Demuxer struct {
 	parser *MatroskaParser
 	reader io.ReadSeeker
+
+	// opMu serializes the methods that read packets, seek or change the
+	// settings of the parser, for their whole duration
+	opMu sync.Mutex
+	// mu is held for reading by the metadata accessors, and for writing by the
+	// methods holding opMu, except while they wait for data with WithFollow
+	mu sync.RWMutex
+}
+
+// newDemuxer creates a Demuxer reading from parser, whose source is reader.
+//
+// Parameters:
+//   - parser: The parser of the file.
+//   - reader: The source of the parser, closed by Close.
+//
+// Returns:
+//   - *Demuxer: The new Demuxer.
+func newDemuxer(parser *MatroskaParser, reader io.ReadSeeker) *Demuxer {
+	d := &Demuxer{parser: parser, reader: reader}
+	parser.waitLock = &d.mu
+	return d
+}
+
+// lock takes the locks of the demuxer for a method that reads packets, seeks
+// or changes the settings of the parser.
+func (d *Demuxer) lock() {
+	d.opMu.Lock()
+	d.mu.Lock()
+}
+
+// unlock releases the locks taken by lock.
+func (d *Demuxer) unlock() {
+	d.mu.Unlock()
+	d.opMu.Unlock()
 }
 
 // NewDemuxer creates a new Matroska demuxer from r.
@@ -103,20 +148,18 @@ type Demuxer struct {
 //
 // Parameters:
 //   - r: An io.ReadSeeker that provides access to the Matroska file data.
+//   - opts: Options configuring the demuxer, such as WithDecompression.
 //
 // Returns:
 //   - *Demuxer: A new Demuxer instance for the given input.
 //   - error: An error if the demuxer could not be created.
-func NewDemuxer(r io.ReadSeeker) (*Demuxer, error) {
-	parser, err := NewMatroskaParser(r, false)
+func NewDemuxer(r io.ReadSeeker, opts ...Option) (*Demuxer, error) {
+	parser, err := NewMatroskaParserWithOptions(r, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create parser: %w", err)
 	}
 
-	return &Demuxer{
-		parser: parser,
-		reader: r,
-	}, nil
+	return newDemuxer(parser, r), nil
 }
 
 // NewStreamingDemuxer creates a new Matroska demuxer from an
@@ -131,6 +174,8 @@ func NewDemuxer(r io.ReadSeeker) (*Demuxer, error) {
 // seeking interface, allowing the Matroska parser to work with streams that don't support
 // random access. Note that some operations, like seeking to specific timecodes or accessing
 // cues, may not work as efficiently or may not be available when using a streaming demuxer.
+// The metadata is read up to the first cluster, so that no packet is lost; tags, chapters
+// and attachments stored after the clusters become available as ReadPacket reaches them.
 //
 // Example:
 //
@@ -148,42 +193,106 @@ func NewDemuxer(r io.ReadSeeker) (*Demuxer, error) {
 //
 // Parameters:
 //   - r: An io.Reader that provides access to the Matroska stream data.
+//   - opts: Options configuring the demuxer. WithAvoidSeeks is always applied.
 //
 // Returns:
 //   - *Demuxer: A new Demuxer instance for the given input stream.
 //   - error: An error if the demuxer could not be created.
-func NewStreamingDemuxer(r io.Reader) (*Demuxer, error) {
+func NewStreamingDemuxer(r io.Reader, opts ...Option) (*Demuxer, error) {
 	fs := &fakeSeeker{r: r}
-	parser, err := NewMatroskaParser(fs, true)
+	parser, err := NewMatroskaParserWithOptions(fs, append(opts, WithAvoidSeeks())...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create streaming parser: %w", err)
 	}
 
-	return &Demuxer{
-		parser: parser,
-		reader: fs,
-	}, nil
+	return newDemuxer(parser, fs), nil
 }
 
-// Close closes a demuxer.
+// NewDemuxerAt creates a new Matroska demuxer from an io.ReaderAt.
+//
+// Unlike a demuxer created by NewDemuxer, which moves the seek position of its
+// input, the demuxer reads at absolute offsets through its own cursor. Several
+// demuxers created from the same io.ReaderAt, such as an *os.File, can
+// therefore read concurrently, one per goroutine. See NewMatroskaParserAt.
 //
-// Close releases any resources associated with the Demuxer.
-// In this pure Go implementation, no explicit cleanup is required as
-// Go's garbage collector handles memory management automatically.
-// However, calling Close is still recommended for consistency and
-// to allow for future implementations that might require cleanup.
+// As the io.ReaderAt is usually shared, Close does not close it.
 //
 // Example:
 //
+//	info, err := file.Stat()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	var wg sync.WaitGroup
+//	for i, out := range outputs {
+//	    wg.Add(1)
+//	    go func(track uint, out io.Writer) {
+//	        defer wg.Done()
+//	        demuxer, err := matroska.NewDemuxerAt(file, info.Size())
+//	        if err != nil {
+//	            log.Fatal(err)
+//	        }
+//	        if err = demuxer.ExtractTrack(out, track, matroska.ExtractOptions{}); err != nil {
+//	            log.Fatal(err)
+//	        }
+//	    }(uint(i), out)
+//	}
+//	wg.Wait()
+//
+// Parameters:
+//   - r: An io.ReaderAt that provides access to the Matroska file data.
+//   - size: The size of the file in bytes.
+//   - opts: The options configuring the demuxer, such as WithDecompression.
+//
+// Returns:
+//   - *Demuxer: A pointer to the initialized Demuxer.
+//   - error: An error if the demuxer could not be created or if the file is not
+//     a valid Matroska or WebM file.
+func NewDemuxerAt(r io.ReaderAt, size int64, opts ...Option) (*Demuxer, error) {
+	sr := io.NewSectionReader(r, 0, size)
+	parser, err := NewMatroskaParserWithOptions(sr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser: %w", err)
+	}
+
+	return newDemuxer(parser, sr), nil
+}
+
+// Close closes a demuxer, implementing io.Closer.
+//
+// If the source the demuxer was created from implements io.Closer, such as
+// an *os.File or an HTTP response body, Close closes it, so that deferring
+// Close is enough to release the file handle. Otherwise Close does nothing.
+// Close does not wait for a read in progress, so it can be called from
+// another goroutine to stop a read waiting for data with WithFollow, which
+// then returns an error. The demuxer must not be used after Close.
+//
+// Example:
+//
+//	file, err := os.Open("video.mkv")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
 //	demuxer, err := matroska.NewDemuxer(file)
 //	if err != nil {
+//	    _ = file.Close()
 //	    log.Fatal(err)
 //	}
-//	defer demuxer.Close()
+//	defer demuxer.Close() // Also closes file
 //
 //	// Use demuxer...
-func (d *Demuxer) Close() {
-	// Pure Go implementation doesn't need explicit cleanup
+//
+// Returns:
+//   - error: The error returned by closing the source, or nil.
+func (d *Demuxer) Close() error {
+	var source io.Reader = d.reader
+	if fs, ok := d.reader.(*fakeSeeker); ok {
+		source = fs.r
+	}
+	if closer, ok := source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 // GetNumTracks gets the number of tracks available to a given demuxer.
@@ -205,6 +314,8 @@ func (d *Demuxer) Close() {
 //   - uint: The number of tracks in the Matroska file.
 //   - error: An error if the track count could not be retrieved.
 func (d *Demuxer) GetNumTracks() (uint, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetNumTracks(), nil
 }
 
@@ -215,6 +326,10 @@ func (d *Demuxer) GetNumTracks() (uint, error) {
 // its type (video, audio, subtitle), codec, language, and other metadata.
 // The track parameter must be a valid track index between 0 and GetNumTracks()-1.
 //
+// The returned TrackInfo is shared with the demuxer, which uses it to read the
+// packets of the track, and with the other callers of GetTrackInfo. It must not
+// be modified; use GetTrackInfoCopy to get a copy that can be.
+//
 // Example:
 //
 //	numTracks, err := demuxer.GetNumTracks()
@@ -237,6 +352,21 @@ func (d *Demuxer) GetNumTracks() (uint, error) {
 //   - *TrackInfo: Detailed information about the track.
 //   - error: An error if the track information could not be retrieved or if the track index is invalid.
 func (d *Demuxer) GetTrackInfo(track uint) (*TrackInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.trackInfo(track)
+}
+
+// trackInfo returns the information about a track, like GetTrackInfo, for
+// the methods that already hold the lock of the demuxer.
+//
+// Parameters:
+//   - track: The index of the track.
+//
+// Returns:
+//   - *TrackInfo: The information about the track.
+//   - error: An error if the track index is invalid.
+func (d *Demuxer) trackInfo(track uint) (*TrackInfo, error) {
 	trackInfo := d.parser.GetTrackInfo(track)
 	if trackInfo == nil {
 		return nil, fmt.Errorf("track %d not found", track)
@@ -244,6 +374,185 @@ func (d *Demuxer) GetTrackInfo(track uint) (*TrackInfo, error) {
 	return trackInfo, nil
 }
 
+// GetTrackInfoCopy returns a deep copy of the information about a given track,
+// where track is less than what is returned by GetNumTracks.
+//
+// Unlike GetTrackInfo, the returned TrackInfo, including its CodecPrivate and
+// ContentEncodings, is not shared with the demuxer, so it can be modified, for
+// example to adjust the track before passing it to Muxer.AddTrack, without
+// affecting the packets read or the other callers.
+//
+// Example:
+//
+//	trackInfo, err := demuxer.GetTrackInfoCopy(0)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	trackInfo.Language = "eng"
+//
+// Parameters:
+//   - track: The index of the track to retrieve information for.
+//
+// Returns:
+//   - *TrackInfo: A copy of the information about the track.
+//   - error: An error if the track index is invalid.
+func (d *Demuxer) GetTrackInfoCopy(track uint) (*TrackInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	trackInfo := d.parser.GetTrackInfoCopy(track)
+	if trackInfo == nil {
+		return nil, fmt.Errorf("track %d not found", track)
+	}
+	return trackInfo, nil
+}
+
+// GetTrackByNumber returns all track-level information available for the track
+// with the given track number.
+//
+// Unlike GetTrackInfo, which takes an index between 0 and GetNumTracks()-1,
+// this function looks the track up by its Matroska track number, which is the
+// value stored in Packet.Track. This makes it easy to find the track a packet
+// belongs to without building a number-to-index map.
+//
+// Example:
+//
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	trackInfo, err := demuxer.GetTrackByNumber(packet.Track)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Packet belongs to %s track\n", trackInfo.CodecID)
+//
+// Parameters:
+//   - num: The track number to look up.
+//
+// Returns:
+//   - *TrackInfo: Detailed information about the track.
+//   - error: An error if no track with the given number exists.
+func (d *Demuxer) GetTrackByNumber(num uint64) (*TrackInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	trackInfo := d.parser.GetTrackByNumber(num)
+	if trackInfo == nil {
+		return nil, fmt.Errorf("track number %d not found", num)
+	}
+	return trackInfo, nil
+}
+
+// GetVideoTrack returns the primary video track of the file.
+//
+// This is the first enabled video track with the FlagDefault flag set or,
+// if no enabled video track has the flag, the first enabled video track.
+//
+// Example:
+//
+//	video := demuxer.GetVideoTrack()
+//	if video == nil {
+//	    log.Fatal("no video track")
+//	}
+//	fmt.Printf("Video: %dx%d %s\n", video.Video.PixelWidth, video.Video.PixelHeight, video.CodecID)
+//
+// Returns:
+//   - *TrackInfo: The primary video track, or nil if there is no enabled video track.
+func (d *Demuxer) GetVideoTrack() *TrackInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.primaryTrack(TypeVideo)
+}
+
+// GetAudioTrack returns the primary audio track of the file.
+//
+// This is the first enabled audio track with the FlagDefault flag set or,
+// if no enabled audio track has the flag, the first enabled audio track.
+//
+// Returns:
+//   - *TrackInfo: The primary audio track, or nil if there is no enabled audio track.
+func (d *Demuxer) GetAudioTrack() *TrackInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.primaryTrack(TypeAudio)
+}
+
+// GetSubtitleTracks returns all the subtitle tracks of the file, in the order
+// in which they are declared.
+//
+// Unlike GetVideoTrack and GetAudioTrack, disabled tracks are included, as a
+// player usually lets the user choose among the subtitles.
+//
+// Returns:
+//   - []*TrackInfo: The subtitle tracks, or nil if there are none.
+func (d *Demuxer) GetSubtitleTracks() []*TrackInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var tracks []*TrackInfo
+	for _, track := range d.parser.tracks {
+		if track.Type == TypeSubtitle {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks
+}
+
+// Stats returns the statistics of each track, accumulated from the packets
+// read so far.
+//
+// Every packet returned by ReadPacket, ReadPacketCtx, ReadPacketMask or
+// Packets is counted, including those read by ExtractTrack and the other
+// functions that read packets. Reading the whole file therefore gives the
+// number of frames and bytes of every track. Tracks of which no packet has
+// been read are not in the map. The returned statistics are a copy, which is
+// not updated by later reads.
+//
+// Example:
+//
+//	for {
+//	    if _, err := demuxer.ReadPacket(); err != nil {
+//	        break
+//	    }
+//	}
+//	for track, stats := range demuxer.Stats() {
+//	    fmt.Printf("Track %d: %d packets, %d bytes\n", track, stats.PacketCount, stats.TotalBytes)
+//	}
+//
+// Returns:
+//   - map[uint64]*TrackStats: The statistics of each track, keyed by track number.
+func (d *Demuxer) Stats() map[uint64]*TrackStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	stats := make(map[uint64]*TrackStats, len(d.parser.stats))
+	for track, trackStats := range d.parser.stats {
+		statsCopy := *trackStats
+		stats[track] = &statsCopy
+	}
+	return stats
+}
+
+// GetEBMLHeader returns the EBML header of the file.
+//
+// The header identifies the document type, "matroska" or "webm", and the
+// version of the specification the file was written for. DocTypeVersion tells
+// which elements may be present, such as LanguageIETF or DiscardPadding, which
+// were added in version 4, and DocType distinguishes WebM, which only allows a
+// subset of Matroska's codecs and elements.
+//
+// Example:
+//
+//	header := demuxer.GetEBMLHeader()
+//	if header.DocType == "webm" {
+//	    fmt.Printf("WebM file, version %d\n", header.DocTypeVersion)
+//	}
+//
+// Returns:
+//   - *EBMLHeader: The EBML header of the file.
+func (d *Demuxer) GetEBMLHeader() *EBMLHeader {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.GetEBMLHeader()
+}
+
 // GetFileInfo gets all top-level (whole file) info available for a given
 // demuxer.
 //
@@ -259,13 +568,15 @@ func (d *Demuxer) GetTrackInfo(track uint) (*TrackInfo, error) {
 //	    log.Fatal(err)
 //	}
 //	fmt.Printf("Title: %s\n", fileInfo.Title)
-//	fmt.Printf("Duration: %f seconds\n", float64(fileInfo.Duration)/1000000000)
+//	fmt.Printf("Duration: %s\n", fileInfo.DurationNanos())
 //	fmt.Printf("Muxing App: %s\n", fileInfo.MuxingApp)
 //
 // Returns:
 //   - *SegmentInfo: File-level metadata about the Matroska file.
 //   - error: An error if the file information could not be retrieved.
 func (d *Demuxer) GetFileInfo() (*SegmentInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	fileInfo := d.parser.GetFileInfo()
 	if fileInfo == nil {
 		return nil, fmt.Errorf("no file info available")
@@ -290,6 +601,8 @@ func (d *Demuxer) GetFileInfo() (*SegmentInfo, error) {
 // Returns:
 //   - []*Attachment: A slice of attachment information. May be empty if no attachments are present.
 func (d *Demuxer) GetAttachments() []*Attachment {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetAttachments()
 }
 
@@ -310,6 +623,8 @@ func (d *Demuxer) GetAttachments() []*Attachment {
 // Returns:
 //   - []*Chapter: A slice of chapter information. May be empty if no chapters are present.
 func (d *Demuxer) GetChapters() []*Chapter {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetChapters()
 }
 
@@ -332,6 +647,8 @@ func (d *Demuxer) GetChapters() []*Chapter {
 // Returns:
 //   - []*Tag: A slice of tag information. May be empty if no tags are present.
 func (d *Demuxer) GetTags() []*Tag {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetTags()
 }
 
@@ -352,6 +669,8 @@ func (d *Demuxer) GetTags() []*Tag {
 // Returns:
 //   - []*Cue: A slice of cue information. May be empty if no cues are present.
 func (d *Demuxer) GetCues() []*Cue {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetCues()
 }
 
@@ -369,6 +688,8 @@ func (d *Demuxer) GetCues() []*Cue {
 // Returns:
 //   - uint64: The file position where the segment begins.
 func (d *Demuxer) GetSegment() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetSegment()
 }
 
@@ -388,6 +709,8 @@ func (d *Demuxer) GetSegment() uint64 {
 // Returns:
 //   - uint64: The file position after the end of the segment.
 func (d *Demuxer) GetSegmentTop() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetSegmentTop()
 }
 
@@ -405,6 +728,8 @@ func (d *Demuxer) GetSegmentTop() uint64 {
 // Returns:
 //   - uint64: The file position where the cues element begins.
 func (d *Demuxer) GetCuesPos() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetCuesPos()
 }
 
@@ -424,6 +749,8 @@ func (d *Demuxer) GetCuesPos() uint64 {
 // Returns:
 //   - uint64: The file position after the end of the cues element.
 func (d *Demuxer) GetCuesTopPos() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.parser.GetCuesTopPos()
 }
 
@@ -442,6 +769,8 @@ func (d *Demuxer) GetCuesTopPos() uint64 {
 //   - flags: Seek behavior flags. May be 0 (normal seek), SeekToPrevKeyFrame,
 //     or SeekToPrevKeyFrameStrict.
 func (d *Demuxer) Seek(timecode uint64, flags uint32) {
+	d.lock()
+	defer d.unlock()
 	if d.parser.avoidSeeks {
 		return
 	}
@@ -477,9 +806,37 @@ func (d *Demuxer) SeekCueAware(timecode uint64, flags uint32, fuzzy bool) {
 // without reference to previous frames, making them ideal starting points
 // for seeking or resuming playback.
 func (d *Demuxer) SkipToKeyframe() {
+	d.lock()
+	defer d.unlock()
 	d.parser.SkipToKeyframe()
 }
 
+// GetSilentTracks returns the tracks that are silent in the current cluster.
+//
+// A cluster may contain a SilentTracks element listing the tracks that have
+// no blocks in it on purpose, such as an audio track during a pause in a
+// recording. A player can use it to stop waiting for data from the decoders of
+// these tracks. The list is that of the cluster of the last packet read, and
+// is reset at the start of each cluster.
+//
+// Example:
+//
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, track := range demuxer.GetSilentTracks() {
+//	    fmt.Printf("Track %d is silent in this cluster\n", track)
+//	}
+//
+// Returns:
+//   - []uint64: The silent track numbers, or nil if the cluster lists none.
+func (d *Demuxer) GetSilentTracks() []uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.GetSilentTracks()
+}
+
 // GetLowestQTimecode returns the lowest queued timecode in the demuxer.
 //
 // This function returns the timecode of the earliest packet
@@ -489,17 +846,21 @@ func (d *Demuxer) SkipToKeyframe() {
 // Returns:
 //   - uint64: The timecode of the lowest queued packet.
 func (d *Demuxer) GetLowestQTimecode() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	if d.parser.fileInfo == nil {
 		return 0
 	}
-	return d.parser.clusterTimestamp * d.parser.fileInfo.TimecodeScale
+	return d.parser.blockTime(d.parser.clusterTimestamp)
 }
 
 // SetTrackMask sets the demuxer's track mask; that is, it tells the demuxer
 // which tracks to skip, and which to use. Any tracks with ones in their bit
 // positions will be ignored.
 //
-// Calling this withh cause all parsed and queued frames to be discarded.
+// The mask applies from the next call to ReadPacket. The packets queued by
+// ReadPrevPacket are discarded, as with Flush, so that the next call to
+// ReadPrevPacket does not return packets of newly masked tracks.
 //
 // This function allows filtering of tracks during playback or
 // processing. The mask is a bitmask where each bit corresponds to a track
@@ -509,25 +870,142 @@ func (d *Demuxer) GetLowestQTimecode() uint64 {
 //   - mask: A bitmask specifying which tracks to ignore. A bit set to 1 at
 //     position N will cause track N to be ignored.
 func (d *Demuxer) SetTrackMask(mask uint64) {
+	d.lock()
+	defer d.unlock()
 	d.parser.SetTrackMask(mask)
 }
 
+// SetDecompression enables or disables the decompression of frames of tracks
+// that declare content compression.
+//
+// Tracks may store their frames compressed with zlib, or with header stripping,
+// where bytes common to every frame are removed and kept in the track header.
+// ReadPacket undoes this compression by default, so that packets hold frames
+// that can be passed to a decoder. Disable it to receive the raw bytes stored
+// in the file, for example when remuxing to another Matroska file.
+//
+// If a frame cannot be decompressed, ReadPacket returns an error for that
+// packet only, and the next call continues with the following packet.
+//
+// Parameters:
+//   - enabled: Whether compressed frames should be decompressed.
+func (d *Demuxer) SetDecompression(enabled bool) {
+	d.lock()
+	defer d.unlock()
+	d.parser.SetDecompression(enabled)
+}
+
+// SetDecryptionKey sets the key used to decrypt the frames of a given track,
+// where track is less than what is returned by GetNumTracks.
+//
+// Tracks encrypted as described by the WebM encryption specification declare
+// an AES-CTR ContentEncryption, whose KeyID can be found in the track's
+// ContentEncodings. Once a key is set, ReadPacket decrypts the frames of the
+// track, removing the signal byte and IV that precede every frame. Frames
+// flagged as unencrypted are returned without their signal byte. Without a
+// key, frames are returned as they are stored in the file.
+//
+// If a frame cannot be decrypted, for example because the key has an invalid
+// length, ReadPacket returns an error for that packet only.
+//
+// Example:
+//
+//	track, _ := demuxer.GetTrackInfo(0)
+//	for _, encoding := range track.ContentEncodings {
+//	    if encoding.Encryption != nil {
+//	        demuxer.SetDecryptionKey(0, keys[string(encoding.Encryption.KeyID)])
+//	    }
+//	}
+//
+// Parameters:
+//   - track: The index of the track to configure.
+//   - key: The 16, 24 or 32-byte AES key, or nil to remove the track's key.
+func (d *Demuxer) SetDecryptionKey(track uint, key []byte) {
+	d.lock()
+	defer d.unlock()
+	d.parser.SetDecryptionKey(track, key)
+}
+
+// SetAnnexBConversion enables or disables the automatic conversion of H.264 and
+// H.265 frames to Annex B format for a given track, where track is less than
+// what is returned by GetNumTracks.
+//
+// Matroska stores H.264 and H.265 frames in AVCC format, with length-prefixed NAL
+// units, while raw elementary streams and many decoders expect Annex B format,
+// with start-code-separated NAL units. When conversion is enabled, ReadPacket
+// converts the frame data of the track and prepends the parameter sets from the
+// track's CodecPrivate to its first keyframe. Conversion is off by default.
+//
+// Tracks with other codecs, and invalid track indices, are ignored.
+//
+// Example:
+//
+//	demuxer.SetAnnexBConversion(0, true)
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_, _ = out.Write(packet.Data) // Annex B data
+//
+// Parameters:
+//   - track: The index of the track to configure.
+//   - enabled: Whether frames of the track should be converted.
+func (d *Demuxer) SetAnnexBConversion(track uint, enabled bool) {
+	d.lock()
+	defer d.unlock()
+	d.parser.SetAnnexBConversion(track, enabled)
+}
+
+// SetPCMNormalize sets the byte order of the samples of an integer PCM track,
+// where track is less than what is returned by GetNumTracks.
+//
+// Matroska stores big-endian PCM in "A_PCM/INT/BIG" tracks and little-endian
+// PCM in "A_PCM/INT/LIT" tracks, while most decoders and WAV files expect
+// little-endian samples. ReadPacket byte-swaps the 16, 24 or 32-bit samples
+// of the track, according to its BitDepth, when their byte order differs from
+// the requested one. Float PCM ("A_PCM/FLOAT/IEEE"), 8-bit tracks, other
+// codecs and invalid track indices are ignored.
+//
+// Example:
+//
+//	demuxer.SetPCMNormalize(1, true)
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_, _ = wav.Write(packet.Data) // Little-endian samples
+//
+// Parameters:
+//   - track: The index of the track to configure.
+//   - little: True for little-endian samples, false for big-endian samples.
+func (d *Demuxer) SetPCMNormalize(track uint, little bool) {
+	d.lock()
+	defer d.unlock()
+	d.parser.SetPCMNormalize(track, little)
+}
+
 // ReadPacketMask is the same as ReadPacket except with a track mask.
 //
-// This function is intended to read the next packet from the demuxer while
-// respecting the track mask specified by the mask parameter. Currently,
-// the mask parameter is ignored and the function behaves identically to
-// ReadPacket.
+// This function reads the next packet from the demuxer, skipping the packets
+// of the tracks set in mask. The mask is used instead of the one set with
+// SetTrackMask for this call only.
+//
+// Example:
+//
+//	// Read only the packets of track number 2
+//	packet, err := demuxer.ReadPacketMask(^uint64(1 << 1))
 //
 // Parameters:
-//   - mask: A bitmask specifying which tracks to ignore. Currently ignored.
+//   - mask: A bitmask specifying which tracks to ignore. A bit set to 1 at
+//     position N will cause the track numbered N+1 to be ignored.
 //
 // Returns:
 //   - *Packet: The next packet from the demuxer.
-//   - error: An error if a packet could not be read.
+//   - error: An error if a packet could not be read, or io.EOF if the end of the file has been reached.
 func (d *Demuxer) ReadPacketMask(mask uint64) (*Packet, error) {
-	// For now, ignore mask and read next packet
-	return d.parser.ReadPacket()
+	d.lock()
+	defer d.unlock()
+	return d.parser.ReadPacketMask(mask)
 }
 
 // ReadPacket returns the next packet from a demuxer.
@@ -551,9 +1029,96 @@ func (d *Demuxer) ReadPacketMask(mask uint64) (*Packet, error) {
 //	    // Process packet data...
 //	}
 //
+// The end of the file is reported as io.EOF only when it falls between
+// elements. A file cut within the data of a block, such as an interrupted
+// download, returns an error wrapping both ErrTruncatedBlock and
+// io.ErrUnexpectedEOF instead, so that a loop stopping at io.EOF can tell a
+// complete file from a truncated one. Use errors.Is to test for either.
+//
 // Returns:
 //   - *Packet: The next packet from the demuxer.
 //   - error: An error if a packet could not be read, or io.EOF if the end of the file has been reached.
 func (d *Demuxer) ReadPacket() (*Packet, error) {
-	return d.parser.ReadPacket()
+	return d.ReadPacketCtx(context.Background())
+}
+
+// ReadPacketCtx is the same as ReadPacket except that it can be cancelled.
+//
+// The context is checked before each element is read, and the read is aborted
+// with ctx.Err() once the context is cancelled. This allows a server that
+// demuxes a slow network source to stop reading when its client disconnects.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	packet, err := demuxer.ReadPacketCtx(ctx)
+//	if err != nil {
+//	    if errors.Is(err, context.DeadlineExceeded) {
+//	        log.Print("read timed out")
+//	    }
+//	    return err
+//	}
+//
+// Parameters:
+//   - ctx: The context whose cancellation aborts the read.
+//
+// Returns:
+//   - *Packet: The next packet from the demuxer.
+//   - error: ctx.Err() if the context is cancelled, an error if a packet could
+//     not be read, or io.EOF if the end of the file has been reached.
+func (d *Demuxer) ReadPacketCtx(ctx context.Context) (*Packet, error) {
+	d.lock()
+	defer d.unlock()
+	return d.parser.ReadPacketCtx(ctx)
+}
+
+// Packets returns a channel that receives the packets of the demuxer, so that
+// they can be read with a range loop.
+//
+// A goroutine reads the packets with ReadPacketCtx and sends them to the
+// channel until the end of the file is reached, a packet cannot be read, or
+// ctx is cancelled, and then closes the channel. A read error is sent as the
+// last result. The end of the file and the cancellation of ctx are not
+// reported as errors.
+//
+// The goroutine owns the demuxer until the channel is closed, so no other
+// method of the demuxer may be called in the meantime.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	for result := range demuxer.Packets(ctx) {
+//	    if result.Err != nil {
+//	        log.Fatal(result.Err)
+//	    }
+//	    fmt.Printf("Packet: track=%d, time=%d\n", result.Packet.Track, result.Packet.StartTime)
+//	}
+//
+// Parameters:
+//   - ctx: The context whose cancellation stops the reading.
+//
+// Returns:
+//   - <-chan PacketResult: The channel receiving the packets.
+func (d *Demuxer) Packets(ctx context.Context) <-chan PacketResult {
+	results := make(chan PacketResult)
+	go func() {
+		defer close(results)
+		for ctx.Err() == nil {
+			packet, err := d.ReadPacketCtx(ctx)
+			if err == io.EOF || ctx.Err() != nil {
+				return
+			}
+			select {
+			case results <- PacketResult{Packet: packet, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return results
 }
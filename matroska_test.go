@@ -2,12 +2,19 @@ package matroska
 
 import (
 	"bytes"
+	"compress/zlib"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 const testDemuxerFile = "testdata/test.mkv"
@@ -104,7 +111,7 @@ func vintEncode(value uint64) []byte {
 
 // createMockTrackEntry creates a mock TrackEntry element for testing.
 // This is a helper function for creating test data.
-func createMockTrackEntry(trackNum uint8, trackType uint8, codecID string, trackName string, language string) ([]byte, error) {
+func createMockTrackEntry(trackNum uint8, trackType TrackType, codecID string, trackName string, language string) ([]byte, error) {
 	buf := new(bytes.Buffer)
 
 	// TrackNumber
@@ -117,7 +124,7 @@ func createMockTrackEntry(trackNum uint8, trackType uint8, codecID string, track
 	buf.Write(uid)
 
 	// TrackType
-	buf.Write([]byte{0x83, 0x81, trackType})
+	buf.Write([]byte{0x83, 0x81, byte(trackType)})
 
 	// CodecID
 	buf.WriteByte(0x86)
@@ -348,6 +355,190 @@ func createMockMatroskaFile() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// createMockMatroskaFileWithTrack creates a mock Matroska file with a single
+// track and one cluster holding the given SimpleBlock payloads.
+// This is a helper function for creating test data.
+func createMockMatroskaFileWithTrack(trackEntry []byte, blocks ...[]byte) []byte {
+	buf := new(bytes.Buffer)
+
+	// EBML Header
+	ebmlHeader := []byte{0x42, 0x82, 0x88, 'm', 'a', 't', 'r', 'o', 's', 'k', 'a'} // DocType
+	buf.Write([]byte{0x1A, 0x45, 0xDF, 0xA3})
+	buf.Write(vintEncode(uint64(len(ebmlHeader))))
+	buf.Write(ebmlHeader)
+
+	segment := new(bytes.Buffer)
+
+	// SegmentInfo with TimestampScale 1,000,000
+	segInfo := []byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}
+	segment.Write([]byte{0x15, 0x49, 0xA9, 0x66})
+	segment.Write(vintEncode(uint64(len(segInfo))))
+	segment.Write(segInfo)
+
+	// Tracks
+	tracks := new(bytes.Buffer)
+	tracks.WriteByte(0xAE)
+	tracks.Write(vintEncode(uint64(len(trackEntry))))
+	tracks.Write(trackEntry)
+	segment.Write([]byte{0x16, 0x54, 0xAE, 0x6B})
+	segment.Write(vintEncode(uint64(tracks.Len())))
+	segment.Write(tracks.Bytes())
+
+	// Cluster
+	cluster := new(bytes.Buffer)
+	cluster.Write([]byte{0xE7, 0x81, 0x00}) // Timestamp 0
+	for _, block := range blocks {
+		cluster.WriteByte(0xA3)
+		cluster.Write(vintEncode(uint64(len(block))))
+		cluster.Write(block)
+	}
+	segment.Write([]byte{0x1F, 0x43, 0xB6, 0x75})
+	segment.Write(vintEncode(uint64(cluster.Len())))
+	segment.Write(cluster.Bytes())
+
+	buf.Write([]byte{0x18, 0x53, 0x80, 0x67})
+	buf.Write(vintEncode(uint64(segment.Len())))
+	buf.Write(segment.Bytes())
+
+	return buf.Bytes()
+}
+
+// segmentElement is a top-level element of a file built by buildTestFile.
+type segmentElement struct {
+	id   uint32
+	data []byte
+	// dataAt, if set, builds the data from the positions of the elements of
+	// the segment relative to its data, as for Cues pointing to a cluster.
+	dataAt func(positions []int64) []byte
+	// unknownSize writes the element with the unknown size.
+	unknownSize bool
+}
+
+// builtFile is a file built by buildTestFile, with the positions of the
+// elements of its segment.
+type builtFile struct {
+	data        []byte
+	segmentData int64   // The position of the segment data in the file
+	positions   []int64 // The positions of the elements relative to the segment data
+	headerSizes []int
+	sizes       []int
+}
+
+// start returns the position in the file of the header of element i.
+func (f *builtFile) start(i int) int64 {
+	return f.segmentData + f.positions[i]
+}
+
+// dataStart returns the position in the file of the data of element i.
+func (f *builtFile) dataStart(i int) int64 {
+	return f.start(i) + int64(f.headerSizes[i])
+}
+
+// end returns the position in the file of the end of element i.
+func (f *builtFile) end(i int) int64 {
+	return f.dataStart(i) + int64(f.sizes[i])
+}
+
+// buildTestFile builds a file with the given EBML header and a segment holding
+// the given elements. If seekIDs is not empty, the elements are preceded by a
+// SeekHead listing the first element with each of these IDs.
+func buildTestFile(header []byte, seekIDs []uint32, elements ...segmentElement) *builtFile {
+	f := &builtFile{
+		positions:   make([]int64, len(elements)),
+		headerSizes: make([]int, len(elements)),
+		sizes:       make([]int, len(elements)),
+	}
+
+	// The positions depend on the SeekHead, whose size depends on the
+	// positions it holds, so both are built until they no longer change
+	var seekHead, body bytes.Buffer
+	for done := false; !done; {
+		positions := make([]int64, len(elements))
+		body.Reset()
+		for i, element := range elements {
+			data := element.data
+			if element.dataAt != nil {
+				data = element.dataAt(f.positions)
+			}
+			start := body.Len()
+			positions[i] = int64(seekHead.Len() + start)
+			body.Write(encodeElementID(element.id))
+			if element.unknownSize {
+				body.Write([]byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+			} else {
+				body.Write(encodeVInt(uint64(len(data))))
+			}
+			f.headerSizes[i] = body.Len() - start
+			f.sizes[i] = len(data)
+			body.Write(data)
+		}
+
+		var entries bytes.Buffer
+		for _, id := range seekIDs {
+			for i, element := range elements {
+				if element.id == id {
+					var seek bytes.Buffer
+					putElement(&seek, IDSeekID, encodeElementID(id))
+					putUIntElement(&seek, IDSeekPos, uint64(positions[i]))
+					putElement(&entries, IDSeek, seek.Bytes())
+					break
+				}
+			}
+		}
+		size := seekHead.Len()
+		seekHead.Reset()
+		if len(seekIDs) > 0 {
+			putElement(&seekHead, IDSeekHead, entries.Bytes())
+		}
+		done = seekHead.Len() == size && slices.Equal(positions, f.positions)
+		f.positions = positions
+	}
+
+	var segment, file bytes.Buffer
+	segment.Write(seekHead.Bytes())
+	segment.Write(body.Bytes())
+	file.Write(header)
+	putElement(&file, IDSegment, segment.Bytes())
+	f.data = file.Bytes()
+	f.segmentData = int64(file.Len() - segment.Len())
+	return f
+}
+
+// mockInfoElement returns a SegmentInfo element with a TimestampScale of 1 ms,
+// followed by the given children.
+func mockInfoElement(children []byte) segmentElement {
+	var info bytes.Buffer
+	putUIntElement(&info, IDTimestampScale, 1000000)
+	info.Write(children)
+	return segmentElement{id: IDSegmentInfo, data: info.Bytes()}
+}
+
+// mockTracksElement returns a Tracks element with a single track number 1 of
+// the given type and codec.
+func mockTracksElement(t testing.TB, trackType TrackType, codecID string) segmentElement {
+	t.Helper()
+	trackEntry, err := createMockTrackEntry(1, trackType, codecID, "Track", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	var tracks bytes.Buffer
+	putElement(&tracks, IDTrackEntry, trackEntry)
+	return segmentElement{id: IDTracks, data: tracks.Bytes()}
+}
+
+// mockFrameCluster returns a Cluster element at timestamp 0 holding a keyframe
+// of track 1 with the data "frame", followed by a Void element of padding
+// bytes if padding is positive.
+func mockFrameCluster(padding int) segmentElement {
+	var cluster bytes.Buffer
+	putUIntElement(&cluster, IDTimestamp, 0)
+	putElement(&cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, 'f', 'r', 'a', 'm', 'e'})
+	if padding > 0 {
+		putElement(&cluster, IDVoid, make([]byte, padding))
+	}
+	return segmentElement{id: IDCluster, data: cluster.Bytes()}
+}
+
 // TestNewDemuxer tests the NewDemuxer function with various inputs.
 func TestNewDemuxer(t *testing.T) {
 	t.Run("Valid Matroska file", func(t *testing.T) {
@@ -394,6 +585,74 @@ func TestNewDemuxer(t *testing.T) {
 	})
 }
 
+// TestStreamingDemuxer_ClustersBeforeTags tests that a streaming demuxer reads
+// the packets of every cluster, including those preceding a Tags element, and
+// parses the tags as it reaches them.
+func TestStreamingDemuxer_ClustersBeforeTags(t *testing.T) {
+	var simpleTag, tag, tags bytes.Buffer
+	putStringElement(&simpleTag, IDTagName, "TITLE")
+	putStringElement(&simpleTag, IDTagString, "Live")
+	putElement(&tag, IDSimpleTag, simpleTag.Bytes())
+	putElement(&tags, IDTag, tag.Bytes())
+
+	// Each cluster holds a keyframe with the cluster's index as data
+	cluster := func(timestamp uint64, data byte) []byte {
+		var cluster bytes.Buffer
+		putUIntElement(&cluster, IDTimestamp, timestamp)
+		putElement(&cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, data})
+		return cluster.Bytes()
+	}
+
+	tests := []struct {
+		name        string
+		unknownSize bool
+	}{
+		{"Known size clusters", false},
+		{"Unknown size clusters", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements := []segmentElement{mockInfoElement(nil), mockTracksElement(t, TypeVideo, "V_VP9")}
+			for i, timestamp := range []uint64{0, 100} {
+				elements = append(elements, segmentElement{id: IDCluster, data: cluster(timestamp, byte(i)), unknownSize: tt.unknownSize})
+				if i == 0 {
+					elements = append(elements, segmentElement{id: IDTags, data: tags.Bytes()})
+				}
+			}
+			file := buildTestFile(createMinimalEBMLHeader(), nil, elements...)
+
+			demuxer, err := NewStreamingDemuxer(&nonSeekableReader{r: bytes.NewReader(file.data)})
+			if err != nil {
+				t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+			}
+			if numTracks, _ := demuxer.GetNumTracks(); numTracks != 1 {
+				t.Errorf("Expected 1 track, got %d", numTracks)
+			}
+			if len(demuxer.GetTags()) != 0 {
+				t.Error("Expected no tags before the clusters are read")
+			}
+
+			for i, start := range []uint64{0, 100000000} {
+				packet, err := demuxer.ReadPacket()
+				if err != nil {
+					t.Fatalf("ReadPacket() %d failed: %v", i, err)
+				}
+				if !bytes.Equal(packet.Data, []byte{byte(i)}) || packet.StartTime != start {
+					t.Errorf("Packet %d: expected data %02x at %d, got %x at %d", i, i, start, packet.Data, packet.StartTime)
+				}
+			}
+			if _, err = demuxer.ReadPacket(); err != io.EOF {
+				t.Errorf("Expected io.EOF, got %v", err)
+			}
+
+			readTags := demuxer.GetTags()
+			if len(readTags) != 1 || len(readTags[0].SimpleTags) != 1 || readTags[0].SimpleTags[0].Value != "Live" {
+				t.Errorf("Expected the TITLE tag after the clusters, got %+v", readTags)
+			}
+		})
+	}
+}
+
 // TestNewStreamingDemuxer_EdgeCases tests edge cases for NewStreamingDemuxer.
 func TestNewStreamingDemuxer_EdgeCases(t *testing.T) {
 	t.Run("Empty stream", func(t *testing.T) {
@@ -428,7 +687,9 @@ func TestDemuxer_Close(t *testing.T) {
 			t.Fatalf("NewDemuxer() failed: %v", err)
 		}
 
-		demuxer.Close() // Close should not fail
+		if err = demuxer.Close(); err != nil {
+			t.Errorf("Close() failed for a source that is not a Closer: %v", err)
+		}
 	})
 
 	t.Run("Close multiple times", func(t *testing.T) {
@@ -444,9 +705,66 @@ func TestDemuxer_Close(t *testing.T) {
 		}
 
 		// Close multiple times should not cause errors
-		demuxer.Close()
-		demuxer.Close() // Should be safe to call multiple times
+		_ = demuxer.Close()
+		if err = demuxer.Close(); err != nil {
+			t.Errorf("Second Close() failed: %v", err)
+		}
+	})
+
+	t.Run("Close closes the source", func(t *testing.T) {
+		mockFile, err := createMockMatroskaFile()
+		if err != nil {
+			t.Fatalf("Failed to create mock matroska file: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "mock.mkv")
+		if err = os.WriteFile(path, mockFile, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+
+		var demuxer io.Closer
+		demuxer, err = NewDemuxer(file)
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if err = demuxer.Close(); err != nil {
+			t.Fatalf("Close() failed: %v", err)
+		}
+		if _, err = file.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("Expected the file to be closed, got %v", err)
+		}
 	})
+
+	t.Run("Close closes a streaming source", func(t *testing.T) {
+		mockFile, err := createMockMatroskaFile()
+		if err != nil {
+			t.Fatalf("Failed to create mock matroska file: %v", err)
+		}
+		source := &closeRecorder{Reader: bytes.NewReader(mockFile), err: errors.New("close failed")}
+		demuxer, err := NewStreamingDemuxer(source)
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		if err = demuxer.Close(); err != source.err || !source.closed {
+			t.Errorf("Close() = %v, want the error of the source's Close", err)
+		}
+	})
+}
+
+// closeRecorder is an io.ReadCloser that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+	err    error
+}
+
+// Close records the call and returns the configured error.
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return c.err
 }
 
 // TestDemuxer_GetTrackInfo tests the GetTrackInfo method.
@@ -487,6 +805,90 @@ func TestDemuxer_GetTrackInfo(t *testing.T) {
 	})
 }
 
+// TestDemuxer_GetTrackInfoCopy tests that the copy returned by
+// GetTrackInfoCopy can be modified without affecting the demuxer.
+func TestDemuxer_GetTrackInfoCopy(t *testing.T) {
+	demuxer := createMuxedFile(t, []*TrackInfo{
+		{Type: TypeAudio, CodecID: "A_OPUS", CodecPrivate: []byte("OpusHead"), Language: "eng"},
+	}, nil)
+
+	trackCopy, err := demuxer.GetTrackInfoCopy(0)
+	if err != nil {
+		t.Fatalf("GetTrackInfoCopy(0) failed: %v", err)
+	}
+	trackCopy.CodecPrivate[0] = 'X'
+	trackCopy.Language = "fre"
+
+	original, err := demuxer.GetTrackInfo(0)
+	if err != nil {
+		t.Fatalf("GetTrackInfo(0) failed: %v", err)
+	}
+	if original == trackCopy {
+		t.Fatal("Expected GetTrackInfoCopy to return a new TrackInfo")
+	}
+	if string(original.CodecPrivate) != "OpusHead" || original.Language != "eng" {
+		t.Errorf("Expected the original to be unchanged, got CodecPrivate %q and Language %q",
+			original.CodecPrivate, original.Language)
+	}
+
+	if _, err = demuxer.GetTrackInfoCopy(999); err == nil {
+		t.Error("Expected error for invalid track number, but got nil")
+	}
+
+	t.Run("Content encodings", func(t *testing.T) {
+		track := &TrackInfo{
+			ContentEncodings: []ContentEncoding{{
+				Type:        ContentEncodingTypeCompression,
+				Compression: &ContentCompression{Algo: CompPrepend, Settings: []byte{0x00, 0x01}},
+			}},
+		}
+		clone := track.Clone()
+		clone.ContentEncodings[0].Compression.Settings[0] = 0xFF
+		clone.ContentEncodings[0].Compression.Algo = CompZlib
+		if compression := track.ContentEncodings[0].Compression; compression.Settings[0] != 0x00 || compression.Algo != CompPrepend {
+			t.Errorf("Expected the original compression to be unchanged, got %+v", compression)
+		}
+	})
+}
+
+// TestDemuxer_GetTrackByNumber tests the GetTrackByNumber method.
+func TestDemuxer_GetTrackByNumber(t *testing.T) {
+	mockFile, err := createMockMatroskaFile()
+	if err != nil {
+		t.Fatalf("Failed to create mock matroska file: %v", err)
+	}
+
+	reader := bytes.NewReader(mockFile)
+	demuxer, err := NewDemuxer(reader)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	defer demuxer.Close()
+
+	t.Run("Existing track number", func(t *testing.T) {
+		trackInfo, errGetTrack := demuxer.GetTrackByNumber(1)
+		if errGetTrack != nil {
+			t.Fatalf("GetTrackByNumber(1) failed: %v", errGetTrack)
+		}
+		if trackInfo.Number != 1 {
+			t.Errorf("Expected track number 1, got %d", trackInfo.Number)
+		}
+		if trackInfo.Type != TypeVideo {
+			t.Errorf("Expected track type %d, got %d", TypeVideo, trackInfo.Type)
+		}
+	})
+
+	t.Run("Unknown track number", func(t *testing.T) {
+		trackInfo, errGetTrack := demuxer.GetTrackByNumber(999)
+		if errGetTrack == nil {
+			t.Errorf("Expected error for unknown track number, but got nil")
+		}
+		if trackInfo != nil {
+			t.Errorf("Expected nil track info, got %+v", trackInfo)
+		}
+	})
+}
+
 // TestDemuxer_GetFileInfo tests the GetFileInfo method.
 func TestDemuxer_GetFileInfo(t *testing.T) {
 	t.Run("Valid file info", func(t *testing.T) {
@@ -1404,4 +1806,840 @@ func TestDemuxer_ReadPacketMask(t *testing.T) {
 		// packet could be nil if no packets match the mask
 		_ = packet
 	})
+
+	t.Run("Mask skips other tracks", func(t *testing.T) {
+		tracks := []*TrackInfo{
+			{Type: TypeAudio, CodecID: "A_OPUS"},
+			{Type: TypeAudio, CodecID: "A_OPUS"},
+		}
+		packets := []*Packet{
+			{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+			{Track: 2, StartTime: 0, Data: []byte{0x02}, Flags: KF},
+			{Track: 1, StartTime: 20000000, Data: []byte{0x03}, Flags: KF},
+			{Track: 2, StartTime: 20000000, Data: []byte{0x04}, Flags: KF},
+		}
+		demuxer := createMuxedFile(t, tracks, packets)
+
+		packet, err := demuxer.ReadPacketMask(0x1)
+		if err != nil || packet.Track != 2 || packet.Data[0] != 0x02 {
+			t.Fatalf("ReadPacketMask(0x1) = %+v, %v, want the first packet of track 2", packet, err)
+		}
+		// The mask only applies to a single call
+		packet, err = demuxer.ReadPacket()
+		if err != nil || packet.Track != 1 || packet.Data[0] != 0x03 {
+			t.Fatalf("ReadPacket() = %+v, %v, want the second packet of track 1", packet, err)
+		}
+	})
+}
+
+// TestDemuxer_SetAnnexBConversion tests the automatic AVCC to Annex B conversion.
+func TestDemuxer_SetAnnexBConversion(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeVideo, "V_MPEG4/ISO/AVC", "Video", "und")
+	codecPrivate := []byte{
+		0x01, 0x64, 0x00, 0x1F, 0xFD, // length size 2
+		0xE1, 0x00, 0x02, 0x67, 0x64, // 1 SPS
+		0x01, 0x00, 0x02, 0x68, 0xEB, // 1 PPS
+	}
+	trackEntry = append(trackEntry, 0x63, 0xA2)
+	trackEntry = append(trackEntry, vintEncode(uint64(len(codecPrivate)))...)
+	trackEntry = append(trackEntry, codecPrivate...)
+
+	keyframe := []byte{0x81, 0x00, 0x00, 0x80, 0x00, 0x02, 0x65, 0x88}
+	interframe := []byte{0x81, 0x00, 0x01, 0x00, 0x00, 0x01, 0x41}
+	file := createMockMatroskaFileWithTrack(trackEntry, keyframe, interframe)
+
+	t.Run("Conversion enabled", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		demuxer.SetAnnexBConversion(0, true)
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		want := []byte{
+			0x00, 0x00, 0x00, 0x01, 0x67, 0x64,
+			0x00, 0x00, 0x00, 0x01, 0x68, 0xEB,
+			0x00, 0x00, 0x00, 0x01, 0x65, 0x88,
+		}
+		if !bytes.Equal(packet.Data, want) {
+			t.Errorf("Keyframe data = %x, want %x", packet.Data, want)
+		}
+
+		packet, err = demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		want = []byte{0x00, 0x00, 0x00, 0x01, 0x41}
+		if !bytes.Equal(packet.Data, want) {
+			t.Errorf("Frame data = %x, want %x", packet.Data, want)
+		}
+	})
+
+	t.Run("Conversion disabled by default", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{0x00, 0x02, 0x65, 0x88}) {
+			t.Errorf("Expected unconverted data, got %x", packet.Data)
+		}
+	})
+
+	t.Run("Conversion disabled again", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		demuxer.SetAnnexBConversion(0, true)
+		demuxer.SetAnnexBConversion(0, false)
+		demuxer.SetAnnexBConversion(5, true) // invalid index is ignored
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{0x00, 0x02, 0x65, 0x88}) {
+			t.Errorf("Expected unconverted data, got %x", packet.Data)
+		}
+	})
+}
+
+// TestDemuxer_HeaderStripping tests that header-stripped frames are restored by ReadPacket.
+func TestDemuxer_HeaderStripping(t *testing.T) {
+	compression := new(bytes.Buffer)
+	writeUIntElement(compression, IDContentCompAlgo, CompPrepend, 1)
+	writeBinaryElement(compression, IDContentCompSettings, []byte{0xFF, 0xFB})
+	encoding := new(bytes.Buffer)
+	writeBinaryElement(encoding, IDContentCompression, compression.Bytes())
+	encodings := new(bytes.Buffer)
+	writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_MPEG/L3", "Audio", "und")
+	buf := bytes.NewBuffer(trackEntry)
+	writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+
+	block := []byte{0x81, 0x00, 0x00, 0x80, 0x90, 0x64}
+	file := createMockMatroskaFileWithTrack(buf.Bytes(), block)
+
+	demuxer, err := NewDemuxer(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	defer demuxer.Close()
+
+	packet, err := demuxer.ReadPacket()
+	if err != nil {
+		t.Fatalf("ReadPacket() failed: %v", err)
+	}
+	want := []byte{0xFF, 0xFB, 0x90, 0x64}
+	if !bytes.Equal(packet.Data, want) {
+		t.Errorf("Packet data = %x, want %x", packet.Data, want)
+	}
+}
+
+// TestDemuxer_ZlibCompression tests that zlib-compressed frames are inflated by ReadPacket.
+func TestDemuxer_ZlibCompression(t *testing.T) {
+	frame := []byte("a frame that has been deflated by the muxer")
+	deflated := new(bytes.Buffer)
+	zw := zlib.NewWriter(deflated)
+	_, _ = zw.Write(frame)
+	_ = zw.Close()
+
+	compression := new(bytes.Buffer)
+	writeUIntElement(compression, IDContentCompAlgo, CompZlib, 1)
+	encoding := new(bytes.Buffer)
+	writeBinaryElement(encoding, IDContentCompression, compression.Bytes())
+	encodings := new(bytes.Buffer)
+	writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+
+	trackEntry, _ := createMockTrackEntry(1, TypeSubtitle, "S_TEXT/UTF8", "Subtitle", "und")
+	buf := bytes.NewBuffer(trackEntry)
+	writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+
+	block := append([]byte{0x81, 0x00, 0x00, 0x80}, deflated.Bytes()...)
+	corrupt := []byte{0x81, 0x00, 0x01, 0x80, 0x00, 0x01, 0x02}
+	file := createMockMatroskaFileWithTrack(buf.Bytes(), block, corrupt, block)
+
+	t.Run("Frames are inflated", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		defer demuxer.Close()
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, frame) {
+			t.Errorf("Packet data = %q, want %q", packet.Data, frame)
+		}
+
+		if _, err = demuxer.ReadPacket(); err == nil {
+			t.Fatal("Expected an error for the corrupt frame")
+		}
+
+		packet, err = demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() after a corrupt frame failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, frame) {
+			t.Errorf("Packet data = %q, want %q", packet.Data, frame)
+		}
+	})
+
+	t.Run("Decompression disabled", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		defer demuxer.Close()
+		demuxer.SetDecompression(false)
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, deflated.Bytes()) {
+			t.Errorf("Packet data = %x, want %x", packet.Data, deflated.Bytes())
+		}
+	})
+}
+
+// TestDemuxer_SetDecryptionKey tests that frames of encrypted tracks are decrypted by ReadPacket.
+func TestDemuxer_SetDecryptionKey(t *testing.T) {
+	aesSettings := new(bytes.Buffer)
+	writeUIntElement(aesSettings, IDAESSettingsCipherMode, AESCipherModeCTR, 1)
+	encryption := new(bytes.Buffer)
+	writeUIntElement(encryption, IDContentEncAlgo, EncAlgoAES, 1)
+	writeBinaryElement(encryption, IDContentEncKeyID, []byte{0xAB, 0xCD})
+	writeBinaryElement(encryption, IDContentEncAESSettings, aesSettings.Bytes())
+	encoding := new(bytes.Buffer)
+	writeUIntElement(encoding, IDContentEncodingType, ContentEncodingTypeEncryption, 1)
+	writeBinaryElement(encoding, IDContentEncryption, encryption.Bytes())
+	encodings := new(bytes.Buffer)
+	writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+
+	trackEntry, _ := createMockTrackEntry(1, TypeVideo, "V_VP9", "Video", "und")
+	buf := bytes.NewBuffer(trackEntry)
+	writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+
+	encrypted := []byte{
+		0x81, 0x00, 0x00, 0x80,
+		0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0x4B, 0xD6, 0xA9, 0x54, 0x04,
+	}
+	clear := []byte{0x81, 0x00, 0x01, 0x00, 0x00, 0xAA}
+	file := createMockMatroskaFileWithTrack(buf.Bytes(), encrypted, clear)
+
+	t.Run("Key set", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		defer demuxer.Close()
+		demuxer.SetDecryptionKey(0, []byte("0123456789abcdef"))
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte("frame")) {
+			t.Errorf("Packet data = %q, want %q", packet.Data, "frame")
+		}
+
+		packet, err = demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{0xAA}) {
+			t.Errorf("Packet data = %x, want aa", packet.Data)
+		}
+	})
+
+	t.Run("No key", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		defer demuxer.Close()
+
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, encrypted[4:]) {
+			t.Errorf("Packet data = %x, want %x", packet.Data, encrypted[4:])
+		}
+	})
+}
+
+// TestDemuxer_VoidElements tests that Void elements between top-level and cluster elements are skipped.
+func TestDemuxer_VoidElements(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_AC3", "Audio", "und")
+	void := []byte{0xEC, 0x85, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	segment := new(bytes.Buffer)
+	segInfo := []byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}
+	writeBinaryElement(segment, IDSegmentInfo, segInfo)
+	tracks := new(bytes.Buffer)
+	writeBinaryElement(tracks, IDTrackEntry, trackEntry)
+	writeBinaryElement(segment, IDTracks, tracks.Bytes())
+	segment.Write(void)
+
+	cluster := new(bytes.Buffer)
+	cluster.Write([]byte{0xE7, 0x81, 0x00})
+	cluster.Write(void)
+	writeBinaryElement(cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, 0xAA})
+	cluster.Write(void)
+	writeBinaryElement(cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x01, 0x80, 0xBB})
+	writeBinaryElement(segment, IDCluster, cluster.Bytes())
+
+	file := new(bytes.Buffer)
+	writeBinaryElement(file, IDEBMLHeader, []byte{0x42, 0x82, 0x88, 'm', 'a', 't', 'r', 'o', 's', 'k', 'a'})
+	writeBinaryElement(file, IDSegment, segment.Bytes())
+
+	demuxer, err := NewDemuxer(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	if numTracks, _ := demuxer.GetNumTracks(); numTracks != 1 {
+		t.Errorf("Expected 1 track, got %d", numTracks)
+	}
+
+	for _, want := range []byte{0xAA, 0xBB} {
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{want}) {
+			t.Errorf("Packet data = %x, want %02x", packet.Data, want)
+		}
+	}
+	if _, err = demuxer.ReadPacket(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	streaming, err := NewStreamingDemuxer(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+	}
+	if numTracks, _ := streaming.GetNumTracks(); numTracks != 1 {
+		t.Errorf("Expected 1 track in streaming mode, got %d", numTracks)
+	}
+}
+
+// TestDemuxer_Packets tests reading packets over a channel.
+func TestDemuxer_Packets(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeAudio, CodecID: "A_OPUS"}}
+	var packets []*Packet
+	for i := uint64(0); i < 10; i++ {
+		packets = append(packets, &Packet{Track: 1, StartTime: i * 20000000, Data: []byte{byte(i)}, Flags: KF})
+	}
+
+	t.Run("Reads until EOF", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		count := 0
+		for result := range demuxer.Packets(context.Background()) {
+			if result.Err != nil {
+				t.Fatalf("Unexpected error: %v", result.Err)
+			}
+			if result.Packet.Data[0] != byte(count) {
+				t.Errorf("Packet %d has data %x", count, result.Packet.Data)
+			}
+			count++
+		}
+		if count != len(packets) {
+			t.Errorf("Received %d packets, want %d", count, len(packets))
+		}
+	})
+
+	t.Run("Cancel mid-stream", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		ctx, cancel := context.WithCancel(context.Background())
+		results := demuxer.Packets(ctx)
+
+		for i := 0; i < 3; i++ {
+			if result := <-results; result.Err != nil || result.Packet == nil {
+				t.Fatalf("Unexpected result %d: %+v", i, result)
+			}
+		}
+		cancel()
+
+		// The channel must be closed after at most one more packet
+		timeout := time.After(time.Second)
+		received := 0
+		for {
+			select {
+			case _, ok := <-results:
+				if !ok {
+					if received > 1 {
+						t.Errorf("Received %d packets after cancelling", received)
+					}
+					return
+				}
+				received++
+			case <-timeout:
+				t.Fatal("Channel was not closed after cancelling")
+			}
+		}
+	})
+}
+
+// TestDemuxer_ReadPacketCtx tests cancelling packet reads.
+func TestDemuxer_ReadPacketCtx(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeAudio, CodecID: "A_OPUS"}}
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		{Track: 1, StartTime: 20000000, Data: []byte{0x02}, Flags: KF},
+	}
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		packet, err := demuxer.ReadPacketCtx(context.Background())
+		if err != nil || packet.Data[0] != 0x01 {
+			t.Fatalf("ReadPacketCtx() = %+v, %v, want the first packet", packet, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err = demuxer.ReadPacketCtx(ctx); err != context.Canceled {
+			t.Fatalf("ReadPacketCtx() error = %v, want context.Canceled", err)
+		}
+
+		// Nothing was consumed by the cancelled read
+		packet, err = demuxer.ReadPacket()
+		if err != nil || packet.Data[0] != 0x02 {
+			t.Errorf("ReadPacket() = %+v, %v, want the second packet", packet, err)
+		}
+	})
+
+	t.Run("Expired deadline with error recovery", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		demuxer.parser.errorRecovery = true
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		if _, err := demuxer.ReadPacketCtx(ctx); err != context.DeadlineExceeded {
+			t.Errorf("ReadPacketCtx() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
+
+// TestDemuxer_PrimaryTracks tests the accessors for the primary tracks.
+func TestDemuxer_PrimaryTracks(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP8", Enabled: true},
+		{Type: TypeAudio, CodecID: "A_OPUS", Enabled: false, Default: true},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8", Enabled: true, Language: "eng"},
+		{Type: TypeVideo, CodecID: "V_VP9", Enabled: true, Default: true},
+		{Type: TypeAudio, CodecID: "A_VORBIS", Enabled: true},
+		{Type: TypeAudio, CodecID: "A_FLAC", Enabled: true},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8", Enabled: false, Language: "fre"},
+	}
+	// The Muxer writes tracks as enabled and default unless told otherwise
+	setFlags := func(tracks []*TrackInfo) func(muxer *Muxer) {
+		return func(muxer *Muxer) {
+			for i, track := range tracks {
+				if err := muxer.SetTrackFlags(i+1, track.Enabled, track.Default); err != nil {
+					t.Fatalf("SetTrackFlags() failed: %v", err)
+				}
+			}
+		}
+	}
+	demuxer := createMuxedFile(t, tracks, []*Packet{{Track: 1, Data: []byte{0x01}, Flags: KF}}, setFlags(tracks))
+
+	if video := demuxer.GetVideoTrack(); video == nil || video.CodecID != "V_VP9" {
+		t.Errorf("GetVideoTrack() = %+v, want the default V_VP9 track", video)
+	}
+	// The default audio track is disabled, so the first enabled one is chosen
+	if audio := demuxer.GetAudioTrack(); audio == nil || audio.CodecID != "A_VORBIS" {
+		t.Errorf("GetAudioTrack() = %+v, want the first enabled A_VORBIS track", audio)
+	}
+	subtitles := demuxer.GetSubtitleTracks()
+	if len(subtitles) != 2 || subtitles[0].Language != "eng" || subtitles[1].Language != "fre" {
+		t.Errorf("GetSubtitleTracks() = %+v, want the eng and fre tracks", subtitles)
+	}
+
+	t.Run("Missing tracks", func(t *testing.T) {
+		tracks := []*TrackInfo{{Type: TypeAudio, CodecID: "A_OPUS", Enabled: false}}
+		demuxer := createMuxedFile(t, tracks, []*Packet{{Track: 1, Data: []byte{0x01}, Flags: KF}}, setFlags(tracks))
+		if video := demuxer.GetVideoTrack(); video != nil {
+			t.Errorf("GetVideoTrack() = %+v, want nil", video)
+		}
+		if audio := demuxer.GetAudioTrack(); audio != nil {
+			t.Errorf("GetAudioTrack() = %+v, want nil for a disabled track", audio)
+		}
+		if subtitles := demuxer.GetSubtitleTracks(); subtitles != nil {
+			t.Errorf("GetSubtitleTracks() = %+v, want nil", subtitles)
+		}
+	})
+}
+
+// TestTrackType_String tests the names of the track types.
+func TestTrackType_String(t *testing.T) {
+	testCases := []struct {
+		trackType TrackType
+		expected  string
+	}{
+		{TypeVideo, "video"},
+		{TypeAudio, "audio"},
+		{TypeComplex, "complex"},
+		{TypeLogo, "logo"},
+		{TypeSubtitle, "subtitle"},
+		{TypeButton, "button"},
+		{TypeControl, "control"},
+		{TypeMetadata, "metadata"},
+		{TrackType(0), "unknown (0)"},
+		{TrackType(99), "unknown (99)"},
+	}
+	for _, tc := range testCases {
+		if got := tc.trackType.String(); got != tc.expected {
+			t.Errorf("TrackType(%d).String() = %q, want %q", uint8(tc.trackType), got, tc.expected)
+		}
+	}
+}
+
+func TestDemuxer_Stats(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9"},
+		{Type: TypeAudio, CodecID: "A_OPUS"},
+	}
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01, 0x02, 0x03}, Flags: KF},
+		{Track: 2, StartTime: 0, Data: []byte{0x04}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x05, 0x06}},
+		{Track: 2, StartTime: 20000000, Data: []byte{0x07, 0x08}, Flags: KF},
+		{Track: 1, StartTime: 80000000, Data: []byte{0x09}, Flags: KF},
+	}
+	demuxer := createMuxedFile(t, tracks, packets)
+
+	if stats := demuxer.Stats(); len(stats) != 0 {
+		t.Errorf("Expected no statistics before reading, got %d tracks", len(stats))
+	}
+
+	// Statistics are incremental
+	if _, err := demuxer.ReadPacket(); err != nil {
+		t.Fatalf("ReadPacket() failed: %v", err)
+	}
+	stats := demuxer.Stats()
+	if len(stats) != 1 || stats[1] == nil || stats[1].PacketCount != 1 || stats[1].TotalBytes != 3 {
+		t.Errorf("Unexpected statistics after one packet: %+v", stats)
+	}
+	stats[1].PacketCount = 100
+
+	for {
+		if _, err := demuxer.ReadPacket(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+	}
+
+	expected := map[uint64]TrackStats{
+		1: {PacketCount: 3, TotalBytes: 6, FirstTimecode: 0, LastTimecode: 80000000, KeyframeCount: 2},
+		2: {PacketCount: 2, TotalBytes: 3, FirstTimecode: 0, LastTimecode: 20000000, KeyframeCount: 2},
+	}
+	stats = demuxer.Stats()
+	if len(stats) != len(expected) {
+		t.Fatalf("Expected statistics for %d tracks, got %d", len(expected), len(stats))
+	}
+	for track, want := range expected {
+		if got := stats[track]; got == nil || *got != want {
+			t.Errorf("Track %d: expected %+v, got %+v", track, want, got)
+		}
+	}
+}
+
+func TestNewDemuxerAt(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9"},
+		{Type: TypeAudio, CodecID: "A_OPUS"},
+	}
+	var packets []*Packet
+	expected := make([][]byte, len(tracks))
+	for i := 0; i < 200; i++ {
+		for track := range tracks {
+			data := []byte{byte(track), byte(i)}
+			packets = append(packets, &Packet{Track: uint64(track + 1), StartTime: uint64(i) * 20000000, Data: data, Flags: KF})
+			expected[track] = append(expected[track], data...)
+		}
+	}
+	file := createMuxedFile(t, tracks, packets).reader.(*os.File)
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+
+	// Each goroutine reads the shared file through its own demuxer
+	outputs := make([]bytes.Buffer, len(tracks))
+	errs := make([]error, len(tracks))
+	var wg sync.WaitGroup
+	for i := range tracks {
+		wg.Add(1)
+		go func(track int) {
+			defer wg.Done()
+			demuxer, errNewDemuxerAt := NewDemuxerAt(file, info.Size())
+			if errNewDemuxerAt != nil {
+				errs[track] = errNewDemuxerAt
+				return
+			}
+			errs[track] = demuxer.ExtractTrack(&outputs[track], uint(track), ExtractOptions{})
+		}(i)
+	}
+	wg.Wait()
+
+	for track := range tracks {
+		if errs[track] != nil {
+			t.Fatalf("Track %d: extraction failed: %v", track, errs[track])
+		}
+		if !bytes.Equal(outputs[track].Bytes(), expected[track]) {
+			t.Errorf("Track %d: extracted data does not match", track)
+		}
+	}
+
+	t.Run("Close keeps the source open", func(t *testing.T) {
+		demuxer, err := NewDemuxerAt(file, info.Size())
+		if err != nil {
+			t.Fatalf("NewDemuxerAt() failed: %v", err)
+		}
+		if err = demuxer.Close(); err != nil {
+			t.Errorf("Close() failed: %v", err)
+		}
+		if _, err = file.ReadAt(make([]byte, 1), 0); err != nil {
+			t.Errorf("Expected the source to stay open, got %v", err)
+		}
+	})
+
+	t.Run("Invalid data", func(t *testing.T) {
+		data := []byte("not a matroska file")
+		if _, err := NewMatroskaParserAt(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Error("Expected an error for invalid data")
+		}
+	})
+}
+
+func TestDemuxer_GetSilentTracks(t *testing.T) {
+	video, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	audio, err := createMockTrackEntry(2, TypeAudio, "A_TEST", "Audio", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+
+	var tracks, silent, first, second bytes.Buffer
+	putElement(&tracks, IDTrackEntry, video)
+	putElement(&tracks, IDTrackEntry, audio)
+	// The audio track is silent in the first cluster
+	putUIntElement(&silent, IDSilentTrackNum, 2)
+	putUIntElement(&first, IDTimestamp, 0)
+	putElement(&first, IDSilentTracks, silent.Bytes())
+	putElement(&first, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, 'v'})
+	putUIntElement(&second, IDTimestamp, 40)
+	putElement(&second, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, 'v'})
+	putElement(&second, IDSimpleBlock, []byte{0x82, 0x00, 0x00, 0x80, 'a'})
+	f := buildTestFile(createMinimalEBMLHeader(), nil,
+		mockInfoElement(nil),
+		segmentElement{id: IDTracks, data: tracks.Bytes()},
+		segmentElement{id: IDCluster, data: first.Bytes()},
+		segmentElement{id: IDCluster, data: second.Bytes()},
+	)
+
+	demuxer, err := NewDemuxer(bytes.NewReader(f.data))
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	expected := [][]uint64{{2}, nil, nil}
+	for i, want := range expected {
+		if _, err = demuxer.ReadPacket(); err != nil {
+			t.Fatalf("ReadPacket() %d failed: %v", i, err)
+		}
+		if got := demuxer.GetSilentTracks(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Packet %d: expected silent tracks %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestTrackInfo_FrameRate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		track    TrackInfo
+		expected float64
+	}{
+		{"NTSC film", TrackInfo{Type: TypeVideo, DefaultDuration: 41708333}, 24000.0 / 1001.0},
+		{"PAL", TrackInfo{Type: TypeVideo, DefaultDuration: 40000000}, 25},
+		{"Variable frame rate", TrackInfo{Type: TypeVideo}, 0},
+		{"Audio", TrackInfo{Type: TypeAudio, DefaultDuration: 20000000}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.track.FrameRate(); math.Abs(got-tc.expected) > 1e-4 {
+				t.Errorf("Expected frame rate %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDemuxer_SetPCMNormalize(t *testing.T) {
+	newTrack := func(codecID string, bitDepth uint8) *TrackInfo {
+		track := &TrackInfo{Type: TypeAudio, CodecID: codecID}
+		track.Audio.Channels = 2
+		track.Audio.SamplingFreq = 48000
+		track.Audio.BitDepth = bitDepth
+		return track
+	}
+	samples := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+
+	testCases := []struct {
+		name     string
+		track    *TrackInfo
+		little   bool
+		expected []byte
+	}{
+		{"16-bit big to little", newTrack("A_PCM/INT/BIG", 16), true, []byte{0x02, 0x01, 0x04, 0x03, 0x06, 0x05, 0x07}},
+		{"24-bit big to little", newTrack("A_PCM/INT/BIG", 24), true, []byte{0x03, 0x02, 0x01, 0x06, 0x05, 0x04, 0x07}},
+		{"32-bit little to big", newTrack("A_PCM/INT/LIT", 32), false, []byte{0x04, 0x03, 0x02, 0x01, 0x05, 0x06, 0x07}},
+		{"Already little-endian", newTrack("A_PCM/INT/LIT", 16), true, samples},
+		{"Float", newTrack("A_PCM/FLOAT/IEEE", 32), true, samples},
+		{"8-bit", newTrack("A_PCM/INT/BIG", 8), true, samples},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			demuxer := createMuxedFile(t, []*TrackInfo{tc.track}, []*Packet{
+				{Track: 1, StartTime: 0, Data: append([]byte(nil), samples...), Flags: KF},
+			})
+			demuxer.SetPCMNormalize(0, tc.little)
+			packet, err := demuxer.ReadPacket()
+			if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+			if !bytes.Equal(packet.Data, tc.expected) {
+				t.Errorf("Expected samples %x, got %x", tc.expected, packet.Data)
+			}
+		})
+	}
+
+	t.Run("Back to the native byte order", func(t *testing.T) {
+		demuxer := createMuxedFile(t, []*TrackInfo{newTrack("A_PCM/INT/BIG", 16)}, []*Packet{
+			{Track: 1, StartTime: 0, Data: append([]byte(nil), samples...), Flags: KF},
+		})
+		demuxer.SetPCMNormalize(0, true)
+		demuxer.SetPCMNormalize(0, false)
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, samples) {
+			t.Errorf("Expected unchanged samples %x, got %x", samples, packet.Data)
+		}
+	})
+}
+
+func TestDemuxer_GetEBMLHeader(t *testing.T) {
+	t.Run("Matroska", func(t *testing.T) {
+		demuxer := createMuxedFile(t, []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9"}}, nil)
+		header := demuxer.GetEBMLHeader()
+		if header == nil {
+			t.Fatal("Expected an EBML header")
+		}
+		if header.DocType != "matroska" || header.DocTypeVersion != 4 || header.DocTypeReadVersion != 2 {
+			t.Errorf("Expected matroska version 4, readable by version 2, got %+v", header)
+		}
+	})
+
+	t.Run("WebM", func(t *testing.T) {
+		f := buildTestFile(createEBMLHeader("webm", 2, 0), nil,
+			mockInfoElement(nil),
+			mockTracksElement(t, TypeVideo, "V_VP9"),
+		)
+
+		demuxer, err := NewDemuxer(bytes.NewReader(f.data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if got := demuxer.GetEBMLHeader(); got.DocType != "webm" || got.DocTypeVersion != 2 {
+			t.Errorf("Expected webm version 2, got %+v", got)
+		}
+	})
+}
+
+// TestDemuxer_ConcurrentMetadata tests that the metadata accessors can be
+// called while another goroutine reads packets. Run with -race.
+func TestDemuxer_ConcurrentMetadata(t *testing.T) {
+	file, err := os.Open(testDemuxerFile)
+	if err != nil {
+		t.Skipf("Skipping demuxer test: could not open test file %s: %v", testDemuxerFile, err)
+	}
+	defer file.Close()
+
+	demuxer, err := NewDemuxer(file)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for {
+			packet, errReadPacket := demuxer.ReadPacket()
+			if errReadPacket != nil {
+				if errReadPacket != io.EOF {
+					t.Errorf("ReadPacket() failed: %v", errReadPacket)
+				}
+				return
+			}
+			packet.Release()
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				numTracks, _ := demuxer.GetNumTracks()
+				for track := uint(0); track < numTracks; track++ {
+					if _, errTrackInfo := demuxer.GetTrackInfo(track); errTrackInfo != nil {
+						t.Errorf("GetTrackInfo(%d) failed: %v", track, errTrackInfo)
+						return
+					}
+				}
+				if _, errFileInfo := demuxer.GetFileInfo(); errFileInfo != nil {
+					t.Errorf("GetFileInfo() failed: %v", errFileInfo)
+					return
+				}
+				demuxer.Stats()
+				demuxer.Segments()
+				demuxer.GetChapters()
+				demuxer.GetSilentTracks()
+				demuxer.GetLowestQTimecode()
+				demuxer.Position()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(demuxer.Stats()) == 0 {
+		t.Error("Expected statistics for the packets read")
+	}
 }
@@ -0,0 +1,165 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the export of the metadata of a file as JSON.
+package matroska
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// MetadataJSON returns the metadata of the file as an indented JSON document.
+//
+// The document holds the segment information, the tracks with their video or
+// audio settings, the chapters, the tags, and the names and sizes of the
+// attachments, but not their data. Its structure is described by the Metadata
+// type, which can be used to decode it. This is similar to the JSON output of
+// mkvmerge --identify, and is meant for tools that report on files.
+//
+// Example:
+//
+//	data, err := demuxer.MetadataJSON()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(string(data))
+//
+// Returns:
+//   - []byte: The JSON document.
+//   - error: An error if the document could not be encoded.
+func (d *Demuxer) MetadataJSON() ([]byte, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return json.MarshalIndent(d.parser.metadata(), "", "  ")
+}
+
+// metadata builds the Metadata document of the file.
+//
+// Returns:
+//   - *Metadata: The metadata of the file.
+func (mp *MatroskaParser) metadata() *Metadata {
+	metadata := &Metadata{
+		Tracks:      []MetadataTrack{},
+		Chapters:    metadataChapters(mp.chapters),
+		Tags:        []MetadataTag{},
+		Attachments: []MetadataAttachment{},
+	}
+
+	if info := mp.fileInfo; info != nil {
+		metadata.File = MetadataFile{
+			Title:         info.Title,
+			MuxingApp:     info.MuxingApp,
+			WritingApp:    info.WritingApp,
+			TimecodeScale: info.TimecodeScale,
+			DurationNs:    int64(info.DurationNanos()),
+		}
+		if info.UID != [16]byte{} {
+			metadata.File.SegmentUID = hex.EncodeToString(info.UID[:])
+		}
+		if info.DateUTCValid {
+			metadata.File.DateUTC = info.DateUTCTime().Format(time.RFC3339Nano)
+		}
+	}
+
+	for _, track := range mp.tracks {
+		entry := MetadataTrack{
+			Number:            track.Number,
+			UID:               track.UID,
+			Type:              track.Type.String(),
+			CodecID:           track.CodecID,
+			CodecName:         track.CodecName,
+			CodecPrivateSize:  len(track.CodecPrivate),
+			Name:              track.Name,
+			Language:          track.Language,
+			LanguageIETF:      track.LanguageIETF,
+			Enabled:           track.Enabled,
+			Default:           track.Default,
+			Forced:            track.Forced,
+			DefaultDurationNs: track.DefaultDuration,
+			CodecDelayNs:      track.CodecDelay,
+			SeekPreRollNs:     track.SeekPreRoll,
+		}
+		switch track.Type {
+		case TypeVideo:
+			entry.Video = &MetadataVideo{
+				PixelWidth:    track.Video.PixelWidth,
+				PixelHeight:   track.Video.PixelHeight,
+				DisplayWidth:  track.Video.DisplayWidth,
+				DisplayHeight: track.Video.DisplayHeight,
+				DisplayUnit:   track.Video.DisplayUnit,
+				Interlaced:    track.Video.Interlaced,
+				StereoMode:    track.Video.StereoMode,
+			}
+		case TypeAudio:
+			entry.Audio = &MetadataAudio{
+				SamplingFrequency:       track.Audio.SamplingFreq,
+				OutputSamplingFrequency: track.Audio.OutputSamplingFreq,
+				Channels:                track.Audio.Channels,
+				BitDepth:                track.Audio.BitDepth,
+			}
+		}
+		metadata.Tracks = append(metadata.Tracks, entry)
+	}
+
+	for _, tag := range mp.tags {
+		entry := MetadataTag{
+			Targets:    []MetadataTagTarget{},
+			SimpleTags: []MetadataSimpleTag{},
+		}
+		for _, target := range tag.Targets {
+			entry.Targets = append(entry.Targets, MetadataTagTarget{Type: target.Type, UID: target.UID})
+		}
+		for _, simpleTag := range tag.SimpleTags {
+			entry.SimpleTags = append(entry.SimpleTags, MetadataSimpleTag{
+				Name:     simpleTag.Name,
+				Value:    simpleTag.Value,
+				Language: simpleTag.Language,
+				Default:  simpleTag.Default,
+			})
+		}
+		metadata.Tags = append(metadata.Tags, entry)
+	}
+
+	for _, attachment := range mp.attachments {
+		metadata.Attachments = append(metadata.Attachments, MetadataAttachment{
+			UID:         attachment.UID,
+			Name:        attachment.Name,
+			MimeType:    attachment.MimeType,
+			Description: attachment.Description,
+			Size:        attachment.Length,
+		})
+	}
+
+	return metadata
+}
+
+// metadataChapters converts chapters, and their nested chapters, for a Metadata document.
+//
+// Parameters:
+//   - chapters: The chapters to convert.
+//
+// Returns:
+//   - []MetadataChapter: The converted chapters, never nil.
+func metadataChapters(chapters []*Chapter) []MetadataChapter {
+	entries := []MetadataChapter{}
+	for _, chapter := range chapters {
+		entry := MetadataChapter{
+			UID:      chapter.UID,
+			StartNs:  chapter.Start,
+			EndNs:    chapter.End,
+			Hidden:   chapter.Hidden,
+			Enabled:  chapter.Enabled,
+			Names:    []MetadataChapterName{},
+			Children: metadataChapters(chapter.Children),
+		}
+		for _, display := range chapter.Display {
+			entry.Names = append(entry.Names, MetadataChapterName{
+				Name:     display.String,
+				Language: display.Language,
+				Country:  display.Country,
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
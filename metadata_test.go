@@ -0,0 +1,120 @@
+package matroska
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestDemuxer_MetadataJSON(t *testing.T) {
+	video := &TrackInfo{
+		Number:          1,
+		UID:             1001,
+		Type:            TypeVideo,
+		CodecID:         "V_MPEG4/ISO/AVC",
+		CodecPrivate:    avcConfig,
+		Language:        "und",
+		Enabled:         true,
+		Default:         true,
+		DefaultDuration: 41708333,
+	}
+	video.Video.PixelWidth = 1920
+	video.Video.PixelHeight = 1080
+	video.Video.DisplayWidth = 1920
+	video.Video.DisplayHeight = 1080
+	audio := &TrackInfo{
+		Number:       2,
+		UID:          1002,
+		Type:         TypeAudio,
+		CodecID:      "A_OPUS",
+		Name:         "Commentary",
+		Language:     "eng",
+		LanguageIETF: "en-US",
+		Enabled:      true,
+		CodecDelay:   6500000,
+		SeekPreRoll:  80000000,
+	}
+	audio.Audio.SamplingFreq = 48000
+	audio.Audio.Channels = 2
+	subtitle := &TrackInfo{Number: 3, UID: 1003, Type: TypeSubtitle, CodecID: "S_TEXT/UTF8", Language: "fre", Enabled: true, Forced: true}
+
+	demuxer := &Demuxer{parser: &MatroskaParser{
+		fileInfo: &SegmentInfo{
+			UID:           [16]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF, 0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF},
+			Title:         "Sample",
+			MuxingApp:     "libebml v1.4.4 + libmatroska v1.7.1",
+			WritingApp:    "mkvmerge v80.0",
+			TimecodeScale: 1000000,
+			Duration:      60000,
+			DateUTC:       757382400000000000,
+			DateUTCValid:  true,
+		},
+		tracks: []*TrackInfo{video, audio, subtitle},
+		chapters: []*Chapter{
+			{
+				UID: 1, Start: 0, End: 30000000000, Enabled: true,
+				Display: []ChapterDisplay{{String: "Opening", Language: "eng"}, {String: "Ouverture", Language: "fre", Country: "fr"}},
+				Children: []*Chapter{
+					{UID: 11, Start: 10000000000, Enabled: true, Hidden: true, Display: []ChapterDisplay{{String: "Credits", Language: "eng"}}},
+				},
+			},
+			{UID: 2, Start: 30000000000, End: 60000000000, Enabled: true},
+		},
+		tags: []*Tag{
+			{SimpleTags: []SimpleTag{{Name: "ARTIST", Value: "Someone", Language: "und", Default: true}}},
+			{Targets: []Target{{Type: 30, UID: 1002}}, SimpleTags: []SimpleTag{{Name: "BPS", Value: "128000", Language: "eng"}}},
+		},
+		attachments: []*Attachment{
+			{UID: 42, Name: "font.ttf", MimeType: "font/ttf", Description: "Subtitle font", Length: 123456, Position: 9999},
+		},
+	}}
+
+	data, err := demuxer.MetadataJSON()
+	if err != nil {
+		t.Fatalf("MetadataJSON() failed: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "metadata.golden.json")
+	if *updateGolden {
+		if err = os.WriteFile(golden, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(append(data, '\n'), want) {
+		t.Errorf("MetadataJSON() does not match %s; run go test -update to regenerate it:\n%s", golden, data)
+	}
+
+	var decoded Metadata
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode the metadata: %v", err)
+	}
+	if len(decoded.Tracks) != 3 || decoded.Tracks[1].Audio == nil || decoded.Tracks[1].Video != nil {
+		t.Errorf("Unexpected decoded tracks: %+v", decoded.Tracks)
+	}
+
+	t.Run("Empty file", func(t *testing.T) {
+		demuxer := &Demuxer{parser: &MatroskaParser{}}
+		data, err := demuxer.MetadataJSON()
+		if err != nil {
+			t.Fatalf("MetadataJSON() failed: %v", err)
+		}
+		var fields map[string]json.RawMessage
+		if err = json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("Failed to decode the metadata: %v", err)
+		}
+		for _, name := range []string{"tracks", "chapters", "tags", "attachments"} {
+			if string(fields[name]) != "[]" {
+				t.Errorf("Expected %s to be an empty list, got %s", name, fields[name])
+			}
+		}
+	})
+}
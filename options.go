@@ -0,0 +1,302 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the functional options that configure a MatroskaParser
+// or Demuxer when it is created.
+package matroska
+
+import (
+	"sync"
+	"time"
+)
+
+// Option configures a MatroskaParser when it is created. Options are passed to
+// NewMatroskaParserWithOptions, NewMatroskaParserAt, NewDemuxer, NewDemuxerAt
+// and NewStreamingDemuxer.
+//
+// Example:
+//
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithDecompression(false))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+type Option func(*MatroskaParser)
+
+// WithAvoidSeeks makes the parser avoid seeking, parsing the file sequentially.
+//
+// This is useful for streaming or non-seekable input sources. Without it, the
+// parser seeks to the elements referenced by the SeekHead, such as the Cues,
+// when the file is opened. With it, the metadata is parsed up to the first
+// cluster, and the Tags, Chapters and Attachments stored after the clusters
+// are only available once ReadPacket has read past them.
+//
+// Returns:
+//   - Option: The option.
+func WithAvoidSeeks() Option {
+	return func(mp *MatroskaParser) {
+		mp.avoidSeeks = true
+	}
+}
+
+// WithDecompression enables or disables undoing the content compression of
+// tracks in ReadPacket. Decompression is enabled by default. See
+// MatroskaParser.SetDecompression.
+//
+// Parameters:
+//   - enabled: Whether compressed frames should be decompressed.
+//
+// Returns:
+//   - Option: The option.
+func WithDecompression(enabled bool) Option {
+	return func(mp *MatroskaParser) {
+		mp.SetDecompression(enabled)
+	}
+}
+
+// WithMaxElementSize sets the maximum size, in bytes, of an element that the
+// parser reads into memory, such as a TrackEntry or a SimpleBlock.
+//
+// Elements declaring a larger size are rejected with an error wrapping
+// ErrElementTooLarge before their data is allocated, which protects against
+// corrupt or crafted files claiming huge elements. The SegmentInfo and Tracks
+// elements are read one child at a time, so the limit applies to each of
+// their children, unless WithCRCValidation is set. The default is
+// DefaultMaxElementSize. A size of 0 removes the limit.
+//
+// Parameters:
+//   - size: The maximum element size.
+//
+// Returns:
+//   - Option: The option.
+func WithMaxElementSize(size uint64) Option {
+	return func(mp *MatroskaParser) {
+		mp.reader.SetMaxElementSize(size)
+	}
+}
+
+// WithErrorRecovery makes ReadPacket recover from corrupt data instead of
+// returning an error.
+//
+// When a block or element cannot be parsed, the parser scans forward for the
+// start code of the next Cluster element and resumes reading packets from
+// there, dropping the rest of the corrupt cluster. This allows partially
+// corrupt or truncated downloads to be played. Errors undoing the content
+// encodings of a packet are still returned, as they do not affect the
+// following packets.
+//
+// Returns:
+//   - Option: The option.
+func WithErrorRecovery() Option {
+	return func(mp *MatroskaParser) {
+		mp.errorRecovery = true
+	}
+}
+
+// WithCRCValidation enables the validation of CRC-32 elements.
+//
+// Master elements may start with a CRC-32 element holding the checksum of the
+// rest of their data. When validation is enabled, the checksum of the
+// top-level elements read into memory (SegmentInfo, Tracks, Cues, Chapters,
+// Tags and Attachments) and of BlockGroups is verified, and a *CRCError
+// naming the failing element is returned on mismatch. Validation is off by
+// default, as it requires hashing all of the data that is read.
+//
+// Returns:
+//   - Option: The option.
+func WithCRCValidation() Option {
+	return func(mp *MatroskaParser) {
+		mp.validateCRC = true
+	}
+}
+
+// WithFollow makes ReadPacket follow a file that is still being written, such
+// as a live recording, instead of returning io.EOF at its current end.
+//
+// When the end of the data is reached before the end of the segment, the
+// parser waits for the given interval, returns to the start of the unfinished
+// element and tries again, until more data has been written. A segment of
+// unknown size, as written by live muxers, is never considered finished. Use
+// ReadPacketCtx or Packets with a context to stop waiting, as ReadPacket waits
+// until the demuxer is closed. Following requires a seekable input.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithFollow(500*time.Millisecond))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for result := range demuxer.Packets(ctx) {
+//	    // Call cancel to stop following the file
+//	}
+//
+// Parameters:
+//   - poll: The interval between two attempts to read more data.
+//
+// Returns:
+//   - Option: The option.
+func WithFollow(poll time.Duration) Option {
+	return func(mp *MatroskaParser) {
+		mp.followPoll = poll
+	}
+}
+
+// WithPacketPool makes ReadPacket read the block data of packets into buffers
+// recycled through a pool, which reduces allocations and garbage collection
+// when demuxing at high throughput.
+//
+// The caller takes ownership of each packet as usual, and must call
+// Packet.Release once it is done with the packet's data. The buffer is then
+// reused by a later ReadPacket, so the Data of a released packet, or any slice
+// of it, must not be used, and a packet must not be released while another
+// goroutine still reads its data. Packets that are never released are simply
+// garbage collected. Without this option, which is the default, every packet
+// owns its data and Release does nothing.
+//
+// Example:
+//
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithPacketPool())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for {
+//	    packet, err := demuxer.ReadPacket()
+//	    if err != nil {
+//	        break
+//	    }
+//	    decoder.Decode(packet.Data) // Must not keep packet.Data
+//	    packet.Release()
+//	}
+//
+// Returns:
+//   - Option: The option.
+func WithPacketPool() Option {
+	return func(mp *MatroskaParser) {
+		mp.packetPool = &sync.Pool{
+			New: func() any {
+				return new([]byte)
+			},
+		}
+	}
+}
+
+// WithBestEffortDocTypeVersion makes the parser open files whose EBML header
+// declares a DocTypeReadVersion higher than MaxDocTypeReadVersion.
+//
+// Such files use features of a newer version of the Matroska specification,
+// and are rejected with ErrUnsupportedDocTypeVersion by default. With this
+// option, they are parsed on a best-effort basis: the elements unknown to the
+// parser are skipped, which may give wrong results if they change how the
+// known elements must be interpreted.
+//
+// Returns:
+//   - Option: The option.
+func WithBestEffortDocTypeVersion() Option {
+	return func(mp *MatroskaParser) {
+		mp.anyDocVersion = true
+	}
+}
+
+// WithStrictWebM makes the parser reject WebM files that use codecs WebM does
+// not allow.
+//
+// When the DocType of the EBML header is "webm", every track must use VP8, VP9
+// or AV1 video, Opus or Vorbis audio, or WebVTT subtitles, and the file is
+// rejected with an error wrapping ErrInvalidWebM otherwise. This helps tools
+// that must only accept spec-compliant WebM. Files with the "matroska" DocType
+// are not affected. By default, WebM files are parsed like Matroska files,
+// whatever their codecs.
+//
+// Returns:
+//   - Option: The option.
+func WithStrictWebM() Option {
+	return func(mp *MatroskaParser) {
+		mp.strictWebM = true
+	}
+}
+
+// WithRawTimestamps makes ReadPacket return the StartTime and EndTime of
+// packets in timestamp units, as stored in the file, instead of nanoseconds.
+//
+// By default, packet times are converted to nanoseconds by multiplying the
+// block timestamps by the TimecodeScale of the segment, and the packets of the
+// segments following the first one are shifted by the TimeOffset of their
+// segment. With this option, StartTime is the sum of the ClusterTimestamp and
+// the BlockOffset of the packet, without any time offset, and EndTime adds the
+// BlockDuration, or the track's DefaultDuration rounded to timestamp units.
+// This lets remuxers write the original timestamps back without the rounding
+// of a round trip through nanoseconds.
+//
+// The option only affects the packets returned to the caller. Stats,
+// CurrentTimecode, seeking and the export functions, such as ExportSRT,
+// still use nanoseconds, and Remux suspends the option while it copies the
+// packets.
+//
+// Example:
+//
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithRawTimestamps())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	info, _ := demuxer.GetFileInfo()
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d ticks of %d ns\n", packet.StartTime, info.TimecodeScale)
+//
+// Returns:
+//   - Option: The option.
+func WithRawTimestamps() Option {
+	return func(mp *MatroskaParser) {
+		mp.rawTimestamps = true
+	}
+}
+
+// WithEnglishLanguageDefault makes tracks without a Language element have the
+// language "eng", as in previous versions of this package.
+//
+// By default, such tracks have the language "und" (undetermined): most muxers
+// omit the element for tracks whose language is not known rather than for
+// English tracks, so reporting them as English is usually wrong. The Matroska
+// specification does declare "eng" as the default value of the element, which
+// this option follows for tools that rely on it.
+//
+// Example:
+//
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithEnglishLanguageDefault())
+//
+// Returns:
+//   - Option: The option.
+func WithEnglishLanguageDefault() Option {
+	return func(mp *MatroskaParser) {
+		mp.englishDefault = true
+	}
+}
+
+// WithElementHandler registers a function to call with the top-level elements
+// of the segment with the given ID, which the parser does not parse itself.
+// See MatroskaParser.RegisterHandler.
+//
+// Example:
+//
+//	var custom []byte
+//	demuxer, err := matroska.NewDemuxer(file, matroska.WithElementHandler(0x1F1F1F1F,
+//	    func(element *matroska.EBMLElement) error {
+//	        custom = element.Data
+//	        return nil
+//	    }))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - fn: The function called with each element with this ID.
+//
+// Returns:
+//   - Option: The option.
+func WithElementHandler(id uint32, fn func(*EBMLElement) error) Option {
+	return func(mp *MatroskaParser) {
+		mp.RegisterHandler(id, fn)
+	}
+}
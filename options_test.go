@@ -0,0 +1,509 @@
+package matroska
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewMatroskaParserWithOptions(t *testing.T) {
+	mockFile, err := createMockMatroskaFile()
+	if err != nil {
+		t.Fatalf("Failed to create mock matroska file: %v", err)
+	}
+
+	t.Run("No options", func(t *testing.T) {
+		parser, err := NewMatroskaParserWithOptions(bytes.NewReader(mockFile))
+		if err != nil {
+			t.Fatalf("NewMatroskaParserWithOptions() failed: %v", err)
+		}
+		if parser.avoidSeeks || parser.noDecompression {
+			t.Errorf("Expected default settings, got avoidSeeks=%v noDecompression=%v", parser.avoidSeeks, parser.noDecompression)
+		}
+	})
+
+	t.Run("WithAvoidSeeks", func(t *testing.T) {
+		parser, err := NewMatroskaParserWithOptions(bytes.NewReader(mockFile), WithAvoidSeeks())
+		if err != nil {
+			t.Fatalf("NewMatroskaParserWithOptions() failed: %v", err)
+		}
+		if !parser.avoidSeeks {
+			t.Error("Expected avoidSeeks to be set")
+		}
+	})
+
+	t.Run("NewMatroskaParser wrapper", func(t *testing.T) {
+		parser, err := NewMatroskaParser(bytes.NewReader(mockFile), true)
+		if err != nil {
+			t.Fatalf("NewMatroskaParser() failed: %v", err)
+		}
+		if !parser.avoidSeeks {
+			t.Error("Expected avoidSeeks to be set")
+		}
+	})
+
+	t.Run("Streaming demuxer always avoids seeks", func(t *testing.T) {
+		demuxer, err := NewStreamingDemuxer(bytes.NewReader(mockFile), WithDecompression(false))
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		if !demuxer.parser.avoidSeeks || !demuxer.parser.noDecompression {
+			t.Errorf("Expected avoidSeeks and noDecompression, got %v and %v", demuxer.parser.avoidSeeks, demuxer.parser.noDecompression)
+		}
+	})
+}
+
+func TestWithDecompression(t *testing.T) {
+	compression := new(bytes.Buffer)
+	writeUIntElement(compression, IDContentCompAlgo, CompPrepend, 1)
+	writeBinaryElement(compression, IDContentCompSettings, []byte{0x47})
+	encoding := new(bytes.Buffer)
+	writeBinaryElement(encoding, IDContentCompression, compression.Bytes())
+	encodings := new(bytes.Buffer)
+	writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_AC3", "Audio", "und")
+	buf := bytes.NewBuffer(trackEntry)
+	writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+	file := createMockMatroskaFileWithTrack(buf.Bytes(), []byte{0x81, 0x00, 0x00, 0x80, 0x01})
+
+	for _, enabled := range []bool{true, false} {
+		demuxer, err := NewDemuxer(bytes.NewReader(file), WithDecompression(enabled))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		want := []byte{0x01}
+		if enabled {
+			want = []byte{0x47, 0x01}
+		}
+		if !bytes.Equal(packet.Data, want) {
+			t.Errorf("WithDecompression(%v): packet data = %x, want %x", enabled, packet.Data, want)
+		}
+	}
+}
+
+func TestWithMaxElementSize(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_AC3", "Audio", "und")
+	file := createMockMatroskaFileWithTrack(trackEntry, []byte{0x81, 0x00, 0x00, 0x80, 0x01, 0x02, 0x03, 0x04})
+
+	t.Run("Oversized header element", func(t *testing.T) {
+		_, err := NewDemuxer(bytes.NewReader(file), WithMaxElementSize(4))
+		if !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("Expected ErrElementTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Oversized SimpleBlock size VINT", func(t *testing.T) {
+		mockFile := createMockMatroskaFileWithTrack(trackEntry)
+		// Append a SimpleBlock claiming a huge size after the empty cluster
+		mockFile = append(mockFile, 0xA3, 0x01, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xFF)
+		demuxer, err := NewDemuxer(bytes.NewReader(mockFile))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if _, err = demuxer.ReadPacket(); !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("Expected ErrElementTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("No limit", func(t *testing.T) {
+		parser, err := NewMatroskaParserWithOptions(bytes.NewReader(file), WithMaxElementSize(0))
+		if err != nil {
+			t.Fatalf("NewMatroskaParserWithOptions() failed: %v", err)
+		}
+		if parser.reader.maxElementSize != 0 {
+			t.Errorf("Expected no limit, got %d", parser.reader.maxElementSize)
+		}
+	})
+}
+
+func TestWithErrorRecovery(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_AC3", "Audio", "und")
+	// A Xiph-laced SimpleBlock without its frame count
+	corrupt := []byte{0x81, 0x00, 0x00, 0x86}
+	file := createMockMatroskaFileWithTrack(trackEntry, corrupt)
+
+	block := []byte{0x81, 0x00, 0x00, 0x80, 0xAA}
+	cluster := []byte{0xE7, 0x81, 0x05, 0xA3}
+	cluster = append(cluster, vintEncode(uint64(len(block)))...)
+	cluster = append(cluster, block...)
+	file = append(file, 0x1F, 0x43, 0xB6, 0x75)
+	file = append(file, vintEncode(uint64(len(cluster)))...)
+	file = append(file, cluster...)
+
+	t.Run("Strict", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if _, err = demuxer.ReadPacket(); !errors.Is(err, ErrTruncatedBlock) {
+			t.Errorf("Expected ErrTruncatedBlock, got %v", err)
+		}
+	})
+
+	t.Run("Recovery", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(file), WithErrorRecovery())
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{0xAA}) || packet.StartTime != 5000000 {
+			t.Errorf("Unexpected packet: data=%x start=%d", packet.Data, packet.StartTime)
+		}
+		if _, err = demuxer.ReadPacket(); err != io.EOF {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("Recovery without a following cluster", func(t *testing.T) {
+		truncated := createMockMatroskaFileWithTrack(trackEntry, corrupt)
+		truncated = append(truncated, 0xA3, 0x8A, 0x81, 0x00)
+		demuxer, err := NewDemuxer(bytes.NewReader(truncated), WithErrorRecovery())
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if _, err = demuxer.ReadPacket(); err != io.EOF {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("Streaming recovery", func(t *testing.T) {
+		demuxer, err := NewStreamingDemuxer(bytes.NewReader(file), WithErrorRecovery())
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, []byte{0xAA}) {
+			t.Errorf("Unexpected packet data %x", packet.Data)
+		}
+	})
+}
+
+func TestWithCRCValidation(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_AC3", "Audio", "und")
+	tracks := new(bytes.Buffer)
+	writeBinaryElement(tracks, IDTrackEntry, trackEntry)
+
+	buildFile := func(checksum uint32) []byte {
+		segment := new(bytes.Buffer)
+		writeBinaryElement(segment, IDSegmentInfo, []byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40})
+		crc := make([]byte, 4)
+		binary.LittleEndian.PutUint32(crc, checksum)
+		tracksData := new(bytes.Buffer)
+		writeBinaryElement(tracksData, IDCRC32, crc)
+		tracksData.Write(tracks.Bytes())
+		writeBinaryElement(segment, IDTracks, tracksData.Bytes())
+		writeBinaryElement(segment, IDCluster, []byte{0xE7, 0x81, 0x00})
+
+		file := new(bytes.Buffer)
+		writeBinaryElement(file, IDEBMLHeader, []byte{0x42, 0x82, 0x88, 'm', 'a', 't', 'r', 'o', 's', 'k', 'a'})
+		writeBinaryElement(file, IDSegment, segment.Bytes())
+		return file.Bytes()
+	}
+	valid := buildFile(crc32.ChecksumIEEE(tracks.Bytes()))
+	corrupt := buildFile(crc32.ChecksumIEEE(tracks.Bytes()) ^ 1)
+
+	t.Run("Correct CRC", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(valid), WithCRCValidation())
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		if numTracks, _ := demuxer.GetNumTracks(); numTracks != 1 {
+			t.Errorf("Expected 1 track, got %d", numTracks)
+		}
+	})
+
+	t.Run("Corrupted CRC", func(t *testing.T) {
+		_, err := NewDemuxer(bytes.NewReader(corrupt), WithCRCValidation())
+		if !errors.Is(err, ErrCRCMismatch) {
+			t.Fatalf("Expected ErrCRCMismatch, got %v", err)
+		}
+		var crcErr *CRCError
+		if !errors.As(err, &crcErr) || crcErr.ElementID != IDTracks {
+			t.Errorf("Expected a CRCError for the Tracks element, got %v", err)
+		}
+	})
+
+	t.Run("Validation disabled", func(t *testing.T) {
+		if _, err := NewDemuxer(bytes.NewReader(corrupt)); err != nil {
+			t.Errorf("NewDemuxer() without validation failed: %v", err)
+		}
+	})
+}
+
+func TestWithFollow(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeAudio, CodecID: "A_OPUS"}}
+	var packets []*Packet
+	for i := 0; i < 100; i++ {
+		packets = append(packets, &Packet{Track: 1, StartTime: uint64(i) * 20000000, Data: bytes.Repeat([]byte{byte(i)}, 50), Flags: KF})
+	}
+	complete, err := os.ReadFile(createMuxedFile(t, tracks, packets).reader.(*os.File).Name())
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+
+	// Only part of the file has been written, cut in the middle of a block
+	path := filepath.Join(t.TempDir(), "live.mkv")
+	cut := len(complete) / 2
+	if err = os.WriteFile(path, complete[:cut], 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	demuxer, err := NewDemuxer(file, WithFollow(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	t.Run("Waits for more data", func(t *testing.T) {
+		var read int
+		for ; read < len(packets); read++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			_, err = demuxer.ReadPacketCtx(ctx)
+			cancel()
+			if err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected the read to wait until the deadline, got %v", err)
+		}
+		if read == 0 || read == len(packets) {
+			t.Fatalf("Expected part of the packets before the cut, got %d", read)
+		}
+
+		// The rest of the file is written while the demuxer waits
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			appender, errOpen := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if errOpen != nil {
+				return
+			}
+			_, _ = appender.Write(complete[cut:])
+			_ = appender.Close()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for ; ; read++ {
+			packet, errReadPacket := demuxer.ReadPacketCtx(ctx)
+			if errReadPacket == io.EOF {
+				break
+			}
+			if errReadPacket != nil {
+				t.Fatalf("ReadPacketCtx() failed after %d packets: %v", read, errReadPacket)
+			}
+			if !bytes.Equal(packet.Data, packets[read].Data) || packet.StartTime != packets[read].StartTime {
+				t.Fatalf("Packet %d does not match", read)
+			}
+		}
+		if read != len(packets) {
+			t.Errorf("Expected %d packets, got %d", len(packets), read)
+		}
+	})
+
+	t.Run("Stops at the end of the segment", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err = demuxer.ReadPacketCtx(ctx); err != io.EOF {
+			t.Errorf("Expected io.EOF after the segment, got %v", err)
+		}
+	})
+
+	t.Run("Accessors and Close during a wait", func(t *testing.T) {
+		truncatedPath := filepath.Join(t.TempDir(), "truncated.mkv")
+		if err = os.WriteFile(truncatedPath, complete[:cut], 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+		truncated, errOpen := os.Open(truncatedPath)
+		if errOpen != nil {
+			t.Fatalf("Open() failed: %v", errOpen)
+		}
+		demuxer, errNew := NewDemuxer(truncated, WithFollow(time.Millisecond))
+		if errNew != nil {
+			t.Fatalf("NewDemuxer() failed: %v", errNew)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			for {
+				if _, errReadPacket := demuxer.ReadPacket(); errReadPacket != nil {
+					done <- errReadPacket
+					return
+				}
+			}
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		tracksRead := make(chan int, 1)
+		go func() {
+			numTracks, _ := demuxer.GetNumTracks()
+			tracksRead <- int(numTracks)
+		}()
+		select {
+		case numTracks := <-tracksRead:
+			if numTracks != 1 {
+				t.Errorf("GetNumTracks() = %d, want 1", numTracks)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("GetNumTracks() blocked while a read waits for data")
+		}
+
+		closed := make(chan error, 1)
+		go func() {
+			closed <- demuxer.Close()
+		}()
+		select {
+		case errClose := <-closed:
+			if errClose != nil {
+				t.Errorf("Close() failed: %v", errClose)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Close() blocked while a read waits for data")
+		}
+		select {
+		case errReadPacket := <-done:
+			if errReadPacket == io.EOF {
+				t.Error("Expected an error other than io.EOF after Close()")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ReadPacket() kept waiting after Close()")
+		}
+	})
+}
+
+func TestWithRawTimestamps(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}}
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x02}},
+		{Track: 1, StartTime: 80000000, Data: []byte{0x03}, Flags: KF},
+	}
+
+	// The Muxer uses a TimecodeScale of 1 ms and starts a cluster at each keyframe
+	expected := []struct {
+		clusterTimestamp uint64
+		blockOffset      int16
+	}{{0, 0}, {0, 40}, {80, 0}}
+
+	tests := []struct {
+		name  string
+		raw   bool
+		scale uint64
+	}{
+		{"Nanoseconds", false, 1000000},
+		{"Raw timestamps", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			demuxer := createMuxedFile(t, tracks, packets)
+			if tt.raw {
+				demuxer.parser.rawTimestamps = true
+			}
+			for i, want := range expected {
+				packet, err := demuxer.ReadPacket()
+				if err != nil {
+					t.Fatalf("ReadPacket() failed: %v", err)
+				}
+				if packet.ClusterTimestamp != want.clusterTimestamp || packet.BlockOffset != want.blockOffset {
+					t.Errorf("Packet %d: expected cluster timestamp %d and offset %d, got %d and %d",
+						i, want.clusterTimestamp, want.blockOffset, packet.ClusterTimestamp, packet.BlockOffset)
+				}
+				start := (want.clusterTimestamp + uint64(want.blockOffset)) * tt.scale
+				if packet.StartTime != start || packet.EndTime != start+40*tt.scale {
+					t.Errorf("Packet %d: expected times %d-%d, got %d-%d",
+						i, start, start+40*tt.scale, packet.StartTime, packet.EndTime)
+				}
+			}
+
+			// The statistics and the current time stay in nanoseconds
+			if last := demuxer.Stats()[1].LastTimecode; last != 80000000 {
+				t.Errorf("Expected last timecode 80000000, got %d", last)
+			}
+			if current := demuxer.CurrentTimecode(); current != 80000000 {
+				t.Errorf("Expected current timecode 80000000, got %d", current)
+			}
+		})
+	}
+
+	t.Run("Option", func(t *testing.T) {
+		mockFile, err := createMockMatroskaFile()
+		if err != nil {
+			t.Fatalf("Failed to create mock matroska file: %v", err)
+		}
+		parser, err := NewMatroskaParserWithOptions(bytes.NewReader(mockFile), WithRawTimestamps())
+		if err != nil {
+			t.Fatalf("NewMatroskaParserWithOptions() failed: %v", err)
+		}
+		if !parser.rawTimestamps {
+			t.Error("Expected WithRawTimestamps to enable raw timestamps")
+		}
+	})
+}
+
+// TestWithEnglishLanguageDefault tests the language of a track without a
+// Language element, with and without WithEnglishLanguageDefault.
+func TestWithEnglishLanguageDefault(t *testing.T) {
+	var entry bytes.Buffer
+	putUIntElement(&entry, IDTrackNum, 1)
+	putUIntElement(&entry, IDTrackType, uint64(TypeAudio))
+	putStringElement(&entry, IDCodecID, "A_OPUS")
+	data := createMockMatroskaFileWithTrack(entry.Bytes())
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{"Undetermined", nil, "und"},
+		{"English", []Option{WithEnglishLanguageDefault()}, "eng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			demuxer, err := NewDemuxer(bytes.NewReader(data), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewDemuxer() failed: %v", err)
+			}
+			track, err := demuxer.GetTrackInfo(0)
+			if err != nil {
+				t.Fatalf("GetTrackInfo() failed: %v", err)
+			}
+			if track.Language != tt.expected {
+				t.Errorf("Expected language %q, got %q", tt.expected, track.Language)
+			}
+		})
+	}
+
+	// A Language element is reported as is with the option
+	languageEntry, err := createMockTrackEntry(1, TypeAudio, "A_OPUS", "Audio", "fre")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(bytes.NewReader(createMockMatroskaFileWithTrack(languageEntry)), WithEnglishLanguageDefault())
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	if track, _ := demuxer.GetTrackInfo(0); track == nil || track.Language != "fre" {
+		t.Errorf("Expected language 'fre', got %+v", track)
+	}
+}
@@ -29,7 +29,7 @@
 //	// Get file information
 //	fileInfo := parser.GetFileInfo()
 //	fmt.Printf("Title: %s\n", fileInfo.Title)
-//	fmt.Printf("Duration: %d\n", fileInfo.Duration)
+//	fmt.Printf("Duration: %f\n", fileInfo.Duration)
 //
 //	// Get track information
 //	numTracks := parser.GetNumTracks()
@@ -53,9 +53,14 @@ package matroska
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"sync"
+	"time"
 )
 
 // MatroskaParser represents a parser for Matroska and WebM files.
@@ -86,7 +91,22 @@ type MatroskaParser struct {
 
 	// Cluster parsing state
 	clusterTimestamp uint64
+	clusterPosition  uint64
+	clusterPrevSize  uint64
 	currentTrackMask uint64
+	// The tracks listed in the SilentTracks element of the current cluster
+	clusterSilentTracks []uint64
+	// The position of the Cluster element of the current cluster
+	clusterStart int64
+
+	// The packets read backwards by ReadPrevPacket: those of the cluster at
+	// prevClusterStart not yet returned, and the position before the last
+	// one returned
+	prevPackets         []prevPacket
+	prevClusterStart    int64
+	prevClusterPrevSize uint64
+	prevPos             int64
+	prevActive          bool
 
 	// Position tracking
 	segmentPos    uint64
@@ -94,10 +114,77 @@ type MatroskaParser struct {
 	cuesPos       uint64
 	cuesTopPos    uint64
 
+	// The size of the input, measured by Progress for a segment of unknown size
+	inputSize int64
+
+	// Positions of the elements listed in the SeekHead, keyed by element ID
+	seekHead map[uint32]uint64
+	// The position at which ReadPacket starts, when opened by OpenMetadata
+	resumePos     int64
+	resumePending bool
+
+	// Per-track packet conversions, keyed by track number
+	annexB map[uint64]*annexBConverter
+	// Content decryption keys, keyed by track number
+	decryptionKeys map[uint64][]byte
+	// Sample sizes of the PCM tracks whose byte order is swapped, keyed by track number
+	pcmSwap map[uint64]int
+
+	// The segments of the file, the index of the one being read, and the
+	// largest packet end time read, used when a segment has no duration
+	segments      []*SegmentElement
+	segmentIndex  int
+	lastPacketEnd uint64
+
+	// Per-track statistics of the packets read, keyed by track number
+	stats map[uint64]*TrackStats
+
+	// The start time of the last packet read, or of the keyframe or cue point
+	// moved to by a seek, see CurrentTimecode
+	currentTimecode uint64
+
+	// The keyframes listed by BuildKeyframeIndex, keyed by track number
+	keyframeIndex map[uint64][]KeyframePoint
+
+	// The duration measured by ComputeDuration
+	computedDuration time.Duration
+	durationComputed bool
+
+	// The interval between reads past the end of a growing file, or 0 to
+	// return io.EOF instead
+	followPoll time.Duration
+	// The lock released while waiting for more data, held by the Demuxer
+	// during reads, or nil
+	waitLock sync.Locker
+
+	// The buffers of the block data of packets, if WithPacketPool is set
+	packetPool *sync.Pool
+	// The packet and buffer to fill during a call to ReadPacketInto
+	intoPacket *Packet
+	intoBuf    []byte
+
+	// The functions called with the children of the segment that are not
+	// parsed, see RegisterHandler
+	handlers map[uint32]ElementHandler
+
 	// Flags
-	avoidSeeks bool
+	avoidSeeks      bool
+	noDecompression bool
+	errorRecovery   bool
+	validateCRC     bool
+	metadataOnly    bool
+	anyDocVersion   bool
+	strictWebM      bool
+	rawTimestamps   bool
+	englishDefault  bool
 }
 
+// MaxDocTypeReadVersion is the highest DocTypeReadVersion of the EBML header
+// that the parser supports, which is version 4 of the Matroska specification.
+// Files requiring a newer reader are rejected with ErrUnsupportedDocTypeVersion,
+// unless WithBestEffortDocTypeVersion is set.
+const MaxDocTypeReadVersion = 4
+
 // SegmentElement represents the main segment element in a Matroska file.
 //
 // The segment is the top-level element in a Matroska file that contains all
@@ -107,10 +194,18 @@ type MatroskaParser struct {
 //
 // The Position field indicates the byte offset from the beginning of the file
 // where the segment element starts, and the Size field indicates the total size
-// of the segment element in bytes.
+// of the segment element in bytes. A file may contain several segments, see
+// MatroskaParser.Segments.
 type SegmentElement struct {
 	Position uint64
 	Size     uint64
+	// Info is the SegmentInfo of the segment, or nil if it has none.
+	Info *SegmentInfo
+	// TimeOffset is the time in nanoseconds added to the timestamps of the
+	// packets of the segment. It is 0 for the first segment, and the end of the
+	// previous segment for the others. It is only known from the durations of
+	// the previous segments or once ReadPacket has reached the segment.
+	TimeOffset uint64
 }
 
 // NewMatroskaParser creates a new Matroska parser for the given ReadSeeker.
@@ -118,6 +213,8 @@ type SegmentElement struct {
 // This function initializes a MatroskaParser and parses the EBML header and
 // main segment of the Matroska file. It validates that the file is a valid
 // Matroska or WebM file by checking the document type in the EBML header.
+// It is equivalent to NewMatroskaParserWithOptions with WithAvoidSeeks when
+// avoidSeeks is true.
 //
 // Parameters:
 //   - r: An io.ReadSeeker that provides access to the Matroska file data.
@@ -147,9 +244,46 @@ type SegmentElement struct {
 //	    log.Fatal(err)
 //	}
 func NewMatroskaParser(r io.ReadSeeker, avoidSeeks bool) (*MatroskaParser, error) {
+	if avoidSeeks {
+		return NewMatroskaParserWithOptions(r, WithAvoidSeeks())
+	}
+	return NewMatroskaParserWithOptions(r)
+}
+
+// NewMatroskaParserWithOptions creates a new Matroska parser for the given
+// ReadSeeker, configured with the given options.
+//
+// Like NewMatroskaParser, this function parses the EBML header and main segment
+// of the Matroska file. The options are applied before anything is read, so
+// they affect the parsing of the file headers as well as of the packets.
+//
+// Parameters:
+//   - r: An io.ReadSeeker that provides access to the Matroska file data.
+//   - opts: The options configuring the parser, such as WithAvoidSeeks.
+//
+// Returns:
+//   - *MatroskaParser: A pointer to the initialized MatroskaParser.
+//   - error: An error if the parser could not be created or if the file is not
+//     a valid Matroska or WebM file.
+//
+// Example:
+//
+//	parser, err := matroska.NewMatroskaParserWithOptions(file,
+//	    matroska.WithAvoidSeeks(),
+//	    matroska.WithDecompression(false),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func NewMatroskaParserWithOptions(r io.ReadSeeker, opts ...Option) (*MatroskaParser, error) {
 	parser := &MatroskaParser{
-		reader:     NewEBMLReader(r),
-		avoidSeeks: avoidSeeks,
+		reader: NewEBMLReader(r),
+	}
+	for _, opt := range opts {
+		opt(parser)
+	}
+	if parser.metadataOnly && parser.avoidSeeks {
+		return nil, fmt.Errorf("reading only the metadata requires seeking")
 	}
 
 	if err := parser.parseHeader(); err != nil {
@@ -160,7 +294,23 @@ func NewMatroskaParser(r io.ReadSeeker, avoidSeeks bool) (*MatroskaParser, error
 		return nil, fmt.Errorf("failed to parse segment: %w", err)
 	}
 
-	if !avoidSeeks && parser.cuesPos == 0 {
+	if parser.strictWebM {
+		if err := parser.validateWebM(); err != nil {
+			return nil, err
+		}
+	}
+
+	if parser.metadataOnly {
+		// Packets are read from the first cluster, where parseSegment stopped
+		parser.resumePos = parser.reader.Position()
+		parser.resumePending = true
+		if err := parser.parseSeekHeadTargets(); err != nil {
+			return nil, fmt.Errorf("failed to parse seek head targets: %w", err)
+		}
+		return parser, nil
+	}
+
+	if !parser.avoidSeeks && parser.cuesPos == 0 {
 		// Cues not found in initial scan, let's scan the whole segment more carefully
 		currentPos := parser.reader.Position()
 		if _, err := parser.reader.Seek(int64(parser.segmentPos), io.SeekStart); err != nil {
@@ -201,9 +351,48 @@ func NewMatroskaParser(r io.ReadSeeker, avoidSeeks bool) (*MatroskaParser, error
 		}
 	}
 
+	if !parser.avoidSeeks {
+		if err := parser.scanSegments(); err != nil {
+			return nil, fmt.Errorf("failed to scan segments: %w", err)
+		}
+	}
+
 	return parser, nil
 }
 
+// NewMatroskaParserAt creates a new Matroska parser reading from an
+// io.ReaderAt, configured with the given options.
+//
+// The parser reads at absolute offsets through its own cursor instead of the
+// shared seek position of an io.ReadSeeker, so several parsers created from the
+// same io.ReaderAt, such as an *os.File, can read different parts of the file
+// concurrently, for example to extract several tracks in parallel. Each parser
+// must still be used by a single goroutine at a time.
+//
+// Parameters:
+//   - r: An io.ReaderAt that provides access to the Matroska file data.
+//   - size: The size of the file in bytes.
+//   - opts: The options configuring the parser, such as WithDecompression.
+//
+// Returns:
+//   - *MatroskaParser: A pointer to the initialized MatroskaParser.
+//   - error: An error if the parser could not be created or if the file is not
+//     a valid Matroska or WebM file.
+//
+// Example:
+//
+//	info, err := file.Stat()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	parser, err := matroska.NewMatroskaParserAt(file, info.Size())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func NewMatroskaParserAt(r io.ReaderAt, size int64, opts ...Option) (*MatroskaParser, error) {
+	return NewMatroskaParserWithOptions(io.NewSectionReader(r, 0, size), opts...)
+}
+
 // parseHeader parses the EBML header from the Matroska file.
 //
 // This method reads and validates the EBML (Extensible Binary Meta Language) header
@@ -212,11 +401,14 @@ func NewMatroskaParser(r io.ReadSeeker, avoidSeeks bool) (*MatroskaParser, error
 //
 // The method validates that the document type is either "matroska" or "webm",
 // ensuring that the file is a valid Matroska or WebM file. If the document type
-// is not recognized, an error is returned.
+// is not recognized, an error is returned. The DocTypeReadVersion, the minimum
+// version of the reader needed to read the file, must not exceed
+// MaxDocTypeReadVersion, unless WithBestEffortDocTypeVersion is set.
 //
 // Returns:
-//   - error: An error if the header could not be read or if the document type
-//     is not supported.
+//   - error: An error if the header could not be read, an error wrapping
+//     ErrUnsupportedDocType if the document type is not supported, or an error
+//     wrapping ErrUnsupportedDocTypeVersion if its read version is not supported.
 func (mp *MatroskaParser) parseHeader() error {
 	header, err := mp.reader.ReadEBMLHeader()
 	if err != nil {
@@ -225,7 +417,11 @@ func (mp *MatroskaParser) parseHeader() error {
 
 	// Validate it's a Matroska/WebM file
 	if header.DocType != "matroska" && header.DocType != "webm" {
-		return fmt.Errorf("unsupported document type: %s", header.DocType)
+		return fmt.Errorf("%w: %s", ErrUnsupportedDocType, header.DocType)
+	}
+	if header.DocTypeReadVersion > MaxDocTypeReadVersion && !mp.anyDocVersion {
+		return fmt.Errorf("%w: %s read version %d, the maximum supported is %d",
+			ErrUnsupportedDocTypeVersion, header.DocType, header.DocTypeReadVersion, MaxDocTypeReadVersion)
 	}
 
 	mp.header = header
@@ -270,6 +466,8 @@ func (mp *MatroskaParser) parseSegment() error {
 		return fmt.Errorf("failed to parse segment children: %w", err)
 	}
 
+	mp.segment.Info = mp.fileInfo
+	mp.segments = []*SegmentElement{mp.segment}
 	return nil
 }
 
@@ -279,6 +477,8 @@ func (mp *MatroskaParser) parseSegment() error {
 // them to appropriate parsing methods based on their element ID. The segment can
 // contain various types of child elements, including:
 //
+//   - SeekHead: Contains the positions of the other top-level elements, which
+//     are recorded for OpenMetadata.
 //   - SegmentInfo: Contains metadata about the file, such as title, duration,
 //     and timestamp scale.
 //   - Tracks: Contains information about the media tracks in the file.
@@ -288,9 +488,11 @@ func (mp *MatroskaParser) parseSegment() error {
 //   - Attachments: Contains attached files (currently skipped).
 //   - Cluster: Contains the actual media data, which is handled during packet reading.
 //
-// If the parser is configured to avoid seeks (avoidSeeks=true), it will parse
-// the entire segment sequentially. Otherwise, it will stop parsing when it
-// encounters the first cluster element, as clusters are handled during packet reading.
+// Parsing stops at the first cluster element, whose header has been read, as
+// clusters are handled during packet reading. This is also the case when the
+// parser avoids seeks (avoidSeeks=true): the packets of a stream must be read
+// from its first cluster on, and the Tags, Chapters and Attachments that follow
+// the clusters are parsed by ReadPacket as it reaches them.
 //
 // Returns:
 //   - error: An error if any of the child elements could not be parsed.
@@ -298,6 +500,7 @@ func (mp *MatroskaParser) parseSegmentChildren() error {
 	segmentEnd := mp.segment.Position + mp.segment.Size
 
 	for mp.reader.Position() < int64(segmentEnd) {
+		elementStart := mp.reader.Position()
 		id, size, err := mp.reader.ReadElementHeader()
 		if err != nil {
 			if err == io.EOF {
@@ -340,31 +543,149 @@ func (mp *MatroskaParser) parseSegmentChildren() error {
 			if err = mp.parseAttachments(size); err != nil {
 				return fmt.Errorf("failed to parse attachments: %w", err)
 			}
-		case IDCluster:
-			// We'll handle clusters during packet reading
-			// For now, just skip to end of parsing metadata
-			if !mp.avoidSeeks {
-				return nil
+		case IDSeekHead:
+			if err = mp.parseSeekHead(size); err != nil {
+				return fmt.Errorf("failed to parse seek head: %w", err)
+			}
+		case IDVoid:
+			// Padding, which carries no data
+			if err = mp.skipElement(size); err != nil {
+				return fmt.Errorf("failed to skip void element: %w", err)
 			}
-			// Fall through to skip if avoiding seeks
-			fallthrough
+		case IDCluster:
+			// Clusters are read by ReadPacket, which continues from here
+			mp.clusterStart = elementStart
+			return nil
 		default:
-			// Skip unknown elements
-			if mp.avoidSeeks {
-				if _, err = mp.reader.Skip(int64(size)); err != nil {
-					return fmt.Errorf("failed to skip element: %w", err)
-				}
-			} else {
-				if _, err = mp.reader.Seek(int64(size), io.SeekCurrent); err != nil {
-					return fmt.Errorf("failed to skip element: %w", err)
+			// Pass unknown elements to their handler, or skip them
+			handled, errHandle := mp.handleElement(id, size)
+			if errHandle != nil {
+				return errHandle
+			}
+			if handled {
+				continue
+			}
+			if err = mp.skipElement(size); err != nil {
+				return fmt.Errorf("failed to skip element: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readMasterData reads the data of a master element whose header has just
+// been read. When CRC validation is enabled, the data is checked against the
+// element's CRC-32 child, if it has one.
+//
+// Parameters:
+//   - id: The ID of the master element.
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - []byte: The element data.
+//   - error: An error if the data could not be read, or a *CRCError if its
+//     CRC-32 does not match.
+func (mp *MatroskaParser) readMasterData(id uint32, size uint64) ([]byte, error) {
+	position := mp.reader.Position()
+	data, err := mp.reader.readData(size)
+	if err != nil {
+		return nil, err
+	}
+	if mp.validateCRC {
+		if err = checkCRC32(id, position, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// parseChildren reads the children of a master element whose header has just
+// been read, one at a time from the main reader, and passes them to fn. Only
+// one child is held in memory at a time instead of the whole element, and each
+// child is limited by the maximum element size, so that a huge or crafted
+// element cannot exhaust memory.
+//
+// When CRC validation is enabled, the element is read into memory with
+// readMasterData instead, as its CRC-32 covers all of its children.
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - size: The size of the element's data.
+//   - fn: The function called with each child.
+//
+// Returns:
+//   - error: An error if a child could not be read, or the first error
+//     returned by fn.
+func (mp *MatroskaParser) parseChildren(id uint32, size uint64, fn func(element *EBMLElement) error) error {
+	if mp.validateCRC {
+		data, err := mp.readMasterData(id, size)
+		if err != nil {
+			return err
+		}
+		childReader := &EBMLReader{r: &seekableReader{bytes.NewReader(data)}, pos: 0}
+		for childReader.pos < int64(size) {
+			element, errReadElement := childReader.ReadElement()
+			if errReadElement != nil {
+				if errReadElement == io.EOF {
+					break
 				}
+				return errReadElement
+			}
+			if err = fn(element); err != nil {
+				return err
 			}
 		}
+		return nil
 	}
 
+	if size == (1<<(7*8))-1 {
+		return ErrUnknownSizeUnsupported
+	}
+	end := mp.reader.Position() + int64(size)
+	for mp.reader.Position() < end {
+		childID, childSize, err := mp.reader.ReadElementHeader()
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("failed to read element header: %w", io.ErrUnexpectedEOF)
+			}
+			return err
+		}
+		if childSize == (1<<(7*8))-1 {
+			return ErrUnknownSizeUnsupported
+		}
+		if childSize > uint64(end-mp.reader.Position()) {
+			return fmt.Errorf("failed to read element data: %w", io.ErrUnexpectedEOF)
+		}
+		data, err := mp.reader.readData(childSize)
+		if err != nil {
+			return fmt.Errorf("failed to read element data: %w", err)
+		}
+		if err = fn(&EBMLElement{ID: childID, Size: childSize, Data: data}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// skipElement skips the data of an element whose header has just been read.
+// It reads through the data when the parser avoids seeks, and seeks past it
+// otherwise.
+//
+// Parameters:
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - error: An error if the data could not be skipped.
+func (mp *MatroskaParser) skipElement(size uint64) error {
+	if mp.avoidSeeks {
+		_, err := mp.reader.Skip(int64(size))
+		return err
+	}
+	_, err := mp.reader.Seek(int64(size), io.SeekCurrent)
+	return err
+}
+
 // parseSegmentInfo parses segment information from the Matroska file.
 //
 // The SegmentInfo element contains metadata about the file, such as the title,
@@ -379,7 +700,8 @@ func (mp *MatroskaParser) parseSegmentChildren() error {
 //   - PrevFilename: The filename of the previous segment.
 //   - NextUID: The unique identifier of the next segment.
 //   - NextFilename: The filename of the next segment.
-//   - TimestampScale: The scale factor for timestamps in nanoseconds.
+//   - TimestampScale: The scale factor for timestamps in nanoseconds. A value of
+//     zero is replaced by the default of 1000000 and flagged as invalid.
 //   - Duration: The duration of the segment in timestamp units.
 //   - DateUTC: The date and time the file was created.
 //   - Title: The title of the segment.
@@ -392,29 +714,11 @@ func (mp *MatroskaParser) parseSegmentChildren() error {
 // Returns:
 //   - error: An error if the SegmentInfo element could not be read or parsed.
 func (mp *MatroskaParser) parseSegmentInfo(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
-	if err != nil {
-		return err
-	}
-	mp.reader.pos += int64(n)
-
 	mp.fileInfo = &SegmentInfo{
 		TimecodeScale: 1000000, // Default timecode scale
 	}
 
-	reader := bytes.NewReader(data)
-	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
-
-	for childReader.pos < int64(size) {
-		element, errReadElement := childReader.ReadElement()
-		if errReadElement != nil {
-			if errReadElement == io.EOF {
-				break
-			}
-			return errReadElement
-		}
-
+	return mp.parseChildren(IDSegmentInfo, size, func(element *EBMLElement) error {
 		switch element.ID {
 		case IDSegmentUID:
 			if len(element.Data) >= 16 {
@@ -436,8 +740,13 @@ func (mp *MatroskaParser) parseSegmentInfo(size uint64) error {
 			mp.fileInfo.NextFilename = element.ReadString()
 		case IDTimestampScale:
 			mp.fileInfo.TimecodeScale = element.ReadUInt()
+			if mp.fileInfo.TimecodeScale == 0 {
+				// A zero scale would collapse every timestamp to zero
+				mp.fileInfo.TimecodeScale = 1000000
+				mp.fileInfo.TimecodeScaleInvalid = true
+			}
 		case IDDuration:
-			mp.fileInfo.Duration = element.ReadUInt()
+			mp.fileInfo.Duration = element.ReadFloat()
 		case IDDateUTC:
 			mp.fileInfo.DateUTC = element.ReadInt()
 			mp.fileInfo.DateUTCValid = true
@@ -448,9 +757,8 @@ func (mp *MatroskaParser) parseSegmentInfo(size uint64) error {
 		case IDWritingApp:
 			mp.fileInfo.WritingApp = element.ReadString()
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // parseTracks parses track information from the Matroska file.
@@ -474,25 +782,7 @@ func (mp *MatroskaParser) parseSegmentInfo(size uint64) error {
 // Returns:
 //   - error: An error if the Tracks element could not be read or parsed.
 func (mp *MatroskaParser) parseTracks(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
-	if err != nil {
-		return err
-	}
-	mp.reader.pos += int64(n)
-
-	reader := bytes.NewReader(data)
-	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
-
-	for childReader.pos < int64(size) {
-		element, errReadElement := childReader.ReadElement()
-		if errReadElement != nil {
-			if errReadElement == io.EOF {
-				break
-			}
-			return errReadElement
-		}
-
+	err := mp.parseChildren(IDTracks, size, func(element *EBMLElement) error {
 		if element.ID == IDTrackEntry {
 			trackInfo, errParseTrackEntry := mp.parseTrackEntry(element.Data)
 			if errParseTrackEntry != nil {
@@ -500,6 +790,10 @@ func (mp *MatroskaParser) parseTracks(size uint64) error {
 			}
 			mp.tracks = append(mp.tracks, trackInfo)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Sort tracks by track number
@@ -520,12 +814,28 @@ func (mp *MatroskaParser) parseTracks(size uint64) error {
 //   - TrackNumber: The track number used to identify the track.
 //   - TrackUID: A unique identifier for the track.
 //   - TrackType: The type of the track (video, audio, subtitle, etc.).
+//   - FlagEnabled, FlagDefault, FlagForced: The track selection flags.
+//   - FlagLacing: Whether the track may use lacing.
+//   - MaxBlockAdditionID: The maximum BlockAddID of the track's BlockAdditions.
+//   - TrackOverlay: The tracks to play when the data of the track is not available.
+//   - DefaultDuration: The nominal duration of a frame in nanoseconds.
+//   - DefaultDecodedFieldDuration: The duration of a decoded field of
+//     interlaced video in nanoseconds, stored in the Video settings.
 //   - TrackName: A human-readable name for the track.
-//   - Language: The language of the track (e.g., "eng" for English).
+//   - Language: The language of the track (e.g., "eng" for English), "und"
+//     if absent, or "eng" with WithEnglishLanguageDefault.
+//   - LanguageIETF: The BCP 47 language of the track (e.g., "en-US").
 //   - CodecID: The identifier for the codec used to encode the track.
+//   - CodecName: A human-readable name for the codec.
+//   - CodecSettings: A human-readable description of the codec settings.
+//   - CodecInfoURL, CodecDownloadURL: URLs about the codec and to download it.
 //   - CodecPrivate: Private data for the codec.
+//   - CodecDelay: The built-in delay of the codec in nanoseconds.
+//   - SeekPreRoll: The amount of data in nanoseconds to decode and discard after a seek.
 //   - Video: Video-specific information (parsed by parseVideoTrack).
 //   - Audio: Audio-specific information (parsed by parseAudioTrack).
+//   - ContentEncodings: Compression and encryption settings (parsed by parseContentEncodings).
+//   - Operation: How a virtual track is built from other tracks (parsed by parseTrackOperation).
 //
 // This method initializes a TrackInfo struct with default values and then updates
 // it with the values found in the TrackEntry element. If the track is a video
@@ -544,7 +854,10 @@ func (mp *MatroskaParser) parseTrackEntry(data []byte) (*TrackInfo, error) {
 		Default:       true,
 		Lacing:        true,
 		TimecodeScale: 1.0,
-		Language:      "eng",
+		Language:      "und",
+	}
+	if mp.englishDefault {
+		track.Language = "eng"
 	}
 
 	reader := bytes.NewReader(data)
@@ -561,21 +874,51 @@ func (mp *MatroskaParser) parseTrackEntry(data []byte) (*TrackInfo, error) {
 
 		switch element.ID {
 		case IDTrackNum:
-			track.Number = uint8(element.ReadUInt())
+			track.Number = element.ReadUInt()
 		case IDTrackUID:
 			track.UID = element.ReadUInt()
 		case IDTrackType:
-			track.Type = uint8(element.ReadUInt())
+			track.Type = TrackType(element.ReadUInt())
+		case IDFlagEnabled:
+			track.Enabled = element.ReadUInt() != 0
+		case IDFlagDefault:
+			track.Default = element.ReadUInt() != 0
+		case IDFlagForced:
+			track.Forced = element.ReadUInt() != 0
+		case IDFlagLacing:
+			track.Lacing = element.ReadUInt() != 0
+		case IDMaxBlockAddID:
+			track.MaxBlockAdditionID = uint32(element.ReadUInt())
+		case IDTrackOverlay:
+			track.TrackOverlay = append(track.TrackOverlay, element.ReadUInt())
+		case IDDefaultDuration:
+			track.DefaultDuration = element.ReadUInt()
+		case IDDefaultDecodedFieldDuration:
+			track.Video.DefaultDecodedFieldDuration = element.ReadUInt()
 		case IDTrackName:
 			track.Name = element.ReadString()
 		case IDLanguage:
 			if len(element.Data) >= 3 {
 				track.Language = string(element.Data[:3])
 			}
+		case IDLanguageIETF:
+			track.LanguageIETF = element.ReadString()
 		case IDCodecID:
 			track.CodecID = element.ReadString()
+		case IDCodecName:
+			track.CodecName = element.ReadString()
+		case IDCodecSettings:
+			track.CodecSettings = element.ReadString()
+		case IDCodecInfoURL:
+			track.CodecInfoURL = element.ReadString()
+		case IDCodecDownloadURL:
+			track.CodecDownloadURL = element.ReadString()
 		case IDCodecPriv:
 			track.CodecPrivate = element.ReadBytes()
+		case IDCodecDelay:
+			track.CodecDelay = element.ReadUInt()
+		case IDSeekPreRoll:
+			track.SeekPreRoll = element.ReadUInt()
 		case IDVideo:
 			if err = mp.parseVideoTrack(element.Data, track); err != nil {
 				return nil, err
@@ -584,39 +927,39 @@ func (mp *MatroskaParser) parseTrackEntry(data []byte) (*TrackInfo, error) {
 			if err = mp.parseAudioTrack(element.Data, track); err != nil {
 				return nil, err
 			}
+		case IDContentEncodings:
+			if err = mp.parseContentEncodings(element.Data, track); err != nil {
+				return nil, err
+			}
+		case IDTrackOperation:
+			if err = mp.parseTrackOperation(element.Data, track); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return track, nil
 }
 
-// parseVideoTrack parses video track information from the Matroska file.
-//
-// The Video element contains video-specific information for a track, such as
-// pixel dimensions, display dimensions, and interlacing settings. This method
-// reads the Video element and populates the Video field of the TrackInfo struct
-// with the parsed data.
-//
-// The Video element can contain the following child elements:
-//   - PixelWidth: The width of the video in pixels.
-//   - PixelHeight: The height of the video in pixels.
-//   - DisplayWidth: The width of the video when displayed (may differ from pixel width).
-//   - DisplayHeight: The height of the video when displayed (may differ from pixel height).
-//   - FlagInterlaced: Indicates whether the video is interlaced.
+// parseTrackOperation parses the TrackOperation of a virtual track.
 //
-// If the display dimensions are not specified in the file, this method sets them
-// to the pixel dimensions as a fallback.
+// The TrackOperation element can contain the following child elements:
+//   - TrackCombinePlanes: The TrackPlane elements of the tracks combined as
+//     planes of a video (parsed by parseTrackPlane).
+//   - TrackJoinBlocks: The TrackJoinUID elements of the tracks whose blocks
+//     are joined.
 //
 // Parameters:
-//   - data: The raw data of the Video element.
+//   - data: The raw data of the TrackOperation element.
 //   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
 //
 // Returns:
-//   - error: An error if the Video element could not be parsed.
-func (mp *MatroskaParser) parseVideoTrack(data []byte, track *TrackInfo) error {
+//   - error: An error if the TrackOperation element could not be parsed.
+func (mp *MatroskaParser) parseTrackOperation(data []byte, track *TrackInfo) error {
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
 
+	operation := &TrackOperation{}
 	for childReader.pos < int64(len(data)) {
 		element, err := childReader.ReadElement()
 		if err != nil {
@@ -626,117 +969,676 @@ func (mp *MatroskaParser) parseVideoTrack(data []byte, track *TrackInfo) error {
 			return err
 		}
 
-		switch element.ID {
-		case IDPixelWidth:
-			track.Video.PixelWidth = uint32(element.ReadUInt())
-		case IDPixelHeight:
-			track.Video.PixelHeight = uint32(element.ReadUInt())
-		case IDDisplayWidth:
-			track.Video.DisplayWidth = uint32(element.ReadUInt())
-		case IDDisplayHeight:
-			track.Video.DisplayHeight = uint32(element.ReadUInt())
-		case IDFlagInterlaced:
-			track.Video.Interlaced = element.ReadUInt() != 0
+		if element.ID != IDTrackCombinePlanes && element.ID != IDTrackJoinBlocks {
+			continue
 		}
-	}
 
-	// Set display dimensions to pixel dimensions if not specified
-	if track.Video.DisplayWidth == 0 {
-		track.Video.DisplayWidth = track.Video.PixelWidth
-	}
-	if track.Video.DisplayHeight == 0 {
-		track.Video.DisplayHeight = track.Video.PixelHeight
+		// Both elements are lists of a single kind of child
+		listReader := &EBMLReader{r: &seekableReader{bytes.NewReader(element.Data)}, pos: 0}
+		for listReader.pos < int64(len(element.Data)) {
+			child, errReadChild := listReader.ReadElement()
+			if errReadChild != nil {
+				if errReadChild == io.EOF {
+					break
+				}
+				return errReadChild
+			}
+
+			switch child.ID {
+			case IDTrackPlane:
+				plane, errParsePlane := mp.parseTrackPlane(child.Data)
+				if errParsePlane != nil {
+					return errParsePlane
+				}
+				operation.CombinePlanes = append(operation.CombinePlanes, *plane)
+			case IDTrackJoinUID:
+				operation.JoinBlocks = append(operation.JoinBlocks, child.ReadUInt())
+			}
+		}
 	}
 
+	track.Operation = operation
 	return nil
 }
 
-// parseAudioTrack parses audio track information from the Matroska file.
-//
-// The Audio element contains audio-specific information for a track, such as
-// sampling frequency, number of channels, and bit depth. This method reads the
-// Audio element and populates the Audio field of the TrackInfo struct with the
-// parsed data.
-//
-// The Audio element can contain the following child elements:
-//   - SamplingFrequency: The sampling frequency of the audio in Hz.
-//   - OutputSamplingFrequency: The output sampling frequency of the audio in Hz.
-//   - Channels: The number of audio channels.
-//   - BitDepth: The number of bits per sample.
+// parseTrackPlane parses a video plane combined by a TrackOperation.
 //
-// This method sets default values for the audio track (1 channel, 8000.0 Hz sampling
-// frequency) before parsing the element. If the output sampling frequency is not
-// specified in the file, this method sets it to the sampling frequency as a fallback.
+// The TrackPlane element can contain the following child elements:
+//   - TrackPlaneUID: The UID of the track used as the plane.
+//   - TrackPlaneType: The kind of plane, such as the left or right eye view.
 //
 // Parameters:
-//   - data: The raw data of the Audio element.
-//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//   - data: The raw data of the TrackPlane element.
 //
 // Returns:
-//   - error: An error if the Audio element could not be parsed.
-func (mp *MatroskaParser) parseAudioTrack(data []byte, track *TrackInfo) error {
-	// Set defaults
-	track.Audio.Channels = 1
-	track.Audio.SamplingFreq = 8000.0
-
+//   - *TrackPlane: The parsed plane.
+//   - error: An error if the TrackPlane element could not be parsed.
+func (mp *MatroskaParser) parseTrackPlane(data []byte) (*TrackPlane, error) {
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
 
+	plane := &TrackPlane{}
 	for childReader.pos < int64(len(data)) {
 		element, err := childReader.ReadElement()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return err
+			return nil, err
 		}
 
 		switch element.ID {
-		case IDSamplingFrequency:
-			track.Audio.SamplingFreq = element.ReadFloat()
-		case IDOutputSamplingFrequency:
-			track.Audio.OutputSamplingFreq = element.ReadFloat()
-		case IDChannels:
-			track.Audio.Channels = uint8(element.ReadUInt())
-		case IDBitDepth:
-			track.Audio.BitDepth = uint8(element.ReadUInt())
+		case IDTrackPlaneUID:
+			plane.UID = element.ReadUInt()
+		case IDTrackPlaneType:
+			plane.Type = element.ReadUInt()
 		}
 	}
 
-	// Set output sampling frequency if not specified
-	if track.Audio.OutputSamplingFreq == 0 {
-		track.Audio.OutputSamplingFreq = track.Audio.SamplingFreq
-	}
-
-	return nil
+	return plane, nil
 }
 
-// parseCues parses cue information for seeking from the Matroska file.
-//
-// The Cues element contains indexing information that enables efficient seeking
-// to specific positions in the file. This information is particularly useful
-// for media players that need to quickly jump to different timecodes in the file.
+// parseContentEncodings parses the content encodings of a track.
 //
-// This method parses the cue points and stores them for later use during seeking operations.
+// The ContentEncodings element lists the compression and encryption settings
+// applied to the track's data. Each ContentEncoding child is parsed by
+// parseContentEncoding and appended to the ContentEncodings field of the
+// TrackInfo struct. The first compression encoding is also reflected in the
+// CompEnabled, CompMethod and CompMethodPrivate fields.
 //
 // Parameters:
-//   - size: The size of the Cues element in bytes.
+//   - data: The raw data of the ContentEncodings element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
 //
 // Returns:
-//   - error: An error if the Cues element could not be parsed.
-func (mp *MatroskaParser) parseCues(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
-	if err != nil {
-		return err
-	}
-	mp.reader.pos += int64(n)
-
+//   - error: An error if the ContentEncodings element could not be parsed.
+func (mp *MatroskaParser) parseContentEncodings(data []byte, track *TrackInfo) error {
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
 
-	for childReader.pos < int64(size) {
-		element, errReadElement := childReader.ReadElement()
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		if element.ID != IDContentEncoding {
+			continue
+		}
+
+		encoding, errParseEncoding := mp.parseContentEncoding(element.Data)
+		if errParseEncoding != nil {
+			return errParseEncoding
+		}
+		track.ContentEncodings = append(track.ContentEncodings, *encoding)
+
+		if encoding.Compression != nil && !track.CompEnabled {
+			track.CompEnabled = true
+			track.CompMethod = uint32(encoding.Compression.Algo)
+			track.CompMethodPrivate = encoding.Compression.Settings
+		}
+	}
+
+	return nil
+}
+
+// parseContentEncoding parses a single content encoding of a track.
+//
+// The ContentEncoding element can contain the following child elements:
+//   - ContentEncodingOrder: The order in which the encoding was applied.
+//   - ContentEncodingScope: The parts of the track the encoding applies to.
+//   - ContentEncodingType: Whether the encoding is a compression or an encryption.
+//   - ContentCompression: The compression algorithm and its settings.
+//   - ContentEncryption: The encryption algorithm, key ID and AES settings.
+//
+// The scope defaults to the frames, and the type to compression. A compression
+// encoding without a ContentCompression element uses zlib.
+//
+// Parameters:
+//   - data: The raw data of the ContentEncoding element.
+//
+// Returns:
+//   - *ContentEncoding: The parsed content encoding.
+//   - error: An error if the ContentEncoding element could not be parsed.
+func (mp *MatroskaParser) parseContentEncoding(data []byte) (*ContentEncoding, error) {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	encoding := &ContentEncoding{
+		Scope: ContentEncodingScopeFrames,
+		Type:  ContentEncodingTypeCompression,
+	}
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch element.ID {
+		case IDContentEncodingOrder:
+			encoding.Order = element.ReadUInt()
+		case IDContentEncodingScope:
+			encoding.Scope = element.ReadUInt()
+		case IDContentEncodingType:
+			encoding.Type = element.ReadUInt()
+		case IDContentCompression:
+			compression, errParseCompression := mp.parseContentCompression(element.Data)
+			if errParseCompression != nil {
+				return nil, errParseCompression
+			}
+			encoding.Compression = compression
+		case IDContentEncryption:
+			encryption, errParseEncryption := mp.parseContentEncryption(element.Data)
+			if errParseEncryption != nil {
+				return nil, errParseEncryption
+			}
+			encoding.Encryption = encryption
+		}
+	}
+
+	switch encoding.Type {
+	case ContentEncodingTypeCompression:
+		encoding.Encryption = nil
+		if encoding.Compression == nil {
+			encoding.Compression = &ContentCompression{Algo: CompZlib}
+		}
+	case ContentEncodingTypeEncryption:
+		encoding.Compression = nil
+		if encoding.Encryption == nil {
+			encoding.Encryption = &ContentEncryption{}
+		}
+	}
+
+	return encoding, nil
+}
+
+// parseContentCompression parses the ContentCompression element of a content encoding.
+//
+// Parameters:
+//   - data: The raw data of the ContentCompression element.
+//
+// Returns:
+//   - *ContentCompression: The parsed compression settings.
+//   - error: An error if the ContentCompression element could not be parsed.
+func (mp *MatroskaParser) parseContentCompression(data []byte) (*ContentCompression, error) {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	compression := &ContentCompression{Algo: CompZlib}
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch element.ID {
+		case IDContentCompAlgo:
+			compression.Algo = element.ReadUInt()
+		case IDContentCompSettings:
+			compression.Settings = element.ReadBytes()
+		}
+	}
+
+	return compression, nil
+}
+
+// parseContentEncryption parses the ContentEncryption element of a content encoding.
+//
+// Parameters:
+//   - data: The raw data of the ContentEncryption element.
+//
+// Returns:
+//   - *ContentEncryption: The parsed encryption settings.
+//   - error: An error if the ContentEncryption element could not be parsed.
+func (mp *MatroskaParser) parseContentEncryption(data []byte) (*ContentEncryption, error) {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	encryption := &ContentEncryption{}
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch element.ID {
+		case IDContentEncAlgo:
+			encryption.Algo = element.ReadUInt()
+		case IDContentEncKeyID:
+			encryption.KeyID = element.ReadBytes()
+		case IDContentEncAESSettings:
+			settingsReader := &EBMLReader{r: &seekableReader{bytes.NewReader(element.Data)}, pos: 0}
+			for settingsReader.pos < int64(len(element.Data)) {
+				setting, errReadSetting := settingsReader.ReadElement()
+				if errReadSetting != nil {
+					if errReadSetting == io.EOF {
+						break
+					}
+					return nil, errReadSetting
+				}
+				if setting.ID == IDAESSettingsCipherMode {
+					encryption.AESCipherMode = setting.ReadUInt()
+				}
+			}
+		}
+	}
+
+	return encryption, nil
+}
+
+// parseVideoTrack parses video track information from the Matroska file.
+//
+// The Video element contains video-specific information for a track, such as
+// pixel dimensions, display dimensions, and interlacing settings. This method
+// reads the Video element and populates the Video field of the TrackInfo struct
+// with the parsed data.
+//
+// The Video element can contain the following child elements:
+//   - PixelWidth: The width of the video in pixels.
+//   - PixelHeight: The height of the video in pixels.
+//   - DisplayWidth: The width of the video when displayed (may differ from pixel width).
+//   - DisplayHeight: The height of the video when displayed (may differ from pixel height).
+//   - DisplayUnit: The unit of DisplayWidth and DisplayHeight.
+//   - AspectRatioType: How the aspect ratio may be changed during playback.
+//   - PixelCropTop, PixelCropBottom, PixelCropLeft, PixelCropRight: The number of
+//     pixels to remove from each edge of the decoded image.
+//   - FlagInterlaced: Indicates whether the video is interlaced.
+//   - FieldOrder: The field order of interlaced video.
+//   - ColourSpace: The FourCC of the pixel layout of uncompressed video.
+//   - Colour: Colour format information (parsed by parseColour).
+//   - Projection: Spherical video projection (parsed by parseProjection).
+//
+// If the display dimensions are not specified in the file and DisplayUnit is
+// pixels, this method sets them to the pixel dimensions minus any cropping as a
+// fallback. FieldOrder defaults to FieldOrderUndetermined.
+//
+// Parameters:
+//   - data: The raw data of the Video element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//
+// Returns:
+//   - error: An error if the Video element could not be parsed.
+func (mp *MatroskaParser) parseVideoTrack(data []byte, track *TrackInfo) error {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	track.Video.FieldOrder = FieldOrderUndetermined
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch element.ID {
+		case IDPixelWidth:
+			track.Video.PixelWidth = uint32(element.ReadUInt())
+		case IDPixelHeight:
+			track.Video.PixelHeight = uint32(element.ReadUInt())
+		case IDDisplayWidth:
+			track.Video.DisplayWidth = uint32(element.ReadUInt())
+		case IDDisplayHeight:
+			track.Video.DisplayHeight = uint32(element.ReadUInt())
+		case IDDisplayUnit:
+			track.Video.DisplayUnit = uint8(element.ReadUInt())
+		case IDAspectRatioType:
+			track.Video.AspectRatioType = uint8(element.ReadUInt())
+		case IDPixelCropBottom:
+			track.Video.CropB = uint32(element.ReadUInt())
+		case IDPixelCropTop:
+			track.Video.CropT = uint32(element.ReadUInt())
+		case IDPixelCropLeft:
+			track.Video.CropL = uint32(element.ReadUInt())
+		case IDPixelCropRight:
+			track.Video.CropR = uint32(element.ReadUInt())
+		case IDFlagInterlaced:
+			track.Video.Interlaced = element.ReadUInt() != 0
+		case IDFieldOrder:
+			track.Video.FieldOrder = FieldOrder(element.ReadUInt())
+		case IDColourSpace:
+			// A FourCC is always 4 bytes long
+			if len(element.Data) == 4 {
+				track.Video.ColourSpace = binary.LittleEndian.Uint32(element.Data)
+			}
+		case IDColour:
+			if errParseColour := mp.parseColour(element.Data, track); errParseColour != nil {
+				return errParseColour
+			}
+		case IDProjection:
+			if errParseProjection := mp.parseProjection(element.Data, track); errParseProjection != nil {
+				return errParseProjection
+			}
+		}
+	}
+
+	// Set display dimensions to the cropped pixel dimensions if not specified.
+	// The fallback only makes sense when the display dimensions are in pixels.
+	if track.Video.DisplayUnit == 0 {
+		if track.Video.DisplayWidth == 0 {
+			track.Video.DisplayWidth = croppedDimension(track.Video.PixelWidth, track.Video.CropL, track.Video.CropR)
+		}
+		if track.Video.DisplayHeight == 0 {
+			track.Video.DisplayHeight = croppedDimension(track.Video.PixelHeight, track.Video.CropT, track.Video.CropB)
+		}
+	}
+
+	return nil
+}
+
+// croppedDimension returns a pixel dimension with the given crop amounts removed.
+// If the crop amounts exceed the dimension, the uncropped dimension is returned.
+func croppedDimension(pixels, cropStart, cropEnd uint32) uint32 {
+	crop := uint64(cropStart) + uint64(cropEnd)
+	if crop >= uint64(pixels) {
+		return pixels
+	}
+	return pixels - uint32(crop)
+}
+
+// parseColour parses the colour information of a video track.
+//
+// The Colour element describes how the decoded video should be interpreted for
+// display, which is required to render HDR and wide-gamut content correctly.
+// This method reads the Colour element and populates the Video.Colour field of
+// the TrackInfo struct with the parsed data.
+//
+// The Colour element can contain the following child elements:
+//   - MatrixCoefficients: The matrix coefficients (ITU-T H.273).
+//   - BitsPerChannel: The number of decoded bits per colour channel.
+//   - ChromaSubsamplingHorz, ChromaSubsamplingVert: The chroma subsampling.
+//   - CbSubsamplingHorz, CbSubsamplingVert: The additional Cb subsampling.
+//   - ChromaSitingHorz, ChromaSitingVert: How chroma is sited.
+//   - Range: The clipping of the colour ranges.
+//   - TransferCharacteristics: The transfer characteristics (ITU-T H.273).
+//   - Primaries: The colour primaries (ITU-T H.273).
+//   - MaxCLL, MaxFALL: The maximum content and frame-average light levels.
+//   - MasteringMetadata: HDR10 static metadata (parsed by parseMasteringMetadata).
+//
+// MatrixCoefficients, TransferCharacteristics and Primaries default to 2
+// (unspecified), as defined by the Matroska specification.
+//
+// Parameters:
+//   - data: The raw data of the Colour element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//
+// Returns:
+//   - error: An error if the Colour element could not be parsed.
+func (mp *MatroskaParser) parseColour(data []byte, track *TrackInfo) error {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	colour := &track.Video.Colour
+	colour.MatrixCoefficients = 2
+	colour.TransferCharacteristics = 2
+	colour.Primaries = 2
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch element.ID {
+		case IDMatrixCoefficients:
+			colour.MatrixCoefficients = uint32(element.ReadUInt())
+		case IDBitsPerChannel:
+			colour.BitsPerChannel = uint32(element.ReadUInt())
+		case IDChromaSubsamplingHorz:
+			colour.ChromaSubsamplingHorz = uint32(element.ReadUInt())
+		case IDChromaSubsamplingVert:
+			colour.ChromaSubsamplingVert = uint32(element.ReadUInt())
+		case IDCbSubsamplingHorz:
+			colour.CbSubsamplingHorz = uint32(element.ReadUInt())
+		case IDCbSubsamplingVert:
+			colour.CbSubsamplingVert = uint32(element.ReadUInt())
+		case IDChromaSitingHorz:
+			colour.ChromaSitingHorz = uint32(element.ReadUInt())
+		case IDChromaSitingVert:
+			colour.ChromaSitingVert = uint32(element.ReadUInt())
+		case IDRange:
+			colour.Range = uint32(element.ReadUInt())
+		case IDTransferCharacteristics:
+			colour.TransferCharacteristics = uint32(element.ReadUInt())
+		case IDPrimaries:
+			colour.Primaries = uint32(element.ReadUInt())
+		case IDMaxCLL:
+			colour.MaxCLL = uint32(element.ReadUInt())
+		case IDMaxFALL:
+			colour.MaxFALL = uint32(element.ReadUInt())
+		case IDMasteringMetadata:
+			if errParseMastering := mp.parseMasteringMetadata(element.Data, track); errParseMastering != nil {
+				return errParseMastering
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseMasteringMetadata parses the SMPTE 2086 mastering display metadata of a
+// video track.
+//
+// The MasteringMetadata element carries the HDR10 static metadata that players
+// need to tone-map content for the display. This method reads the element and
+// populates the Video.Colour.MasteringMetadata field of the TrackInfo struct.
+//
+// The MasteringMetadata element can contain the following child elements:
+//   - PrimaryRChromaticityX/Y: The chromaticity of the red primary.
+//   - PrimaryGChromaticityX/Y: The chromaticity of the green primary.
+//   - PrimaryBChromaticityX/Y: The chromaticity of the blue primary.
+//   - WhitePointChromaticityX/Y: The chromaticity of the white point.
+//   - LuminanceMax: The maximum luminance in candelas per square meter.
+//   - LuminanceMin: The minimum luminance in candelas per square meter.
+//
+// All values are stored as floats, which may be encoded with either 4 or 8 bytes.
+//
+// Parameters:
+//   - data: The raw data of the MasteringMetadata element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//
+// Returns:
+//   - error: An error if the MasteringMetadata element could not be parsed.
+func (mp *MatroskaParser) parseMasteringMetadata(data []byte, track *TrackInfo) error {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	mastering := &track.Video.Colour.MasteringMetadata
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch element.ID {
+		case IDPrimaryRChromaticityX:
+			mastering.PrimaryRChromaticityX = float32(element.ReadFloat())
+		case IDPrimaryRChromaticityY:
+			mastering.PrimaryRChromaticityY = float32(element.ReadFloat())
+		case IDPrimaryGChromaticityX:
+			mastering.PrimaryGChromaticityX = float32(element.ReadFloat())
+		case IDPrimaryGChromaticityY:
+			mastering.PrimaryGChromaticityY = float32(element.ReadFloat())
+		case IDPrimaryBChromaticityX:
+			mastering.PrimaryBChromaticityX = float32(element.ReadFloat())
+		case IDPrimaryBChromaticityY:
+			mastering.PrimaryBChromaticityY = float32(element.ReadFloat())
+		case IDWhitePointChromaticityX:
+			mastering.WhitePointChromaticityX = float32(element.ReadFloat())
+		case IDWhitePointChromaticityY:
+			mastering.WhitePointChromaticityY = float32(element.ReadFloat())
+		case IDLuminanceMax:
+			mastering.LuminanceMax = float32(element.ReadFloat())
+		case IDLuminanceMin:
+			mastering.LuminanceMin = float32(element.ReadFloat())
+		}
+	}
+
+	return nil
+}
+
+// parseProjection parses the projection information of a video track.
+//
+// The Projection element describes how spherical (360 or VR) video is mapped
+// onto the frame, so that players can set up equirectangular or cubemap
+// rendering. This method reads the Projection element and populates the
+// Video.Projection field of the TrackInfo struct with the parsed data.
+//
+// The Projection element can contain the following child elements:
+//   - ProjectionType: The projection used (rectangular, equirectangular, cubemap, mesh).
+//   - ProjectionPrivate: Projection-specific private data.
+//   - ProjectionPoseYaw, ProjectionPosePitch, ProjectionPoseRoll: The rotation
+//     of the projection in degrees.
+//
+// Parameters:
+//   - data: The raw data of the Projection element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//
+// Returns:
+//   - error: An error if the Projection element could not be parsed.
+func (mp *MatroskaParser) parseProjection(data []byte, track *TrackInfo) error {
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	projection := &track.Video.Projection
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch element.ID {
+		case IDProjectionType:
+			projection.ProjectionType = uint8(element.ReadUInt())
+		case IDProjectionPrivate:
+			projection.ProjectionPrivate = element.ReadBytes()
+		case IDProjectionPoseYaw:
+			projection.PoseYaw = element.ReadFloat()
+		case IDProjectionPosePitch:
+			projection.PosePitch = element.ReadFloat()
+		case IDProjectionPoseRoll:
+			projection.PoseRoll = element.ReadFloat()
+		}
+	}
+
+	return nil
+}
+
+// parseAudioTrack parses audio track information from the Matroska file.
+//
+// The Audio element contains audio-specific information for a track, such as
+// sampling frequency, number of channels, and bit depth. This method reads the
+// Audio element and populates the Audio field of the TrackInfo struct with the
+// parsed data.
+//
+// The Audio element can contain the following child elements:
+//   - SamplingFrequency: The sampling frequency of the audio in Hz.
+//   - OutputSamplingFrequency: The output sampling frequency of the audio in Hz.
+//   - Channels: The number of audio channels.
+//   - BitDepth: The number of bits per sample.
+//   - Emphasis: The emphasis applied on the audio samples.
+//
+// This method sets default values for the audio track (1 channel, 8000.0 Hz sampling
+// frequency) before parsing the element. If the output sampling frequency is not
+// specified in the file, this method sets it to the sampling frequency as a fallback.
+//
+// Parameters:
+//   - data: The raw data of the Audio element.
+//   - track: A pointer to the TrackInfo struct to be updated with the parsed data.
+//
+// Returns:
+//   - error: An error if the Audio element could not be parsed.
+func (mp *MatroskaParser) parseAudioTrack(data []byte, track *TrackInfo) error {
+	// Set defaults
+	track.Audio.Channels = 1
+	track.Audio.SamplingFreq = 8000.0
+
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	for childReader.pos < int64(len(data)) {
+		element, err := childReader.ReadElement()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch element.ID {
+		case IDSamplingFrequency:
+			track.Audio.SamplingFreq = element.ReadFloat()
+		case IDOutputSamplingFrequency:
+			track.Audio.OutputSamplingFreq = element.ReadFloat()
+		case IDChannels:
+			track.Audio.Channels = uint8(element.ReadUInt())
+		case IDBitDepth:
+			track.Audio.BitDepth = uint8(element.ReadUInt())
+		case IDEmphasis:
+			track.Audio.Emphasis = uint8(element.ReadUInt())
+		}
+	}
+
+	// Set output sampling frequency if not specified
+	if track.Audio.OutputSamplingFreq == 0 {
+		track.Audio.OutputSamplingFreq = track.Audio.SamplingFreq
+	}
+
+	return nil
+}
+
+// parseCues parses cue information for seeking from the Matroska file.
+//
+// The Cues element contains indexing information that enables efficient seeking
+// to specific positions in the file. This information is particularly useful
+// for media players that need to quickly jump to different timecodes in the file.
+//
+// This method parses the cue points and stores them for later use during seeking operations.
+//
+// Parameters:
+//   - size: The size of the Cues element in bytes.
+//
+// Returns:
+//   - error: An error if the Cues element could not be parsed.
+func (mp *MatroskaParser) parseCues(size uint64) error {
+	data, err := mp.readMasterData(IDCues, size)
+	if err != nil {
+		return err
+	}
+
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	for childReader.pos < int64(size) {
+		element, errReadElement := childReader.ReadElement()
 		if errReadElement != nil {
 			if errReadElement == io.EOF {
 				break
@@ -809,7 +1711,7 @@ func (mp *MatroskaParser) parseCueTrackPositions(data []byte) (*Cue, error) {
 
 		switch element.ID {
 		case IDCueTrack:
-			cue.Track = uint8(element.ReadUInt())
+			cue.Track = element.ReadUInt()
 		case IDCueClusterPos:
 			cue.Position = element.ReadUInt()
 		case IDCueRelativePos:
@@ -838,12 +1740,10 @@ func (mp *MatroskaParser) parseCueTrackPositions(data []byte) (*Cue, error) {
 // Returns:
 //   - error: An error if the Chapters element could not be parsed.
 func (mp *MatroskaParser) parseChapters(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
+	data, err := mp.readMasterData(IDChapters, size)
 	if err != nil {
 		return err
 	}
-	mp.reader.pos += int64(n)
 
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
@@ -985,12 +1885,10 @@ func (mp *MatroskaParser) parseChapterDisplay(data []byte) (ChapterDisplay, erro
 // Returns:
 //   - error: An error if the Tags element could not be parsed.
 func (mp *MatroskaParser) parseTags(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
+	data, err := mp.readMasterData(IDTags, size)
 	if err != nil {
 		return err
 	}
-	mp.reader.pos += int64(n)
 
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
@@ -1131,12 +2029,10 @@ func (mp *MatroskaParser) parseSimpleTag(data []byte) (SimpleTag, error) {
 // Returns:
 //   - error: An error if the Attachments element could not be parsed.
 func (mp *MatroskaParser) parseAttachments(size uint64) error {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
+	data, err := mp.readMasterData(IDAttachments, size)
 	if err != nil {
 		return err
 	}
-	mp.reader.pos += int64(n)
 
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
@@ -1212,14 +2108,18 @@ func (mp *MatroskaParser) parseAttachedFile(data []byte) (*Attachment, error) {
 // it parses the block and returns a Packet struct containing the media data
 // and metadata.
 //
-// If the method encounters a Timestamp element within a cluster, it updates
-// the cluster timestamp accordingly. Unknown elements are skipped.
+// If the method encounters a Timestamp, Position or PrevSize element within a
+// cluster, it updates the cluster state accordingly. Unknown elements are skipped.
 //
 // Returns:
 //   - *Packet: A pointer to the parsed Packet struct containing the media data
 //     and metadata. Returns nil when the end of the file is reached.
-//   - error: An error if a packet could not be read or parsed. When the end
-//     of the file is reached, the error will be io.EOF.
+//   - error: An error if a packet could not be read or parsed, unless error
+//     recovery is enabled with WithErrorRecovery, in which case the parser
+//     skips to the next Cluster instead. When the end of the file is reached,
+//     the error will be io.EOF. If only the content encodings of a packet
+//     could not be undone, such as a corrupt zlib frame, that error is
+//     returned and the next call reads the following packet.
 //
 // Example:
 //
@@ -1235,10 +2135,266 @@ func (mp *MatroskaParser) parseAttachedFile(data []byte) (*Attachment, error) {
 //	    fmt.Printf("Track: %d, Timestamp: %d\n", packet.Track, packet.StartTime)
 //	}
 func (mp *MatroskaParser) ReadPacket() (*Packet, error) {
+	return mp.ReadPacketCtx(context.Background())
+}
+
+// ReadPacketCtx reads the next packet from the Matroska stream, aborting if
+// the context is cancelled.
+//
+// The context is checked before each element is read, so a read that has to
+// skip many elements, or to wait for a slow input, can be interrupted between
+// elements. See ReadPacket for the details of how packets are read.
+//
+// With WithFollow, the context is also the way to stop waiting for more data
+// at the end of a growing file.
+//
+// Parameters:
+//   - ctx: The context whose cancellation aborts the read.
+//
+// Returns:
+//   - *Packet: The next packet.
+//   - error: ctx.Err() if the context is cancelled, an error if a packet could
+//     not be read or parsed, or io.EOF.
+func (mp *MatroskaParser) ReadPacketCtx(ctx context.Context) (*Packet, error) {
+	if mp.resumePending {
+		if _, err := mp.reader.Seek(mp.resumePos, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek to the first cluster: %w", err)
+		}
+		mp.resumePending = false
+	}
 	for {
-		// Try to read next element
-		id, size, err := mp.reader.ReadElementHeader()
+		start := mp.reader.Position()
+		clusterTimestamp, clusterPosition, clusterPrevSize := mp.clusterTimestamp, mp.clusterPosition, mp.clusterPrevSize
+		silentTracks := mp.clusterSilentTracks
+		packet, err := mp.readPacket(ctx)
+		if err != nil {
+			if mp.followPoll > 0 && ctx.Err() == nil && mp.isFollowEnd(err) {
+				// Retry the unfinished read once more data has been written
+				mp.clusterTimestamp, mp.clusterPosition, mp.clusterPrevSize = clusterTimestamp, clusterPosition, clusterPrevSize
+				mp.clusterSilentTracks = silentTracks
+				if err = mp.waitForData(ctx, start); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err == io.EOF || ctx.Err() != nil || !mp.errorRecovery {
+				return nil, err
+			}
+			if err = mp.resyncToCluster(start + 1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err = mp.processPacket(packet); err != nil {
+			packet.Release()
+			return nil, err
+		}
+		mp.updateStats(packet)
+		mp.currentTimecode = packet.StartTime
+		if mp.rawTimestamps {
+			mp.rawPacketTimes(packet)
+		}
+		return packet, nil
+	}
+}
+
+// rawPacketTimes replaces the start and end times of a packet, in nanoseconds,
+// by the timestamps stored in the file, in timestamp units of the segment the
+// packet was read from. See WithRawTimestamps.
+//
+// Parameters:
+//   - packet: The packet that was read.
+func (mp *MatroskaParser) rawPacketTimes(packet *Packet) {
+	scale := mp.timecodeScale()
+	duration := (packet.EndTime - packet.StartTime + scale/2) / scale
+	packet.StartTime = packet.ClusterTimestamp + uint64(packet.BlockOffset)
+	packet.Duration = duration
+	packet.EndTime = packet.StartTime + duration
+}
+
+// updateStats adds a packet returned by ReadPacketCtx to the statistics of
+// its track.
+//
+// Parameters:
+//   - packet: The packet that was read.
+func (mp *MatroskaParser) updateStats(packet *Packet) {
+	if mp.stats == nil {
+		mp.stats = make(map[uint64]*TrackStats)
+	}
+	stats, ok := mp.stats[packet.Track]
+	if !ok {
+		stats = &TrackStats{FirstTimecode: packet.StartTime}
+		mp.stats[packet.Track] = stats
+	}
+	stats.PacketCount++
+	stats.TotalBytes += uint64(len(packet.Data))
+	stats.LastTimecode = packet.StartTime
+	if packet.Flags&KF != 0 {
+		stats.KeyframeCount++
+	}
+}
+
+// isFollowEnd reports whether a read error is caused by reaching the current
+// end of a growing file before the end of the segment. See WithFollow.
+//
+// Parameters:
+//   - err: The error returned by readPacket.
+//
+// Returns:
+//   - bool: True if the read should be retried once more data is written.
+func (mp *MatroskaParser) isFollowEnd(err error) bool {
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false
+	}
+	return mp.segment.Size == (1<<(7*8))-1 || uint64(mp.reader.Position()) < mp.segmentTopPos
+}
+
+// waitForData waits for the polling interval of WithFollow, and returns to
+// the position at which an unfinished read started. The lock of the Demuxer
+// is released during the wait.
+//
+// Parameters:
+//   - ctx: The context whose cancellation stops the wait.
+//   - start: The position to return to.
+//
+// Returns:
+//   - error: ctx.Err() if the context is cancelled, or an error if the
+//     position could not be restored.
+func (mp *MatroskaParser) waitForData(ctx context.Context, start int64) error {
+	if _, err := mp.reader.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to return to the unfinished element: %w", err)
+	}
+	if mp.waitLock != nil {
+		// Let the metadata accessors run while no data is read
+		mp.waitLock.Unlock()
+		defer mp.waitLock.Lock()
+	}
+	timer := time.NewTimer(mp.followPoll)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ReadPacketMask reads the next packet from the Matroska stream, skipping the
+// packets of the tracks set in mask instead of those of the current track mask.
+//
+// Parameters:
+//   - mask: A bitmask where bit N-1 set to 1 causes track number N to be ignored.
+//
+// Returns:
+//   - *Packet: The next packet.
+//   - error: An error if a packet could not be read, or io.EOF.
+func (mp *MatroskaParser) ReadPacketMask(mask uint64) (*Packet, error) {
+	previous := mp.currentTrackMask
+	mp.currentTrackMask = mask
+	defer func() {
+		mp.currentTrackMask = previous
+	}()
+	return mp.ReadPacket()
+}
+
+// resyncToCluster recovers from a corrupt stream by scanning forward, byte by
+// byte, for the ID of the next Cluster element.
+//
+// The scan starts at the given position if the input can seek there, and at
+// the current position otherwise. When a Cluster ID is found, its size is read
+// and the cluster state is reset, so that the following reads return the
+// blocks of that cluster.
+//
+// Parameters:
+//   - from: The position from which to start scanning.
+//
+// Returns:
+//   - error: io.EOF if the end of the stream is reached without finding a
+//     Cluster, or an error if the stream could not be read.
+func (mp *MatroskaParser) resyncToCluster(from int64) error {
+	// Non-seekable inputs continue scanning from the current position
+	_, _ = mp.reader.Seek(from, io.SeekStart)
+
+	var window uint32
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(mp.reader.r, b); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return io.EOF
+			}
+			return err
+		}
+		mp.reader.pos++
+
+		window = window<<8 | uint32(b[0])
+		if window != IDCluster {
+			continue
+		}
+		mp.clusterStart = mp.reader.Position() - 4
+
+		if _, err := mp.reader.ReadVInt(); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return io.EOF
+			}
+			return err
+		}
+		mp.resetClusterState()
+		mp.segmentIndex = mp.segmentAt(uint64(mp.reader.Position()))
+		return nil
+	}
+}
+
+// processPacket applies the per-track conversions configured on the parser to
+// a packet that has just been read. The track's content encodings, such as
+// encryption, header stripping and zlib compression, are undone first,
+// followed by the Annex B conversion and the PCM byte order normalization.
+//
+// Parameters:
+//   - packet: The packet to process in place.
+//
+// Returns:
+//   - error: An error if the packet's content encodings could not be undone.
+func (mp *MatroskaParser) processPacket(packet *Packet) error {
+	if track := mp.GetTrackByNumber(packet.Track); track != nil && len(track.ContentEncodings) > 0 {
+		data, err := mp.decodeFrame(track, packet.Data)
 		if err != nil {
+			return err
+		}
+		packet.Data = data
+	}
+	if converter, ok := mp.annexB[packet.Track]; ok {
+		converter.convert(packet)
+	}
+	if sampleSize, ok := mp.pcmSwap[packet.Track]; ok {
+		swapPCMEndianness(packet.Data, sampleSize)
+	}
+	return nil
+}
+
+// readPacket reads the next unmasked packet from the stream, without applying
+// any per-track conversions. See ReadPacket.
+//
+// Parameters:
+//   - ctx: The context checked before each element is read.
+//
+// Returns:
+//   - *Packet: The next packet.
+//   - error: ctx.Err() if the context is cancelled, an error if a packet could
+//     not be read or parsed, or io.EOF.
+func (mp *MatroskaParser) readPacket(ctx context.Context) (*Packet, error) {
+	var pending *pendingElement
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Try to read next element, unless the cluster header already has
+		elementStart, id, size := mp.reader.Position(), uint32(0), uint64(0)
+		var err error
+		if pending != nil {
+			elementStart, id, size = pending.position, pending.id, pending.size
+			pending = nil
+		} else if id, size, err = mp.reader.ReadElementHeader(); err != nil {
 			return nil, err
 		}
 
@@ -1247,49 +2403,10 @@ func (mp *MatroskaParser) ReadPacket() (*Packet, error) {
 
 		switch id {
 		case IDCluster:
-			// Start of a new cluster, reset timestamp and parse its children
-			mp.clusterTimestamp = 0
-			clusterEnd := mp.reader.Position() + int64(size)
-			for mp.reader.Position() < clusterEnd {
-				childID, childSize, childErr := mp.reader.ReadElementHeader()
-				if childErr != nil {
-					return nil, childErr
-				}
-				switch childID {
-				case IDTimestamp:
-					data := make([]byte, childSize)
-					if n, errReadFull := io.ReadFull(mp.reader.r, data); errReadFull != nil {
-						return nil, errReadFull
-					} else {
-						mp.reader.pos += int64(n)
-					}
-					element := &EBMLElement{ID: childID, Size: childSize, Data: data}
-					mp.clusterTimestamp = element.ReadUInt()
-				case IDSimpleBlock:
-					packet, parseErr = mp.parseSimpleBlock(childSize)
-					if parseErr != nil {
-						return nil, parseErr
-					}
-					if packet != nil {
-						if mp.currentTrackMask == 0 || (1<<(packet.Track-1))&mp.currentTrackMask == 0 {
-							return packet, nil
-						}
-					}
-				case IDBlockGroup:
-					packet, parseErr = mp.parseBlockGroup(childSize)
-					if parseErr != nil {
-						return nil, parseErr
-					}
-					if packet != nil {
-						if mp.currentTrackMask == 0 || (1<<(packet.Track-1))&mp.currentTrackMask == 0 {
-							return packet, nil
-						}
-					}
-				default:
-					if _, err = mp.reader.Seek(int64(childSize), io.SeekCurrent); err != nil {
-						return nil, err
-					}
-				}
+			// Start of a new cluster, whose blocks are read in turn
+			mp.clusterStart = elementStart
+			if pending, err = mp.parseClusterHeader(size); err != nil {
+				return nil, err
 			}
 			continue
 
@@ -1299,21 +2416,45 @@ func (mp *MatroskaParser) ReadPacket() (*Packet, error) {
 		case IDBlockGroup:
 			packet, parseErr = mp.parseBlockGroup(size)
 
-		case IDTimestamp:
-			// Update cluster timestamp
-			data := make([]byte, size)
-			if n, errReadFull := io.ReadFull(mp.reader.r, data); errReadFull != nil {
-				return nil, errReadFull
-			} else {
-				mp.reader.pos += int64(n)
+		case IDTimestamp, IDClusterPosition, IDPrevSize, IDSilentTracks:
+			// Update cluster state
+			data, errReadData := mp.reader.readData(size)
+			if errReadData != nil {
+				return nil, errReadData
+			}
+			mp.setClusterField(&EBMLElement{ID: id, Size: size, Data: data})
+			continue
+
+		case IDVoid:
+			// Padding between elements
+			if err = mp.skipElement(size); err != nil {
+				return nil, err
+			}
+			continue
+
+		case IDSegment:
+			// The next of several concatenated segments
+			if err = mp.enterSegment(size); err != nil {
+				return nil, err
+			}
+			continue
+
+		case IDTags, IDChapters, IDAttachments:
+			// A stream cannot come back to metadata after the clusters
+			if mp.avoidSeeks {
+				if err = mp.parseStreamMetadata(id, size); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err = mp.skipElement(size); err != nil {
+				return nil, err
 			}
-			element := &EBMLElement{ID: id, Size: size, Data: data}
-			mp.clusterTimestamp = element.ReadUInt()
 			continue
 
 		default:
 			// Skip unknown elements
-			if _, err = mp.reader.Seek(int64(size), io.SeekCurrent); err != nil {
+			if err = mp.skipElement(size); err != nil {
 				return nil, err
 			}
 			continue
@@ -1325,6 +2466,7 @@ func (mp *MatroskaParser) ReadPacket() (*Packet, error) {
 
 		if packet != nil {
 			if mp.currentTrackMask != 0 && (1<<(packet.Track-1))&mp.currentTrackMask != 0 {
+				packet.Release()
 				continue
 			}
 			return packet, nil
@@ -1332,59 +2474,174 @@ func (mp *MatroskaParser) ReadPacket() (*Packet, error) {
 	}
 }
 
-// parseClusterHeader parses the header of a Cluster element.
+// parseStreamMetadata parses a Tags, Chapters or Attachments element reached
+// by readPacket when the parser avoids seeks, as in a stream where they follow
+// the clusters. Their content is added to that of the segment's metadata.
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - error: An error if the element could not be parsed.
+func (mp *MatroskaParser) parseStreamMetadata(id uint32, size uint64) error {
+	switch id {
+	case IDTags:
+		if err := mp.parseTags(size); err != nil {
+			return fmt.Errorf("failed to parse tags: %w", err)
+		}
+	case IDChapters:
+		if err := mp.parseChapters(size); err != nil {
+			return fmt.Errorf("failed to parse chapters: %w", err)
+		}
+	case IDAttachments:
+		if err := mp.parseAttachments(size); err != nil {
+			return fmt.Errorf("failed to parse attachments: %w", err)
+		}
+	}
+	return nil
+}
+
+// pendingElement is an element whose header has been read, but not its data.
+type pendingElement struct {
+	id       uint32
+	size     uint64
+	position int64 // The position of the element's header
+}
+
+// parseClusterHeader parses the header of a Cluster element whose element
+// header has just been read.
 //
 // A Cluster is a top-level element that contains a group of blocks (media data)
 // that are related to each other, typically by time. The cluster header contains
-// metadata about the cluster, such as the timestamp.
-//
-// This method currently only resets the cluster timestamp to zero when a new cluster
-// is encountered. A more complete implementation would parse the cluster
-// header elements, such as the timestamp, and update the parser's state accordingly.
+// metadata about the cluster, such as the timestamp, the cluster's own position
+// within the segment and the size of the previous cluster.
+//
+// This method resets the cluster state, then reads the children of the cluster
+// from the stream and records the Timestamp, Position, PrevSize and
+// SilentTracks elements, which precede the blocks. It stops at the first other
+// element, usually the first block, whose header it returns so that the caller
+// continues with it without seeking back, or at the end of the cluster. As the
+// children are read one by one, this works the same for clusters of known and
+// unknown size, the latter ending at the first element that is not a child,
+// such as the next Cluster.
 //
 // Parameters:
-//   - size: The size of the Cluster element in bytes.
+//   - size: The size of the Cluster element in bytes, or the unknown size.
 //
 // Returns:
-//   - error: An error if the cluster header could not be parsed.
-func (mp *MatroskaParser) parseClusterHeader(size uint64) error {
-	// We need to find the timestamp of the cluster.
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
-	if err != nil {
-		return err
+//   - *pendingElement: The element following the header elements, whose data
+//     has not been read, or nil at the end of the cluster or of the input.
+//   - error: An error if the cluster header could not be read.
+func (mp *MatroskaParser) parseClusterHeader(size uint64) (*pendingElement, error) {
+	mp.resetClusterState()
+
+	unknown := size == (1<<(7*8))-1
+	end := mp.reader.Position() + int64(size)
+	for unknown || mp.reader.Position() < end {
+		position := mp.reader.Position()
+		id, childSize, err := mp.reader.ReadElementHeader()
+		if err != nil {
+			if err == io.EOF {
+				// A cluster without blocks at the end of the input
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !unknown && (childSize == (1<<(7*8))-1 || mp.reader.Position()+int64(childSize) > end) {
+			return nil, fmt.Errorf("element 0x%X at %d overflows its cluster", id, position)
+		}
+
+		switch id {
+		case IDTimestamp, IDClusterPosition, IDPrevSize, IDSilentTracks:
+			data, errReadData := mp.reader.readData(childSize)
+			if errReadData != nil {
+				return nil, errReadData
+			}
+			mp.setClusterField(&EBMLElement{ID: id, Size: childSize, Data: data})
+		case IDVoid, IDCRC32:
+			if err = mp.skipElement(childSize); err != nil {
+				return nil, err
+			}
+		default:
+			// Header elements always precede the blocks
+			return &pendingElement{id: id, size: childSize, position: position}, nil
+		}
 	}
-	mp.reader.pos += int64(n)
+	return nil, nil
+}
 
-	reader := bytes.NewReader(data)
-	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+// setClusterField stores the value of a cluster-level metadata element
+// (Timestamp, Position, PrevSize or SilentTracks) on the parser.
+//
+// The Position element is relative to the start of the segment data, so it is
+// converted to an absolute file offset before being stored.
+func (mp *MatroskaParser) setClusterField(element *EBMLElement) {
+	switch element.ID {
+	case IDTimestamp:
+		mp.clusterTimestamp = element.ReadUInt()
+	case IDClusterPosition:
+		mp.clusterPosition = mp.segmentPos + element.ReadUInt()
+	case IDPrevSize:
+		mp.clusterPrevSize = element.ReadUInt()
+	case IDSilentTracks:
+		mp.clusterSilentTracks = parseSilentTracks(element.Data)
+	}
+}
 
+// parseSilentTracks parses the track numbers listed in a SilentTracks
+// element. Malformed trailing data is ignored.
+//
+// Parameters:
+//   - data: The data of the SilentTracks element.
+//
+// Returns:
+//   - []uint64: The numbers of the silent tracks.
+func parseSilentTracks(data []byte) []uint64 {
+	var tracks []uint64
+	childReader := &EBMLReader{r: &seekableReader{bytes.NewReader(data)}, pos: 0}
 	for childReader.pos < int64(len(data)) {
-		element, errReadElement := childReader.ReadElement()
-		if errReadElement != nil {
-			if errReadElement == io.EOF {
-				break
-			}
-			return errReadElement
+		element, err := childReader.ReadElement()
+		if err != nil {
+			break
 		}
-
-		if element.ID == IDTimestamp {
-			mp.clusterTimestamp = element.ReadUInt()
-			// We found the timestamp, but we need to continue parsing the rest of the cluster
-			// so we have to seek back.
-			if _, err = mp.reader.Seek(int64(-size), io.SeekCurrent); err != nil {
-				return err
-			}
-			return nil
+		if element.ID == IDSilentTrackNum {
+			tracks = append(tracks, element.ReadUInt())
 		}
 	}
+	return tracks
+}
 
-	// Timestamp not found, which is weird, but let's seek back to where we were.
-	if _, err = mp.reader.Seek(int64(-size), io.SeekCurrent); err != nil {
-		return err
+// saveReadState saves the read position and the cluster, segment and track
+// mask state, for functions that read packets out of the normal order, such
+// as ComputeDuration.
+//
+// Returns:
+//   - func() error: A function that restores the saved state, and returns an
+//     error if the read position could not be restored.
+func (mp *MatroskaParser) saveReadState() func() error {
+	position := mp.reader.Position()
+	clusterTimestamp, clusterPosition, clusterPrevSize := mp.clusterTimestamp, mp.clusterPosition, mp.clusterPrevSize
+	clusterStart, silentTracks := mp.clusterStart, mp.clusterSilentTracks
+	trackMask, segmentIndex, lastPacketEnd := mp.currentTrackMask, mp.segmentIndex, mp.lastPacketEnd
+	return func() error {
+		mp.clusterTimestamp, mp.clusterPosition, mp.clusterPrevSize = clusterTimestamp, clusterPosition, clusterPrevSize
+		mp.clusterStart, mp.clusterSilentTracks = clusterStart, silentTracks
+		mp.currentTrackMask, mp.segmentIndex, mp.lastPacketEnd = trackMask, segmentIndex, lastPacketEnd
+		if _, err := mp.reader.Seek(position, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to restore position: %w", err)
+		}
+		return nil
 	}
+}
+
+// resetClusterState clears the cluster-level metadata when a new cluster
+// starts, as its elements are optional.
+func (mp *MatroskaParser) resetClusterState() {
 	mp.clusterTimestamp = 0
-	return nil
+	mp.clusterPosition = 0
+	mp.clusterPrevSize = 0
+	mp.clusterSilentTracks = nil
 }
 
 // parseSimpleBlock parses a simple block element from the Matroska file.
@@ -1399,6 +2656,7 @@ func (mp *MatroskaParser) parseClusterHeader(size uint64) error {
 //   - Reading the timestamp (relative to the cluster timestamp)
 //   - Reading the flags (which indicate keyframe status, discardable status, etc.)
 //   - Extracting the frame data, handling different lacing types if present
+//   - Deriving the end time from the track's DefaultDuration, if known
 //
 // Matroska supports three types of lacing for storing multiple frames in a single block:
 //   - Fixed-size lacing: All frames have the same size.
@@ -1413,46 +2671,26 @@ func (mp *MatroskaParser) parseClusterHeader(size uint64) error {
 //     and metadata.
 //   - error: An error if the SimpleBlock element could not be parsed.
 func (mp *MatroskaParser) parseSimpleBlock(size uint64) (*Packet, error) {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
+	data, buffer, err := mp.readBlockData(size)
 	if err != nil {
 		return nil, err
 	}
-	mp.reader.pos += int64(n)
-
-	if len(data) < 4 {
-		return nil, fmt.Errorf("block too short")
-	}
-
-	// Parse track number (VINT)
-	trackNum, trackBytes := mp.parseVInt(data)
-	if trackBytes == 0 {
-		return nil, fmt.Errorf("invalid track number")
-	}
-
-	// Parse timestamp (2 bytes, signed)
-	if len(data) < trackBytes+2 {
-		return nil, fmt.Errorf("block too short for timestamp")
-	}
-
-	timestamp := int16(data[trackBytes])<<8 | int16(data[trackBytes+1])
 
-	// Parse flags
-	if len(data) < trackBytes+3 {
-		return nil, fmt.Errorf("block too short for flags")
+	trackNum, timestamp, flags, frameData, err := mp.parseBlockHeader(data)
+	if err != nil {
+		mp.releaseBuffer(buffer)
+		return nil, err
 	}
 
-	flags := data[trackBytes+2]
-
-	// Extract frame data, handling lacing
-	frameData := data[trackBytes+3:]
+	laceType, numFrames := blockLacing(flags, frameData)
 
 	// Check lacing flags (bits 1-0)
 	lacingType := flags & 0x06
 	if lacingType != 0 {
 		// Handle laced frames
 		if len(frameData) < 1 {
-			return nil, fmt.Errorf("laced block too short")
+			mp.releaseBuffer(buffer)
+			return nil, fmt.Errorf("%w: laced block has no frame count", ErrTruncatedBlock)
 		}
 
 		frameCount := int(frameData[0]) + 1
@@ -1474,32 +2712,12 @@ func (mp *MatroskaParser) parseSimpleBlock(size uint64) (*Packet, error) {
 		case 0x06: // Xiph lacing
 			// Parse Xiph lacing sizes
 			if frameCount > 1 {
-				frameSizes := make([]int, frameCount)
-				offset := 0
-
-				// Parse sizes for all frames except the last one
-				for i := 0; i < frameCount-1; i++ {
-					dataSize := 0
-					// Xiph lacing: sizes are encoded as a series of 255 bytes
-					// followed by the remainder
-					for offset < len(frameData) && frameData[offset] == 0xFF {
-						dataSize += 255
-						offset++
-					}
-					if offset < len(frameData) {
-						dataSize += int(frameData[offset])
-						offset++
-					}
-					frameSizes[i] = dataSize
+				frameSizes, offset, errXiphLacing := parseXiphLacing(frameData, frameCount)
+				if errXiphLacing != nil {
+					mp.releaseBuffer(buffer)
+					return nil, errXiphLacing
 				}
 
-				// Last frame size is the remainder
-				totalPrevFrames := 0
-				for i := 0; i < frameCount-1; i++ {
-					totalPrevFrames += frameSizes[i]
-				}
-				frameSizes[frameCount-1] = len(frameData) - offset - totalPrevFrames
-
 				// Extract the first frame (for simplicity, just return the first frame)
 				// In a full implementation, you'd want to return all frames
 				if frameSizes[0] > 0 && offset+frameSizes[0] <= len(frameData) {
@@ -1512,24 +2730,106 @@ func (mp *MatroskaParser) parseSimpleBlock(size uint64) (*Packet, error) {
 		}
 	}
 
-	scaledTime := (mp.clusterTimestamp + uint64(timestamp)) * mp.fileInfo.TimecodeScale
-	packet := &Packet{
-		Track:     uint8(trackNum),
-		StartTime: scaledTime,
-		EndTime:   scaledTime, // Will be updated if duration is known
-		FilePos:   uint64(mp.reader.Position()) - size,
-		Data:      frameData,
-		Flags:     uint32(flags),
+	scaledTime := mp.blockTime(mp.clusterTimestamp + uint64(timestamp))
+	duration := mp.trackDefaultDuration(trackNum)
+	packet, frameData := mp.newPacket(frameData)
+	*packet = Packet{
+		Track:            trackNum,
+		StartTime:        scaledTime,
+		EndTime:          scaledTime + duration,
+		Duration:         duration,
+		ClusterTimestamp: mp.clusterTimestamp,
+		BlockOffset:      timestamp,
+		ClusterTimecode:  mp.blockTime(mp.clusterTimestamp),
+		ClusterPos:       uint64(mp.clusterStart),
+		FilePos:          uint64(mp.reader.Position()) - size,
+		Data:             frameData,
+
+		LaceType:         laceType,
+		NumFramesInBlock: numFrames,
+
+		buffer: buffer,
+		pool:   mp.packetPool,
 	}
 
-	// Set keyframe flag if present
+	// Decode the block flags into the packet flags
 	if flags&0x80 != 0 {
 		packet.Flags |= KF
 	}
+	if flags&0x08 != 0 {
+		packet.Flags |= INVISIBLE
+	}
+	if flags&0x01 != 0 {
+		packet.Flags |= DISCARDABLE
+		packet.Discard = 1
+	}
 
+	mp.notePacketEnd(packet)
 	return packet, nil
 }
 
+// blockLacing returns the lacing type and the number of frames of a block.
+//
+// Parameters:
+//   - flags: The flags of the block.
+//   - frameData: The data of the block following its flags, which starts with
+//     the number of frames minus one when the block is laced.
+//
+// Returns:
+//   - LaceType: The lacing type, from bits 0x06 of the flags.
+//   - int: The number of frames in the block.
+func blockLacing(flags byte, frameData []byte) (LaceType, int) {
+	laceType := LaceType(flags&0x06) >> 1
+	if laceType == LaceNone || len(frameData) == 0 {
+		return laceType, 1
+	}
+	return laceType, int(frameData[0]) + 1
+}
+
+// parseXiphLacing parses the frame sizes of Xiph-laced data.
+//
+// In Xiph lacing, the size of every frame except the last is encoded as a run
+// of 0xFF bytes followed by a byte smaller than 0xFF, and the sizes are summed.
+// The last frame takes up the remaining data.
+//
+// Parameters:
+//   - data: The laced data, starting with the encoded frame sizes.
+//   - frameCount: The number of frames in the lace.
+//
+// Returns:
+//   - []int: The size of each frame.
+//   - int: The offset of the first frame's data, just past the encoded sizes.
+//   - error: An error if the sizes are truncated or exceed the data.
+func parseXiphLacing(data []byte, frameCount int) ([]int, int, error) {
+	frameSizes := make([]int, frameCount)
+	offset := 0
+	total := 0
+
+	// Parse sizes for all frames except the last one
+	for i := 0; i < frameCount-1; i++ {
+		dataSize := 0
+		for offset < len(data) && data[offset] == 0xFF {
+			dataSize += 255
+			offset++
+		}
+		if offset >= len(data) {
+			return nil, 0, fmt.Errorf("%w: xiph lacing size for frame %d is truncated", ErrTruncatedBlock, i)
+		}
+		dataSize += int(data[offset])
+		offset++
+		frameSizes[i] = dataSize
+		total += dataSize
+	}
+
+	// Last frame size is the remainder
+	frameSizes[frameCount-1] = len(data) - offset - total
+	if frameSizes[frameCount-1] < 0 {
+		return nil, 0, fmt.Errorf("%w: xiph lacing sizes exceed block data", ErrTruncatedBlock)
+	}
+
+	return frameSizes, offset, nil
+}
+
 // parseBlockGroup parses a block group element from the Matroska file.
 //
 // A BlockGroup element contains a block along with additional metadata, such as
@@ -1540,6 +2840,10 @@ func (mp *MatroskaParser) parseSimpleBlock(size uint64) (*Packet, error) {
 // the media data and metadata. The parsing process includes:
 //   - Reading the Block element, which contains the actual media data
 //   - Reading the BlockDuration element, which specifies the duration of the block
+//   - Reading the DiscardPadding element, which shortens the duration of the
+//     block by the silent data at its end
+//   - Reading the ReferenceBlock elements, whose presence marks the block as
+//     an inter frame instead of a keyframe
 //   - Extracting the frame data and metadata
 //
 // Unlike SimpleBlocks, BlockGroups do not have flags in the block header itself,
@@ -1553,66 +2857,169 @@ func (mp *MatroskaParser) parseSimpleBlock(size uint64) (*Packet, error) {
 //     and metadata.
 //   - error: An error if the BlockGroup element could not be parsed.
 func (mp *MatroskaParser) parseBlockGroup(size uint64) (*Packet, error) {
-	data := make([]byte, size)
-	n, err := io.ReadFull(mp.reader.r, data)
+	position := mp.reader.Position()
+	data, buffer, err := mp.readBlockData(size)
 	if err != nil {
 		return nil, err
 	}
-	mp.reader.pos += int64(n)
+	if mp.validateCRC {
+		if err = checkCRC32(IDBlockGroup, position, data); err != nil {
+			mp.releaseBuffer(buffer)
+			return nil, err
+		}
+	}
 
 	reader := bytes.NewReader(data)
 	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
 
 	var packet *Packet
 	var duration uint64
+	var discardPadding int64
+	var referenced bool
 
 	for childReader.pos < int64(len(data)) {
-		element, errReadElement := childReader.ReadElement()
-		if errReadElement != nil {
-			if errReadElement == io.EOF {
+		// The children are sliced from the data, so the frame data is not copied
+		childID, childSize, errReadHeader := childReader.ReadElementHeader()
+		if errReadHeader != nil {
+			if errReadHeader == io.EOF {
 				break
 			}
-			return nil, errReadElement
+			mp.releaseBuffer(buffer)
+			return nil, errReadHeader
+		}
+		if childSize == (1<<(7*8))-1 {
+			mp.releaseBuffer(buffer)
+			return nil, ErrUnknownSizeUnsupported
+		}
+		start := childReader.pos
+		if childSize > uint64(int64(len(data))-start) {
+			mp.releaseBuffer(buffer)
+			return nil, fmt.Errorf("failed to read element data: %w", io.ErrUnexpectedEOF)
+		}
+		element := &EBMLElement{ID: childID, Size: childSize, Data: data[start : start+int64(childSize)]}
+		childReader.pos += int64(childSize)
+		if _, err = reader.Seek(childReader.pos, io.SeekStart); err != nil {
+			mp.releaseBuffer(buffer)
+			return nil, err
 		}
 
 		switch element.ID {
 		case IDBlock:
 			// Parse block similar to simple block but without flags
-			blockData := element.Data
-			if len(blockData) < 4 {
-				return nil, fmt.Errorf("block too short")
+			trackNum, timestamp, flags, frameData, errParseBlockHeader := mp.parseBlockHeader(element.Data)
+			if errParseBlockHeader != nil {
+				mp.releaseBuffer(buffer)
+				return nil, errParseBlockHeader
 			}
 
-			trackNum, trackBytes := mp.parseVInt(blockData)
-			if trackBytes == 0 {
-				return nil, fmt.Errorf("invalid track number")
+			scaledTime := mp.blockTime(mp.clusterTimestamp + uint64(timestamp))
+			packet, frameData = mp.newPacket(frameData)
+			*packet = Packet{
+				Track:            trackNum,
+				StartTime:        scaledTime,
+				EndTime:          scaledTime,
+				ClusterTimestamp: mp.clusterTimestamp,
+				BlockOffset:      timestamp,
+				ClusterTimecode:  mp.blockTime(mp.clusterTimestamp),
+				ClusterPos:       uint64(mp.clusterStart),
+				FilePos:          uint64(mp.reader.Position()) - size,
+				Data:             frameData,
+				Flags:            KF, // Cleared below if the block references another
+
+				buffer: buffer,
+				pool:   mp.packetPool,
 			}
-
-			timestamp := int16(blockData[trackBytes])<<8 | int16(blockData[trackBytes+1])
-			frameData := blockData[trackBytes+3:] // Skip flags byte
-
-			scaledTime := (mp.clusterTimestamp + uint64(timestamp)) * mp.fileInfo.TimecodeScale
-			packet = &Packet{
-				Track:     uint8(trackNum),
-				StartTime: scaledTime,
-				EndTime:   scaledTime,
-				FilePos:   uint64(mp.reader.Position()) - size,
-				Data:      frameData,
-				Flags:     KF, // Block groups are typically keyframes
+			if flags&0x08 != 0 {
+				packet.Flags |= INVISIBLE
 			}
+			packet.LaceType, packet.NumFramesInBlock = blockLacing(flags, frameData)
 
-		case 0x9B: // BlockDuration
+		case IDBlockDuration:
 			duration = element.ReadUInt()
+		case IDDiscardPadding:
+			discardPadding = element.ReadInt()
+		case IDReferenceBlock:
+			referenced = true
 		}
 	}
 
-	if packet != nil && duration > 0 {
-		packet.EndTime = packet.StartTime + (duration * mp.fileInfo.TimecodeScale)
+	if packet != nil {
+		if referenced {
+			packet.Flags &^= KF
+		}
+		if duration > 0 {
+			packet.Duration = duration * mp.timecodeScale()
+		} else {
+			packet.Duration = mp.trackDefaultDuration(packet.Track)
+		}
+		// The padding is silent data at the end of the block, not played back
+		if packet.Duration > 0 && discardPadding > 0 {
+			if uint64(discardPadding) < packet.Duration {
+				packet.Duration -= uint64(discardPadding)
+			} else {
+				packet.Duration = 0
+			}
+		}
+		packet.EndTime = packet.StartTime + packet.Duration
+		mp.notePacketEnd(packet)
+	} else {
+		mp.releaseBuffer(buffer)
 	}
 
 	return packet, nil
 }
 
+// trackDefaultDuration returns the DefaultDuration in nanoseconds of the track
+// with the given track number, or 0 if the track is unknown or has none.
+func (mp *MatroskaParser) trackDefaultDuration(trackNum uint64) uint64 {
+	if track := mp.GetTrackByNumber(trackNum); track != nil {
+		return track.DefaultDuration
+	}
+	return 0
+}
+
+// parseBlockHeader parses the header shared by Block and SimpleBlock elements.
+//
+// The header consists of the track number (a VINT), a signed 16-bit timestamp
+// relative to the cluster timestamp and a flags byte. Every index into the data
+// is bounds-checked so that truncated or malformed blocks result in an error
+// instead of a panic.
+//
+// Parameters:
+//   - data: The raw data of the Block or SimpleBlock element.
+//
+// Returns:
+//   - uint64: The track number.
+//   - int16: The timestamp relative to the cluster timestamp.
+//   - byte: The block flags.
+//   - []byte: The remaining block payload following the header.
+//   - error: An error if the header is truncated or the track number is invalid.
+func (mp *MatroskaParser) parseBlockHeader(data []byte) (uint64, int16, byte, []byte, error) {
+	if len(data) < 4 {
+		return 0, 0, 0, nil, fmt.Errorf("%w: %d bytes", ErrTruncatedBlock, len(data))
+	}
+
+	// Parse track number (VINT)
+	trackNum, trackBytes := mp.parseVInt(data)
+	if trackBytes == 0 {
+		return 0, 0, 0, nil, fmt.Errorf("invalid track number")
+	}
+
+	// Parse timestamp (2 bytes, signed)
+	if len(data) < trackBytes+2 {
+		return 0, 0, 0, nil, fmt.Errorf("%w: missing timestamp", ErrTruncatedBlock)
+	}
+	timestamp := int16(data[trackBytes])<<8 | int16(data[trackBytes+1])
+
+	// Parse flags
+	if len(data) < trackBytes+3 {
+		return 0, 0, 0, nil, fmt.Errorf("%w: missing flags", ErrTruncatedBlock)
+	}
+	flags := data[trackBytes+2]
+
+	return trackNum, timestamp, flags, data[trackBytes+3:], nil
+}
+
 // parseVInt parses a variable-length integer (VINT) from the given data.
 //
 // Variable-length integers are used throughout Matroska and EBML to encode
@@ -1675,7 +3082,9 @@ func (mp *MatroskaParser) GetNumTracks() uint {
 	return uint(len(mp.tracks))
 }
 
-// GetTrackInfo returns information about a specific track
+// GetTrackInfo returns information about a specific track. The returned
+// TrackInfo is shared with the parser and must not be modified, see
+// GetTrackInfoCopy.
 func (mp *MatroskaParser) GetTrackInfo(track uint) *TrackInfo {
 	if track >= uint(len(mp.tracks)) {
 		return nil
@@ -1683,11 +3092,51 @@ func (mp *MatroskaParser) GetTrackInfo(track uint) *TrackInfo {
 	return mp.tracks[track]
 }
 
+// GetTrackInfoCopy returns a deep copy of the information about a specific
+// track, which the caller may modify, or nil if the track does not exist.
+func (mp *MatroskaParser) GetTrackInfoCopy(track uint) *TrackInfo {
+	return mp.GetTrackInfo(track).Clone()
+}
+
+// GetTrackByNumber returns information about the track with the given track
+// number, as referenced by blocks and packets, or nil if no such track exists.
+func (mp *MatroskaParser) GetTrackByNumber(num uint64) *TrackInfo {
+	for _, track := range mp.tracks {
+		if track.Number == num {
+			return track
+		}
+	}
+	return nil
+}
+
+// primaryTrack returns the first enabled track of a type, preferring one with
+// the FlagDefault flag set, or nil if there is no enabled track of the type.
+func (mp *MatroskaParser) primaryTrack(trackType TrackType) *TrackInfo {
+	var first *TrackInfo
+	for _, track := range mp.tracks {
+		if track.Type != trackType || !track.Enabled {
+			continue
+		}
+		if track.Default {
+			return track
+		}
+		if first == nil {
+			first = track
+		}
+	}
+	return first
+}
+
 // GetFileInfo returns file-level information
 func (mp *MatroskaParser) GetFileInfo() *SegmentInfo {
 	return mp.fileInfo
 }
 
+// GetEBMLHeader returns the EBML header of the file
+func (mp *MatroskaParser) GetEBMLHeader() *EBMLHeader {
+	return mp.header
+}
+
 // GetAttachments returns all attachments
 func (mp *MatroskaParser) GetAttachments() []*Attachment {
 	return mp.attachments
@@ -1703,6 +3152,15 @@ func (mp *MatroskaParser) GetTags() []*Tag {
 	return mp.tags
 }
 
+// GetSilentTracks returns the numbers of the tracks listed in the SilentTracks
+// element of the current cluster. See Demuxer.GetSilentTracks.
+//
+// Returns:
+//   - []uint64: The silent track numbers, or nil if the cluster lists none.
+func (mp *MatroskaParser) GetSilentTracks() []uint64 {
+	return mp.clusterSilentTracks
+}
+
 // GetCues returns all cues
 func (mp *MatroskaParser) GetCues() []*Cue {
 	return mp.cues
@@ -1736,6 +3194,7 @@ func (mp *MatroskaParser) Seek(timecode uint64, flags uint32) error {
 	if len(mp.cues) == 0 {
 		return fmt.Errorf("no cues available for seeking")
 	}
+	mp.Flush()
 
 	// Find the right cue point. Cues are sorted by time.
 	// We want to find the last cue point with time <= timecode.
@@ -1759,8 +3218,12 @@ func (mp *MatroskaParser) Seek(timecode uint64, flags uint32) error {
 		return fmt.Errorf("failed to seek to cue position: %w", err)
 	}
 
-	// Reset cluster parsing state so ReadPacket will look for a new cluster
+	// Reset cluster parsing state so ReadPacket will look for a new cluster.
+	// The cues index the first segment.
 	mp.clusterTimestamp = 0
+	mp.segmentIndex = 0
+	mp.resumePending = false
+	mp.currentTimecode = cue.Time
 	return nil
 }
 
@@ -1784,6 +3247,7 @@ func (mp *MatroskaParser) SkipToKeyframe() {
 		// Check if this is a keyframe and the track is not masked
 		isKeyframe := (packet.Flags & KF) != 0
 		isTrackEnabled := mp.currentTrackMask == 0 || (1<<(packet.Track-1))&mp.currentTrackMask == 0
+		packet.Release()
 
 		if isKeyframe && isTrackEnabled {
 			// Don't seek back - we've already consumed this packet
@@ -1795,5 +3259,113 @@ func (mp *MatroskaParser) SkipToKeyframe() {
 
 func (mp *MatroskaParser) SetTrackMask(mask uint64) {
 	mp.currentTrackMask = mask
-	// Here we could discard queued packets if we had a queue
+	mp.Flush()
+}
+
+// SetDecompression enables or disables undoing the content compression of
+// tracks, such as header stripping and zlib, in ReadPacket. It is enabled by
+// default; disable it to receive frames as they are stored in the file.
+//
+// Parameters:
+//   - enabled: Whether compressed frames should be decompressed.
+func (mp *MatroskaParser) SetDecompression(enabled bool) {
+	mp.noDecompression = !enabled
+}
+
+// SetDecryptionKey sets the key used to decrypt the frames of the track at the
+// given index in ReadPacket. A nil key removes the track's key, so that its
+// frames are returned encrypted. Invalid track indices are ignored.
+//
+// Parameters:
+//   - track: The index of the track, between 0 and GetNumTracks()-1.
+//   - key: The AES key of the track.
+func (mp *MatroskaParser) SetDecryptionKey(track uint, key []byte) {
+	info := mp.GetTrackInfo(track)
+	if info == nil {
+		return
+	}
+	if key == nil {
+		delete(mp.decryptionKeys, info.Number)
+		return
+	}
+	if mp.decryptionKeys == nil {
+		mp.decryptionKeys = make(map[uint64][]byte)
+	}
+	mp.decryptionKeys[info.Number] = append([]byte(nil), key...)
+}
+
+// SetPCMNormalize sets the byte order of the samples returned by ReadPacket
+// for the integer PCM track at the given index.
+//
+// The samples of "A_PCM/INT/BIG" and "A_PCM/INT/LIT" tracks of 16, 24 or 32
+// bits, according to the track's BitDepth, are byte-swapped when their byte
+// order differs from the requested one. Other tracks, including float PCM
+// tracks, 8-bit tracks and invalid track indices, are ignored.
+//
+// Parameters:
+//   - track: The index of the track, between 0 and GetNumTracks()-1.
+//   - little: True for little-endian samples, false for big-endian samples.
+func (mp *MatroskaParser) SetPCMNormalize(track uint, little bool) {
+	info := mp.GetTrackInfo(track)
+	if info == nil {
+		return
+	}
+
+	var sampleSize int
+	switch info.Audio.BitDepth {
+	case 16, 24, 32:
+		sampleSize = int(info.Audio.BitDepth) / 8
+	default:
+		return
+	}
+	var swap bool
+	switch info.CodecID {
+	case "A_PCM/INT/BIG":
+		swap = little
+	case "A_PCM/INT/LIT":
+		swap = !little
+	default:
+		return
+	}
+
+	if !swap {
+		delete(mp.pcmSwap, info.Number)
+		return
+	}
+	if mp.pcmSwap == nil {
+		mp.pcmSwap = make(map[uint64]int)
+	}
+	mp.pcmSwap[info.Number] = sampleSize
+}
+
+// SetAnnexBConversion enables or disables the automatic conversion of H.264 and
+// H.265 frames from AVCC to Annex B format for the track at the given index.
+//
+// When enabled, ReadPacket replaces the NAL unit length prefixes of every frame
+// with start codes, using the NAL unit length size declared in the track's
+// CodecPrivate, and prepends the parameter sets (VPS/SPS/PPS) to the first
+// keyframe. Tracks whose CodecID is not "V_MPEG4/ISO/AVC" or "V_MPEGH/ISO/HEVC",
+// and invalid track indices, are ignored.
+//
+// Parameters:
+//   - track: The index of the track, between 0 and GetNumTracks()-1.
+//   - enabled: Whether frames of the track should be converted.
+func (mp *MatroskaParser) SetAnnexBConversion(track uint, enabled bool) {
+	info := mp.GetTrackInfo(track)
+	if info == nil {
+		return
+	}
+	if !enabled {
+		delete(mp.annexB, info.Number)
+		return
+	}
+
+	converter := newAnnexBConverter(info)
+	if converter == nil {
+		return
+	}
+	if mp.annexB == nil {
+		mp.annexB = make(map[uint64]*annexBConverter)
+	}
+	mp.annexB[info.Number] = converter
 }
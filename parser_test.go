@@ -75,7 +75,7 @@ func TestParseSegmentInfo(t *testing.T) {
 	// TimestampScale
 	buf.Write([]byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}) // 1,000,000
 	// Duration
-	buf.Write([]byte{0x44, 0x89, 0x88, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x86, 0xA0}) // 100000
+	buf.Write([]byte{0x44, 0x89, 0x88, 0x40, 0xF8, 0x6A, 0x00, 0x00, 0x00, 0x00, 0x00}) // 100000.0
 
 	parser := &MatroskaParser{
 		reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
@@ -99,7 +99,108 @@ func TestParseSegmentInfo(t *testing.T) {
 		t.Errorf("Expected TimecodeScale 1000000, got %d", parser.fileInfo.TimecodeScale)
 	}
 	if parser.fileInfo.Duration != 100000 {
-		t.Errorf("Expected Duration 100000, got %d", parser.fileInfo.Duration)
+		t.Errorf("Expected Duration 100000, got %v", parser.fileInfo.Duration)
+	}
+}
+
+// TestParseSegmentInfo_ZeroTimecodeScale tests that a zero TimestampScale falls back to the default.
+func TestParseSegmentInfo_ZeroTimecodeScale(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0x2A, 0xD7, 0xB1, 0x81, 0x00}) // TimestampScale 0
+
+	parser := &MatroskaParser{
+		reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
+	}
+
+	if err := parser.parseSegmentInfo(uint64(buf.Len())); err != nil {
+		t.Fatalf("parseSegmentInfo() failed: %v", err)
+	}
+	if parser.fileInfo.TimecodeScale != 1000000 {
+		t.Errorf("Expected TimecodeScale 1000000, got %d", parser.fileInfo.TimecodeScale)
+	}
+	if !parser.fileInfo.TimecodeScaleInvalid {
+		t.Error("Expected TimecodeScaleInvalid to be set")
+	}
+}
+
+// TestSegmentInfo_DateUTCTime tests converting DateUTC to a time.Time.
+func TestSegmentInfo_DateUTCTime(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		want := time.Date(2024, time.March, 15, 12, 30, 45, 123456789, time.UTC)
+		offset := want.Sub(time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+		buf := new(bytes.Buffer)
+		buf.Write([]byte{0x44, 0x61, 0x88})
+		_ = binary.Write(buf, binary.BigEndian, int64(offset))
+
+		parser := &MatroskaParser{
+			reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
+		}
+		if err := parser.parseSegmentInfo(uint64(buf.Len())); err != nil {
+			t.Fatalf("parseSegmentInfo() failed: %v", err)
+		}
+
+		got := parser.fileInfo.DateUTCTime()
+		if !got.Equal(want) {
+			t.Errorf("DateUTCTime() = %v, want %v", got, want)
+		}
+		if int64(got.Sub(time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC))) != parser.fileInfo.DateUTC {
+			t.Errorf("DateUTCTime() does not round-trip to DateUTC %d", parser.fileInfo.DateUTC)
+		}
+	})
+
+	t.Run("Before the epoch", func(t *testing.T) {
+		info := &SegmentInfo{DateUTC: -int64(24 * time.Hour), DateUTCValid: true}
+		want := time.Date(2000, time.December, 31, 0, 0, 0, 0, time.UTC)
+		if got := info.DateUTCTime(); !got.Equal(want) {
+			t.Errorf("DateUTCTime() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		info := &SegmentInfo{DateUTC: 12345}
+		if got := info.DateUTCTime(); !got.IsZero() {
+			t.Errorf("DateUTCTime() = %v, want zero time", got)
+		}
+	})
+}
+
+// TestSegmentInfo_DurationNanos tests converting Duration to a time.Duration.
+func TestSegmentInfo_DurationNanos(t *testing.T) {
+	tests := []struct {
+		name string
+		info SegmentInfo
+		want time.Duration
+	}{
+		{"Milliseconds", SegmentInfo{Duration: 5000.5, TimecodeScale: 1000000}, 5000500 * time.Microsecond},
+		{"Custom scale", SegmentInfo{Duration: 90, TimecodeScale: 100000}, 9 * time.Millisecond},
+		{"Zero duration", SegmentInfo{TimecodeScale: 1000000}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.DurationNanos(); got != tt.want {
+				t.Errorf("DurationNanos() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseSegmentInfo_FractionalDuration tests that Duration is parsed as a float.
+func TestParseSegmentInfo_FractionalDuration(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0x44, 0x89, 0x88})
+	_ = binary.Write(buf, binary.BigEndian, math.Float64bits(12345.5))
+
+	parser := &MatroskaParser{
+		reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
+	}
+
+	if err := parser.parseSegmentInfo(uint64(buf.Len())); err != nil {
+		t.Fatalf("parseSegmentInfo() failed: %v", err)
+	}
+	if parser.fileInfo.Duration != 12345.5 {
+		t.Errorf("Expected Duration 12345.5, got %v", parser.fileInfo.Duration)
 	}
 }
 
@@ -164,6 +265,57 @@ func TestParseTracks(t *testing.T) {
 	}
 }
 
+// TestParseTracks_ChildrenLimit checks that the Tracks element is parsed one
+// TrackEntry at a time, so that only each entry is limited by the maximum
+// element size.
+func TestParseTracks_ChildrenLimit(t *testing.T) {
+	var tracksElement bytes.Buffer
+	var entrySize int
+	for number := uint8(1); number <= 3; number++ {
+		trackEntry, _ := createMockTrackEntry(number, TypeAudio, "A_AAC", "Audio", "eng")
+		putElement(&tracksElement, IDTrackEntry, trackEntry)
+		entrySize = len(trackEntry)
+	}
+
+	t.Run("Element larger than the limit", func(t *testing.T) {
+		parser := &MatroskaParser{
+			reader:   NewEBMLReader(bytes.NewReader(tracksElement.Bytes())),
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+		parser.reader.SetMaxElementSize(uint64(entrySize))
+		if err := parser.parseTracks(uint64(tracksElement.Len())); err != nil {
+			t.Fatalf("parseTracks() failed: %v", err)
+		}
+		if len(parser.tracks) != 3 {
+			t.Errorf("Expected 3 tracks, got %d", len(parser.tracks))
+		}
+		if position := parser.reader.Position(); position != int64(tracksElement.Len()) {
+			t.Errorf("Expected the reader at the end of the element, got %d", position)
+		}
+	})
+
+	t.Run("Child larger than the limit", func(t *testing.T) {
+		parser := &MatroskaParser{
+			reader:   NewEBMLReader(bytes.NewReader(tracksElement.Bytes())),
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+		parser.reader.SetMaxElementSize(uint64(entrySize - 1))
+		if err := parser.parseTracks(uint64(tracksElement.Len())); !errors.Is(err, ErrElementTooLarge) {
+			t.Errorf("Expected ErrElementTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Child past the end of the element", func(t *testing.T) {
+		parser := &MatroskaParser{
+			reader:   NewEBMLReader(bytes.NewReader(tracksElement.Bytes())),
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+		if err := parser.parseTracks(uint64(tracksElement.Len() - 1)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+}
+
 // TestParseSimpleBlock tests the parsing of a SimpleBlock.
 func TestParseSimpleBlock(t *testing.T) {
 	// SimpleBlock: Track 1, Timecode 1234, Flags 0x80 (Keyframe), Data "frame"
@@ -202,6 +354,79 @@ func TestParseSimpleBlock(t *testing.T) {
 	}
 }
 
+// TestParseSimpleBlock_MultiByteTrackNumber tests that the timestamp and the
+// flags of blocks are read after track numbers encoded on several bytes.
+func TestParseSimpleBlock_MultiByteTrackNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		trackNum []byte
+		expected uint64
+	}{
+		{"1-byte track number", []byte{0x81}, 1},
+		{"2-byte track number", []byte{0x40, 0xC8}, 200},
+		{"2-byte encoding of a small track number", []byte{0x40, 0x01}, 1},
+		{"3-byte track number", []byte{0x21, 0x11, 0x70}, 70000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Timecode 0x0102, Flags 0x88 (keyframe, invisible), Data "frame"
+			blockData := append(append([]byte{}, tt.trackNum...), 0x01, 0x02, 0x88, 'f', 'r', 'a', 'm', 'e')
+
+			parser := &MatroskaParser{
+				reader:   NewEBMLReader(bytes.NewReader(blockData)),
+				fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+			}
+			packet, err := parser.parseSimpleBlock(uint64(len(blockData)))
+			if err != nil {
+				t.Fatalf("parseSimpleBlock() failed: %v", err)
+			}
+			if packet.Track != tt.expected {
+				t.Errorf("Expected track %d, got %d", tt.expected, packet.Track)
+			}
+			if packet.BlockOffset != 0x0102 || packet.StartTime != 0x0102*1000000 {
+				t.Errorf("Expected timecode 258, got offset %d and start time %d", packet.BlockOffset, packet.StartTime)
+			}
+			if packet.Flags != KF|INVISIBLE {
+				t.Errorf("Expected keyframe and invisible flags, got 0x%X", packet.Flags)
+			}
+			if string(packet.Data) != "frame" {
+				t.Errorf("Expected data 'frame', got %q", string(packet.Data))
+			}
+
+			// The flags of a block cut after its timestamp are missing
+			truncated := blockData[:len(tt.trackNum)+2]
+			parser.reader = NewEBMLReader(bytes.NewReader(truncated))
+			if _, err = parser.parseSimpleBlock(uint64(len(truncated))); !errors.Is(err, ErrTruncatedBlock) {
+				t.Errorf("Expected ErrTruncatedBlock for a block without flags, got %v", err)
+			}
+		})
+	}
+
+	t.Run("ReadPacket", func(t *testing.T) {
+		trackEntry, err := createMockTrackEntry(200, TypeVideo, "V_TEST", "Video", "und")
+		if err != nil {
+			t.Fatalf("createMockTrackEntry() failed: %v", err)
+		}
+		data := createMockMatroskaFileWithTrack(trackEntry, []byte{0x40, 0xC8, 0x00, 0x0A, 0x80, 'f', 'r', 'a', 'm', 'e'})
+		demuxer, err := NewDemuxer(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if packet.Track != 200 || packet.StartTime != 10000000 || string(packet.Data) != "frame" {
+			t.Errorf("Expected frame of track 200 at 10000000, got %q of track %d at %d",
+				packet.Data, packet.Track, packet.StartTime)
+		}
+		if track, errTrack := demuxer.GetTrackByNumber(packet.Track); errTrack != nil || track.CodecID != "V_TEST" {
+			t.Errorf("Expected the packet's track to be found, got %v", errTrack)
+		}
+	})
+}
+
 // TestNewMatroskaParser_EdgeCases tests edge cases for NewMatroskaParser.
 func TestNewMatroskaParser_EdgeCases(t *testing.T) {
 	t.Run("Invalid reader - empty", func(t *testing.T) {
@@ -387,8 +612,33 @@ func TestParseHeader_EdgeCases(t *testing.T) {
 		}
 
 		err := parser.parseHeader()
-		if err == nil {
-			t.Errorf("Expected error for non-Matroska file header, but got nil")
+		if !errors.Is(err, ErrUnsupportedDocType) {
+			t.Errorf("Expected ErrUnsupportedDocType for non-Matroska file header, got %v", err)
+		}
+
+		_, err = NewMatroskaParser(bytes.NewReader(buf.Bytes()), false)
+		if !errors.Is(err, ErrUnsupportedDocType) {
+			t.Errorf("Expected NewMatroskaParser() to wrap ErrUnsupportedDocType, got %v", err)
+		}
+	})
+
+	t.Run("Unsupported read version", func(t *testing.T) {
+		file := buildTestFile(createEBMLHeader("matroska", MaxDocTypeReadVersion+1, MaxDocTypeReadVersion+1), nil,
+			mockInfoElement(nil),
+			mockTracksElement(t, TypeVideo, "V_VP9"),
+		)
+
+		_, err := NewMatroskaParser(bytes.NewReader(file.data), false)
+		if !errors.Is(err, ErrUnsupportedDocTypeVersion) {
+			t.Errorf("Expected NewMatroskaParser() to wrap ErrUnsupportedDocTypeVersion, got %v", err)
+		}
+
+		parser, err := NewMatroskaParserWithOptions(bytes.NewReader(file.data), WithBestEffortDocTypeVersion())
+		if err != nil {
+			t.Fatalf("NewMatroskaParserWithOptions() with WithBestEffortDocTypeVersion failed: %v", err)
+		}
+		if numTracks := parser.GetNumTracks(); numTracks != 1 {
+			t.Errorf("Expected 1 track, got %d", numTracks)
 		}
 	})
 }
@@ -482,6 +732,287 @@ func TestParseVideoTrack(t *testing.T) {
 			t.Errorf("expected interlaced=true")
 		}
 	})
+
+	t.Run("Interlaced with field order", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDFlagInterlaced, 1, 1)
+		writeUIntElement(buf, IDFieldOrder, uint64(FieldOrderBFF), 1)
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack(buf.Bytes(), track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+		if !track.Video.Interlaced {
+			t.Errorf("expected interlaced=true")
+		}
+		if track.Video.FieldOrder != FieldOrderBFF {
+			t.Errorf("Expected FieldOrder %d, got %d", FieldOrderBFF, track.Video.FieldOrder)
+		}
+	})
+
+	t.Run("Pixel cropping", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDPixelWidth, 1920, 2)
+		writeUIntElement(buf, IDPixelHeight, 1088, 2)
+		writeUIntElement(buf, IDPixelCropTop, 2, 1)
+		writeUIntElement(buf, IDPixelCropBottom, 6, 1)
+		writeUIntElement(buf, IDPixelCropLeft, 10, 1)
+		writeUIntElement(buf, IDPixelCropRight, 30, 1)
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack(buf.Bytes(), track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+		v := track.Video
+		if v.CropT != 2 || v.CropB != 6 || v.CropL != 10 || v.CropR != 30 {
+			t.Errorf("Unexpected crop T=%d B=%d L=%d R=%d", v.CropT, v.CropB, v.CropL, v.CropR)
+		}
+		if v.DisplayWidth != 1880 {
+			t.Errorf("Expected DisplayWidth 1880, got %d", v.DisplayWidth)
+		}
+		if v.DisplayHeight != 1080 {
+			t.Errorf("Expected DisplayHeight 1080, got %d", v.DisplayHeight)
+		}
+	})
+
+	t.Run("Pixel cropping larger than frame", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDPixelWidth, 16, 1)
+		writeUIntElement(buf, IDPixelCropLeft, 10, 1)
+		writeUIntElement(buf, IDPixelCropRight, 10, 1)
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack(buf.Bytes(), track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+		if track.Video.DisplayWidth != 16 {
+			t.Errorf("Expected DisplayWidth 16, got %d", track.Video.DisplayWidth)
+		}
+	})
+
+	t.Run("DisplayUnit as display aspect ratio", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDPixelWidth, 720, 2)
+		writeUIntElement(buf, IDPixelHeight, 576, 2)
+		writeUIntElement(buf, IDDisplayWidth, 16, 1)
+		writeUIntElement(buf, IDDisplayHeight, 9, 1)
+		writeUIntElement(buf, IDDisplayUnit, 3, 1)
+		writeUIntElement(buf, IDAspectRatioType, 1, 1)
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack(buf.Bytes(), track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+		if track.Video.DisplayUnit != 3 {
+			t.Errorf("Expected DisplayUnit 3, got %d", track.Video.DisplayUnit)
+		}
+		if track.Video.AspectRatioType != 1 {
+			t.Errorf("Expected AspectRatioType 1, got %d", track.Video.AspectRatioType)
+		}
+		if track.Video.DisplayWidth != 16 || track.Video.DisplayHeight != 9 {
+			t.Errorf("Expected display 16x9, got %dx%d", track.Video.DisplayWidth, track.Video.DisplayHeight)
+		}
+		if dar := track.DisplayAspectRatio(); math.Abs(dar-16.0/9.0) > 1e-9 {
+			t.Errorf("Expected DisplayAspectRatio 16/9, got %v", dar)
+		}
+	})
+
+	t.Run("Display aspect ratio without display height", func(t *testing.T) {
+		track := &TrackInfo{}
+		if dar := track.DisplayAspectRatio(); dar != 0 {
+			t.Errorf("Expected DisplayAspectRatio 0, got %v", dar)
+		}
+	})
+
+	t.Run("Field order defaults to undetermined", func(t *testing.T) {
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack([]byte{}, track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+		if track.Video.FieldOrder != FieldOrderUndetermined {
+			t.Errorf("Expected FieldOrder %d, got %d", FieldOrderUndetermined, track.Video.FieldOrder)
+		}
+	})
+}
+
+// TestParseColour tests the parsing of the Colour element of video tracks.
+func TestParseColour(t *testing.T) {
+	t.Run("HDR10 colour", func(t *testing.T) {
+		colour := new(bytes.Buffer)
+		writeUIntElement(colour, IDMatrixCoefficients, 9, 1)
+		writeUIntElement(colour, IDBitsPerChannel, 10, 1)
+		writeUIntElement(colour, IDChromaSubsamplingHorz, 1, 1)
+		writeUIntElement(colour, IDChromaSubsamplingVert, 1, 1)
+		writeUIntElement(colour, IDChromaSitingHorz, 1, 1)
+		writeUIntElement(colour, IDChromaSitingVert, 2, 1)
+		writeUIntElement(colour, IDRange, 1, 1)
+		writeUIntElement(colour, IDTransferCharacteristics, 16, 1)
+		writeUIntElement(colour, IDPrimaries, 9, 1)
+		writeUIntElement(colour, IDMaxCLL, 1000, 2)
+		writeUIntElement(colour, IDMaxFALL, 400, 2)
+
+		video := new(bytes.Buffer)
+		writeUIntElement(video, IDPixelWidth, 3840, 2)
+		video.Write([]byte{0x55, 0xB0})
+		video.Write(vintEncode(uint64(colour.Len())))
+		video.Write(colour.Bytes())
+
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseVideoTrack(video.Bytes(), track); err != nil {
+			t.Fatalf("parseVideoTrack() failed: %v", err)
+		}
+
+		c := track.Video.Colour
+		if c.MatrixCoefficients != 9 {
+			t.Errorf("Expected MatrixCoefficients 9, got %d", c.MatrixCoefficients)
+		}
+		if c.BitsPerChannel != 10 {
+			t.Errorf("Expected BitsPerChannel 10, got %d", c.BitsPerChannel)
+		}
+		if c.ChromaSubsamplingHorz != 1 || c.ChromaSubsamplingVert != 1 {
+			t.Errorf("Expected chroma subsampling 1x1, got %dx%d", c.ChromaSubsamplingHorz, c.ChromaSubsamplingVert)
+		}
+		if c.ChromaSitingHorz != 1 || c.ChromaSitingVert != 2 {
+			t.Errorf("Expected chroma siting 1/2, got %d/%d", c.ChromaSitingHorz, c.ChromaSitingVert)
+		}
+		if c.Range != 1 {
+			t.Errorf("Expected Range 1, got %d", c.Range)
+		}
+		if c.TransferCharacteristics != 16 {
+			t.Errorf("Expected TransferCharacteristics 16, got %d", c.TransferCharacteristics)
+		}
+		if c.Primaries != 9 {
+			t.Errorf("Expected Primaries 9, got %d", c.Primaries)
+		}
+		if c.MaxCLL != 1000 {
+			t.Errorf("Expected MaxCLL 1000, got %d", c.MaxCLL)
+		}
+		if c.MaxFALL != 400 {
+			t.Errorf("Expected MaxFALL 400, got %d", c.MaxFALL)
+		}
+		if track.Video.PixelWidth != 3840 {
+			t.Errorf("Expected PixelWidth 3840, got %d", track.Video.PixelWidth)
+		}
+	})
+
+	t.Run("MasteringMetadata with 4-byte and 8-byte floats", func(t *testing.T) {
+		writeFloat32 := func(buf *bytes.Buffer, id uint32, value float32) {
+			buf.Write([]byte{byte(id >> 8), byte(id), 0x84})
+			_ = binary.Write(buf, binary.BigEndian, math.Float32bits(value))
+		}
+		writeFloat64 := func(buf *bytes.Buffer, id uint32, value float64) {
+			buf.Write([]byte{byte(id >> 8), byte(id), 0x88})
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(value))
+		}
+
+		mastering := new(bytes.Buffer)
+		writeFloat32(mastering, IDPrimaryRChromaticityX, 0.708)
+		writeFloat32(mastering, IDPrimaryRChromaticityY, 0.292)
+		writeFloat64(mastering, IDPrimaryGChromaticityX, 0.170)
+		writeFloat64(mastering, IDPrimaryGChromaticityY, 0.797)
+		writeFloat32(mastering, IDPrimaryBChromaticityX, 0.131)
+		writeFloat32(mastering, IDPrimaryBChromaticityY, 0.046)
+		writeFloat32(mastering, IDWhitePointChromaticityX, 0.3127)
+		writeFloat32(mastering, IDWhitePointChromaticityY, 0.3290)
+		writeFloat64(mastering, IDLuminanceMax, 1000)
+		writeFloat32(mastering, IDLuminanceMin, 0.0001)
+
+		colour := new(bytes.Buffer)
+		colour.Write([]byte{0x55, 0xD0})
+		colour.Write(vintEncode(uint64(mastering.Len())))
+		colour.Write(mastering.Bytes())
+
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseColour(colour.Bytes(), track); err != nil {
+			t.Fatalf("parseColour() failed: %v", err)
+		}
+
+		m := track.Video.Colour.MasteringMetadata
+		if m.PrimaryRChromaticityX != 0.708 || m.PrimaryRChromaticityY != 0.292 {
+			t.Errorf("Unexpected red primary (%v, %v)", m.PrimaryRChromaticityX, m.PrimaryRChromaticityY)
+		}
+		if m.PrimaryGChromaticityX != 0.170 || m.PrimaryGChromaticityY != 0.797 {
+			t.Errorf("Unexpected green primary (%v, %v)", m.PrimaryGChromaticityX, m.PrimaryGChromaticityY)
+		}
+		if m.PrimaryBChromaticityX != 0.131 || m.PrimaryBChromaticityY != 0.046 {
+			t.Errorf("Unexpected blue primary (%v, %v)", m.PrimaryBChromaticityX, m.PrimaryBChromaticityY)
+		}
+		if m.WhitePointChromaticityX != 0.3127 || m.WhitePointChromaticityY != 0.3290 {
+			t.Errorf("Unexpected white point (%v, %v)", m.WhitePointChromaticityX, m.WhitePointChromaticityY)
+		}
+		if m.LuminanceMax != 1000 {
+			t.Errorf("Expected LuminanceMax 1000, got %v", m.LuminanceMax)
+		}
+		if m.LuminanceMin != 0.0001 {
+			t.Errorf("Expected LuminanceMin 0.0001, got %v", m.LuminanceMin)
+		}
+	})
+
+	t.Run("MasteringMetadata float with invalid size", func(t *testing.T) {
+		// LuminanceMax with a 2-byte payload, which is not a valid float size
+		data := []byte{0x55, 0xD9, 0x82, 0x01, 0x02}
+
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseMasteringMetadata(data, track); err != nil {
+			t.Fatalf("parseMasteringMetadata() failed: %v", err)
+		}
+		if track.Video.Colour.MasteringMetadata.LuminanceMax != 0 {
+			t.Errorf("Expected LuminanceMax 0, got %v", track.Video.Colour.MasteringMetadata.LuminanceMax)
+		}
+	})
+
+	t.Run("Empty colour uses defaults", func(t *testing.T) {
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseColour([]byte{}, track); err != nil {
+			t.Fatalf("parseColour() failed: %v", err)
+		}
+
+		c := track.Video.Colour
+		if c.MatrixCoefficients != 2 || c.TransferCharacteristics != 2 || c.Primaries != 2 {
+			t.Errorf("Expected unspecified (2) defaults, got matrix=%d transfer=%d primaries=%d",
+				c.MatrixCoefficients, c.TransferCharacteristics, c.Primaries)
+		}
+	})
+}
+
+// TestParseProjection tests the parsing of the Projection element of video tracks.
+func TestParseProjection(t *testing.T) {
+	projection := new(bytes.Buffer)
+	writeUIntElement(projection, IDProjectionType, 2, 1)
+	projection.Write([]byte{0x76, 0x72, 0x84, 0x00, 0x00, 0x00, 0x00})
+	projection.Write([]byte{0x76, 0x73, 0x88})
+	_ = binary.Write(projection, binary.BigEndian, math.Float64bits(90))
+	projection.Write([]byte{0x76, 0x74, 0x84})
+	_ = binary.Write(projection, binary.BigEndian, math.Float32bits(-45))
+	projection.Write([]byte{0x76, 0x75, 0x88})
+	_ = binary.Write(projection, binary.BigEndian, math.Float64bits(180))
+
+	video := new(bytes.Buffer)
+	video.Write([]byte{0x76, 0x70})
+	video.Write(vintEncode(uint64(projection.Len())))
+	video.Write(projection.Bytes())
+
+	parser := &MatroskaParser{}
+	track := &TrackInfo{}
+	if err := parser.parseVideoTrack(video.Bytes(), track); err != nil {
+		t.Fatalf("parseVideoTrack() failed: %v", err)
+	}
+
+	p := track.Video.Projection
+	if p.ProjectionType != 2 {
+		t.Errorf("Expected ProjectionType 2, got %d", p.ProjectionType)
+	}
+	if !bytes.Equal(p.ProjectionPrivate, []byte{0x00, 0x00, 0x00, 0x00}) {
+		t.Errorf("Unexpected ProjectionPrivate %v", p.ProjectionPrivate)
+	}
+	if p.PoseYaw != 90 || p.PosePitch != -45 || p.PoseRoll != 180 {
+		t.Errorf("Unexpected pose yaw=%v pitch=%v roll=%v", p.PoseYaw, p.PosePitch, p.PoseRoll)
+	}
 }
 
 // TestParseAudioTrack tests the parsing of audio track data.
@@ -529,6 +1060,36 @@ func TestParseAudioTrack(t *testing.T) {
 		}
 		// Should handle empty data gracefully
 	})
+
+	t.Run("Emphasis and channel layout", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDChannels, 6, 1)
+		writeUIntElement(buf, IDEmphasis, 1, 1)
+
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseAudioTrack(buf.Bytes(), track); err != nil {
+			t.Fatalf("parseAudioTrack() failed: %v", err)
+		}
+
+		if track.Audio.Emphasis != 1 {
+			t.Errorf("Expected Emphasis 1, got %d", track.Audio.Emphasis)
+		}
+		if layout := track.ChannelLayout(); layout != "5.1" {
+			t.Errorf("Expected channel layout '5.1', got %q", layout)
+		}
+	})
+
+	t.Run("Channel layout for channel counts", func(t *testing.T) {
+		tests := map[uint8]string{1: "mono", 2: "stereo", 8: "7.1", 0: "", 24: ""}
+		for channels, want := range tests {
+			track := &TrackInfo{}
+			track.Audio.Channels = channels
+			if got := track.ChannelLayout(); got != want {
+				t.Errorf("ChannelLayout() with %d channels = %q, want %q", channels, got, want)
+			}
+		}
+	})
 }
 
 // TestParseCues tests the parsing of Cues element.
@@ -1369,8 +1930,10 @@ func TestParseSimpleBlock_Advanced(t *testing.T) {
 		}{
 			{"Keyframe", 0x80, KF},
 			{"No flags", 0x00, 0},
-			{"Invisible", 0x08, 0},   // No specific constant for invisible
-			{"Discardable", 0x01, 0}, // No specific constant for discardable
+			{"Invisible", 0x08, INVISIBLE},
+			{"Discardable", 0x01, DISCARDABLE},
+			{"Invisible discardable keyframe", 0x89, KF | INVISIBLE | DISCARDABLE},
+			{"EBML lacing is not a keyframe", 0x04, 0},
 		}
 
 		for _, tc := range testCases {
@@ -1395,8 +1958,11 @@ func TestParseSimpleBlock_Advanced(t *testing.T) {
 					t.Fatalf("parseSimpleBlock() failed: %v", err)
 				}
 
-				if (packet.Flags&tc.expected) == 0 && tc.expected != 0 {
-					t.Errorf("Expected flag %d to be set, but it wasn't. Got flags: %d", tc.expected, packet.Flags)
+				if packet.Flags != tc.expected {
+					t.Errorf("Expected flags 0x%X, got 0x%X", tc.expected, packet.Flags)
+				}
+				if discardable := packet.Discard != 0; discardable != (tc.expected&DISCARDABLE != 0) {
+					t.Errorf("Expected Discard to match the DISCARDABLE flag, got %d", packet.Discard)
 				}
 			})
 		}
@@ -1430,6 +1996,63 @@ func TestParseSimpleBlock_Advanced(t *testing.T) {
 		}
 	})
 
+	t.Run("SimpleBlock end time from DefaultDuration", func(t *testing.T) {
+		blockData := []byte{
+			0x81,       // Track number 1
+			0x00, 0x0A, // Timecode 10
+			0x80,                    // Keyframe flag
+			'f', 'r', 'a', 'm', 'e', // Frame data
+		}
+
+		parser := &MatroskaParser{
+			reader: NewEBMLReader(bytes.NewReader(blockData)),
+			tracks: []*TrackInfo{{Number: 1, DefaultDuration: 40000000}},
+			fileInfo: &SegmentInfo{
+				TimecodeScale: uint64(time.Millisecond / time.Nanosecond),
+			},
+		}
+
+		packet, err := parser.parseSimpleBlock(uint64(len(blockData)))
+		if err != nil {
+			t.Fatalf("parseSimpleBlock() failed: %v", err)
+		}
+
+		if packet.StartTime != 10000000 {
+			t.Errorf("Expected start time 10000000, got %d", packet.StartTime)
+		}
+		if packet.EndTime != 50000000 {
+			t.Errorf("Expected end time 50000000, got %d", packet.EndTime)
+		}
+	})
+
+	t.Run("SimpleBlock with track number above 255", func(t *testing.T) {
+		blockData := []byte{
+			0x41, 0x2C, // Track number 300 (2-byte VINT)
+			0x00, 0x00, // Timecode 0
+			0x80,                    // Keyframe flag
+			'f', 'r', 'a', 'm', 'e', // Frame data
+		}
+
+		parser := &MatroskaParser{
+			reader: NewEBMLReader(bytes.NewReader(blockData)),
+			fileInfo: &SegmentInfo{
+				TimecodeScale: uint64(time.Millisecond / time.Nanosecond),
+			},
+		}
+
+		packet, err := parser.parseSimpleBlock(uint64(len(blockData)))
+		if err != nil {
+			t.Fatalf("parseSimpleBlock() failed: %v", err)
+		}
+
+		if packet.Track != 300 {
+			t.Errorf("Expected track 300, got %d", packet.Track)
+		}
+		if string(packet.Data) != "frame" {
+			t.Errorf("Expected data 'frame', got %q", packet.Data)
+		}
+	})
+
 	t.Run("SimpleBlock with invalid track number", func(t *testing.T) {
 		// Create SimpleBlock with invalid track number (0)
 		blockData := []byte{
@@ -1467,7 +2090,7 @@ func TestParseClusterHeader(t *testing.T) {
 			reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
 		}
 
-		err := parser.parseClusterHeader(uint64(buf.Len()))
+		_, err := parser.parseClusterHeader(uint64(buf.Len()))
 		if err != nil {
 			t.Fatalf("parseClusterHeader() failed: %v", err)
 		}
@@ -1477,12 +2100,69 @@ func TestParseClusterHeader(t *testing.T) {
 		}
 	})
 
+	t.Run("Position and PrevSize", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTimestamp, 2000, 2)
+		writeUIntElement(buf, IDClusterPosition, 4096, 2)
+		writeUIntElement(buf, IDPrevSize, 1234, 2)
+		// SimpleBlock following the header elements
+		buf.Write([]byte{0xA3, 0x85, 0x81, 0x00, 0x00, 0x80, 0x01})
+
+		parser := &MatroskaParser{
+			reader:     NewEBMLReader(bytes.NewReader(buf.Bytes())),
+			segmentPos: 100,
+		}
+
+		pending, err := parser.parseClusterHeader(uint64(buf.Len()))
+		if err != nil {
+			t.Fatalf("parseClusterHeader() failed: %v", err)
+		}
+		if parser.clusterTimestamp != 2000 {
+			t.Errorf("Expected cluster timestamp 2000, got %d", parser.clusterTimestamp)
+		}
+		if parser.clusterPosition != 4196 {
+			t.Errorf("Expected absolute cluster position 4196, got %d", parser.clusterPosition)
+		}
+		if parser.clusterPrevSize != 1234 {
+			t.Errorf("Expected previous cluster size 1234, got %d", parser.clusterPrevSize)
+		}
+		// The header of the first block is returned, and its data is next
+		blockStart := int64(buf.Len() - 7)
+		if pending == nil || pending.id != IDSimpleBlock || pending.size != 5 || pending.position != blockStart {
+			t.Errorf("Expected the SimpleBlock at %d to be pending, got %+v", blockStart, pending)
+		}
+		if parser.reader.Position() != blockStart+2 {
+			t.Errorf("Expected reader at the block data at %d, got %d", blockStart+2, parser.reader.Position())
+		}
+	})
+
+	t.Run("Unknown size", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTimestamp, 3000, 2)
+		// The next cluster ends this one
+		buf.Write([]byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+
+		parser := &MatroskaParser{
+			reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
+		}
+		pending, err := parser.parseClusterHeader((1 << (7 * 8)) - 1)
+		if err != nil {
+			t.Fatalf("parseClusterHeader() failed: %v", err)
+		}
+		if parser.clusterTimestamp != 3000 {
+			t.Errorf("Expected cluster timestamp 3000, got %d", parser.clusterTimestamp)
+		}
+		if pending == nil || pending.id != IDCluster || pending.position != 4 {
+			t.Errorf("Expected the next Cluster at 4 to be pending, got %+v", pending)
+		}
+	})
+
 	t.Run("Empty cluster header", func(t *testing.T) {
 		parser := &MatroskaParser{
 			reader: NewEBMLReader(bytes.NewReader([]byte{})),
 		}
 
-		err := parser.parseClusterHeader(0)
+		_, err := parser.parseClusterHeader(0)
 		if err != nil {
 			t.Fatalf("parseClusterHeader() with empty data failed: %v", err)
 		}
@@ -1499,7 +2179,7 @@ func TestParseClusterHeader(t *testing.T) {
 			reader: NewEBMLReader(bytes.NewReader(buf.Bytes())),
 		}
 
-		err := parser.parseClusterHeader(uint64(buf.Len()))
+		_, err := parser.parseClusterHeader(uint64(buf.Len()))
 		if err != nil {
 			t.Fatalf("parseClusterHeader() without timestamp failed: %v", err)
 		}
@@ -1517,7 +2197,7 @@ func TestParseClusterHeader(t *testing.T) {
 			reader: NewEBMLReader(bytes.NewReader(invalidData)),
 		}
 
-		err := parser.parseClusterHeader(uint64(len(invalidData)))
+		_, err := parser.parseClusterHeader(uint64(len(invalidData)))
 		if err == nil {
 			t.Error("Expected error for invalid cluster header data, but got nil")
 		}
@@ -1530,11 +2210,42 @@ func TestParseClusterHeader(t *testing.T) {
 			reader: NewEBMLReader(reader),
 		}
 
-		err := parser.parseClusterHeader(100) // Request more data than available
+		_, err := parser.parseClusterHeader(100) // Request more data than available
 		if err == nil {
 			t.Error("Expected error for ReadFull failure, but got nil")
 		}
 	})
+
+	t.Run("SilentTracks", func(t *testing.T) {
+		silent := new(bytes.Buffer)
+		putUIntElement(silent, IDSilentTrackNum, 2)
+		putUIntElement(silent, IDSilentTrackNum, 3)
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTimestamp, 0, 1)
+		putElement(buf, IDSilentTracks, silent.Bytes())
+
+		parser := &MatroskaParser{
+			reader:              NewEBMLReader(bytes.NewReader(buf.Bytes())),
+			clusterSilentTracks: []uint64{5},
+		}
+		if _, err := parser.parseClusterHeader(uint64(buf.Len())); err != nil {
+			t.Fatalf("parseClusterHeader() failed: %v", err)
+		}
+		if got := parser.GetSilentTracks(); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+			t.Errorf("Expected silent tracks [2 3], got %v", got)
+		}
+
+		// A cluster without SilentTracks has no silent tracks
+		buf.Reset()
+		writeUIntElement(buf, IDTimestamp, 0, 1)
+		parser.reader = NewEBMLReader(bytes.NewReader(buf.Bytes()))
+		if _, err := parser.parseClusterHeader(uint64(buf.Len())); err != nil {
+			t.Fatalf("parseClusterHeader() failed: %v", err)
+		}
+		if got := parser.GetSilentTracks(); got != nil {
+			t.Errorf("Expected no silent tracks, got %v", got)
+		}
+	})
 }
 
 // TestParseBlockGroup tests the parsing of BlockGroup element.
@@ -1819,7 +2530,7 @@ func TestParseSegmentInfo_Advanced(t *testing.T) {
 		// TimestampScale
 		buf.Write([]byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}) // 1,000,000
 		// Duration (as float)
-		buf.Write([]byte{0x44, 0x89, 0x88, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x86, 0xA0}) // Duration as 8-byte float
+		buf.Write([]byte{0x44, 0x89, 0x88, 0x40, 0xF8, 0x6A, 0x00, 0x00, 0x00, 0x00, 0x00}) // Duration as 8-byte float 100000.0
 		// DateUTC (as int)
 		buf.Write([]byte{0x44, 0x61, 0x88, 0x00, 0x00, 0x01, 0x86, 0xA0, 0x00, 0x00, 0x00}) // Some timestamp
 		// SegmentUID
@@ -1847,7 +2558,7 @@ func TestParseSegmentInfo_Advanced(t *testing.T) {
 			t.Errorf("Expected TimecodeScale 1000000, got %d", parser.fileInfo.TimecodeScale)
 		}
 		if parser.fileInfo.Duration != 100000 {
-			t.Errorf("Expected Duration 100000, got %d", parser.fileInfo.Duration)
+			t.Errorf("Expected Duration 100000, got %v", parser.fileInfo.Duration)
 		}
 	})
 
@@ -2125,11 +2836,213 @@ func TestParseTrackEntry_EdgeCases(t *testing.T) {
 		if track.Enabled != true {
 			t.Errorf("Expected default Enabled true, got %v", track.Enabled)
 		}
-		if track.Default != true {
-			t.Errorf("Expected default Default true, got %v", track.Default)
+		if track.Default != true {
+			t.Errorf("Expected default Default true, got %v", track.Default)
+		}
+		if track.Lacing != true {
+			t.Errorf("Expected default Lacing true, got %v", track.Lacing)
+		}
+		if track.Language != "und" {
+			t.Errorf("Expected default Language 'und', got %q", track.Language)
+		}
+	})
+
+	t.Run("TrackEntry with flags", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 3, 1)
+		writeUIntElement(buf, IDTrackType, uint64(TypeSubtitle), 1)
+		writeUIntElement(buf, IDFlagEnabled, 0, 1)
+		writeUIntElement(buf, IDFlagDefault, 0, 1)
+		writeUIntElement(buf, IDFlagForced, 1, 1)
+		writeUIntElement(buf, IDFlagLacing, 0, 1)
+		writeUIntElement(buf, IDDefaultDuration, 41708333, 4)
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with flags failed: %v", err)
+		}
+
+		if track.Enabled {
+			t.Errorf("Expected Enabled false, got %v", track.Enabled)
+		}
+		if track.Default {
+			t.Errorf("Expected Default false, got %v", track.Default)
+		}
+		if !track.Forced {
+			t.Errorf("Expected Forced true, got %v", track.Forced)
+		}
+		if track.Lacing {
+			t.Errorf("Expected Lacing false, got %v", track.Lacing)
+		}
+		if track.DefaultDuration != 41708333 {
+			t.Errorf("Expected DefaultDuration 41708333, got %d", track.DefaultDuration)
+		}
+	})
+
+	t.Run("TrackEntry with track number above 255", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 300, 2)
+		writeUIntElement(buf, IDTrackType, uint64(TypeAudio), 1)
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with large track number failed: %v", err)
+		}
+
+		if track.Number != 300 {
+			t.Errorf("Expected track number 300, got %d", track.Number)
+		}
+	})
+
+	t.Run("TrackEntry with Language and LanguageIETF", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 1, 1)
+		buf.Write([]byte{0x22, 0xB5, 0x9C, 0x83})
+		buf.WriteString("por")
+		buf.Write([]byte{0x22, 0xB5, 0x9D, 0x85})
+		buf.WriteString("pt-BR")
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with LanguageIETF failed: %v", err)
+		}
+
+		if track.Language != "por" {
+			t.Errorf("Expected Language 'por', got %q", track.Language)
+		}
+		if track.LanguageIETF != "pt-BR" {
+			t.Errorf("Expected LanguageIETF 'pt-BR', got %q", track.LanguageIETF)
+		}
+	})
+
+	t.Run("TrackEntry with ContentEncodings", func(t *testing.T) {
+		compression := new(bytes.Buffer)
+		writeUIntElement(compression, IDContentCompAlgo, CompPrepend, 1)
+		writeBinaryElement(compression, IDContentCompSettings, []byte{0xFF, 0xFB})
+
+		stripping := new(bytes.Buffer)
+		writeUIntElement(stripping, IDContentEncodingOrder, 0, 1)
+		writeBinaryElement(stripping, IDContentCompression, compression.Bytes())
+
+		aesSettings := new(bytes.Buffer)
+		writeUIntElement(aesSettings, IDAESSettingsCipherMode, 1, 1)
+		encryption := new(bytes.Buffer)
+		writeUIntElement(encryption, IDContentEncAlgo, 5, 1)
+		writeBinaryElement(encryption, IDContentEncKeyID, []byte{0x01, 0x02, 0x03, 0x04})
+		writeBinaryElement(encryption, IDContentEncAESSettings, aesSettings.Bytes())
+
+		encrypted := new(bytes.Buffer)
+		writeUIntElement(encrypted, IDContentEncodingOrder, 1, 1)
+		writeUIntElement(encrypted, IDContentEncodingType, ContentEncodingTypeEncryption, 1)
+		writeBinaryElement(encrypted, IDContentEncryption, encryption.Bytes())
+
+		encodings := new(bytes.Buffer)
+		writeBinaryElement(encodings, IDContentEncoding, stripping.Bytes())
+		writeBinaryElement(encodings, IDContentEncoding, encrypted.Bytes())
+
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 1, 1)
+		writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with ContentEncodings failed: %v", err)
+		}
+
+		if len(track.ContentEncodings) != 2 {
+			t.Fatalf("Expected 2 content encodings, got %d", len(track.ContentEncodings))
+		}
+
+		first := track.ContentEncodings[0]
+		if first.Order != 0 || first.Scope != ContentEncodingScopeFrames || first.Type != ContentEncodingTypeCompression {
+			t.Errorf("Unexpected first encoding: %+v", first)
+		}
+		if first.Compression == nil || first.Compression.Algo != CompPrepend || !bytes.Equal(first.Compression.Settings, []byte{0xFF, 0xFB}) {
+			t.Errorf("Unexpected compression: %+v", first.Compression)
+		}
+		if first.Encryption != nil {
+			t.Errorf("Expected no encryption on compression encoding")
+		}
+
+		second := track.ContentEncodings[1]
+		if second.Order != 1 || second.Type != ContentEncodingTypeEncryption {
+			t.Errorf("Unexpected second encoding: %+v", second)
+		}
+		if second.Encryption == nil || second.Encryption.Algo != 5 || second.Encryption.AESCipherMode != 1 ||
+			!bytes.Equal(second.Encryption.KeyID, []byte{0x01, 0x02, 0x03, 0x04}) {
+			t.Errorf("Unexpected encryption: %+v", second.Encryption)
+		}
+
+		if !track.CompEnabled || track.CompMethod != CompPrepend || !bytes.Equal(track.CompMethodPrivate, []byte{0xFF, 0xFB}) {
+			t.Errorf("Unexpected legacy compression fields: enabled=%v method=%d private=%x",
+				track.CompEnabled, track.CompMethod, track.CompMethodPrivate)
+		}
+	})
+
+	t.Run("ContentEncoding defaults to zlib compression", func(t *testing.T) {
+		encodings := new(bytes.Buffer)
+		writeBinaryElement(encodings, IDContentEncoding, nil)
+
+		parser := &MatroskaParser{}
+		track := &TrackInfo{}
+		if err := parser.parseContentEncodings(encodings.Bytes(), track); err != nil {
+			t.Fatalf("parseContentEncodings() failed: %v", err)
+		}
+		if len(track.ContentEncodings) != 1 {
+			t.Fatalf("Expected 1 content encoding, got %d", len(track.ContentEncodings))
+		}
+		if c := track.ContentEncodings[0].Compression; c == nil || c.Algo != CompZlib {
+			t.Errorf("Expected zlib compression, got %+v", c)
+		}
+	})
+
+	t.Run("TrackEntry with CodecName", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 1, 1)
+		buf.Write([]byte{0x86, 0x8F})
+		buf.WriteString("V_MPEG4/ISO/AVC")
+		name := "H.264 / AVC / MPEG-4 AVC"
+		buf.Write([]byte{0x25, 0x86, 0x88})
+		buf.Write(vintEncode(uint64(len(name))))
+		buf.WriteString(name)
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with CodecName failed: %v", err)
+		}
+
+		if track.CodecID != "V_MPEG4/ISO/AVC" {
+			t.Errorf("Expected CodecID 'V_MPEG4/ISO/AVC', got %q", track.CodecID)
+		}
+		if track.CodecName != name {
+			t.Errorf("Expected CodecName %q, got %q", name, track.CodecName)
+		}
+	})
+
+	t.Run("Opus TrackEntry with CodecDelay and SeekPreRoll", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		writeUIntElement(buf, IDTrackNum, 2, 1)
+		writeUIntElement(buf, IDTrackType, uint64(TypeAudio), 1)
+		buf.Write([]byte{0x86, 0x86, 'A', '_', 'O', 'P', 'U', 'S'})
+		writeUIntElement(buf, IDCodecDelay, 6500000, 4)
+		writeUIntElement(buf, IDSeekPreRoll, 80000000, 4)
+
+		parser := &MatroskaParser{}
+		track, err := parser.parseTrackEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() with Opus fields failed: %v", err)
+		}
+
+		if track.CodecDelay != 6500000 {
+			t.Errorf("Expected CodecDelay 6500000, got %d", track.CodecDelay)
 		}
-		if track.Language != "eng" {
-			t.Errorf("Expected default Language 'eng', got %q", track.Language)
+		if track.SeekPreRoll != 80000000 {
+			t.Errorf("Expected SeekPreRoll 80000000, got %d", track.SeekPreRoll)
 		}
 	})
 
@@ -2184,8 +3097,8 @@ func TestParseTrackEntry_EdgeCases(t *testing.T) {
 		}
 
 		// Language should remain default since the provided one was too short
-		if track.Language != "eng" {
-			t.Errorf("Expected default language 'eng' for short language field, got %q", track.Language)
+		if track.Language != "und" {
+			t.Errorf("Expected default language 'und' for short language field, got %q", track.Language)
 		}
 	})
 
@@ -3203,6 +4116,43 @@ func TestParseVideoTrack_Defaults(t *testing.T) {
 	}
 }
 
+// TestParseVideoTrack_ColourSpace tests the parsing of the FourCC of an
+// uncompressed video track.
+func TestParseVideoTrack_ColourSpace(t *testing.T) {
+	tests := []struct {
+		name       string
+		colorSpace []byte
+		expected   uint32
+	}{
+		// MAKEFOURCC('U', 'Y', 'V', 'Y')
+		{"UYVY", []byte("UYVY"), 0x59565955},
+		{"Invalid length", []byte("I42"), 0},
+		{"Absent", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var video bytes.Buffer
+			putUIntElement(&video, IDPixelWidth, 640)
+			putUIntElement(&video, IDPixelHeight, 480)
+			if tt.colorSpace != nil {
+				putElement(&video, IDColourSpace, tt.colorSpace)
+			}
+
+			parser := &MatroskaParser{}
+			track := &TrackInfo{}
+			if err := parser.parseVideoTrack(video.Bytes(), track); err != nil {
+				t.Fatalf("parseVideoTrack() failed: %v", err)
+			}
+			if track.Video.ColourSpace != tt.expected {
+				t.Errorf("Expected ColourSpace 0x%08X, got 0x%08X", tt.expected, track.Video.ColourSpace)
+			}
+			if track.Video.PixelWidth != 640 || track.Video.PixelHeight != 480 {
+				t.Errorf("Expected 640x480, got %dx%d", track.Video.PixelWidth, track.Video.PixelHeight)
+			}
+		})
+	}
+}
+
 // TestParseAudioTrack_Defaults verifies default channel/freq and OutputSamplingFreq fallback.
 func TestParseAudioTrack_Defaults(t *testing.T) {
 	parser := &MatroskaParser{}
@@ -3288,6 +4238,86 @@ func TestParseBlockGroup_WithDuration(t *testing.T) {
 	}
 }
 
+// TestPacket_Duration tests the Duration of the packets read from each source
+// of duration.
+func TestPacket_Duration(t *testing.T) {
+	tests := []struct {
+		name            string
+		simpleBlock     bool
+		defaultDuration uint64
+		children        []byte
+		expected        uint64
+	}{
+		{"SimpleBlock without duration", true, 0, nil, 0},
+		{"SimpleBlock with DefaultDuration", true, 40000000, nil, 40000000},
+		{"BlockGroup without duration", false, 0, nil, 0},
+		{"BlockGroup with DefaultDuration", false, 40000000, nil, 40000000},
+		// BlockDuration = 4, overriding the DefaultDuration
+		{"BlockGroup with BlockDuration", false, 40000000, []byte{0x9B, 0x81, 0x04}, 4000000},
+		// BlockDuration = 20, DiscardPadding = 6500000
+		{"BlockGroup with DiscardPadding", false, 0, []byte{0x9B, 0x81, 0x14, 0x75, 0xA2, 0x83, 0x63, 0x2E, 0xA0}, 13500000},
+		// DiscardPadding = 6500000
+		{"DiscardPadding with DefaultDuration", false, 40000000, []byte{0x75, 0xA2, 0x83, 0x63, 0x2E, 0xA0}, 33500000},
+		{"DiscardPadding without duration", false, 0, []byte{0x75, 0xA2, 0x83, 0x63, 0x2E, 0xA0}, 0},
+		// DiscardPadding = 50000000, longer than the block
+		{"DiscardPadding longer than the block", false, 40000000, []byte{0x75, 0xA2, 0x84, 0x02, 0xFA, 0xF0, 0x80}, 0},
+		// DiscardPadding = -1000000, at the start of the block
+		{"Negative DiscardPadding", false, 40000000, []byte{0x75, 0xA2, 0x83, 0xF0, 0xBD, 0xC0}, 40000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Track 1, timecode 10, keyframe, data "D"
+			block := []byte{0x81, 0x00, 0x0A, 0x80, 'D'}
+			data := block
+			if !tt.simpleBlock {
+				data = append([]byte{0xA1, byte(0x80 | len(block))}, block...)
+				data = append(data, tt.children...)
+			}
+
+			mp := &MatroskaParser{
+				reader:   NewEBMLReader(bytes.NewReader(data)),
+				fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+				tracks:   []*TrackInfo{{Number: 1, DefaultDuration: tt.defaultDuration}},
+			}
+			var packet *Packet
+			var err error
+			if tt.simpleBlock {
+				packet, err = mp.parseSimpleBlock(uint64(len(data)))
+			} else {
+				packet, err = mp.parseBlockGroup(uint64(len(data)))
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse block: %v", err)
+			}
+			if packet.Duration != tt.expected {
+				t.Errorf("Expected duration %d, got %d", tt.expected, packet.Duration)
+			}
+			if packet.StartTime != 10000000 || packet.EndTime != packet.StartTime+packet.Duration {
+				t.Errorf("Expected end time %d, got %d", packet.StartTime+packet.Duration, packet.EndTime)
+			}
+		})
+	}
+
+	t.Run("WithRawTimestamps", func(t *testing.T) {
+		// BlockDuration = 4
+		block := []byte{0xA1, 0x85, 0x81, 0x00, 0x0A, 0x80, 'D', 0x9B, 0x81, 0x04}
+		mp := &MatroskaParser{
+			reader:   NewEBMLReader(bytes.NewReader(block)),
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+		packet, err := mp.parseBlockGroup(uint64(len(block)))
+		if err != nil {
+			t.Fatalf("parseBlockGroup() failed: %v", err)
+		}
+		mp.rawPacketTimes(packet)
+		if packet.StartTime != 10 || packet.Duration != 4 || packet.EndTime != 14 {
+			t.Errorf("Expected times 10 to 14 lasting 4, got %d to %d lasting %d",
+				packet.StartTime, packet.EndTime, packet.Duration)
+		}
+	})
+}
+
 // TestReadPacket_TopLevelTimestamp_And_Mask exercises top-level Timestamp and mask filtering.
 func TestReadPacket_TopLevelTimestamp_And_Mask(t *testing.T) {
 	makeFile := func() []byte {
@@ -3462,8 +4492,8 @@ func TestParseSegmentInfo_Rich(t *testing.T) {
 	segInfo.Write([]byte{0x3E, 0x83, 0xBB, 0x85, 'n', '.', 'm', 'k', 'v'})
 	// TimestampScale 1,000,000
 	segInfo.Write([]byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40})
-	// Duration = 123 (as uint)
-	segInfo.Write([]byte{0x44, 0x89, 0x81, 0x7B})
+	// Duration = 123.0 (as 4-byte float)
+	segInfo.Write([]byte{0x44, 0x89, 0x84, 0x42, 0xF6, 0x00, 0x00})
 	// DateUTC (int64 as signed vint stored in ReadInt path via element.ReadInt; here emulate 8-byte int 0)
 	// We will skip setting DateUTC to keep test simple and stable.
 	// Title
@@ -3811,6 +4841,61 @@ func TestParseSimpleBlock_LacingVariants(t *testing.T) {
 	}
 }
 
+// TestParseSimpleBlock_LaceInfo tests the lacing type and frame count of packets.
+func TestParseSimpleBlock_LaceInfo(t *testing.T) {
+	testCases := []struct {
+		name      string
+		flags     byte
+		payload   []byte
+		laceType  LaceType
+		numFrames int
+	}{
+		{"No lacing", 0x80, []byte{'A', 'B'}, LaceNone, 1},
+		{"Xiph lacing", 0x82, []byte{0x02, 0x01, 0x01, 'A', 'B', 'C'}, LaceXiph, 3},
+		{"Fixed-size lacing", 0x84, []byte{0x01, 'A', 'B', 'C', 'D'}, LaceFixed, 2},
+		{"EBML lacing", 0x86, []byte{0x01, 0x01, 'A', 'B'}, LaceEBML, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blockData := append([]byte{0x81, 0x00, 0x00, tc.flags}, tc.payload...)
+			parser := &MatroskaParser{
+				reader:   NewEBMLReader(bytes.NewReader(blockData)),
+				fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+			}
+			packet, err := parser.parseSimpleBlock(uint64(len(blockData)))
+			if err != nil {
+				t.Fatalf("parseSimpleBlock() failed: %v", err)
+			}
+			if packet.LaceType != tc.laceType || packet.NumFramesInBlock != tc.numFrames {
+				t.Errorf("Expected %v lacing with %d frames, got %v with %d", tc.laceType, tc.numFrames, packet.LaceType, packet.NumFramesInBlock)
+			}
+		})
+	}
+
+	t.Run("Block in a BlockGroup", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		putElement(buf, IDBlock, []byte{0x81, 0x00, 0x00, 0x00, 'A'})
+		parser := &MatroskaParser{
+			reader:   NewEBMLReader(bytes.NewReader(buf.Bytes())),
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+		packet, err := parser.parseBlockGroup(uint64(buf.Len()))
+		if err != nil {
+			t.Fatalf("parseBlockGroup() failed: %v", err)
+		}
+		if packet.LaceType != LaceNone || packet.NumFramesInBlock != 1 {
+			t.Errorf("Expected no lacing with 1 frame, got %v with %d", packet.LaceType, packet.NumFramesInBlock)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		if LaceEBML.String() != "ebml" || LaceType(7).String() != "LaceType(7)" {
+			t.Errorf("Unexpected names %q and %q", LaceEBML.String(), LaceType(7).String())
+		}
+	})
+}
+
 // Fixed-size lacing variant to cover 0x02 branch
 func TestParseSimpleBlock_LacingFixed(t *testing.T) {
 	// Build fixed-size laced SimpleBlock with 2 frames of equal size
@@ -3900,6 +4985,76 @@ func TestParseSimpleBlock_ErrorPaths(t *testing.T) {
 	}
 }
 
+// TestParseBlocks_Truncated feeds every prefix of well-formed blocks to the
+// block parsers and checks that malformed input yields errors instead of panics.
+func TestParseBlocks_Truncated(t *testing.T) {
+	newParser := func(data []byte) *MatroskaParser {
+		return &MatroskaParser{
+			reader:   &EBMLReader{r: &seekableReader{bytes.NewReader(data)}, pos: 0},
+			fileInfo: &SegmentInfo{TimecodeScale: 1000000},
+		}
+	}
+
+	blocks := map[string][]byte{
+		"plain":        {0x81, 0x00, 0x01, 0x80, 'a', 'b', 'c'},
+		"8-byte track": {0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x80, 'x'},
+		"xiph laced":   {0x81, 0x00, 0x00, 0x86, 0x02, 0xFF, 0x01, 0x02},
+		"ebml laced":   {0x81, 0x00, 0x00, 0x84, 0x01, 0x82, 'a', 'b', 'c'},
+		"fixed laced":  {0x81, 0x00, 0x00, 0x82, 0x01, 'a', 'b', 'c', 'd'},
+	}
+
+	for name, block := range blocks {
+		t.Run(name, func(t *testing.T) {
+			for n := 0; n <= len(block); n++ {
+				data := block[:n]
+
+				_, _ = newParser(data).parseSimpleBlock(uint64(len(data)))
+
+				group := new(bytes.Buffer)
+				group.WriteByte(0xA1) // Block ID
+				group.Write(vintEncode(uint64(len(data))))
+				group.Write(data)
+				_, _ = newParser(group.Bytes()).parseBlockGroup(uint64(group.Len()))
+			}
+		})
+	}
+
+	t.Run("Block group with long track number", func(t *testing.T) {
+		// 8-byte track number VINT followed by only one more byte
+		block := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}
+		group := new(bytes.Buffer)
+		group.WriteByte(0xA1)
+		group.Write(vintEncode(uint64(len(block))))
+		group.Write(block)
+		if _, err := newParser(group.Bytes()).parseBlockGroup(uint64(group.Len())); !errors.Is(err, ErrTruncatedBlock) {
+			t.Errorf("expected ErrTruncatedBlock for truncated block header, got %v", err)
+		}
+	})
+
+	t.Run("Xiph lacing sizes exceed data", func(t *testing.T) {
+		// Two frames, first frame claims 255+16 bytes but only 2 bytes follow
+		block := []byte{0x81, 0x00, 0x00, 0x86, 0x01, 0xFF, 0x10, 'a', 'b'}
+		if _, err := newParser(block).parseSimpleBlock(uint64(len(block))); !errors.Is(err, ErrTruncatedBlock) {
+			t.Errorf("expected ErrTruncatedBlock for oversized xiph lacing, got %v", err)
+		}
+	})
+
+	t.Run("Xiph lacing size truncated", func(t *testing.T) {
+		// Three frames but the lacing sizes run off the end of the block
+		block := []byte{0x81, 0x00, 0x00, 0x86, 0x02, 0xFF, 0xFF}
+		if _, err := newParser(block).parseSimpleBlock(uint64(len(block))); !errors.Is(err, ErrTruncatedBlock) {
+			t.Errorf("expected ErrTruncatedBlock for truncated xiph lacing, got %v", err)
+		}
+	})
+
+	t.Run("SimpleBlock shorter than its size", func(t *testing.T) {
+		block := []byte{0x81, 0x00, 0x00, 0x80, 'a'}
+		if _, err := newParser(block).parseSimpleBlock(uint64(len(block) + 4)); !errors.Is(err, ErrTruncatedBlock) {
+			t.Errorf("expected ErrTruncatedBlock for short SimpleBlock, got %v", err)
+		}
+	})
+}
+
 // TestParseChapters tests the parsing of Chapters element.
 func TestParseChapters(t *testing.T) {
 	t.Run("Valid chapters data", func(t *testing.T) {
@@ -4586,17 +5741,21 @@ func TestParseSegment_CompleteFlow(t *testing.T) {
 
 // createMinimalEBMLHeader creates a minimal EBML header for testing
 func createMinimalEBMLHeader() []byte {
-	buf := new(bytes.Buffer)
-
-	// EBML Header content
-	ebmlHeader := new(bytes.Buffer)
-	ebmlHeader.Write([]byte{0x42, 0x82, 0x88, 'm', 'a', 't', 'r', 'o', 's', 'k', 'a'}) // DocType: "matroska"
-
-	// EBML Header element
-	buf.Write([]byte{0x1A, 0x45, 0xDF, 0xA3}) // EBML Header ID
-	buf.Write(vintEncode(uint64(ebmlHeader.Len())))
-	buf.Write(ebmlHeader.Bytes())
+	return createEBMLHeader("matroska", 0, 0)
+}
 
+// createEBMLHeader creates an EBML header with the given DocType, and with the
+// given DocTypeVersion and DocTypeReadVersion unless they are 0.
+func createEBMLHeader(docType string, version, readVersion uint64) []byte {
+	var header, buf bytes.Buffer
+	putStringElement(&header, IDEBMLDocType, docType)
+	if version > 0 {
+		putUIntElement(&header, IDEBMLDocTypeVersion, version)
+	}
+	if readVersion > 0 {
+		putUIntElement(&header, IDEBMLDocTypeReadVersion, readVersion)
+	}
+	putElement(&buf, IDEBMLHeader, header.Bytes())
 	return buf.Bytes()
 }
 
@@ -5186,6 +6345,57 @@ func TestReadPacket_ClusterHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("Cluster Position and PrevSize", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		buf.Write(createMinimalEBMLHeader())
+
+		segmentData := new(bytes.Buffer)
+		segmentInfo := new(bytes.Buffer)
+		segmentInfo.Write([]byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}) // TimestampScale: 1000000
+		segmentData.Write([]byte{0x15, 0x49, 0xA9, 0x66})                   // SegmentInfo ID
+		segmentData.Write(vintEncode(uint64(segmentInfo.Len())))
+		segmentData.Write(segmentInfo.Bytes())
+
+		trackEntry := new(bytes.Buffer)
+		trackEntry.Write([]byte{0xD7, 0x81, 0x01}) // TrackNumber: 1
+		trackEntry.Write([]byte{0x83, 0x81, 0x01}) // TrackType: 1 (video)
+		tracks := new(bytes.Buffer)
+		tracks.Write([]byte{0xAE}) // TrackEntry ID
+		tracks.Write(vintEncode(uint64(trackEntry.Len())))
+		tracks.Write(trackEntry.Bytes())
+		segmentData.Write([]byte{0x16, 0x54, 0xAE, 0x6B}) // Tracks ID
+		segmentData.Write(vintEncode(uint64(tracks.Len())))
+		segmentData.Write(tracks.Bytes())
+
+		clusterPos := uint64(segmentData.Len())
+		cluster := new(bytes.Buffer)
+		writeUIntElement(cluster, IDTimestamp, 0, 1)
+		writeUIntElement(cluster, IDClusterPosition, clusterPos, 2)
+		writeUIntElement(cluster, IDPrevSize, 512, 2)
+		cluster.Write([]byte{0xA3, 0x85, 0x81, 0x00, 0x00, 0x80, 0x01}) // SimpleBlock
+		segmentData.Write([]byte{0x1F, 0x43, 0xB6, 0x75})               // Cluster ID
+		segmentData.Write(vintEncode(uint64(cluster.Len())))
+		segmentData.Write(cluster.Bytes())
+
+		buf.Write([]byte{0x18, 0x53, 0x80, 0x67}) // Segment ID
+		buf.Write(vintEncode(uint64(segmentData.Len())))
+		buf.Write(segmentData.Bytes())
+
+		parser, err := NewMatroskaParser(bytes.NewReader(buf.Bytes()), false)
+		if err != nil {
+			t.Fatalf("NewMatroskaParser() failed: %v", err)
+		}
+		if _, err = parser.ReadPacket(); err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if parser.clusterPosition != parser.segmentPos+clusterPos {
+			t.Errorf("Expected cluster position %d, got %d", parser.segmentPos+clusterPos, parser.clusterPosition)
+		}
+		if parser.clusterPrevSize != 512 {
+			t.Errorf("Expected previous cluster size 512, got %d", parser.clusterPrevSize)
+		}
+	})
+
 	t.Run("Cluster with unknown elements", func(t *testing.T) {
 		// Create a cluster with unknown elements that should be skipped
 		buf := new(bytes.Buffer)
@@ -5361,3 +6571,376 @@ func TestParseCuePoint_TimeAndTrackPositions(t *testing.T) {
 		t.Errorf("cue fields unexpected: %+v", cues[0])
 	}
 }
+
+// helper to write an EBML binary or master element: [ID][size-vint][data]
+func writeBinaryElement(buf *bytes.Buffer, id uint32, data []byte) {
+	for shift := 24; shift >= 0; shift -= 8 {
+		if id>>uint(shift) != 0 {
+			buf.WriteByte(byte(id >> uint(shift)))
+		}
+	}
+	buf.Write(vintEncode(uint64(len(data))))
+	buf.Write(data)
+}
+
+// TestReadPacket_EndOfInput tests that the end of the input between elements
+// is reported as io.EOF, and within the data of a block as ErrTruncatedBlock.
+func TestReadPacket_EndOfInput(t *testing.T) {
+	trackEntry, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	first := []byte{0x81, 0x00, 0x00, 0x80, 'f', 'i', 'r', 's', 't'}
+	last := []byte{0x81, 0x00, 0x01, 0x80, 'l', 'a', 's', 't'}
+	data := createMockMatroskaFileWithTrack(trackEntry, first, last)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		complete  bool
+		truncated bool
+	}{
+		{"Complete file", data, true, false},
+		{"Cut within the block data", data[:len(data)-2], false, true},
+		{"Cut after the block header", data[:len(data)-len(last)], false, true},
+		{"Cut between blocks", data[:len(data)-len(last)-2], false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opts := range [][]Option{nil, {WithPacketPool()}} {
+				parser, errParser := NewMatroskaParserWithOptions(bytes.NewReader(tt.data), opts...)
+				if errParser != nil {
+					t.Fatalf("NewMatroskaParserWithOptions() failed: %v", errParser)
+				}
+				packet, errRead := parser.ReadPacket()
+				if errRead != nil || string(packet.Data) != "first" {
+					t.Fatalf("Expected the first packet, got %v", errRead)
+				}
+
+				_, errRead = parser.ReadPacket()
+				if tt.truncated {
+					if !errors.Is(errRead, ErrTruncatedBlock) || !errors.Is(errRead, io.ErrUnexpectedEOF) {
+						t.Errorf("Expected ErrTruncatedBlock and io.ErrUnexpectedEOF, got %v", errRead)
+					}
+					continue
+				}
+				if tt.complete {
+					if errRead != nil {
+						t.Fatalf("Expected the last packet, got %v", errRead)
+					}
+					_, errRead = parser.ReadPacket()
+				}
+				if errRead != io.EOF {
+					t.Errorf("Expected io.EOF, got %v", errRead)
+				}
+			}
+		})
+	}
+}
+
+// TestParseTrackEntry_TrackOperation tests parsing the TrackOperation of
+// virtual tracks combining planes or joining blocks.
+func TestParseTrackEntry_TrackOperation(t *testing.T) {
+	parser := &MatroskaParser{}
+
+	t.Run("Combined planes", func(t *testing.T) {
+		var left, right, planes, operation, entry bytes.Buffer
+		putUIntElement(&left, IDTrackPlaneUID, 101)
+		putUIntElement(&left, IDTrackPlaneType, TrackPlaneLeftEye)
+		putUIntElement(&right, IDTrackPlaneUID, 102)
+		putUIntElement(&right, IDTrackPlaneType, TrackPlaneRightEye)
+		putElement(&planes, IDTrackPlane, left.Bytes())
+		putElement(&planes, IDTrackPlane, right.Bytes())
+		putElement(&operation, IDTrackCombinePlanes, planes.Bytes())
+		putUIntElement(&entry, IDTrackNum, 3)
+		putUIntElement(&entry, IDTrackType, uint64(TypeVideo))
+		putElement(&entry, IDTrackOperation, operation.Bytes())
+
+		track, err := parser.parseTrackEntry(entry.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() failed: %v", err)
+		}
+		if track.Operation == nil {
+			t.Fatal("Expected a track operation")
+		}
+		want := []TrackPlane{{UID: 101, Type: TrackPlaneLeftEye}, {UID: 102, Type: TrackPlaneRightEye}}
+		if len(track.Operation.CombinePlanes) != len(want) {
+			t.Fatalf("Expected planes %+v, got %+v", want, track.Operation.CombinePlanes)
+		}
+		for i, plane := range track.Operation.CombinePlanes {
+			if plane != want[i] {
+				t.Errorf("Plane %d: expected %+v, got %+v", i, want[i], plane)
+			}
+		}
+		if len(track.Operation.JoinBlocks) != 0 {
+			t.Errorf("Expected no joined tracks, got %v", track.Operation.JoinBlocks)
+		}
+
+		clone := track.Clone()
+		clone.Operation.CombinePlanes[0].UID = 0
+		if track.Operation.CombinePlanes[0].UID != 101 {
+			t.Error("Expected Clone to copy the track operation")
+		}
+	})
+
+	t.Run("Joined blocks", func(t *testing.T) {
+		var join, operation, entry bytes.Buffer
+		putUIntElement(&join, IDTrackJoinUID, 201)
+		putUIntElement(&join, IDTrackJoinUID, 202)
+		putUIntElement(&join, IDTrackJoinUID, 203)
+		putElement(&operation, IDTrackJoinBlocks, join.Bytes())
+		putUIntElement(&entry, IDTrackNum, 4)
+		putElement(&entry, IDTrackOperation, operation.Bytes())
+
+		track, err := parser.parseTrackEntry(entry.Bytes())
+		if err != nil {
+			t.Fatalf("parseTrackEntry() failed: %v", err)
+		}
+		if track.Operation == nil || len(track.Operation.JoinBlocks) != 3 || track.Operation.JoinBlocks[2] != 203 {
+			t.Errorf("Expected the UIDs of the joined tracks, got %+v", track.Operation)
+		}
+	})
+
+	t.Run("No operation", func(t *testing.T) {
+		entry, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+		if err != nil {
+			t.Fatalf("createMockTrackEntry() failed: %v", err)
+		}
+		track, err := parser.parseTrackEntry(entry)
+		if err != nil {
+			t.Fatalf("parseTrackEntry() failed: %v", err)
+		}
+		if track.Operation != nil {
+			t.Errorf("Expected no track operation, got %+v", track.Operation)
+		}
+	})
+}
+
+// TestParseTrackEntry_MaxBlockAdditionIDAndOverlay tests parsing the
+// MaxBlockAdditionID and the TrackOverlay elements of a track entry.
+func TestParseTrackEntry_MaxBlockAdditionIDAndOverlay(t *testing.T) {
+	var entry bytes.Buffer
+	putUIntElement(&entry, IDTrackNum, 2)
+	putUIntElement(&entry, IDTrackType, uint64(TypeSubtitle))
+	putUIntElement(&entry, IDMaxBlockAddID, 4)
+	putUIntElement(&entry, IDTrackOverlay, 3)
+	putUIntElement(&entry, IDTrackOverlay, 5)
+
+	parser := &MatroskaParser{}
+	track, err := parser.parseTrackEntry(entry.Bytes())
+	if err != nil {
+		t.Fatalf("parseTrackEntry() failed: %v", err)
+	}
+	if track.MaxBlockAdditionID != 4 {
+		t.Errorf("Expected MaxBlockAdditionID 4, got %d", track.MaxBlockAdditionID)
+	}
+	if len(track.TrackOverlay) != 2 || track.TrackOverlay[0] != 3 || track.TrackOverlay[1] != 5 {
+		t.Errorf("Expected TrackOverlay [3 5], got %v", track.TrackOverlay)
+	}
+
+	clone := track.Clone()
+	clone.TrackOverlay[0] = 0
+	if track.TrackOverlay[0] != 3 {
+		t.Error("Expected Clone to copy the overlay tracks")
+	}
+
+	// Both elements are optional
+	entryData, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	track, err = parser.parseTrackEntry(entryData)
+	if err != nil {
+		t.Fatalf("parseTrackEntry() failed: %v", err)
+	}
+	if track.MaxBlockAdditionID != 0 || track.TrackOverlay != nil {
+		t.Errorf("Expected no BlockAdditions and no overlay, got %d and %v", track.MaxBlockAdditionID, track.TrackOverlay)
+	}
+}
+
+// TestParseTrackEntry_DefaultDecodedFieldDuration tests the parsing of the
+// DefaultDecodedFieldDuration of an interlaced video track.
+func TestParseTrackEntry_DefaultDecodedFieldDuration(t *testing.T) {
+	var video, entry bytes.Buffer
+	putUIntElement(&video, IDPixelWidth, 720)
+	putUIntElement(&video, IDPixelHeight, 480)
+	putUIntElement(&video, IDFlagInterlaced, 1)
+	putUIntElement(&video, IDFieldOrder, uint64(FieldOrderBFF))
+	putUIntElement(&entry, IDTrackNum, 1)
+	putUIntElement(&entry, IDTrackType, uint64(TypeVideo))
+	putStringElement(&entry, IDCodecID, "V_MPEG2")
+	// 29.97 frames per second, each made of two fields
+	putUIntElement(&entry, IDDefaultDuration, 33366667)
+	putUIntElement(&entry, IDDefaultDecodedFieldDuration, 16683333)
+	putElement(&entry, IDVideo, video.Bytes())
+
+	parser := &MatroskaParser{}
+	track, err := parser.parseTrackEntry(entry.Bytes())
+	if err != nil {
+		t.Fatalf("parseTrackEntry() failed: %v", err)
+	}
+	if track.Video.DefaultDecodedFieldDuration != 16683333 {
+		t.Errorf("Expected DefaultDecodedFieldDuration 16683333, got %d", track.Video.DefaultDecodedFieldDuration)
+	}
+	if track.DefaultDuration != 33366667 {
+		t.Errorf("Expected DefaultDuration 33366667, got %d", track.DefaultDuration)
+	}
+	if !track.Video.Interlaced || track.Video.FieldOrder != FieldOrderBFF || track.Video.PixelWidth != 720 {
+		t.Errorf("Expected the interlaced video settings, got %+v", track.Video)
+	}
+
+	// The element is optional
+	entryData, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+	if err != nil {
+		t.Fatalf("createMockTrackEntry() failed: %v", err)
+	}
+	if track, err = parser.parseTrackEntry(entryData); err != nil {
+		t.Fatalf("parseTrackEntry() failed: %v", err)
+	}
+	if track.Video.DefaultDecodedFieldDuration != 0 {
+		t.Errorf("Expected no DefaultDecodedFieldDuration, got %d", track.Video.DefaultDecodedFieldDuration)
+	}
+}
+
+// TestParseTrackEntry_CodecURLs tests the parsing of the CodecSettings,
+// CodecInfoURL and CodecDownloadURL elements.
+func TestParseTrackEntry_CodecURLs(t *testing.T) {
+	var entry bytes.Buffer
+	putUIntElement(&entry, IDTrackNum, 1)
+	putUIntElement(&entry, IDTrackType, uint64(TypeVideo))
+	putStringElement(&entry, IDCodecID, "V_MS/VFW/FOURCC")
+	putStringElement(&entry, IDCodecSettings, "quality=90")
+	putStringElement(&entry, IDCodecInfoURL, "http://example.com/codec")
+	putStringElement(&entry, IDCodecDownloadURL, "http://example.com/codec/download")
+
+	parser := &MatroskaParser{}
+	track, err := parser.parseTrackEntry(entry.Bytes())
+	if err != nil {
+		t.Fatalf("parseTrackEntry() failed: %v", err)
+	}
+	if track.CodecSettings != "quality=90" {
+		t.Errorf("Expected CodecSettings 'quality=90', got %q", track.CodecSettings)
+	}
+	if track.CodecInfoURL != "http://example.com/codec" {
+		t.Errorf("Expected CodecInfoURL 'http://example.com/codec', got %q", track.CodecInfoURL)
+	}
+	if track.CodecDownloadURL != "http://example.com/codec/download" {
+		t.Errorf("Expected CodecDownloadURL 'http://example.com/codec/download', got %q", track.CodecDownloadURL)
+	}
+	if track.CodecID != "V_MS/VFW/FOURCC" {
+		t.Errorf("Expected CodecID 'V_MS/VFW/FOURCC', got %q", track.CodecID)
+	}
+}
+
+// TestReadPacket_ClusterFields tests that packets record the timecode and the
+// position of the cluster they were read from.
+func TestReadPacket_ClusterFields(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}}
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x02}},
+		{Track: 1, StartTime: 80000000, Data: []byte{0x03}, Flags: KF},
+		{Track: 1, StartTime: 120000000, Data: []byte{0x04}},
+	}
+	// The Muxer starts a cluster at each keyframe
+	demuxer := createMuxedFile(t, tracks, packets)
+
+	var read []*Packet
+	for {
+		packet, err := demuxer.ReadPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		read = append(read, packet)
+	}
+	if len(read) != len(packets) {
+		t.Fatalf("Expected %d packets, got %d", len(packets), len(read))
+	}
+
+	expectedTimecodes := []uint64{0, 0, 80000000, 80000000}
+	for i, packet := range read {
+		if packet.ClusterTimecode != expectedTimecodes[i] {
+			t.Errorf("Packet %d: expected cluster timecode %d, got %d", i, expectedTimecodes[i], packet.ClusterTimecode)
+		}
+		if packet.ClusterPos >= packet.FilePos {
+			t.Errorf("Packet %d: expected the cluster at %d to start before the packet at %d", i, packet.ClusterPos, packet.FilePos)
+		}
+	}
+	if read[0].ClusterPos != read[1].ClusterPos || read[2].ClusterPos != read[3].ClusterPos {
+		t.Errorf("Expected the packets of a cluster to share its position, got %d, %d, %d and %d",
+			read[0].ClusterPos, read[1].ClusterPos, read[2].ClusterPos, read[3].ClusterPos)
+	}
+	if read[0].ClusterPos == read[2].ClusterPos {
+		t.Errorf("Expected two clusters, got both at %d", read[0].ClusterPos)
+	}
+
+	// ClusterPos is the position of the Cluster element
+	for _, packet := range []*Packet{read[0], read[2]} {
+		if _, err := demuxer.reader.Seek(int64(packet.ClusterPos), io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		id, _, err := NewEBMLReader(demuxer.reader.(io.ReadSeeker)).ReadElementHeader()
+		if err != nil {
+			t.Fatalf("ReadElementHeader() failed: %v", err)
+		}
+		if id != IDCluster {
+			t.Errorf("Expected a Cluster at %d, got element 0x%X", packet.ClusterPos, id)
+		}
+	}
+}
+
+// TestReadPacket_ClusterHeader tests that the Timestamp of each cluster is
+// read as its child, for clusters of known and unknown size.
+func TestReadPacket_ClusterHeader(t *testing.T) {
+	createFile := func(t *testing.T, unknown bool) []byte {
+		t.Helper()
+		elements := []segmentElement{mockInfoElement(nil), mockTracksElement(t, TypeVideo, "V_TEST")}
+		for i, timestamp := range []uint64{100, 200} {
+			var cluster bytes.Buffer
+			if i == 1 {
+				putElement(&cluster, IDVoid, []byte{0x00, 0x00})
+			}
+			putUIntElement(&cluster, IDTimestamp, timestamp)
+			putUIntElement(&cluster, IDPrevSize, 10*uint64(i))
+			putElement(&cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x05, 0x80, byte(i + 1)})
+			elements = append(elements, segmentElement{id: IDCluster, data: cluster.Bytes(), unknownSize: unknown})
+		}
+		return buildTestFile(createMinimalEBMLHeader(), nil, elements...).data
+	}
+
+	tests := []struct {
+		name    string
+		unknown bool
+	}{
+		{"Known size", false},
+		{"Unknown size", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			demuxer, err := NewDemuxer(bytes.NewReader(createFile(t, tt.unknown)))
+			if err != nil {
+				t.Fatalf("Failed to create demuxer: %v", err)
+			}
+
+			for i, expected := range []uint64{105000000, 205000000} {
+				packet, errReadPacket := demuxer.ReadPacket()
+				if errReadPacket != nil {
+					t.Fatalf("ReadPacket() failed: %v", errReadPacket)
+				}
+				if packet.Data[0] != byte(i+1) || packet.StartTime != expected {
+					t.Errorf("Packet %d: expected frame %d at %d, got frame %d at %d",
+						i, i+1, expected, packet.Data[0], packet.StartTime)
+				}
+				if prevSize := demuxer.parser.clusterPrevSize; prevSize != 10*uint64(i) {
+					t.Errorf("Packet %d: expected previous cluster size %d, got %d", i, 10*i, prevSize)
+				}
+			}
+			if _, err = demuxer.ReadPacket(); err != io.EOF {
+				t.Errorf("Expected io.EOF after the last packet, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the reuse of the buffers of packet data: the pool used
+// when WithPacketPool is set, and ReadPacketInto.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReadPacketInto reads the next packet like ReadPacket, but fills p and reads
+// the packet's data into buf instead of allocating them, for pipelines that
+// process one packet at a time.
+//
+// p.Data is set to the first n bytes of buf, or of a larger buffer allocated
+// when buf is too small, and is only valid until the next call. Callers can
+// keep a grown buffer for the next call with p.Data[:cap(p.Data)]. When the
+// content encodings of the track are undone or its frames converted, for
+// example with SetAnnexBConversion, p.Data may instead be a new slice. With a
+// large enough buffer, reading a packet of an unencoded track within a
+// cluster does not allocate.
+//
+// Example:
+//
+//	var packet matroska.Packet
+//	buf := make([]byte, 1<<20)
+//	for {
+//	    n, err := demuxer.ReadPacketInto(&packet, buf)
+//	    if err != nil {
+//	        break
+//	    }
+//	    decoder.Decode(packet.Data[:n])
+//	    buf = packet.Data[:cap(packet.Data)]
+//	}
+//
+// Parameters:
+//   - p: The packet to fill.
+//   - buf: The buffer to read the packet's data into.
+//
+// Returns:
+//   - int: The size of the packet's data.
+//   - error: An error if a packet could not be read, or io.EOF if the end of
+//     the file has been reached.
+func (d *Demuxer) ReadPacketInto(p *Packet, buf []byte) (int, error) {
+	d.lock()
+	defer d.unlock()
+	return d.parser.ReadPacketInto(p, buf)
+}
+
+// ReadPacketInto reads the next packet into p and buf. See
+// Demuxer.ReadPacketInto.
+//
+// Parameters:
+//   - p: The packet to fill.
+//   - buf: The buffer to read the packet's data into.
+//
+// Returns:
+//   - int: The size of the packet's data.
+//   - error: An error if a packet could not be read, or io.EOF.
+func (mp *MatroskaParser) ReadPacketInto(p *Packet, buf []byte) (int, error) {
+	mp.intoPacket, mp.intoBuf = p, buf[:cap(buf)]
+	defer func() {
+		mp.intoPacket, mp.intoBuf = nil, nil
+	}()
+
+	packet, err := mp.ReadPacket()
+	if err != nil {
+		return 0, err
+	}
+	return len(packet.Data), nil
+}
+
+// newPacket returns the packet to fill with a block, which is a new packet,
+// or the packet passed to ReadPacketInto. In the latter case, the frame data
+// is moved to the start of the buffer passed to ReadPacketInto, which holds
+// the block.
+//
+// Parameters:
+//   - frameData: The frame data of the block.
+//
+// Returns:
+//   - *Packet: The packet to fill.
+//   - []byte: The frame data to store in the packet.
+func (mp *MatroskaParser) newPacket(frameData []byte) (*Packet, []byte) {
+	if mp.intoPacket == nil {
+		return &Packet{}, frameData
+	}
+	return mp.intoPacket, mp.intoBuf[:copy(mp.intoBuf, frameData)]
+}
+
+// Release returns the buffer holding the data of a packet to the pool of the
+// demuxer that read it, so that a later ReadPacket can reuse it. The packet's
+// Data is cleared, and neither it nor any slice of it may be used afterwards.
+//
+// Release only has an effect for packets read with WithPacketPool set, and
+// does nothing for other packets or when called more than once.
+//
+// Example:
+//
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	process(packet.Data)
+//	packet.Release()
+func (p *Packet) Release() {
+	if p.pool == nil || p.buffer == nil {
+		return
+	}
+	p.pool.Put(p.buffer)
+	p.buffer = nil
+	p.pool = nil
+	p.Data = nil
+}
+
+// readBlockData reads the data of a SimpleBlock or BlockGroup element. During
+// ReadPacketInto, the data is read into the caller's buffer. Otherwise, when
+// WithPacketPool is set, the data is read into a buffer of the pool, which is
+// also returned so that it can be released with the packet.
+//
+// Parameters:
+//   - size: The size of the element's data.
+//
+// Returns:
+//   - []byte: The element data.
+//   - *[]byte: The pooled buffer holding the data, or nil without a pool.
+//   - error: An error if the data could not be read, wrapping ErrTruncatedBlock
+//     if the input ends within it.
+func (mp *MatroskaParser) readBlockData(size uint64) ([]byte, *[]byte, error) {
+	if mp.intoPacket != nil {
+		data, err := mp.reader.readDataInto(mp.intoBuf, size)
+		if err != nil {
+			return nil, nil, truncatedBlockError(err)
+		}
+		mp.intoBuf = data[:cap(data)]
+		return data, nil, nil
+	}
+	if mp.packetPool == nil {
+		data, err := mp.reader.readData(size)
+		return data, nil, truncatedBlockError(err)
+	}
+
+	buffer := mp.packetPool.Get().(*[]byte)
+	data, err := mp.reader.readDataInto(*buffer, size)
+	if err != nil {
+		mp.packetPool.Put(buffer)
+		return nil, nil, truncatedBlockError(err)
+	}
+	*buffer = data
+	return data, buffer, nil
+}
+
+// truncatedBlockError converts the end of the input within the data of a block
+// into an error wrapping both ErrTruncatedBlock and io.ErrUnexpectedEOF. The
+// header of the block has been read, so the input ending before any of its
+// data is a truncation too, and must not be reported as io.EOF, which means
+// that the input ended between elements.
+//
+// Parameters:
+//   - err: The error returned when reading the data of a block, or nil.
+//
+// Returns:
+//   - error: The converted error, or err if it is not an end of the input.
+func truncatedBlockError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %w", ErrTruncatedBlock, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// releaseBuffer returns a buffer obtained from readBlockData to the pool,
+// when the block could not be turned into a packet.
+//
+// Parameters:
+//   - buffer: The pooled buffer, or nil.
+func (mp *MatroskaParser) releaseBuffer(buffer *[]byte) {
+	if buffer != nil {
+		mp.packetPool.Put(buffer)
+	}
+}
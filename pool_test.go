@@ -0,0 +1,188 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// poolTestFile returns the tracks and packets of a file with video frames in
+// SimpleBlocks and subtitles in BlockGroups.
+func poolTestFile() ([]*TrackInfo, []*Packet) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"},
+	}
+	var packets []*Packet
+	for frame := 0; frame < 200; frame++ {
+		timecode := uint64(frame) * 40000000
+		flags := uint32(0)
+		if frame%25 == 0 {
+			flags = KF
+		}
+		packets = append(packets, &Packet{Track: 1, StartTime: timecode, Data: bytes.Repeat([]byte{byte(frame)}, 1000+frame), Flags: flags})
+		if frame%10 == 0 {
+			packets = append(packets, &Packet{Track: 2, StartTime: timecode, EndTime: timecode + 400000000, Data: []byte{'S', byte(frame)}})
+		}
+	}
+	return tracks, packets
+}
+
+func TestWithPacketPool(t *testing.T) {
+	tracks, packets := poolTestFile()
+	file := createMuxedFile(t, tracks, packets).reader.(*os.File)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(file, WithPacketPool())
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	var held *Packet
+	var count int
+	for {
+		packet, errReadPacket := demuxer.ReadPacket()
+		if errReadPacket == io.EOF {
+			break
+		}
+		if errReadPacket != nil {
+			t.Fatalf("ReadPacket() failed: %v", errReadPacket)
+		}
+		if count >= len(packets) {
+			t.Fatalf("Expected %d packets, got more", len(packets))
+		}
+		expected := packets[count]
+		if packet.Track != expected.Track || !bytes.Equal(packet.Data, expected.Data) {
+			t.Fatalf("Packet %d: expected track %d with %d bytes, got track %d with %d bytes", count, expected.Track, len(expected.Data), packet.Track, len(packet.Data))
+		}
+		count++
+
+		// A packet that is not released keeps its data
+		if count == 1 {
+			held = packet
+			continue
+		}
+		packet.Release()
+		if packet.Data != nil {
+			t.Fatal("Expected Release() to clear the data")
+		}
+		packet.Release()
+	}
+	if count != len(packets) {
+		t.Errorf("Expected %d packets, got %d", len(packets), count)
+	}
+	if !bytes.Equal(held.Data, packets[0].Data) {
+		t.Error("Expected the data of a packet that was not released to be kept")
+	}
+
+	t.Run("Release without a pool", func(t *testing.T) {
+		packet := &Packet{Data: []byte{0x01}}
+		packet.Release()
+		if packet.Data == nil {
+			t.Error("Expected Release() to keep the data of a packet that is not pooled")
+		}
+	})
+}
+
+// BenchmarkReadPacket compares the allocations of ReadPacket with and without
+// WithPacketPool.
+func BenchmarkReadPacket(b *testing.B) {
+	tracks, packets := poolTestFile()
+	file := createMuxedFile(b, tracks, packets).reader.(*os.File)
+
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Copy", nil},
+		{"Pool", []Option{WithPacketPool()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				b.Fatalf("Seek() failed: %v", err)
+			}
+			demuxer, err := NewDemuxer(file, bench.opts...)
+			if err != nil {
+				b.Fatalf("NewDemuxer() failed: %v", err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				demuxer.Seek(0, 0)
+				for {
+					packet, errReadPacket := demuxer.ReadPacket()
+					if errReadPacket == io.EOF {
+						break
+					}
+					if errReadPacket != nil {
+						b.Fatalf("ReadPacket() failed: %v", errReadPacket)
+					}
+					packet.Release()
+				}
+			}
+		})
+	}
+}
+
+func TestDemuxer_ReadPacketInto(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}}
+	// A single cluster, as only the first frame is a keyframe
+	var packets []*Packet
+	for frame := 0; frame < 200; frame++ {
+		flags := uint32(0)
+		if frame == 0 {
+			flags = KF
+		}
+		packets = append(packets, &Packet{Track: 1, StartTime: uint64(frame) * 40000000, Data: bytes.Repeat([]byte{byte(frame)}, 100+frame), Flags: flags})
+	}
+	demuxer := createMuxedFile(t, tracks, packets)
+
+	var packet Packet
+	buf := make([]byte, 4096)
+	n, err := demuxer.ReadPacketInto(&packet, buf)
+	if err != nil {
+		t.Fatalf("ReadPacketInto() failed: %v", err)
+	}
+	if n != len(packets[0].Data) || !bytes.Equal(packet.Data, packets[0].Data) {
+		t.Fatalf("Expected the data of the first packet, got %d bytes", n)
+	}
+	if &packet.Data[0] != &buf[0] {
+		t.Error("Expected the packet data to alias the buffer")
+	}
+	if packet.Track != 1 || packet.Flags&KF == 0 {
+		t.Errorf("Expected a keyframe of track 1, got track %d with flags %#x", packet.Track, packet.Flags)
+	}
+
+	t.Run("Grows a small buffer", func(t *testing.T) {
+		n, err = demuxer.ReadPacketInto(&packet, make([]byte, 1))
+		if err != nil {
+			t.Fatalf("ReadPacketInto() failed: %v", err)
+		}
+		if n != len(packets[1].Data) || !bytes.Equal(packet.Data, packets[1].Data) {
+			t.Errorf("Expected the data of the second packet, got %d bytes", n)
+		}
+		if packet.StartTime != packets[1].StartTime || packet.Flags&KF != 0 {
+			t.Errorf("Expected a frame at %d, got one at %d with flags %#x", packets[1].StartTime, packet.StartTime, packet.Flags)
+		}
+	})
+
+	t.Run("No allocation", func(t *testing.T) {
+		next := 2
+		mismatches := 0
+		allocs := testing.AllocsPerRun(100, func() {
+			n, err = demuxer.ReadPacketInto(&packet, buf)
+			if err != nil || !bytes.Equal(packet.Data[:n], packets[next].Data) {
+				mismatches++
+			}
+			next++
+		})
+		if mismatches > 0 {
+			t.Fatalf("Expected the packets in order, got %d mismatches", mismatches)
+		}
+		if allocs != 0 {
+			t.Errorf("Expected no allocation per packet, got %v", allocs)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains ProbeTracks, which reads only the tracks of a file.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// ProbeTracks returns the tracks of a Matroska or WebM file, reading as little
+// of the file as possible.
+//
+// Only the EBML header and the Tracks element are parsed: the other children
+// of the segment before the Tracks are skipped without being read, and if the
+// segment starts with a SeekHead listing the Tracks, the Tracks are read from
+// there directly. The segment information, cues, chapters, tags, attachments
+// and clusters are never read. This is the fastest way to find the codecs of a
+// file and their CodecPrivate data, for example to set up decoders; use
+// NewDemuxer or OpenMetadata for anything else.
+//
+// Example:
+//
+//	tracks, err := matroska.ProbeTracks(file)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, track := range tracks {
+//	    fmt.Printf("Track %d: %s (%d bytes of private data)\n",
+//	        track.Number, track.CodecID, len(track.CodecPrivate))
+//	}
+//
+// Parameters:
+//   - r: An io.ReadSeeker that provides access to the Matroska file data.
+//
+// Returns:
+//   - []*TrackInfo: The tracks of the file, sorted by track number.
+//   - error: An error if the file is not a valid Matroska or WebM file, or if
+//     no Tracks element is found before the first cluster.
+func ProbeTracks(r io.ReadSeeker) ([]*TrackInfo, error) {
+	mp := &MatroskaParser{
+		reader: NewEBMLReader(r),
+	}
+	if err := mp.parseHeader(); err != nil {
+		return nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+
+	id, size, err := mp.reader.ReadElementHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment header: %w", err)
+	}
+	if id != IDSegment {
+		return nil, fmt.Errorf("expected segment element, got ID 0x%X", id)
+	}
+	mp.segmentPos = uint64(mp.reader.Position())
+
+	if err = mp.probeTracks(mp.segmentPos + size); err != nil {
+		return nil, err
+	}
+	return mp.tracks, nil
+}
+
+// probeTracks finds and parses the Tracks element of the segment. See
+// ProbeTracks.
+//
+// Parameters:
+//   - segmentEnd: The end of the segment, which may be beyond the end of the
+//     input if its size is unknown.
+//
+// Returns:
+//   - error: An error if the Tracks element could not be found or parsed.
+func (mp *MatroskaParser) probeTracks(segmentEnd uint64) error {
+	for uint64(mp.reader.Position()) < segmentEnd {
+		id, size, err := mp.reader.ReadElementHeader()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("failed to read element header: %w", err)
+		}
+
+		switch id {
+		case IDTracks:
+			if err = mp.parseTracks(size); err != nil {
+				return fmt.Errorf("failed to parse tracks: %w", err)
+			}
+			return nil
+		case IDSeekHead:
+			if err = mp.parseSeekHead(size); err != nil {
+				return fmt.Errorf("failed to parse seek head: %w", err)
+			}
+			if position, ok := mp.seekHead[IDTracks]; ok {
+				found, errSeek := mp.parseTracksAt(position)
+				if errSeek != nil || found {
+					return errSeek
+				}
+			}
+		case IDCluster:
+			return fmt.Errorf("no tracks found before the first cluster")
+		default:
+			if err = mp.skipElement(size); err != nil {
+				return fmt.Errorf("failed to skip element: %w", err)
+			}
+		}
+	}
+	return fmt.Errorf("no tracks found")
+}
+
+// parseTracksAt parses the Tracks element at a position listed in the
+// SeekHead. If the SeekHead is wrong, as in a file edited without updating it,
+// the read position is restored so that the segment can be scanned instead.
+//
+// Parameters:
+//   - position: The position of the Tracks element.
+//
+// Returns:
+//   - bool: True if the Tracks element was found and parsed.
+//   - error: An error if the Tracks element could not be parsed or the read
+//     position could not be restored.
+func (mp *MatroskaParser) parseTracksAt(position uint64) (bool, error) {
+	current := mp.reader.Position()
+	if _, err := mp.reader.Seek(int64(position), io.SeekStart); err == nil {
+		if id, size, err := mp.reader.ReadElementHeader(); err == nil && id == IDTracks {
+			if err = mp.parseTracks(size); err != nil {
+				return false, fmt.Errorf("failed to parse tracks: %w", err)
+			}
+			return true, nil
+		}
+	}
+	if _, err := mp.reader.Seek(current, io.SeekStart); err != nil {
+		return false, fmt.Errorf("failed to restore position: %w", err)
+	}
+	return false, nil
+}
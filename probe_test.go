@@ -0,0 +1,114 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// createProbeFile creates a file whose Tracks follow its cluster, optionally
+// listed in a SeekHead, and returns it with the range of the cluster.
+func createProbeFile(t *testing.T, withSeekHead bool) ([]byte, int64, int64) {
+	t.Helper()
+	var seekIDs []uint32
+	if withSeekHead {
+		seekIDs = []uint32{IDTracks}
+	}
+	// Padding larger than the read buffer, which must not be read
+	f := buildTestFile(createMinimalEBMLHeader(), seekIDs,
+		mockInfoElement(nil),
+		mockFrameCluster(4*readBufferSize),
+		mockTracksElement(t, TypeAudio, "A_OPUS"),
+	)
+	return f.data, f.start(1), f.end(1)
+}
+
+func TestProbeTracks(t *testing.T) {
+	t.Run("Same tracks as NewDemuxer", func(t *testing.T) {
+		tracks := []*TrackInfo{
+			{Type: TypeVideo, CodecID: "V_MPEG4/ISO/AVC", CodecPrivate: []byte{0x01, 0x64, 0x00, 0x1F}},
+			{Type: TypeAudio, CodecID: "A_OPUS", CodecPrivate: []byte("OpusHead")},
+		}
+		packets := []*Packet{{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF}}
+		demuxer := createMuxedFile(t, tracks, packets)
+		file := demuxer.reader.(*os.File)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+
+		probed, err := ProbeTracks(file)
+		if err != nil {
+			t.Fatalf("ProbeTracks() failed: %v", err)
+		}
+		if len(probed) != len(tracks) {
+			t.Fatalf("Expected %d tracks, got %d", len(tracks), len(probed))
+		}
+		for i, track := range probed {
+			expected := demuxer.parser.tracks[i]
+			if track.Number != expected.Number || track.Type != expected.Type || track.CodecID != expected.CodecID ||
+				!bytes.Equal(track.CodecPrivate, expected.CodecPrivate) {
+				t.Errorf("Track %d: expected %+v, got %+v", i, expected, track)
+			}
+		}
+	})
+
+	t.Run("Tracks listed in the SeekHead", func(t *testing.T) {
+		data, clusterStart, clusterEnd := createProbeFile(t, true)
+		recorder := &readRecorder{Reader: bytes.NewReader(data)}
+		tracks, err := ProbeTracks(recorder)
+		if err != nil {
+			t.Fatalf("ProbeTracks() failed: %v", err)
+		}
+		if len(tracks) != 1 || tracks[0].CodecID != "A_OPUS" {
+			t.Fatalf("Expected the A_OPUS track, got %+v", tracks)
+		}
+		if recorder.overlaps(clusterStart+readBufferSize, clusterEnd) {
+			t.Error("Expected the cluster not to be read")
+		}
+	})
+
+	t.Run("Tracks after the cluster without SeekHead", func(t *testing.T) {
+		data, _, _ := createProbeFile(t, false)
+		if _, err := ProbeTracks(bytes.NewReader(data)); err == nil {
+			t.Error("Expected an error when no tracks precede the first cluster")
+		}
+	})
+
+	t.Run("Not a Matroska file", func(t *testing.T) {
+		if _, err := ProbeTracks(bytes.NewReader([]byte("not a matroska file"))); err == nil {
+			t.Error("Expected an error for invalid data")
+		}
+	})
+}
+
+func BenchmarkProbeTracks(b *testing.B) {
+	tracks, packets := poolTestFile()
+	file := createMuxedFile(b, tracks, packets).reader.(*os.File)
+
+	for _, bench := range []struct {
+		name  string
+		probe func(io.ReadSeeker) error
+	}{
+		{"ProbeTracks", func(r io.ReadSeeker) error {
+			_, err := ProbeTracks(r)
+			return err
+		}},
+		{"NewDemuxer", func(r io.ReadSeeker) error {
+			_, err := NewDemuxer(r)
+			return err
+		}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := file.Seek(0, io.SeekStart); err != nil {
+					b.Fatalf("Seek() failed: %v", err)
+				}
+				if err := bench.probe(file); err != nil {
+					b.Fatalf("%s failed: %v", bench.name, err)
+				}
+			}
+		})
+	}
+}
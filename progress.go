@@ -0,0 +1,115 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains Position and Progress, which report how far the reading
+// of a file has advanced.
+package matroska
+
+import "io"
+
+// Position returns the current byte offset of the demuxer in its input, which
+// is the position of the next element to be read.
+//
+// The position is tracked by the demuxer as it reads and seeks, so it does not
+// include data read ahead into its buffer. It is 0 before the first byte
+// read, and is at or past the end of the last packet returned by ReadPacket.
+//
+// Example:
+//
+//	packet, err := demuxer.ReadPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Read a packet, now at byte %d\n", demuxer.Position())
+//
+// Returns:
+//   - int64: The byte offset in the input.
+func (d *Demuxer) Position() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.Position()
+}
+
+// Progress returns how far the demuxer has read through the segments of the
+// file, from 0 at the start of the first segment to 1 at the end of the last
+// one, for example to display a progress bar during an extraction.
+//
+// When the size of the last segment is unknown, the size of the input is used
+// as its end, or 0 is returned if the demuxer avoids seeks and the size
+// cannot be determined.
+//
+// Example:
+//
+//	for {
+//	    if _, err := demuxer.ReadPacket(); err != nil {
+//	        break
+//	    }
+//	    fmt.Printf("\r%3.0f%%", demuxer.Progress()*100)
+//	}
+//
+// Returns:
+//   - float64: The progress, between 0 and 1.
+func (d *Demuxer) Progress() float64 {
+	d.lock()
+	defer d.unlock()
+	return d.parser.Progress()
+}
+
+// Position returns the current byte offset of the parser in its input. See
+// Demuxer.Position.
+//
+// Returns:
+//   - int64: The byte offset in the input.
+func (mp *MatroskaParser) Position() int64 {
+	return mp.reader.Position()
+}
+
+// Progress returns how far the parser has read through the segments of the
+// file. See Demuxer.Progress.
+//
+// Returns:
+//   - float64: The progress, between 0 and 1.
+func (mp *MatroskaParser) Progress() float64 {
+	start := int64(mp.segmentPos)
+	end := mp.segmentsEnd()
+	if end <= start {
+		return 0
+	}
+
+	progress := float64(mp.reader.Position()-start) / float64(end-start)
+	if progress < 0 {
+		return 0
+	}
+	if progress > 1 {
+		return 1
+	}
+	return progress
+}
+
+// segmentsEnd returns the position of the end of the last segment, which is
+// the size of the input if the size of the segment is unknown. The size of
+// the input is measured once and cached.
+//
+// Returns:
+//   - int64: The end of the last segment, or 0 if it cannot be determined.
+func (mp *MatroskaParser) segmentsEnd() int64 {
+	segment := mp.segment
+	if len(mp.segments) > 0 {
+		segment = mp.segments[len(mp.segments)-1]
+	}
+	if segment == nil {
+		return 0
+	}
+	if segment.Size != (1<<(7*8))-1 {
+		return int64(segment.Position + segment.Size)
+	}
+
+	if mp.inputSize == 0 && !mp.avoidSeeks {
+		position := mp.reader.Position()
+		if size, err := mp.reader.Seek(0, io.SeekEnd); err == nil {
+			mp.inputSize = size
+		}
+		if _, err := mp.reader.Seek(position, io.SeekStart); err != nil {
+			return 0
+		}
+	}
+	return mp.inputSize
+}
@@ -0,0 +1,80 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestDemuxer_Progress(t *testing.T) {
+	tracks, packets := poolTestFile()
+
+	t.Run("Monotonic", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		// Only the metadata before the first cluster has been read
+		lastPosition, lastProgress := demuxer.Position(), demuxer.Progress()
+		if lastProgress <= 0 || lastProgress >= 0.01 {
+			t.Errorf("Expected little progress before the first cluster, got %v", lastProgress)
+		}
+
+		for {
+			packet, err := demuxer.ReadPacket()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+			position, progress := demuxer.Position(), demuxer.Progress()
+			if position < lastPosition || progress < lastProgress {
+				t.Fatalf("Expected monotonic progress, went from %d (%v) to %d (%v)", lastPosition, lastProgress, position, progress)
+			}
+			if position < int64(packet.FilePos)+int64(len(packet.Data)) {
+				t.Errorf("Expected the position %d past the packet at %d", position, packet.FilePos)
+			}
+			lastPosition, lastProgress = position, progress
+		}
+		if progress := demuxer.Progress(); progress != 1 {
+			t.Errorf("Expected a progress of 1 at the end, got %v", progress)
+		}
+
+		// The position follows seeks
+		demuxer.Seek(0, 0)
+		cues := demuxer.GetCues()
+		if position := demuxer.Position(); position != int64(demuxer.GetSegment()+cues[0].Position) {
+			t.Errorf("Expected the position of the first cluster after a seek, got %d", position)
+		}
+		if progress := demuxer.Progress(); progress <= 0 || progress >= lastProgress {
+			t.Errorf("Expected the progress to go back after a seek, got %v", progress)
+		}
+	})
+
+	t.Run("Unknown segment size", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		data, err := os.ReadFile(demuxer.reader.(*os.File).Name())
+		if err != nil {
+			t.Fatalf("ReadFile() failed: %v", err)
+		}
+		sizePos := int(demuxer.GetSegment()) - len(unknownSizeVInt)
+		copy(data[sizePos:], unknownSizeVInt)
+
+		demuxer, err = NewDemuxer(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		for i := 0; i < len(packets)/2; i++ {
+			if _, err = demuxer.ReadPacket(); err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		position := demuxer.Position()
+		expected := float64(position-int64(demuxer.GetSegment())) / float64(len(data)-int(demuxer.GetSegment()))
+		if progress := demuxer.Progress(); progress != expected {
+			t.Errorf("Expected a progress of %v from the size of the input, got %v", expected, progress)
+		}
+		if demuxer.Position() != position {
+			t.Errorf("Expected Progress() to keep the position %d, got %d", position, demuxer.Position())
+		}
+	})
+}
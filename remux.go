@@ -0,0 +1,138 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains Remux, which copies tracks of a Matroska file to a new one.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// Remux copies the packets of selected tracks of a Matroska file to a new
+// Matroska file, without re-encoding them.
+//
+// The metadata of the kept tracks, including their CodecPrivate and flags,
+// and the timestamps of their packets are preserved, with millisecond
+// precision. Packets of the other tracks are dropped. The source's cues point
+// to its own clusters, so they are not copied; the Muxer writes new cues for
+// the output.
+//
+// Packets are read from the current position of src, and are written as
+// ReadPacket returns them, so frames are stored decompressed and decrypted.
+// Decompression is enabled during the call, and an encrypted track can only
+// be kept once its key has been set with SetDecryptionKey, as the output
+// declares no content encodings.
+// ReadPacket does not split the frames of laced blocks, so Remux fails on the
+// first laced block of a kept track rather than write it as a single frame.
+// The Annex B conversions and PCM byte order normalizations set on src, and
+// WithRawTimestamps, are suspended during the call, so that frames are
+// written as the copied track declares them.
+//
+// Example:
+//
+//	// Keep the video track and the first audio track
+//	if err := matroska.Remux(out, demuxer, []uint{0, 1}); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - dst: The destination of the new Matroska file.
+//   - src: The demuxer of the source file.
+//   - keepTracks: The indices of the tracks to keep, between 0 and
+//     GetNumTracks()-1. The tracks are numbered in this order in the output.
+//
+// Returns:
+//   - error: An error if no track is kept, a track index is invalid or
+//     repeated, a kept track has encodings that cannot be undone or a laced
+//     block, or the packets could not be read or written.
+func Remux(dst io.WriteSeeker, src *Demuxer, keepTracks []uint) error {
+	if len(keepTracks) == 0 {
+		return fmt.Errorf("no tracks to keep")
+	}
+
+	src.lock()
+	defer src.unlock()
+
+	// The tracks are written with their times in nanoseconds and their frames
+	// decoded, in the format of their CodecID
+	raw, annexB, pcmSwap := src.parser.rawTimestamps, src.parser.annexB, src.parser.pcmSwap
+	noDecompression := src.parser.noDecompression
+	src.parser.rawTimestamps, src.parser.annexB, src.parser.pcmSwap = false, nil, nil
+	src.parser.noDecompression = false
+	defer func() {
+		src.parser.rawTimestamps, src.parser.annexB, src.parser.pcmSwap = raw, annexB, pcmSwap
+		src.parser.noDecompression = noDecompression
+	}()
+
+	muxer := NewMuxer(dst)
+	trackNums := make(map[uint64]uint64, len(keepTracks))
+	for _, index := range keepTracks {
+		track, err := src.trackInfo(index)
+		if err != nil {
+			return err
+		}
+		if _, ok := trackNums[track.Number]; ok {
+			return fmt.Errorf("track %d is kept more than once", index)
+		}
+		if err = src.parser.checkDecodable(track); err != nil {
+			return err
+		}
+		trackNum, err := muxer.AddTrack(track)
+		if err != nil {
+			return fmt.Errorf("failed to add track %d: %w", index, err)
+		}
+		if err = muxer.SetTrackFlags(trackNum, track.Enabled, track.Default); err != nil {
+			return fmt.Errorf("failed to add track %d: %w", index, err)
+		}
+		trackNums[track.Number] = uint64(trackNum)
+	}
+
+	if err := muxer.WriteHeader(); err != nil {
+		return err
+	}
+
+	for {
+		packet, err := src.parser.ReadPacket()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("failed to read packet: %w", err)
+		}
+
+		trackNum, ok := trackNums[packet.Track]
+		if !ok {
+			packet.Release()
+			continue
+		}
+		if err = checkUnlaced(packet); err != nil {
+			packet.Release()
+			return err
+		}
+		out := *packet
+		out.Track = trackNum
+		err = muxer.WritePacket(&out)
+		packet.Release()
+		if err != nil {
+			return err
+		}
+	}
+
+	return muxer.Finalize()
+}
+
+// checkUnlaced checks that a packet holds a single frame. ReadPacket returns
+// the data of a laced block without splitting it into frames, which must not
+// be written or exported as one frame.
+//
+// Parameters:
+//   - packet: The packet to check.
+//
+// Returns:
+//   - error: An error if the packet was read from a laced block.
+func checkUnlaced(packet *Packet) error {
+	if packet.LaceType != LaceNone || packet.NumFramesInBlock > 1 {
+		return fmt.Errorf("packet of track %d at %d ns is in a block with %s lacing, which is not supported",
+			packet.Track, packet.StartTime, packet.LaceType)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package matroska
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemux(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.Create(filepath.Join(dir, "source.mkv"))
+	if err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	defer src.Close()
+
+	muxer := NewMuxer(src)
+	video := &TrackInfo{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}
+	video.Video.PixelWidth = 320
+	video.Video.PixelHeight = 240
+	_, _ = muxer.AddTrack(video)
+	audio := &TrackInfo{Type: TypeAudio, CodecID: "A_OPUS", CodecPrivate: []byte("OpusHead"), Name: "Commentary", Language: "eng"}
+	audio.Audio.SamplingFreq = 48000
+	audio.Audio.Channels = 2
+	_, _ = muxer.AddTrack(audio)
+	if err = muxer.WriteHeader(); err != nil {
+		t.Fatalf("WriteHeader() failed: %v", err)
+	}
+	for i := uint64(0); i < 4; i++ {
+		flags := uint32(0)
+		if i%2 == 0 {
+			flags = KF
+		}
+		packets := []*Packet{
+			{Track: 1, StartTime: i * 40000000, EndTime: (i + 1) * 40000000, Data: []byte{byte(i)}, Flags: flags},
+			{Track: 2, StartTime: i * 20000000, Data: []byte{0xA0 + byte(i)}, Flags: KF},
+		}
+		for _, packet := range packets {
+			if err = muxer.WritePacket(packet); err != nil {
+				t.Fatalf("WritePacket() failed: %v", err)
+			}
+		}
+	}
+	if err = muxer.Finalize(); err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+
+	if _, err = src.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(src)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	if len(demuxer.GetCues()) != 2 {
+		t.Fatalf("Expected 2 video cues in the source, got %d", len(demuxer.GetCues()))
+	}
+
+	if err = Remux(nil, demuxer, nil); err == nil {
+		t.Error("Expected an error when no track is kept")
+	}
+	if err = Remux(nil, demuxer, []uint{5}); err == nil {
+		t.Error("Expected an error for an invalid track index")
+	}
+
+	dst, err := os.Create(filepath.Join(dir, "remuxed.mkv"))
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer dst.Close()
+	if err = Remux(dst, demuxer, []uint{1}); err != nil {
+		t.Fatalf("Remux() failed: %v", err)
+	}
+
+	if _, err = dst.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	remuxed, err := NewDemuxer(dst)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed on the remuxed file: %v", err)
+	}
+
+	numTracks, _ := remuxed.GetNumTracks()
+	if numTracks != 1 {
+		t.Fatalf("Expected 1 track, got %d", numTracks)
+	}
+	track, _ := remuxed.GetTrackInfo(0)
+	if track.Number != 1 || track.Type != TypeAudio || track.CodecID != "A_OPUS" ||
+		!bytes.Equal(track.CodecPrivate, []byte("OpusHead")) || track.Name != "Commentary" ||
+		track.Language != "eng" || track.Audio.SamplingFreq != 48000 || track.Audio.Channels != 2 {
+		t.Errorf("Unexpected track: %+v", track)
+	}
+
+	// The audio-only output indexes the audio track instead of the dropped video track
+	cues := remuxed.GetCues()
+	if len(cues) == 0 {
+		t.Fatal("Expected cues to be regenerated")
+	}
+	for _, cue := range cues {
+		if cue.Track != 1 {
+			t.Errorf("Cue for track %d, want 1", cue.Track)
+		}
+	}
+
+	for i := uint64(0); i < 4; i++ {
+		packet, err := remuxed.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if packet.Track != 1 || packet.StartTime != i*20000000 || !bytes.Equal(packet.Data, []byte{0xA0 + byte(i)}) {
+			t.Errorf("Unexpected packet %d: %+v", i, packet)
+		}
+	}
+	if _, err = remuxed.ReadPacket(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last packet, got %v", err)
+	}
+
+	last := cues[len(cues)-1]
+	remuxed.Seek(last.Time, 0)
+	packet, err := remuxed.ReadPacket()
+	if err != nil {
+		t.Fatalf("ReadPacket() after Seek() failed: %v", err)
+	}
+	if packet.StartTime != last.Time {
+		t.Errorf("Seek() to %d read a packet at %d", last.Time, packet.StartTime)
+	}
+}
+
+// TestRemux_SuspendsConversions tests that Remux writes the frames and times
+// of the source track, whatever conversions are set on the source demuxer.
+func TestRemux_SuspendsConversions(t *testing.T) {
+	pcm := &TrackInfo{Type: TypeAudio, CodecID: "A_PCM/INT/BIG"}
+	pcm.Audio.SamplingFreq = 48000
+	pcm.Audio.Channels = 1
+	pcm.Audio.BitDepth = 16
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01, 0x02}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x03, 0x04}, Flags: KF},
+	}
+	file := createMuxedFile(t, []*TrackInfo{pcm}, packets).reader.(*os.File)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(file, WithRawTimestamps())
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+	demuxer.SetPCMNormalize(0, true)
+
+	dst, err := os.Create(filepath.Join(t.TempDir(), "remuxed.mkv"))
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer dst.Close()
+	if err = Remux(dst, demuxer, []uint{0}); err != nil {
+		t.Fatalf("Remux() failed: %v", err)
+	}
+
+	if _, err = dst.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	remuxed, err := NewDemuxer(dst)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed on the remuxed file: %v", err)
+	}
+	for _, expected := range packets {
+		packet, errReadPacket := remuxed.ReadPacket()
+		if errReadPacket != nil {
+			t.Fatalf("ReadPacket() failed: %v", errReadPacket)
+		}
+		if packet.StartTime != expected.StartTime || !bytes.Equal(packet.Data, expected.Data) {
+			t.Errorf("Packet at %d with data %x, want %d with %x", packet.StartTime, packet.Data, expected.StartTime, expected.Data)
+		}
+	}
+
+	// The conversions of the source are restored afterwards
+	demuxer.Seek(0, 0)
+	packet, err := demuxer.ReadPacket()
+	if err != nil {
+		t.Fatalf("ReadPacket() failed: %v", err)
+	}
+	if !bytes.Equal(packet.Data, []byte{0x02, 0x01}) {
+		t.Errorf("Packet data = %x after Remux(), want 0201", packet.Data)
+	}
+}
+
+// TestRemux_Laced tests that Remux fails on a laced block instead of writing
+// it as a single frame.
+func TestRemux_Laced(t *testing.T) {
+	trackEntry, _ := createMockTrackEntry(1, TypeAudio, "A_VORBIS", "Audio", "und")
+	// Three Xiph-laced frames: "aa", "bbb" and "cccc"
+	laced := []byte{0x81, 0x00, 0x00, 0x82, 0x02, 0x02, 0x03}
+	laced = append(laced, "aabbbcccc"...)
+	file := createMockMatroskaFileWithTrack(trackEntry, laced)
+	demuxer, err := NewDemuxer(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	dst, err := os.Create(filepath.Join(t.TempDir(), "remuxed.mkv"))
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer dst.Close()
+	if err = Remux(dst, demuxer, []uint{0}); err == nil {
+		t.Error("Expected an error for a laced block")
+	}
+}
+
+// TestRemux_ContentEncodings tests that Remux writes decoded frames, and
+// fails on encodings it cannot undo.
+func TestRemux_ContentEncodings(t *testing.T) {
+	remux := func(t *testing.T, trackEntry []byte, encodings *bytes.Buffer, block []byte, setup func(demuxer *Demuxer)) (*Demuxer, error) {
+		buf := bytes.NewBuffer(trackEntry)
+		writeBinaryElement(buf, IDContentEncodings, encodings.Bytes())
+		demuxer, err := NewDemuxer(bytes.NewReader(createMockMatroskaFileWithTrack(buf.Bytes(), block)))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		setup(demuxer)
+
+		dst, err := os.Create(filepath.Join(t.TempDir(), "remuxed.mkv"))
+		if err != nil {
+			t.Fatalf("Failed to create output file: %v", err)
+		}
+		t.Cleanup(func() { _ = dst.Close() })
+		if err = Remux(dst, demuxer, []uint{0}); err != nil {
+			return nil, err
+		}
+		if _, err = dst.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		remuxed, err := NewDemuxer(dst)
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed on the remuxed file: %v", err)
+		}
+		return remuxed, nil
+	}
+
+	t.Run("Decompression disabled on the source", func(t *testing.T) {
+		frame := []byte("a frame that has been deflated by the muxer")
+		deflated := new(bytes.Buffer)
+		zw := zlib.NewWriter(deflated)
+		_, _ = zw.Write(frame)
+		_ = zw.Close()
+
+		compression := new(bytes.Buffer)
+		writeUIntElement(compression, IDContentCompAlgo, CompZlib, 1)
+		encoding := new(bytes.Buffer)
+		writeBinaryElement(encoding, IDContentCompression, compression.Bytes())
+		encodings := new(bytes.Buffer)
+		writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+		trackEntry, _ := createMockTrackEntry(1, TypeSubtitle, "S_TEXT/UTF8", "Subtitle", "und")
+
+		block := append([]byte{0x81, 0x00, 0x00, 0x80}, deflated.Bytes()...)
+		remuxed, err := remux(t, trackEntry, encodings, block, func(demuxer *Demuxer) {
+			demuxer.SetDecompression(false)
+		})
+		if err != nil {
+			t.Fatalf("Remux() failed: %v", err)
+		}
+		packet, err := remuxed.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, frame) {
+			t.Errorf("Packet data = %q, want %q", packet.Data, frame)
+		}
+	})
+
+	t.Run("Encrypted without a key", func(t *testing.T) {
+		aesSettings := new(bytes.Buffer)
+		writeUIntElement(aesSettings, IDAESSettingsCipherMode, AESCipherModeCTR, 1)
+		encryption := new(bytes.Buffer)
+		writeUIntElement(encryption, IDContentEncAlgo, EncAlgoAES, 1)
+		writeBinaryElement(encryption, IDContentEncAESSettings, aesSettings.Bytes())
+		encoding := new(bytes.Buffer)
+		writeUIntElement(encoding, IDContentEncodingType, ContentEncodingTypeEncryption, 1)
+		writeBinaryElement(encoding, IDContentEncryption, encryption.Bytes())
+		encodings := new(bytes.Buffer)
+		writeBinaryElement(encodings, IDContentEncoding, encoding.Bytes())
+		trackEntry, _ := createMockTrackEntry(1, TypeVideo, "V_VP9", "Video", "und")
+
+		block := []byte{0x81, 0x00, 0x00, 0x80, 0x00, 0xAA}
+		if _, err := remux(t, trackEntry, encodings, block, func(*Demuxer) {}); err == nil {
+			t.Error("Expected an error for an encrypted track without a key")
+		}
+	})
+}
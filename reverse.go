@@ -0,0 +1,217 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains ReadPrevPacket, which reads packets backwards.
+package matroska
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// prevPacket is a packet buffered by ReadPrevPacket, with the position from
+// which readPacket returns it.
+type prevPacket struct {
+	packet *Packet
+	start  int64
+}
+
+// ReadPrevPacket returns the packet before the read position, so that
+// repeated calls iterate over the packets backwards, for example when
+// scrubbing backwards in an editor.
+//
+// The read position acts as a cursor between packets: ReadPrevPacket returns
+// the packet before it and moves it back, and ReadPacket returns the packet
+// after it and moves it forward. After reading a file to the end, the first
+// call returns its last packet.
+//
+// The packets of one cluster are read at a time. The previous cluster is found
+// from the PrevSize element of the current cluster, or, if it has none, by
+// searching backwards for a Cluster ID. Packets of the tracks masked by
+// ReadPacketMask are not returned.
+//
+// Example:
+//
+//	for {
+//	    packet, err := demuxer.ReadPrevPacket()
+//	    if err == io.EOF {
+//	        break // The first packet has been returned
+//	    }
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Printf("Track %d at %d ns\n", packet.Track, packet.StartTime)
+//	}
+//
+// Returns:
+//   - *Packet: The previous packet.
+//   - error: io.EOF before the first packet, or an error if the demuxer
+//     avoids seeks or a packet could not be read.
+func (d *Demuxer) ReadPrevPacket() (*Packet, error) {
+	d.lock()
+	defer d.unlock()
+	return d.parser.ReadPrevPacket()
+}
+
+// ReadPrevPacket returns the packet before the read position. See
+// Demuxer.ReadPrevPacket.
+//
+// Returns:
+//   - *Packet: The previous packet.
+//   - error: io.EOF before the first packet, or an error if the packet could
+//     not be read.
+func (mp *MatroskaParser) ReadPrevPacket() (*Packet, error) {
+	if mp.avoidSeeks {
+		return nil, fmt.Errorf("reading backwards not supported in streaming mode")
+	}
+	if mp.resumePending {
+		if _, err := mp.reader.Seek(mp.resumePos, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek to the first cluster: %w", err)
+		}
+		mp.resumePending = false
+	}
+
+	position := mp.reader.Position()
+	if !mp.prevActive || position != mp.prevPos {
+		// The reader has moved since the last call, start from the packets of
+		// the current cluster before the read position
+		mp.prevActive = false
+		mp.releasePrevPackets()
+		if mp.clusterStart > 0 && mp.clusterStart < position {
+			if err := mp.bufferPrevCluster(mp.clusterStart, position); err != nil {
+				return nil, err
+			}
+		} else {
+			// The reader is at the start of a cluster after a seek, or before
+			// the first cluster
+			mp.prevClusterStart, mp.prevClusterPrevSize = position, 0
+		}
+		mp.prevPos = position
+		mp.prevActive = true
+	}
+
+	for len(mp.prevPackets) == 0 {
+		start, found, err := mp.previousCluster()
+		if err == nil && !found {
+			err = io.EOF
+		}
+		if err != nil {
+			// Leave the reader before the first packet
+			if _, errSeek := mp.reader.Seek(mp.prevPos, io.SeekStart); errSeek != nil {
+				return nil, errSeek
+			}
+			return nil, err
+		}
+		if err = mp.bufferPrevCluster(start, mp.prevClusterStart); err != nil {
+			return nil, err
+		}
+	}
+
+	last := mp.prevPackets[len(mp.prevPackets)-1]
+	mp.prevPackets = mp.prevPackets[:len(mp.prevPackets)-1]
+	if _, err := mp.reader.Seek(last.start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	mp.prevPos = last.start
+	if err := mp.processPacket(last.packet); err != nil {
+		last.packet.Release()
+		return nil, err
+	}
+	if mp.rawTimestamps {
+		mp.rawPacketTimes(last.packet)
+	}
+	return last.packet, nil
+}
+
+// Flush discards the packets queued by ReadPrevPacket, which reads the packets
+// of a cluster at once and returns them one by one. SetTrackMask and Seek
+// flush the queue, so that the following calls read the packets again with
+// the new mask or from the new position; call Flush after moving the read
+// position by other means.
+//
+// Example:
+//
+//	packet, err := demuxer.ReadPrevPacket()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	demuxer.Flush()
+func (d *Demuxer) Flush() {
+	d.lock()
+	defer d.unlock()
+	d.parser.Flush()
+}
+
+// Flush discards the packets queued by ReadPrevPacket, releasing their
+// buffers. See Demuxer.Flush.
+func (mp *MatroskaParser) Flush() {
+	mp.releasePrevPackets()
+	mp.prevActive = false
+}
+
+// bufferPrevCluster reads the packets of the cluster at start that begin
+// before end, for ReadPrevPacket to return in reverse order. The cluster
+// state is left as that of the cluster.
+//
+// Parameters:
+//   - start: The position of the Cluster element.
+//   - end: The position at which reading stops, which is the read position or
+//     the start of the following cluster.
+//
+// Returns:
+//   - error: An error if a packet could not be read.
+func (mp *MatroskaParser) bufferPrevCluster(start, end int64) error {
+	if _, err := mp.reader.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	mp.segmentIndex = mp.segmentAt(uint64(start))
+	mp.releasePrevPackets()
+	for mp.reader.Position() < end {
+		position := mp.reader.Position()
+		packet, err := mp.readPacket(context.Background())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read packet: %w", err)
+		}
+		if int64(packet.FilePos) >= end {
+			packet.Release()
+			break
+		}
+		mp.prevPackets = append(mp.prevPackets, prevPacket{packet: packet, start: position})
+	}
+	mp.prevClusterStart = start
+	mp.prevClusterPrevSize = mp.clusterPrevSize
+	return nil
+}
+
+// previousCluster finds the cluster before the one last buffered by
+// bufferPrevCluster, from its PrevSize or by searching backwards up to the
+// start of the first segment.
+//
+// Returns:
+//   - int64: The position of the previous Cluster element.
+//   - bool: True if a previous cluster was found.
+//   - error: An error if the input could not be read.
+func (mp *MatroskaParser) previousCluster() (int64, bool, error) {
+	if mp.prevClusterPrevSize > 0 && mp.prevClusterPrevSize <= uint64(mp.prevClusterStart) {
+		start := mp.prevClusterStart - int64(mp.prevClusterPrevSize)
+		if _, err := mp.reader.Seek(start, io.SeekStart); err != nil {
+			return 0, false, err
+		}
+		id, _, err := mp.reader.ReadElementHeader()
+		if err == nil && id == IDCluster {
+			return start, true, nil
+		}
+	}
+	return mp.findClusterBefore(mp.prevClusterStart, int64(mp.segmentPos))
+}
+
+// releasePrevPackets drops the packets buffered by bufferPrevCluster that
+// were not returned, releasing their buffers. See WithPacketPool.
+func (mp *MatroskaParser) releasePrevPackets() {
+	for _, buffered := range mp.prevPackets {
+		buffered.packet.Release()
+	}
+	mp.prevPackets = nil
+}
@@ -0,0 +1,270 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// createPrevSizeFile creates a file with three clusters of two frames each,
+// the last two having a PrevSize element.
+func createPrevSizeFile(t *testing.T) []byte {
+	t.Helper()
+	elements := []segmentElement{mockInfoElement(nil), mockTracksElement(t, TypeVideo, "V_TEST")}
+	prevSize := 0
+	for i := 0; i < 3; i++ {
+		var cluster bytes.Buffer
+		putUIntElement(&cluster, IDTimestamp, uint64(i*1000))
+		if prevSize > 0 {
+			putUIntElement(&cluster, IDPrevSize, uint64(prevSize))
+		}
+		putElement(&cluster, IDSimpleBlock, []byte{0x81, 0x00, 0x00, 0x80, byte(i * 2)})
+		putElement(&cluster, IDSimpleBlock, []byte{0x81, 0x01, 0xF4, 0x00, byte(i*2 + 1)})
+		elements = append(elements, segmentElement{id: IDCluster, data: cluster.Bytes()})
+		prevSize = len(encodeElementID(IDCluster)) + len(encodeVInt(uint64(cluster.Len()))) + cluster.Len()
+	}
+	return buildTestFile(createMinimalEBMLHeader(), nil, elements...).data
+}
+
+// readAllPrev reads packets backwards until io.EOF.
+func readAllPrev(t *testing.T, demuxer *Demuxer) []*Packet {
+	t.Helper()
+	var packets []*Packet
+	for {
+		packet, err := demuxer.ReadPrevPacket()
+		if err == io.EOF {
+			return packets
+		}
+		if err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		packets = append(packets, packet)
+	}
+}
+
+func TestDemuxer_ReadPrevPacket(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+		{Type: TypeAudio, CodecID: "A_OPUS", DefaultDuration: 20000000},
+	}
+	// Each video keyframe starts a cluster, giving four clusters
+	var packets []*Packet
+	for frame := uint64(0); frame < 12; frame++ {
+		flags := uint32(0)
+		if frame%3 == 0 {
+			flags = KF
+		}
+		packets = append(packets,
+			&Packet{Track: 1, StartTime: frame * 40000000, Data: []byte{byte(frame)}, Flags: flags},
+			&Packet{Track: 2, StartTime: frame * 40000000, Data: []byte{0xA0, byte(frame)}, Flags: KF},
+		)
+	}
+
+	t.Run("Backwards from the end", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		for {
+			if _, err := demuxer.ReadPacket(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+
+		backward := readAllPrev(t, demuxer)
+		if len(backward) != len(packets) {
+			t.Fatalf("Expected %d packets, got %d", len(packets), len(backward))
+		}
+		for i, packet := range backward {
+			expected := packets[len(packets)-1-i]
+			if packet.Track != expected.Track || packet.StartTime != expected.StartTime || !bytes.Equal(packet.Data, expected.Data) {
+				t.Errorf("Packet %d: expected track %d at %d, got track %d at %d", i, expected.Track, expected.StartTime, packet.Track, packet.StartTime)
+			}
+		}
+
+		// The reader is left before the first packet
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if packet.StartTime != 0 || packet.Track != 1 {
+			t.Errorf("Expected the first packet, got track %d at %d", packet.Track, packet.StartTime)
+		}
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		// Stop in the middle of the second cluster
+		for i := 0; i < 8; i++ {
+			if _, err := demuxer.ReadPacket(); err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		for i := 7; i >= 5; i-- {
+			packet, err := demuxer.ReadPrevPacket()
+			if err != nil {
+				t.Fatalf("ReadPrevPacket() failed: %v", err)
+			}
+			if !bytes.Equal(packet.Data, packets[i].Data) {
+				t.Errorf("Expected packet %d, got % X", i, packet.Data)
+			}
+		}
+
+		// Reading forward returns the packet last read backwards
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, packets[5].Data) {
+			t.Errorf("Expected packet 5, got % X", packet.Data)
+		}
+		if packet, err = demuxer.ReadPrevPacket(); err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		if !bytes.Equal(packet.Data, packets[5].Data) {
+			t.Errorf("Expected packet 5 again, got % X", packet.Data)
+		}
+		if backward := readAllPrev(t, demuxer); len(backward) != 5 {
+			t.Errorf("Expected 5 earlier packets, got %d", len(backward))
+		}
+	})
+
+	t.Run("After a seek", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		for {
+			if _, err := demuxer.ReadPacket(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		// The seek lands on the cluster starting at frame 6
+		demuxer.Seek(240000000, 0)
+		backward := readAllPrev(t, demuxer)
+		if len(backward) != 12 {
+			t.Fatalf("Expected 12 packets before the cluster, got %d", len(backward))
+		}
+		if !bytes.Equal(backward[0].Data, packets[11].Data) {
+			t.Errorf("Expected packet 11 first, got % X", backward[0].Data)
+		}
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, packets)
+		for {
+			if _, err := demuxer.ReadPacket(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		if _, err := demuxer.ReadPrevPacket(); err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		if len(demuxer.parser.prevPackets) == 0 {
+			t.Fatal("Expected the rest of the cluster to be queued")
+		}
+		demuxer.Flush()
+		if len(demuxer.parser.prevPackets) != 0 || demuxer.parser.prevActive {
+			t.Errorf("Expected an empty queue after Flush(), got %d packets", len(demuxer.parser.prevPackets))
+		}
+
+		// Changing the mask flushes the queue, so the audio track is skipped
+		if _, err := demuxer.ReadPrevPacket(); err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		demuxer.SetTrackMask(1 << 1)
+		if len(demuxer.parser.prevPackets) != 0 {
+			t.Errorf("Expected an empty queue after SetTrackMask(), got %d packets", len(demuxer.parser.prevPackets))
+		}
+		for _, packet := range readAllPrev(t, demuxer) {
+			if packet.Track != 1 {
+				t.Fatalf("Expected only video packets after SetTrackMask(), got track %d", packet.Track)
+			}
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := demuxer.ReadPacket(); err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		if _, err := demuxer.ReadPrevPacket(); err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		if len(demuxer.parser.prevPackets) == 0 {
+			t.Fatal("Expected the first packet to be queued")
+		}
+		demuxer.Seek(240000000, 0)
+		if len(demuxer.parser.prevPackets) != 0 {
+			t.Errorf("Expected an empty queue after Seek(), got %d packets", len(demuxer.parser.prevPackets))
+		}
+	})
+
+	t.Run("PrevSize", func(t *testing.T) {
+		demuxer, err := NewDemuxer(bytes.NewReader(createPrevSizeFile(t)))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		for {
+			if _, err = demuxer.ReadPacket(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+
+		backward := readAllPrev(t, demuxer)
+		if len(backward) != 6 {
+			t.Fatalf("Expected 6 packets, got %d", len(backward))
+		}
+		for i, packet := range backward {
+			frame := 5 - i
+			expectedTime := uint64(frame/2*1000+frame%2*500) * 1000000
+			if packet.Data[0] != byte(frame) || packet.StartTime != expectedTime {
+				t.Errorf("Packet %d: expected frame %d at %d, got frame %d at %d", i, frame, expectedTime, packet.Data[0], packet.StartTime)
+			}
+		}
+	})
+
+	t.Run("Raw timestamps", func(t *testing.T) {
+		file := createMuxedFile(t, tracks, packets).reader.(*os.File)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		demuxer, err := NewDemuxer(file, WithRawTimestamps())
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err = demuxer.ReadPacket(); err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+		}
+		forward, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		backward, err := demuxer.ReadPrevPacket()
+		if err != nil {
+			t.Fatalf("ReadPrevPacket() failed: %v", err)
+		}
+		if backward.StartTime != forward.StartTime || backward.EndTime != forward.EndTime || forward.StartTime != 40 {
+			t.Errorf("ReadPrevPacket() returned [%d, %d], want [%d, %d] at 40 ms",
+				backward.StartTime, backward.EndTime, forward.StartTime, forward.EndTime)
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		file := createMuxedFile(t, tracks, packets).reader.(*os.File)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		demuxer, err := NewStreamingDemuxer(file)
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		if _, err = demuxer.ReadPrevPacket(); err == nil {
+			t.Error("Expected an error without seeking")
+		}
+	})
+}
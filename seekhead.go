@@ -0,0 +1,215 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the SeekHead support used by OpenMetadata to read the
+// metadata of a file without reading its clusters.
+package matroska
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// seekHeadTargets are the top-level elements that OpenMetadata reads through
+// the SeekHead, in the order in which they are read. A SeekHead may point to
+// a second SeekHead, which is read first.
+var seekHeadTargets = []uint32{
+	IDSeekHead,
+	IDSegmentInfo,
+	IDTracks,
+	IDCues,
+	IDChapters,
+	IDTags,
+	IDAttachments,
+}
+
+// OpenMetadata creates a new Matroska demuxer that reads only the metadata of
+// a file: the EBML header, the segment information, the tracks, and the cues,
+// chapters, tags and attachments.
+//
+// Unlike NewDemuxer, OpenMetadata does not scan the clusters of the file.
+// The elements found before the first cluster are read, and those stored
+// after the clusters are read by seeking to the positions listed in the
+// SeekHead, when the file has one. This makes opening large files fast when
+// only their metadata is needed, such as when indexing a media library.
+//
+// ReadPacket can still be used afterwards: the first call seeks back to the
+// first cluster. The concatenated segments following the first one are not
+// looked for, see Segments.
+//
+// Example:
+//
+//	demuxer, err := matroska.OpenMetadata(file)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	numTracks, _ := demuxer.GetNumTracks()
+//	fmt.Printf("%d tracks, %d chapters, %d tags\n",
+//	    numTracks, len(demuxer.GetChapters()), len(demuxer.GetTags()))
+//
+// Parameters:
+//   - r: An io.ReadSeeker that provides access to the Matroska file data.
+//   - opts: The options configuring the demuxer, such as WithCRCValidation.
+//     WithAvoidSeeks cannot be used, as the SeekHead requires seeking.
+//
+// Returns:
+//   - *Demuxer: A pointer to the initialized Demuxer.
+//   - error: An error if the metadata could not be read or if the file is not
+//     a valid Matroska or WebM file.
+func OpenMetadata(r io.ReadSeeker, opts ...Option) (*Demuxer, error) {
+	parser, err := NewMatroskaParserWithOptions(r, append(opts, withMetadataOnly())...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser: %w", err)
+	}
+
+	return newDemuxer(parser, r), nil
+}
+
+// withMetadataOnly makes the parser skip the scans of the segment done when a
+// file is opened, and read the elements listed in the SeekHead instead. See
+// OpenMetadata.
+//
+// Returns:
+//   - Option: The option.
+func withMetadataOnly() Option {
+	return func(mp *MatroskaParser) {
+		mp.metadataOnly = true
+	}
+}
+
+// parseSeekHead records the positions of the elements listed in a SeekHead.
+// The first position found for an element is kept.
+//
+// Parameters:
+//   - size: The size of the SeekHead element in bytes.
+//
+// Returns:
+//   - error: An error if the SeekHead could not be read or parsed.
+func (mp *MatroskaParser) parseSeekHead(size uint64) error {
+	data, err := mp.readMasterData(IDSeekHead, size)
+	if err != nil {
+		return err
+	}
+	if mp.seekHead == nil {
+		mp.seekHead = make(map[uint32]uint64)
+	}
+
+	reader := bytes.NewReader(data)
+	childReader := &EBMLReader{r: &seekableReader{reader}, pos: 0}
+
+	for childReader.pos < int64(size) {
+		element, errReadElement := childReader.ReadElement()
+		if errReadElement != nil {
+			if errReadElement == io.EOF {
+				break
+			}
+			return errReadElement
+		}
+		if element.ID != IDSeek {
+			continue
+		}
+
+		var id uint32
+		var position uint64
+		hasPosition := false
+		seekReader := &EBMLReader{r: &seekableReader{bytes.NewReader(element.Data)}, pos: 0}
+		for seekReader.pos < int64(len(element.Data)) {
+			child, errReadChild := seekReader.ReadElement()
+			if errReadChild != nil {
+				if errReadChild == io.EOF {
+					break
+				}
+				return errReadChild
+			}
+			switch child.ID {
+			case IDSeekID:
+				id = uint32(child.ReadUInt())
+			case IDSeekPos:
+				position = child.ReadUInt()
+				hasPosition = true
+			}
+		}
+		if _, ok := mp.seekHead[id]; id != 0 && hasPosition && !ok {
+			mp.seekHead[id] = mp.segmentPos + position
+		}
+	}
+
+	return nil
+}
+
+// parseSeekHeadTargets reads the elements listed in the SeekHead that were
+// not found before the first cluster, such as Cues or Tags stored at the end
+// of the file. The read position is left after the last element read.
+//
+// Entries that do not point to the expected element, as in a file edited
+// without updating its SeekHead, are ignored.
+//
+// Returns:
+//   - error: An error if an element could not be read or parsed.
+func (mp *MatroskaParser) parseSeekHeadTargets() error {
+	visited := make(map[uint64]bool)
+	for _, target := range seekHeadTargets {
+		position, ok := mp.seekHead[target]
+		if !ok || visited[position] || mp.hasParsed(target) {
+			continue
+		}
+		visited[position] = true
+
+		if _, err := mp.reader.Seek(int64(position), io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek to element 0x%X: %w", target, err)
+		}
+		id, size, err := mp.reader.ReadElementHeader()
+		if err != nil || id != target {
+			continue
+		}
+
+		switch id {
+		case IDSeekHead:
+			err = mp.parseSeekHead(size)
+		case IDSegmentInfo:
+			err = mp.parseSegmentInfo(size)
+		case IDTracks:
+			err = mp.parseTracks(size)
+		case IDCues:
+			mp.cuesPos = uint64(mp.reader.Position())
+			mp.cuesTopPos = mp.cuesPos + size
+			err = mp.parseCues(size)
+		case IDChapters:
+			err = mp.parseChapters(size)
+		case IDTags:
+			err = mp.parseTags(size)
+		case IDAttachments:
+			err = mp.parseAttachments(size)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse element 0x%X: %w", id, err)
+		}
+	}
+
+	mp.segment.Info = mp.fileInfo
+	return nil
+}
+
+// hasParsed reports whether a top-level element has already been parsed.
+//
+// Parameters:
+//   - id: The ID of the element.
+//
+// Returns:
+//   - bool: True if the data of the element is already known.
+func (mp *MatroskaParser) hasParsed(id uint32) bool {
+	switch id {
+	case IDSegmentInfo:
+		return mp.fileInfo != nil
+	case IDTracks:
+		return len(mp.tracks) > 0
+	case IDCues:
+		return mp.cuesPos != 0
+	case IDChapters:
+		return len(mp.chapters) > 0
+	case IDTags:
+		return len(mp.tags) > 0
+	case IDAttachments:
+		return len(mp.attachments) > 0
+	}
+	return false
+}
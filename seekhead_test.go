@@ -0,0 +1,147 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// readRecorder records the ranges of an io.ReadSeeker that are read.
+type readRecorder struct {
+	*bytes.Reader
+	ranges [][2]int64
+}
+
+func (rr *readRecorder) Read(p []byte) (int, error) {
+	start, _ := rr.Seek(0, io.SeekCurrent)
+	n, err := rr.Reader.Read(p)
+	if n > 0 {
+		rr.ranges = append(rr.ranges, [2]int64{start, start + int64(n)})
+	}
+	return n, err
+}
+
+// overlaps reports whether any byte in [start, end) has been read.
+func (rr *readRecorder) overlaps(start, end int64) bool {
+	for _, r := range rr.ranges {
+		if r[0] < end && start < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// createSeekHeadFile creates a file whose Cues and Tags follow its cluster and
+// are listed in a SeekHead, and returns it with the range of the cluster data.
+func createSeekHeadFile(t *testing.T) ([]byte, int64, int64) {
+	t.Helper()
+	var title, simpleTag, tag, tags bytes.Buffer
+	putStringElement(&title, IDTitle, "SeekHead Title")
+	putStringElement(&simpleTag, IDTagName, "ARTIST")
+	putStringElement(&simpleTag, IDTagString, "Someone")
+	putElement(&tag, IDSimpleTag, simpleTag.Bytes())
+	putElement(&tags, IDTag, tag.Bytes())
+
+	const cluster = 2
+	cues := func(positions []int64) []byte {
+		var trackPosition, cuePoint, cues bytes.Buffer
+		putUIntElement(&trackPosition, IDCueTrack, 1)
+		putUIntElement(&trackPosition, IDCueClusterPos, uint64(positions[cluster]))
+		putUIntElement(&cuePoint, IDCueTime, 0)
+		putElement(&cuePoint, IDCueTrackPosition, trackPosition.Bytes())
+		putElement(&cues, IDCuePoint, cuePoint.Bytes())
+		return cues.Bytes()
+	}
+
+	// Padding larger than the read buffer, which must not be read
+	f := buildTestFile(createMinimalEBMLHeader(), []uint32{IDCues, IDTags},
+		mockInfoElement(title.Bytes()),
+		mockTracksElement(t, TypeVideo, "V_TEST"),
+		mockFrameCluster(4*readBufferSize),
+		segmentElement{id: IDCues, dataAt: cues},
+		segmentElement{id: IDTags, data: tags.Bytes()},
+	)
+	return f.data, f.dataStart(cluster), f.end(cluster)
+}
+
+func TestOpenMetadata(t *testing.T) {
+	data, clusterStart, clusterEnd := createSeekHeadFile(t)
+
+	recorder := &readRecorder{Reader: bytes.NewReader(data)}
+	demuxer, err := OpenMetadata(recorder)
+	if err != nil {
+		t.Fatalf("OpenMetadata() failed: %v", err)
+	}
+	// Only the read buffer filled when the start of the cluster is found may
+	// hold cluster data
+	if recorder.overlaps(clusterStart+readBufferSize, clusterEnd) {
+		t.Error("Expected the cluster data not to be read")
+	}
+
+	if fileInfo, _ := demuxer.GetFileInfo(); fileInfo == nil || fileInfo.Title != "SeekHead Title" {
+		t.Errorf("Expected the segment info to be read, got %+v", fileInfo)
+	}
+	if numTracks, _ := demuxer.GetNumTracks(); numTracks != 1 {
+		t.Errorf("Expected 1 track, got %d", numTracks)
+	}
+	tags := demuxer.GetTags()
+	if len(tags) != 1 || len(tags[0].SimpleTags) != 1 || tags[0].SimpleTags[0].Value != "Someone" {
+		t.Errorf("Expected the tags listed in the SeekHead, got %+v", tags)
+	}
+	if cues := demuxer.GetCues(); len(cues) != 1 {
+		t.Errorf("Expected the cues listed in the SeekHead, got %d", len(cues))
+	}
+
+	t.Run("ReadPacket after opening", func(t *testing.T) {
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if string(packet.Data) != "frame" {
+			t.Errorf("Expected packet data %q, got %q", "frame", packet.Data)
+		}
+		if _, err = demuxer.ReadPacket(); err != io.EOF {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("Seek after opening", func(t *testing.T) {
+		demuxer, err := OpenMetadata(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("OpenMetadata() failed: %v", err)
+		}
+		demuxer.Seek(0, 0)
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if string(packet.Data) != "frame" {
+			t.Errorf("Expected packet data %q, got %q", "frame", packet.Data)
+		}
+	})
+
+	t.Run("Without SeekHead", func(t *testing.T) {
+		trackEntry, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "TestVideo", "und")
+		if err != nil {
+			t.Fatalf("createMockTrackEntry() failed: %v", err)
+		}
+		data := createMockMatroskaFileWithTrack(trackEntry, []byte{0x81, 0x00, 0x00, 0x80, 'x'})
+		demuxer, err := OpenMetadata(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("OpenMetadata() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if string(packet.Data) != "x" {
+			t.Errorf("Expected packet data %q, got %q", "x", packet.Data)
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		if _, err := OpenMetadata(bytes.NewReader(data), WithAvoidSeeks()); err == nil {
+			t.Error("Expected an error when avoiding seeks")
+		}
+	})
+}
@@ -0,0 +1,252 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the support for files made of several concatenated
+// segments, such as appended recordings.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// Segments returns the segments of the file, in file order.
+//
+// A Matroska file may contain several Segment elements one after the other.
+// ReadPacket reads them in turn, using the tracks of the first segment, and
+// adds the TimeOffset of each segment to the timestamps of its packets so that
+// they follow the previous segments. Metadata, cues, chapters, tags and
+// attachments are those of the first segment.
+//
+// With a seekable input, the segments following the first one are found when
+// the file is opened, provided that the first segment has a known size.
+// Otherwise they are added as ReadPacket reaches them. The returned segments
+// are a copy, which is not updated by later reads.
+//
+// Example:
+//
+//	for i, segment := range demuxer.Segments() {
+//	    fmt.Printf("Segment %d at %d: %d bytes, starting at %d ns\n",
+//	        i, segment.Position, segment.Size, segment.TimeOffset)
+//	}
+//
+// Returns:
+//   - []*SegmentElement: The segments found so far. The first one is always present.
+func (d *Demuxer) Segments() []*SegmentElement {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	segments := make([]*SegmentElement, len(d.parser.segments))
+	for i, segment := range d.parser.segments {
+		segmentCopy := *segment
+		segments[i] = &segmentCopy
+	}
+	return segments
+}
+
+// Segments returns the segments of the file found so far, in file order.
+// See Demuxer.Segments.
+//
+// Returns:
+//   - []*SegmentElement: The segments of the file.
+func (mp *MatroskaParser) Segments() []*SegmentElement {
+	return mp.segments
+}
+
+// scanSegments finds the segments following the first one, by seeking from
+// the end of each segment to the next top-level element, and records them
+// with their SegmentInfo. The read position is restored afterwards.
+//
+// The scan stops at the end of the file, at a segment of unknown size, or at
+// data that cannot be read, so a damaged file keeps the segments found before.
+//
+// Returns:
+//   - error: An error if the read position could not be restored.
+func (mp *MatroskaParser) scanSegments() error {
+	if mp.segment.Size == (1<<(7*8))-1 {
+		return nil
+	}
+
+	currentPos := mp.reader.Position()
+	next := int64(mp.segmentTopPos)
+	for {
+		if _, err := mp.reader.Seek(next, io.SeekStart); err != nil {
+			break
+		}
+		id, size, err := mp.reader.ReadElementHeader()
+		if err != nil {
+			break
+		}
+		if id == IDSegment {
+			segment := &SegmentElement{Position: uint64(mp.reader.Position()), Size: size}
+			if err = mp.parseSegmentHead(segment); err != nil {
+				break
+			}
+			mp.segments = append(mp.segments, segment)
+			if size == (1<<(7*8))-1 {
+				break
+			}
+			next = int64(segment.Position + size)
+			continue
+		}
+		if size == (1<<(7*8))-1 {
+			break
+		}
+		next = mp.reader.Position() + int64(size)
+	}
+
+	// Offsets are known up to the first segment of unknown duration
+	for i := 1; i < len(mp.segments); i++ {
+		previous := mp.segments[i-1]
+		if previous.Info == nil || previous.Info.DurationNanos() == 0 {
+			break
+		}
+		mp.segments[i].TimeOffset = previous.TimeOffset + uint64(previous.Info.DurationNanos())
+	}
+
+	if _, err := mp.reader.Seek(currentPos, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to restore position: %w", err)
+	}
+	return nil
+}
+
+// parseSegmentHead parses the children of a segment whose header has just
+// been read, up to its first Cluster, and sets the segment's Info from its
+// SegmentInfo. Other elements, such as the Tracks, are skipped.
+//
+// When a Cluster is found, its header has been read and the cluster state is
+// reset, so that reading continues with the blocks of the cluster.
+//
+// Parameters:
+//   - segment: The segment being parsed.
+//
+// Returns:
+//   - error: An error if an element could not be read or parsed.
+func (mp *MatroskaParser) parseSegmentHead(segment *SegmentElement) error {
+	end := segment.Position + segment.Size
+	for uint64(mp.reader.Position()) < end {
+		position := mp.reader.Position()
+		id, size, err := mp.reader.ReadElementHeader()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case IDSegmentInfo:
+			// parseSegmentInfo sets the info of the first segment, which is kept
+			first := mp.fileInfo
+			err = mp.parseSegmentInfo(size)
+			segment.Info, mp.fileInfo = mp.fileInfo, first
+			if err != nil {
+				return fmt.Errorf("failed to parse segment info: %w", err)
+			}
+		case IDCluster:
+			mp.resetClusterState()
+			mp.clusterStart = position
+			return nil
+		default:
+			if err = mp.skipElement(size); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// enterSegment continues reading in a segment whose header has just been
+// read by readPacket. The segment is recorded if it was not found when the
+// file was opened, and its time offset is set to follow the previous segment.
+//
+// Parameters:
+//   - size: The size of the Segment element.
+//
+// Returns:
+//   - error: An error if the head of the segment could not be parsed.
+func (mp *MatroskaParser) enterSegment(size uint64) error {
+	position := uint64(mp.reader.Position())
+	index := -1
+	for i, segment := range mp.segments {
+		if segment.Position == position {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		mp.segments = append(mp.segments, &SegmentElement{Position: position, Size: size})
+		index = len(mp.segments) - 1
+	}
+
+	// The previous segment is the current one, unless it is being read again
+	segment := mp.segments[index]
+	if index > 0 {
+		previous := mp.segments[index-1]
+		if previous.Info != nil && previous.Info.DurationNanos() > 0 {
+			segment.TimeOffset = previous.TimeOffset + uint64(previous.Info.DurationNanos())
+		} else if mp.segmentIndex == index-1 {
+			segment.TimeOffset = mp.lastPacketEnd
+		}
+	}
+	mp.segmentIndex = index
+
+	return mp.parseSegmentHead(segment)
+}
+
+// segmentAt returns the index of the segment containing a file position.
+//
+// Parameters:
+//   - position: The file position.
+//
+// Returns:
+//   - int: The index of the last segment starting at or before position.
+func (mp *MatroskaParser) segmentAt(position uint64) int {
+	index := 0
+	for i, segment := range mp.segments {
+		if segment.Position <= position {
+			index = i
+		}
+	}
+	return index
+}
+
+// timecodeScale returns the TimecodeScale of the segment being read.
+//
+// Returns:
+//   - uint64: The number of nanoseconds per timestamp unit.
+func (mp *MatroskaParser) timecodeScale() uint64 {
+	if mp.segmentIndex > 0 {
+		if info := mp.segments[mp.segmentIndex].Info; info != nil {
+			return info.TimecodeScale
+		}
+		return 1000000
+	}
+	return mp.fileInfo.TimecodeScale
+}
+
+// blockTime converts a block timestamp, in timestamp units of the segment
+// being read, to nanoseconds from the start of the file.
+//
+// Parameters:
+//   - timestamp: The absolute timestamp of the block.
+//
+// Returns:
+//   - uint64: The time in nanoseconds, including the time offset of the segment.
+func (mp *MatroskaParser) blockTime(timestamp uint64) uint64 {
+	time := timestamp * mp.timecodeScale()
+	if mp.segmentIndex > 0 {
+		time += mp.segments[mp.segmentIndex].TimeOffset
+	}
+	return time
+}
+
+// notePacketEnd records the end time of a packet that has been parsed, which
+// becomes the time offset of the next segment if the current one has no
+// duration.
+//
+// Parameters:
+//   - packet: The parsed packet.
+func (mp *MatroskaParser) notePacketEnd(packet *Packet) {
+	end := packet.EndTime
+	if packet.StartTime > end {
+		end = packet.StartTime
+	}
+	if end > mp.lastPacketEnd {
+		mp.lastPacketEnd = end
+	}
+}
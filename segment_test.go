@@ -0,0 +1,141 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// createTwoSegmentFile muxes two files with the same tracks and concatenates
+// them, keeping or dropping the EBML header of the second file.
+func createTwoSegmentFile(t *testing.T, tracks []*TrackInfo, first, second []*Packet, secondHeader bool) []byte {
+	t.Helper()
+	data, err := os.ReadFile(createMuxedFile(t, tracks, first).reader.(*os.File).Name())
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	appended, err := os.ReadFile(createMuxedFile(t, tracks, second).reader.(*os.File).Name())
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !secondHeader {
+		reader := NewEBMLReader(bytes.NewReader(appended))
+		if _, size, errReadElementHeader := reader.ReadElementHeader(); errReadElementHeader != nil {
+			t.Fatalf("ReadElementHeader() failed: %v", errReadElementHeader)
+		} else {
+			appended = appended[reader.Position()+int64(size):]
+		}
+	}
+	return append(data, appended...)
+}
+
+func TestDemuxer_Segments(t *testing.T) {
+	tracks := []*TrackInfo{{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000}}
+	first := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x02}},
+		{Track: 1, StartTime: 80000000, Data: []byte{0x03}},
+	}
+	second := []*Packet{
+		{Track: 1, StartTime: 0, Data: []byte{0x04}, Flags: KF},
+		{Track: 1, StartTime: 40000000, Data: []byte{0x05}},
+	}
+
+	// The Muxer declares the start time of the last packet as the duration
+	const firstDuration = 80000000
+	expected := []uint64{0, 40000000, 80000000, firstDuration, firstDuration + 40000000}
+
+	readAll := func(t *testing.T, demuxer *Demuxer) []uint64 {
+		t.Helper()
+		var times []uint64
+		for i := 0; ; i++ {
+			packet, err := demuxer.ReadPacket()
+			if err == io.EOF {
+				return times
+			}
+			if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+			if packet.Data[0] != byte(i+1) {
+				t.Errorf("Packet %d: expected data 0x%02X, got 0x%02X", i, i+1, packet.Data[0])
+			}
+			times = append(times, packet.StartTime)
+		}
+	}
+	checkTimes := func(t *testing.T, times, expected []uint64) {
+		t.Helper()
+		if len(times) != len(expected) {
+			t.Fatalf("Expected %d packets, got %d", len(expected), len(times))
+		}
+		for i := range times {
+			if times[i] != expected[i] {
+				t.Errorf("Packet %d: expected start time %d, got %d", i, expected[i], times[i])
+			}
+		}
+	}
+
+	for _, secondHeader := range []bool{true, false} {
+		name := "Without EBML header"
+		if secondHeader {
+			name = "With EBML header"
+		}
+		t.Run(name, func(t *testing.T) {
+			data := createTwoSegmentFile(t, tracks, first, second, secondHeader)
+			demuxer, err := NewDemuxer(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("NewDemuxer() failed: %v", err)
+			}
+
+			segments := demuxer.Segments()
+			if len(segments) != 2 {
+				t.Fatalf("Expected 2 segments, got %d", len(segments))
+			}
+			if segments[0].Position != demuxer.GetSegment() || segments[1].Position <= demuxer.GetSegmentTop() {
+				t.Errorf("Unexpected segment positions %d and %d", segments[0].Position, segments[1].Position)
+			}
+			if segments[1].Info == nil || segments[1].TimeOffset != firstDuration {
+				t.Errorf("Expected the second segment to have info and offset %d, got %+v", firstDuration, segments[1])
+			}
+
+			checkTimes(t, readAll(t, demuxer), expected)
+
+			duration, err := demuxer.ComputeDuration()
+			if err != nil {
+				t.Fatalf("ComputeDuration() failed: %v", err)
+			}
+			if duration != firstDuration+80000000 {
+				t.Errorf("Expected duration %d, got %d", firstDuration+80000000, duration)
+			}
+		})
+	}
+
+	t.Run("First segment without duration", func(t *testing.T) {
+		data := createTwoSegmentFile(t, tracks, first, second, false)
+		demuxer, err := NewDemuxer(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		demuxer.parser.fileInfo.Duration = 0
+		demuxer.parser.segments[1].TimeOffset = 0
+
+		// The second segment follows the end of the last frame of the first
+		const firstEnd = 120000000
+		checkTimes(t, readAll(t, demuxer), []uint64{0, 40000000, 80000000, firstEnd, firstEnd + 40000000})
+		if offset := demuxer.Segments()[1].TimeOffset; offset != firstEnd {
+			t.Errorf("Expected offset %d, got %d", firstEnd, offset)
+		}
+	})
+
+	t.Run("Seeking returns to the first segment", func(t *testing.T) {
+		data := createTwoSegmentFile(t, tracks, first, second, false)
+		demuxer, err := NewDemuxer(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+		readAll(t, demuxer)
+		demuxer.Seek(0, 0)
+		checkTimes(t, readAll(t, demuxer), expected)
+	})
+
+}
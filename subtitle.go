@@ -0,0 +1,281 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the formatting of subtitle tracks to standalone subtitle files.
+package matroska
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatSRTEntry formats a subtitle packet as a SubRip (SRT) entry.
+//
+// An entry consists of its sequence number, its start and end times, and its
+// text, followed by a blank line:
+//
+//	1
+//	00:00:01,000 --> 00:00:04,000
+//	Subtitle text here
+//
+// CRLF line endings in the text are converted to LF, and empty subtitles are
+// written as a single space.
+//
+// Parameters:
+//   - index: The sequence number of the entry, starting at 1.
+//   - packet: The subtitle packet.
+//
+// Returns:
+//   - string: The formatted entry.
+func formatSRTEntry(index int, packet *Packet) string {
+	text := strings.ReplaceAll(string(packet.Data), "\r\n", "\n")
+	if text == "" {
+		text = " "
+	}
+	return fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index, formatSRTTime(packet.StartTime), formatSRTTime(packet.EndTime), text)
+}
+
+// formatSRTTime formats a time in nanoseconds as an SRT timestamp (HH:MM:SS,mmm).
+//
+// Parameters:
+//   - ns: The time in nanoseconds.
+//
+// Returns:
+//   - string: The formatted timestamp, for example "01:01:01,123".
+func formatSRTTime(ns uint64) string {
+	ms := ns / 1000000
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// ExportSRT writes a "S_TEXT/UTF8" subtitle track to w as a SubRip (SRT) file,
+// where track is less than what is returned by GetNumTracks.
+//
+// Subtitles are read from the current position until the end of the file, and
+// are numbered from 1 in the order they are read. Each entry's timestamps are
+// the StartTime and EndTime of its packet, and CRLF line endings in its text
+// are converted to LF. No byte order mark is written.
+//
+// Matroska blocks only carry a start time, so the end time of a subtitle comes
+// from its BlockDuration or from the track's DefaultDuration. An error is
+// returned if a subtitle has neither, and the entries before it are written.
+//
+// Example:
+//
+//	out, err := os.Create("subtitles.srt")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer out.Close()
+//	if err = demuxer.ExportSRT(out, 2); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - w: The destination of the SRT file.
+//   - track: The index of the subtitle track.
+//
+// Returns:
+//   - error: An error if the track index is invalid, the track is not a
+//     "S_TEXT/UTF8" track, a subtitle has no end time, or the data could not
+//     be read or written.
+func (d *Demuxer) ExportSRT(w io.Writer, track uint) error {
+	d.lock()
+	defer d.unlock()
+	trackInfo, err := d.trackInfo(track)
+	if err != nil {
+		return err
+	}
+	if trackInfo.CodecID != "S_TEXT/UTF8" {
+		return fmt.Errorf("%w: track %d has codec %s, not S_TEXT/UTF8", ErrUnsupportedCodec, track, trackInfo.CodecID)
+	}
+	return d.extractTrack(w, track, ExtractOptions{SRT: true})
+}
+
+// assBlockFields is the order of the fields stored in the blocks of ASS and SSA
+// tracks. Matroska moves the start and end times to the block timestamps, and
+// adds the ReadOrder of the event in the original file. SSA files store their
+// Marked field in place of the Layer.
+var assBlockFields = []string{"readorder", "layer", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+// assDefaultFormat is the format of the [Events] section written when the
+// CodecPrivate of a track does not have one.
+const assDefaultFormat = "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text"
+
+// assEvent is a Dialogue line of an ASS or SSA file, with its fields by name.
+type assEvent struct {
+	readOrder int
+	fields    map[string]string
+}
+
+// ExportASS writes an ASS or SSA subtitle track to w as a standalone .ass or
+// .ssa file, where track is less than what is returned by GetNumTracks.
+//
+// The [Script Info], [V4+ Styles] and [Events] headers of the file are stored
+// in the track's CodecPrivate, and are written first. Each block of the track
+// holds a single event, with its fields in the Matroska order: ReadOrder,
+// Layer, Style, Name, MarginL, MarginR, MarginV, Effect and Text, and with
+// its start and end times in the block timestamps. ExportASS rebuilds the
+// Dialogue lines in the order of the Format line of the [Events] section, and
+// writes them in their ReadOrder, which is the order of the original file.
+//
+// The events are read from the current position until the end of the file,
+// and are all held in memory, as they are written in ReadOrder. As with
+// ExportSRT, an error is returned if an event has no end time.
+//
+// Example:
+//
+//	out, err := os.Create("subtitles.ass")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer out.Close()
+//	if err = demuxer.ExportASS(out, 2); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - w: The destination of the ASS or SSA file.
+//   - track: The index of the subtitle track.
+//
+// Returns:
+//   - error: An error if the track index is invalid, the track is not an ASS
+//     or SSA track, an event is malformed or has no end time, or the data could
+//     not be read or written.
+func (d *Demuxer) ExportASS(w io.Writer, track uint) error {
+	d.lock()
+	defer d.unlock()
+	trackInfo, err := d.trackInfo(track)
+	if err != nil {
+		return err
+	}
+	switch trackInfo.CodecID {
+	case "S_TEXT/ASS", "S_TEXT/SSA", "S_ASS", "S_SSA":
+	default:
+		return fmt.Errorf("%w: track %d has codec %s, not S_TEXT/ASS or S_TEXT/SSA", ErrUnsupportedCodec, track, trackInfo.CodecID)
+	}
+
+	var events []*assEvent
+	err = d.readTrackPackets(trackInfo.Number, func(packet *Packet) error {
+		if packet.EndTime <= packet.StartTime {
+			return fmt.Errorf("event %d has no end time, as the track has no BlockDuration or DefaultDuration", len(events)+1)
+		}
+		event, errParse := parseASSEvent(packet)
+		if errParse != nil {
+			return fmt.Errorf("event %d: %w", len(events)+1, errParse)
+		}
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].readOrder < events[j].readOrder
+	})
+
+	header, format := assHeader(string(trackInfo.CodecPrivate))
+	var out strings.Builder
+	out.WriteString(header)
+	for _, event := range events {
+		values := make([]string, len(format))
+		for i, name := range format {
+			values[i] = event.fields[name]
+		}
+		out.WriteString("Dialogue: " + strings.Join(values, ",") + "\n")
+	}
+
+	if _, err = io.WriteString(w, out.String()); err != nil {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
+	return nil
+}
+
+// parseASSEvent parses the fields of an ASS or SSA event stored in a block.
+//
+// Parameters:
+//   - packet: The packet of the event.
+//
+// Returns:
+//   - *assEvent: The event, with its fields and times by lowercase field name.
+//   - error: An error if the event does not have all its fields.
+func parseASSEvent(packet *Packet) (*assEvent, error) {
+	values := strings.SplitN(strings.TrimRight(string(packet.Data), "\r\n"), ",", len(assBlockFields))
+	if len(values) < len(assBlockFields) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(assBlockFields), len(values))
+	}
+	readOrder, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ReadOrder %q", values[0])
+	}
+
+	event := &assEvent{readOrder: readOrder, fields: make(map[string]string, len(values)+2)}
+	for i, name := range assBlockFields {
+		event.fields[name] = values[i]
+	}
+	event.fields["start"] = formatASSTime(packet.StartTime)
+	event.fields["end"] = formatASSTime(packet.EndTime)
+	event.fields["marked"] = "Marked=" + strings.TrimPrefix(values[1], "Marked=")
+	event.fields["actor"] = event.fields["name"]
+	return event, nil
+}
+
+// assHeader returns the header of an ASS or SSA file from a track's
+// CodecPrivate, and the field names of its [Events] Format line.
+//
+// If the CodecPrivate has no [Events] section, or no Format line in it, one is
+// added with the standard ASS fields.
+//
+// Parameters:
+//   - codecPrivate: The track's CodecPrivate.
+//
+// Returns:
+//   - string: The header, ending with a newline.
+//   - []string: The lowercase field names of the Dialogue lines.
+func assHeader(codecPrivate string) (string, []string) {
+	header := strings.TrimRight(strings.ReplaceAll(codecPrivate, "\r\n", "\n"), "\n") + "\n"
+
+	inEvents := false
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if inEvents && strings.HasPrefix(line, "Format:") {
+			return header, parseASSFormat(line)
+		}
+	}
+
+	if !inEvents {
+		header += "\n[Events]\n"
+	}
+	return header + assDefaultFormat + "\n", parseASSFormat(assDefaultFormat)
+}
+
+// parseASSFormat parses the field names of a Format line.
+//
+// Parameters:
+//   - line: The Format line, such as "Format: Layer, Start, End, ...".
+//
+// Returns:
+//   - []string: The lowercase field names.
+func parseASSFormat(line string) []string {
+	names := strings.Split(strings.TrimPrefix(line, "Format:"), ",")
+	for i, name := range names {
+		names[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	return names
+}
+
+// formatASSTime formats a time in nanoseconds as an ASS timestamp (H:MM:SS.cc).
+//
+// Parameters:
+//   - ns: The time in nanoseconds.
+//
+// Returns:
+//   - string: The formatted timestamp, for example "1:01:01.12".
+func formatASSTime(ns uint64) string {
+	cs := ns / 10000000
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
@@ -0,0 +1,151 @@
+package matroska
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFormatSRTTime(t *testing.T) {
+	testCases := []struct {
+		ns       uint64
+		expected string
+	}{
+		{0, "00:00:00,000"},
+		{999999, "00:00:00,000"},
+		{1500000000, "00:00:01,500"},
+		{3661123000000, "01:01:01,123"},
+		{360000000000000, "100:00:00,000"},
+	}
+	for _, tc := range testCases {
+		if got := formatSRTTime(tc.ns); got != tc.expected {
+			t.Errorf("formatSRTTime(%d) = %q, want %q", tc.ns, got, tc.expected)
+		}
+	}
+}
+
+func TestDemuxer_ExportSRT(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeAudio, CodecID: "A_OPUS"},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8", DefaultDuration: 2000000000},
+	}
+
+	t.Run("Block durations", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+			{Track: 2, StartTime: 1000000000, EndTime: 2500000000, Data: []byte("Line 1\r\nLine 2")},
+			{Track: 2, StartTime: 3000000000, EndTime: 4000000000, Data: []byte{}},
+		})
+		var out bytes.Buffer
+		if err := demuxer.ExportSRT(&out, 1); err != nil {
+			t.Fatalf("ExportSRT() failed: %v", err)
+		}
+		want := "1\n00:00:01,000 --> 00:00:02,500\nLine 1\nLine 2\n\n2\n00:00:03,000 --> 00:00:04,000\n \n\n"
+		if out.String() != want {
+			t.Errorf("ExportSRT() wrote %q, want %q", out.String(), want)
+		}
+	})
+
+	t.Run("Default duration", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 3, StartTime: 1000000000, EndTime: 3000000000, Data: []byte("Hello")},
+		})
+		var out bytes.Buffer
+		if err := demuxer.ExportSRT(&out, 2); err != nil {
+			t.Fatalf("ExportSRT() failed: %v", err)
+		}
+		want := "1\n00:00:01,000 --> 00:00:03,000\nHello\n\n"
+		if out.String() != want {
+			t.Errorf("ExportSRT() wrote %q, want %q", out.String(), want)
+		}
+	})
+
+	t.Run("Missing end time", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 2, StartTime: 1000000000, EndTime: 2000000000, Data: []byte("First")},
+			{Track: 2, StartTime: 3000000000, Data: []byte("Second")},
+		})
+		var out bytes.Buffer
+		if err := demuxer.ExportSRT(&out, 1); err == nil {
+			t.Error("Expected an error for a subtitle without an end time")
+		}
+		if out.String() != "1\n00:00:01,000 --> 00:00:02,000\nFirst\n\n" {
+			t.Errorf("Expected the entries before the error to be written, got %q", out.String())
+		}
+	})
+
+	t.Run("Not a text subtitle track", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 1, StartTime: 0, Data: []byte{0x01}, Flags: KF},
+		})
+		if err := demuxer.ExportSRT(&bytes.Buffer{}, 0); !errors.Is(err, ErrUnsupportedCodec) {
+			t.Errorf("ExportSRT() error = %v, want ErrUnsupportedCodec", err)
+		}
+		if err := demuxer.ExportSRT(&bytes.Buffer{}, 5); err == nil {
+			t.Error("Expected an error for an invalid track index")
+		}
+	})
+}
+
+func TestDemuxer_ExportASS(t *testing.T) {
+	header := "[Script Info]\r\nScriptType: v4.00+\r\n\r\n" +
+		"[V4+ Styles]\r\nFormat: Name, Fontname, Fontsize\r\nStyle: Default,Arial,20\r\n\r\n" +
+		"[Events]\r\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\n"
+	tracks := []*TrackInfo{
+		{Type: TypeSubtitle, CodecID: "S_TEXT/ASS", CodecPrivate: []byte(header)},
+		{Type: TypeSubtitle, CodecID: "S_TEXT/SSA", CodecPrivate: []byte("[Script Info]\nScriptType: v4.00\n")},
+	}
+
+	t.Run("Header and events", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 1, StartTime: 1000000000, EndTime: 3500000000, Data: []byte("1,0,Default,,0,0,0,,Second, in the file")},
+			{Track: 1, StartTime: 3723450000000, EndTime: 3725000000000, Data: []byte("0,1,Default,Bob,10,10,20,,{\\i1}First{\\i0}")},
+		})
+		var out bytes.Buffer
+		if err := demuxer.ExportASS(&out, 0); err != nil {
+			t.Fatalf("ExportASS() failed: %v", err)
+		}
+		want := "[Script Info]\nScriptType: v4.00+\n\n" +
+			"[V4+ Styles]\nFormat: Name, Fontname, Fontsize\nStyle: Default,Arial,20\n\n" +
+			"[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+			"Dialogue: 1,1:02:03.45,1:02:05.00,Default,Bob,10,10,20,,{\\i1}First{\\i0}\n" +
+			"Dialogue: 0,0:00:01.00,0:00:03.50,Default,,0,0,0,,Second, in the file\n"
+		if out.String() != want {
+			t.Errorf("ExportASS() wrote:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("SSA without events header", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 2, StartTime: 0, EndTime: 1000000000, Data: []byte("0,0,Default,,0,0,0,,Hi")},
+		})
+		var out bytes.Buffer
+		if err := demuxer.ExportASS(&out, 1); err != nil {
+			t.Fatalf("ExportASS() failed: %v", err)
+		}
+		want := "[Script Info]\nScriptType: v4.00\n\n[Events]\n" + assDefaultFormat + "\n" +
+			"Dialogue: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,Hi\n"
+		if out.String() != want {
+			t.Errorf("ExportASS() wrote:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("Malformed event", func(t *testing.T) {
+		demuxer := createMuxedFile(t, tracks, []*Packet{
+			{Track: 1, StartTime: 0, EndTime: 1000000000, Data: []byte("0,0,Default")},
+		})
+		if err := demuxer.ExportASS(&bytes.Buffer{}, 0); err == nil {
+			t.Error("Expected an error for an event with missing fields")
+		}
+	})
+
+	t.Run("Not an ASS track", func(t *testing.T) {
+		demuxer := createMuxedFile(t, []*TrackInfo{{Type: TypeSubtitle, CodecID: "S_TEXT/UTF8"}}, []*Packet{
+			{Track: 1, StartTime: 0, EndTime: 1000000000, Data: []byte("Hi")},
+		})
+		if err := demuxer.ExportASS(&bytes.Buffer{}, 0); !errors.Is(err, ErrUnsupportedCodec) {
+			t.Errorf("ExportASS() error = %v, want ErrUnsupportedCodec", err)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains SeekToTimecode and CurrentTimecode, which express the read
+// position as a time instead of a byte offset.
+package matroska
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// SeekToTimecode moves the read position to the keyframe from which a player
+// should start decoding to show the given time, so that the next ReadPacket
+// returns that keyframe.
+//
+// The cues are used to find the cluster to start from, and the packets are
+// then read to the last keyframe at or before timecode of the reference track,
+// which is the first video track not masked by SetTrackMask, or the first
+// track not masked if there is no video. Without cues, the packets are read
+// from the first cluster. When timecode precedes the first keyframe, the read
+// position is set to the first keyframe. The packets of other tracks between
+// the start of the cluster and the keyframe are skipped.
+//
+// Example:
+//
+//	if err := demuxer.SeekToTimecode(90 * uint64(time.Second)); err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Resuming at %d ns\n", demuxer.CurrentTimecode())
+//	packet, err := demuxer.ReadPacket() // The keyframe at CurrentTimecode
+//
+// Parameters:
+//   - timecode: The target time in nanoseconds.
+//
+// Returns:
+//   - error: An error if the demuxer avoids seeks, no keyframe of the reference
+//     track is found, or the input could not be read.
+func (d *Demuxer) SeekToTimecode(timecode uint64) error {
+	d.lock()
+	defer d.unlock()
+	return d.parser.SeekToTimecode(timecode)
+}
+
+// CurrentTimecode returns the time of the read position, in nanoseconds.
+//
+// This is the start time of the last packet returned by ReadPacket, or, after
+// SeekToTimecode or Seek, the time of the keyframe or cue point the read
+// position was moved to. It is 0 before the first packet is read.
+//
+// Returns:
+//   - uint64: The time of the read position in nanoseconds.
+func (d *Demuxer) CurrentTimecode() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.parser.CurrentTimecode()
+}
+
+// SeekToTimecode moves the read position to the keyframe from which decoding
+// must start to show timecode. See Demuxer.SeekToTimecode.
+//
+// Parameters:
+//   - timecode: The target time in nanoseconds.
+//
+// Returns:
+//   - error: An error if the read position could not be moved.
+func (mp *MatroskaParser) SeekToTimecode(timecode uint64) error {
+	if mp.avoidSeeks {
+		return fmt.Errorf("seeking not supported in streaming mode")
+	}
+
+	if len(mp.cues) > 0 {
+		if err := mp.Seek(timecode, SeekToPrevKeyFrame); err != nil {
+			return err
+		}
+	} else {
+		// Read from the start of the segment, whose metadata elements are skipped
+		if _, err := mp.reader.Seek(int64(mp.segmentPos), io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek to the segment: %w", err)
+		}
+		mp.resetClusterState()
+		mp.segmentIndex = 0
+		mp.resumePending = false
+	}
+
+	reference := mp.seekReferenceTrack()
+	var target func() error
+	var targetTime uint64
+	for {
+		// The state before each packet, to return to the chosen keyframe
+		restore := mp.saveReadState()
+		packet, err := mp.readPacket(context.Background())
+		if err != nil {
+			if err == io.EOF || target != nil {
+				break
+			}
+			return err
+		}
+		isReference := reference == 0 || packet.Track == reference
+		keyframe := packet.Flags&KF != 0
+		startTime := packet.StartTime
+		packet.Release()
+		if !isReference {
+			continue
+		}
+		if startTime > timecode && target != nil {
+			break
+		}
+		if keyframe {
+			target, targetTime = restore, startTime
+			if startTime > timecode {
+				break
+			}
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("no keyframe found to seek to %d", timecode)
+	}
+
+	if err := target(); err != nil {
+		return err
+	}
+	mp.currentTimecode = targetTime
+	return nil
+}
+
+// CurrentTimecode returns the time of the read position, in nanoseconds. See
+// Demuxer.CurrentTimecode.
+//
+// Returns:
+//   - uint64: The time of the read position in nanoseconds.
+func (mp *MatroskaParser) CurrentTimecode() uint64 {
+	return mp.currentTimecode
+}
+
+// seekReferenceTrack returns the track whose keyframes SeekToTimecode seeks
+// to: the first video track that is not masked, or the first track that is not
+// masked if there is no such video track.
+//
+// Returns:
+//   - uint64: The number of the track, or 0 if every track is masked.
+func (mp *MatroskaParser) seekReferenceTrack() uint64 {
+	var first uint64
+	for _, track := range mp.tracks {
+		if track.Number >= 1 && track.Number <= 64 && mp.currentTrackMask&(1<<(track.Number-1)) != 0 {
+			continue
+		}
+		if track.Type == TypeVideo {
+			return track.Number
+		}
+		if first == 0 {
+			first = track.Number
+		}
+	}
+	return first
+}
@@ -0,0 +1,114 @@
+package matroska
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDemuxer_SeekToTimecode(t *testing.T) {
+	tracks := []*TrackInfo{
+		{Type: TypeAudio, CodecID: "A_OPUS", DefaultDuration: 20000000},
+		{Type: TypeVideo, CodecID: "V_VP9", DefaultDuration: 40000000},
+	}
+	// Four GOPs of five frames, with the audio before the video so that the
+	// video track is the reference although it is not the first track
+	var packets []*Packet
+	for frame := uint64(0); frame < 20; frame++ {
+		timecode := frame * 40000000
+		flags := uint32(0)
+		if frame%5 == 0 {
+			flags = KF
+		}
+		packets = append(packets,
+			&Packet{Track: 1, StartTime: timecode, Data: []byte{0xA0, byte(frame)}, Flags: KF},
+			&Packet{Track: 2, StartTime: timecode, Data: []byte{byte(frame)}, Flags: flags},
+		)
+	}
+
+	tests := []struct {
+		name     string
+		timecode uint64
+		want     uint64
+	}{
+		{"Mid-file", 500000000, 400000000},
+		{"On a keyframe", 200000000, 200000000},
+		{"Start", 0, 0},
+		{"Past the end", 5000000000, 600000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			demuxer := createMuxedFile(t, tracks, packets)
+			// Move away from the start, so that the seek must go back
+			for i := 0; i < 30; i++ {
+				if _, err := demuxer.ReadPacket(); err != nil {
+					t.Fatalf("ReadPacket() failed: %v", err)
+				}
+			}
+
+			if err := demuxer.SeekToTimecode(tt.timecode); err != nil {
+				t.Fatalf("SeekToTimecode() failed: %v", err)
+			}
+			if got := demuxer.CurrentTimecode(); got != tt.want {
+				t.Errorf("Expected CurrentTimecode %d, got %d", tt.want, got)
+			}
+			packet, err := demuxer.ReadPacket()
+			if err != nil {
+				t.Fatalf("ReadPacket() failed: %v", err)
+			}
+			if packet.Track != 2 || packet.StartTime != tt.want || packet.Flags&KF == 0 {
+				t.Errorf("Expected the video keyframe at %d, got track %d at %d with flags %d",
+					tt.want, packet.Track, packet.StartTime, packet.Flags)
+			}
+			if got := demuxer.CurrentTimecode(); got != packet.StartTime {
+				t.Errorf("Expected CurrentTimecode %d after ReadPacket, got %d", packet.StartTime, got)
+			}
+		})
+	}
+
+	t.Run("Without cues", func(t *testing.T) {
+		trackEntry, err := createMockTrackEntry(1, TypeVideo, "V_TEST", "Video", "und")
+		if err != nil {
+			t.Fatalf("createMockTrackEntry() failed: %v", err)
+		}
+		// A single cluster with keyframes at 0 and 3 ms, in which the seek
+		// must stop at the keyframe rather than at the cluster
+		var blocks [][]byte
+		for frame := byte(0); frame < 6; frame++ {
+			flags := byte(0)
+			if frame%3 == 0 {
+				flags = 0x80
+			}
+			blocks = append(blocks, []byte{0x81, 0x00, frame, flags, frame})
+		}
+		data := createMockMatroskaFileWithTrack(trackEntry, blocks...)
+		demuxer, err := NewDemuxer(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDemuxer() failed: %v", err)
+		}
+
+		if err = demuxer.SeekToTimecode(4000000); err != nil {
+			t.Fatalf("SeekToTimecode() failed: %v", err)
+		}
+		packet, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() failed: %v", err)
+		}
+		if packet.StartTime != 3000000 || packet.Data[0] != 3 {
+			t.Errorf("Expected frame 3 at 3000000, got frame %d at %d", packet.Data[0], packet.StartTime)
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		mockFile, err := createMockMatroskaFile()
+		if err != nil {
+			t.Fatalf("Failed to create mock matroska file: %v", err)
+		}
+		demuxer, err := NewStreamingDemuxer(bytes.NewReader(mockFile))
+		if err != nil {
+			t.Fatalf("NewStreamingDemuxer() failed: %v", err)
+		}
+		if err = demuxer.SeekToTimecode(0); err == nil {
+			t.Error("Expected an error when the demuxer avoids seeks")
+		}
+	})
+}
@@ -15,6 +15,13 @@
 // and serve as the central location for all data type definitions used by other files in the project.
 package matroska
 
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
 // Matroska compression types
 //
 // These constants define the compression algorithms that can be applied to Matroska tracks.
@@ -29,16 +36,93 @@ const (
 	CompPrepend = 3
 )
 
+// TrackType is the type of a track, as stored in the TrackType element of a
+// track entry.
+type TrackType uint8
+
 // Track types
 //
 // These constants define the different types of tracks that can be present in a Matroska file.
 const (
 	// TypeVideo indicates a video track.
-	TypeVideo = 1
+	TypeVideo TrackType = 1
 	// TypeAudio indicates an audio track.
-	TypeAudio = 2
+	TypeAudio TrackType = 2
+	// TypeComplex indicates a track mixing audio and video, such as a DV stream.
+	TypeComplex TrackType = 3
+	// TypeLogo indicates an overlay logo or picture.
+	TypeLogo TrackType = 16
 	// TypeSubtitle indicates a subtitle track.
-	TypeSubtitle = 17
+	TypeSubtitle TrackType = 17
+	// TypeButton indicates a track of interactive buttons for menus.
+	TypeButton TrackType = 18
+	// TypeControl indicates a track of control codes for menus and other controls.
+	TypeControl TrackType = 32
+	// TypeMetadata indicates a track of timed metadata, such as GPS coordinates.
+	TypeMetadata TrackType = 33
+)
+
+// String returns the name of a track type, such as "video" or "subtitle".
+//
+// Returns:
+//   - string: The name of the track type, or "unknown (N)" for unknown types.
+func (t TrackType) String() string {
+	switch t {
+	case TypeVideo:
+		return "video"
+	case TypeAudio:
+		return "audio"
+	case TypeComplex:
+		return "complex"
+	case TypeLogo:
+		return "logo"
+	case TypeSubtitle:
+		return "subtitle"
+	case TypeButton:
+		return "button"
+	case TypeControl:
+		return "control"
+	case TypeMetadata:
+		return "metadata"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint8(t))
+	}
+}
+
+// FieldOrder is the field ordering of interlaced video, as stored in the
+// FieldOrder element of a video track.
+type FieldOrder uint8
+
+// Field orders
+//
+// These constants define the field orders an interlaced video track can declare.
+const (
+	// FieldOrderProgressive indicates progressive video with no fields.
+	FieldOrderProgressive FieldOrder = 0
+	// FieldOrderTFF indicates interlaced video with the top field displayed first.
+	FieldOrderTFF FieldOrder = 1
+	// FieldOrderUndetermined indicates that the field order is not known.
+	// This is the default when the FieldOrder element is absent.
+	FieldOrderUndetermined FieldOrder = 2
+	// FieldOrderBFF indicates interlaced video with the bottom field displayed first.
+	FieldOrderBFF FieldOrder = 6
+	// FieldOrderBFFSwapped indicates bottom field first, with the top field stored first.
+	FieldOrderBFFSwapped FieldOrder = 9
+	// FieldOrderTFFSwapped indicates top field first, with the bottom field stored first.
+	FieldOrderTFFSwapped FieldOrder = 14
+)
+
+// Track plane types
+//
+// These constants define the kinds of planes a track can be used for when
+// combined into a video track by a TrackOperation.
+const (
+	// TrackPlaneLeftEye indicates that the plane is the left eye view of a 3D video.
+	TrackPlaneLeftEye = 0
+	// TrackPlaneRightEye indicates that the plane is the right eye view of a 3D video.
+	TrackPlaneRightEye = 1
+	// TrackPlaneBackground indicates that the plane is the background of the video.
+	TrackPlaneBackground = 2
 )
 
 // Tag target types
@@ -78,6 +162,11 @@ const (
 	UnknownEnd = 0x00000002
 	// KF indicates that the packet is a key frame.
 	KF = 0x00000004
+	// INVISIBLE indicates that the frame must be decoded but not displayed.
+	INVISIBLE = 0x00000008
+	// DISCARDABLE indicates that the frame can be dropped, for example during
+	// trick play or when decoding falls behind, as no other frame references it.
+	DISCARDABLE = 0x00000010
 	// GAP indicates that the packet is a gap packet, which should be skipped during playback.
 	GAP = 0x00800000
 	// StreamMask is a bitmask used to extract the stream number from the Flags field.
@@ -86,6 +175,58 @@ const (
 	StreamShift = 24
 )
 
+// PGS segment types
+//
+// These constants define the types of the segments of PGS (Presentation Graphic
+// Stream) subtitles, as returned in PGSSegment.Type.
+const (
+	// PGSPaletteDefinition indicates a Palette Definition Segment (PDS).
+	PGSPaletteDefinition = 0x14
+	// PGSObjectDefinition indicates an Object Definition Segment (ODS), holding a bitmap.
+	PGSObjectDefinition = 0x15
+	// PGSPresentationComposition indicates a Presentation Composition Segment (PCS),
+	// which starts a display set.
+	PGSPresentationComposition = 0x16
+	// PGSWindowDefinition indicates a Window Definition Segment (WDS).
+	PGSWindowDefinition = 0x17
+	// PGSEndOfDisplaySet indicates the End of Display Set Segment (END).
+	PGSEndOfDisplaySet = 0x80
+)
+
+// LaceType is the lacing of a block, which stores several frames of a track
+// in a single block.
+type LaceType uint8
+
+// Lacing types, as stored in bits 0x06 of the block flags.
+const (
+	// LaceNone indicates a block holding a single frame.
+	LaceNone LaceType = 0
+	// LaceXiph indicates Xiph lacing, where frame sizes are sums of bytes.
+	LaceXiph LaceType = 1
+	// LaceFixed indicates fixed-size lacing, where all frames have the same size.
+	LaceFixed LaceType = 2
+	// LaceEBML indicates EBML lacing, where frame sizes are EBML-coded differences.
+	LaceEBML LaceType = 3
+)
+
+// String returns the name of the lacing type, such as "xiph".
+//
+// Returns:
+//   - string: The name of the lacing type.
+func (lt LaceType) String() string {
+	switch lt {
+	case LaceNone:
+		return "none"
+	case LaceXiph:
+		return "xiph"
+	case LaceFixed:
+		return "fixed"
+	case LaceEBML:
+		return "ebml"
+	}
+	return fmt.Sprintf("LaceType(%d)", uint8(lt))
+}
+
 // Packet contains a demuxed packet from a Matroska file.
 //
 // A Packet represents a single unit of media data that has been extracted (demuxed) from
@@ -94,13 +235,33 @@ const (
 type Packet struct {
 	// Track is the track number this packet belongs to.
 	// This corresponds to the TrackInfo.Number of the track.
-	Track uint8
-	// StartTime is the start time of this packet in nanoseconds.
+	Track uint64
+	// StartTime is the start time of this packet in nanoseconds, or in
+	// timestamp units with WithRawTimestamps.
 	// This is the timestamp when the packet should be presented.
 	StartTime uint64
-	// EndTime is the end time of this packet in nanoseconds.
+	// EndTime is the end time of this packet in nanoseconds, or in timestamp
+	// units with WithRawTimestamps.
 	// This is the timestamp when the packet should stop being presented.
 	EndTime uint64
+	// Duration is the duration of this packet, in the same units as StartTime,
+	// so that EndTime is StartTime plus Duration. It is the BlockDuration of
+	// the block, or the DefaultDuration of its track, less the DiscardPadding
+	// of the block if any, and 0 if neither duration is known.
+	Duration uint64
+	// ClusterTimestamp is the timestamp of the cluster the packet was read
+	// from, in timestamp units of its segment (see SegmentInfo.TimecodeScale).
+	ClusterTimestamp uint64
+	// BlockOffset is the timestamp of the packet's block relative to
+	// ClusterTimestamp, in the same units.
+	BlockOffset int16
+	// ClusterTimecode is the start time in nanoseconds of the cluster the
+	// packet was read from, as ClusterTimestamp converted like StartTime
+	// without WithRawTimestamps.
+	ClusterTimecode uint64
+	// ClusterPos is the position in the input stream of the Cluster element
+	// the packet was read from, which can be used to index or seek to it.
+	ClusterPos uint64
 	// FilePos is the position in the input stream where this packet is located.
 	// This can be useful for seeking or debugging purposes.
 	FilePos uint64
@@ -112,7 +273,56 @@ type Packet struct {
 	Flags uint32
 	// Discard indicates whether this packet can be discarded.
 	// A non-zero value suggests that the packet can be safely discarded without affecting playback.
+	// It is set to 1 for packets with the DISCARDABLE flag.
 	Discard int64
+	// LaceType is the lacing of the block the packet was read from, or
+	// LaceNone if the block held a single frame.
+	LaceType LaceType
+	// NumFramesInBlock is the number of frames in the block the packet was
+	// read from, which is 1 for a block without lacing.
+	NumFramesInBlock int
+
+	// The pooled buffer holding the block data, if WithPacketPool is set. See
+	// Release.
+	buffer *[]byte
+	pool   *sync.Pool
+}
+
+// KeyframePoint is the location of a keyframe, as returned by
+// Demuxer.BuildKeyframeIndex.
+type KeyframePoint struct {
+	// Timecode is the start time of the keyframe in nanoseconds.
+	Timecode uint64
+	// FilePos is the position in the file of the keyframe's block, after the
+	// SimpleBlock or Block element header, as in Packet.FilePos.
+	FilePos uint64
+}
+
+// PacketResult is a value received from the channel returned by Demuxer.Packets.
+//
+// Exactly one of Packet and Err is set.
+type PacketResult struct {
+	// Packet is the packet that was read.
+	Packet *Packet
+	// Err is the error that stopped the reading. It is never io.EOF, as the
+	// channel is closed at the end of the file instead.
+	Err error
+}
+
+// TrackStats contains the statistics of a track, accumulated from the packets
+// read so far. See Demuxer.Stats.
+type TrackStats struct {
+	// PacketCount is the number of packets read.
+	PacketCount uint64
+	// TotalBytes is the total size in bytes of the data of the packets read,
+	// after decompression and conversion.
+	TotalBytes uint64
+	// FirstTimecode is the start time in nanoseconds of the first packet read.
+	FirstTimecode uint64
+	// LastTimecode is the start time in nanoseconds of the last packet read.
+	LastTimecode uint64
+	// KeyframeCount is the number of packets read that are keyframes.
+	KeyframeCount uint64
 }
 
 // TrackInfo contains information about a track in a Matroska file.
@@ -123,12 +333,12 @@ type Packet struct {
 type TrackInfo struct {
 	// Number is the track number used to identify this track within the Matroska file.
 	// Track numbers are unique within a segment and are used to associate packets with tracks.
-	Number uint8
-	// Type is the track type. See the track type constants (TypeVideo, TypeAudio, TypeSubtitle).
-	Type uint8
-	// TrackOverlay specifies whether this track should be overlaid on another track.
-	// This is typically used for subtitle or menu tracks that need to be displayed over video.
-	TrackOverlay uint8
+	Number uint64
+	// Type is the track type. See the track type constants (TypeVideo, TypeAudio, TypeSubtitle, ...).
+	Type TrackType
+	// TrackOverlay lists the numbers of the tracks to play, in order of
+	// preference, when the data of this track is not available.
+	TrackOverlay []uint64
 	// UID is a unique identifier for this track.
 	// This allows tracks to be referenced even if their numbers change.
 	UID uint64
@@ -158,8 +368,14 @@ type TrackInfo struct {
 	// CompMethodPrivate contains any private data that should be passed to the decompressor
 	// used to decompress the track.
 	CompMethodPrivate []byte
-	// MaxBlockAdditionID is the maximum ID of the BlockAdditional elements for this track.
-	// This is used to identify additional data blocks associated with the track.
+	// ContentEncodings lists the compression and encryption settings applied to the
+	// track's frames, in the order they appear in the file.
+	ContentEncodings []ContentEncoding
+	// Operation describes how this virtual track is built from other tracks,
+	// or is nil for a track with its own blocks. See TrackOperation.
+	Operation *TrackOperation
+	// MaxBlockAdditionID is the maximum BlockAddID of the BlockAdditional elements
+	// of this track's blocks, or 0 if the blocks have no BlockAdditions.
 	MaxBlockAdditionID uint32
 
 	// Enabled indicates whether this track is enabled and should be played.
@@ -184,8 +400,12 @@ type TrackInfo struct {
 		// StereoMode is the stereo 3D mode used, if any.
 		// This defines how the video should be displayed for 3D playback.
 		StereoMode uint8
-		// DisplayUnit is the unit used for DisplayWidth and DisplayHeight.
-		// This defines whether the display dimensions are in pixels, centimeters, or inches.
+		// DisplayUnit is the unit used for DisplayWidth and DisplayHeight:
+		//     0 = pixels
+		//     1 = centimeters
+		//     2 = inches
+		//     3 = display aspect ratio
+		//     4 = unknown
 		DisplayUnit uint8
 		// AspectRatioType defines what type of resizing is needed for the aspect ratio:
 		//     0 = free resizing
@@ -211,6 +431,8 @@ type TrackInfo struct {
 		// CropB is the number of pixels to crop from the bottom of the video.
 		CropB uint32
 		// ColourSpace is the colorspace of the video, similar to biCompression from BITMAPINFOHEADER.
+		// It is the FourCC describing the pixel layout of uncompressed video, such as "UYVY",
+		// read in little-endian order like biCompression, or 0 if the track declares none.
 		ColourSpace uint32
 		// GammaValue is the gamma value to use for color adjustment.
 		GammaValue float64
@@ -283,9 +505,35 @@ type TrackInfo struct {
 				LuminanceMin float32
 			}
 		}
+		// Projection describes the video projection used for spherical (360 or VR) video.
+		Projection struct {
+			// ProjectionType is the projection used for the video:
+			//     0 = rectangular
+			//     1 = equirectangular
+			//     2 = cubemap
+			//     3 = mesh
+			ProjectionType uint8
+			// ProjectionPrivate contains projection-specific data, such as the
+			// equirectangular bounds or the cubemap layout.
+			ProjectionPrivate []byte
+			// PoseYaw is the yaw rotation to apply to the projection, in degrees.
+			PoseYaw float64
+			// PosePitch is the pitch rotation to apply to the projection, in degrees.
+			PosePitch float64
+			// PoseRoll is the roll rotation to apply to the projection, in degrees.
+			PoseRoll float64
+		}
 		// Interlaced indicates whether the video is interlaced.
 		// If true, the video consists of interlaced fields rather than progressive frames.
 		Interlaced bool
+		// FieldOrder is the order in which the fields of interlaced video should be displayed.
+		// See the FieldOrder constants for the possible values.
+		FieldOrder FieldOrder
+		// DefaultDecodedFieldDuration is the duration in nanoseconds of a field
+		// decoded from interlaced or telecined video, or 0 if unknown. It differs
+		// from the track's DefaultDuration, which is the duration of a frame as
+		// stored, and gives the output timing of deinterlacers.
+		DefaultDecodedFieldDuration uint64
 	}
 	// Audio contains audio-specific information. Only valid if the track is an audio track.
 	Audio struct {
@@ -298,17 +546,262 @@ type TrackInfo struct {
 		Channels uint8
 		// BitDepth is the bit depth of the audio samples.
 		BitDepth uint8
+		// Emphasis is the audio emphasis applied on the audio samples, which the
+		// player must reverse (de-emphasis) during playback:
+		//     0 = no emphasis
+		//     1 = CD audio
+		//     3 = CCITT J.17
+		//     4 = FM 50
+		//     5 = FM 75
+		//     10 = Phono RIAA
+		//     11 = Phono IEC N78
+		//     12 = Phono TELDEC
+		//     13 = Phono EMI
+		//     14 = Phono Columbia LP
+		//     15 = Phono LONDON
+		//     16 = Phono NARTB
+		Emphasis uint8
 	}
 
 	// Name is the human-readable name of the track.
 	// This can be displayed to users to identify the track.
 	Name string
 	// Language is the language code of the track.
-	// This follows the ISO 639-2 language codes (e.g., "eng" for English).
+	// This follows the ISO 639-2 language codes (e.g., "eng" for English), and
+	// is "und" if the track declares no language (see WithEnglishLanguageDefault).
 	Language string
+	// LanguageIETF is the language of the track as a BCP 47 tag (e.g., "en-US").
+	// When present, it should be preferred over Language.
+	LanguageIETF string
 	// CodecID is the identifier for the codec used by this track.
 	// This is a string that identifies the codec, such as "V_MPEG4/ISO/AVC" for H.264 video.
 	CodecID string
+	// CodecName is a human-readable name of the codec used by this track,
+	// such as "H.264 / AVC / MPEG-4 AVC".
+	CodecName string
+	// CodecSettings is a human-readable description of the settings used by
+	// the codec to encode the track. Few muxers write it.
+	CodecSettings string
+	// CodecInfoURL is a URL giving information about the codec, if the muxer
+	// wrote one.
+	CodecInfoURL string
+	// CodecDownloadURL is a URL to download the codec from, if the muxer
+	// wrote one.
+	CodecDownloadURL string
+}
+
+// Clone returns a deep copy of the track information.
+//
+// The byte slices, such as CodecPrivate, the ContentEncodings and the
+// Operation are copied too, so the copy can be modified without affecting the original.
+//
+// Returns:
+//   - *TrackInfo: The copy, or nil if t is nil.
+func (t *TrackInfo) Clone() *TrackInfo {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	clone.CodecPrivate = bytes.Clone(t.CodecPrivate)
+	clone.CompMethodPrivate = bytes.Clone(t.CompMethodPrivate)
+	clone.Video.Projection.ProjectionPrivate = bytes.Clone(t.Video.Projection.ProjectionPrivate)
+	clone.TrackOverlay = append([]uint64(nil), t.TrackOverlay...)
+	if t.ContentEncodings != nil {
+		clone.ContentEncodings = make([]ContentEncoding, len(t.ContentEncodings))
+		for i, encoding := range t.ContentEncodings {
+			if encoding.Compression != nil {
+				compression := *encoding.Compression
+				compression.Settings = bytes.Clone(compression.Settings)
+				encoding.Compression = &compression
+			}
+			if encoding.Encryption != nil {
+				encryption := *encoding.Encryption
+				encryption.KeyID = bytes.Clone(encryption.KeyID)
+				encoding.Encryption = &encryption
+			}
+			clone.ContentEncodings[i] = encoding
+		}
+	}
+	if t.Operation != nil {
+		clone.Operation = &TrackOperation{
+			CombinePlanes: append([]TrackPlane(nil), t.Operation.CombinePlanes...),
+			JoinBlocks:    append([]uint64(nil), t.Operation.JoinBlocks...),
+		}
+	}
+	return &clone
+}
+
+// DisplayAspectRatio returns the display aspect ratio of a video track.
+//
+// The ratio is derived from DisplayWidth and DisplayHeight, which yields the
+// correct value for every DisplayUnit, including when the display dimensions
+// are expressed as an aspect ratio (e.g. 16 and 9) rather than as a size.
+//
+// Returns:
+//   - float64: The display aspect ratio, or 0 if the display height is unknown.
+func (t *TrackInfo) DisplayAspectRatio() float64 {
+	if t.Video.DisplayHeight == 0 {
+		return 0
+	}
+	return float64(t.Video.DisplayWidth) / float64(t.Video.DisplayHeight)
+}
+
+// FrameRate returns the frame rate of a video track, derived from its
+// DefaultDuration.
+//
+// Tracks with a variable frame rate usually have no DefaultDuration, in which
+// case 0 is returned rather than a guess. As the DefaultDuration is an integer
+// number of nanoseconds, rates such as 24000/1001 are approximated, to about
+// six significant digits.
+//
+// Returns:
+//   - float64: The number of frames per second, or 0 if the track is not a
+//     video track or has no DefaultDuration.
+func (t *TrackInfo) FrameRate() float64 {
+	if t.Type != TypeVideo || t.DefaultDuration == 0 {
+		return 0
+	}
+	return 1e9 / float64(t.DefaultDuration)
+}
+
+// ChannelLayout returns a best-effort channel layout name for an audio track,
+// derived from its channel count.
+//
+// Matroska does not store the channel layout itself, so this function returns
+// the conventional layout for the number of channels (e.g. "stereo" for 2 or
+// "5.1" for 6). Codec-specific data may describe a different layout.
+//
+// Returns:
+//   - string: The channel layout name, or an empty string for unusual channel counts.
+func (t *TrackInfo) ChannelLayout() string {
+	switch t.Audio.Channels {
+	case 1:
+		return "mono"
+	case 2:
+		return "stereo"
+	case 3:
+		return "2.1"
+	case 4:
+		return "4.0"
+	case 5:
+		return "5.0"
+	case 6:
+		return "5.1"
+	case 7:
+		return "6.1"
+	case 8:
+		return "7.1"
+	default:
+		return ""
+	}
+}
+
+// Content encoding types
+//
+// These constants define the kinds of transformation a ContentEncoding can describe.
+const (
+	// ContentEncodingTypeCompression indicates that the content is compressed.
+	ContentEncodingTypeCompression = 0
+	// ContentEncodingTypeEncryption indicates that the content is encrypted.
+	ContentEncodingTypeEncryption = 1
+)
+
+// Content encoding scopes
+//
+// These constants define the bits of ContentEncoding.Scope, which tell which
+// parts of a track a ContentEncoding applies to.
+const (
+	// ContentEncodingScopeFrames indicates that the encoding applies to the frames.
+	ContentEncodingScopeFrames = 1
+	// ContentEncodingScopeCodecPrivate indicates that the encoding applies to the CodecPrivate.
+	ContentEncodingScopeCodecPrivate = 2
+	// ContentEncodingScopeNext indicates that the encoding applies to the next ContentEncoding.
+	ContentEncodingScopeNext = 4
+)
+
+// Content encryption algorithms
+//
+// These constants define the values of ContentEncryption.Algo and
+// ContentEncryption.AESCipherMode that ReadPacket can decrypt.
+const (
+	// EncAlgoAES indicates AES encryption.
+	EncAlgoAES = 5
+	// AESCipherModeCTR indicates the AES-CTR cipher mode, as used by WebM.
+	AESCipherModeCTR = 1
+)
+
+// ContentEncoding contains a compression or encryption setting of a track.
+//
+// A ContentEncoding structure describes one transformation applied to the data of
+// a track. When a track has several encodings, they must be undone in decreasing
+// Order to get back the original data.
+type ContentEncoding struct {
+	// Order is the order in which the encoding was applied. Encodings with a
+	// higher order are applied later and must be undone first.
+	Order uint64
+	// Scope is a bit field of the ContentEncodingScope constants telling which
+	// parts of the track the encoding applies to. The default is frames only.
+	Scope uint64
+	// Type is the type of the encoding. See the ContentEncodingType constants.
+	Type uint64
+	// Compression contains the compression settings. Only set if Type is
+	// ContentEncodingTypeCompression.
+	Compression *ContentCompression
+	// Encryption contains the encryption settings. Only set if Type is
+	// ContentEncodingTypeEncryption.
+	Encryption *ContentEncryption
+}
+
+// TrackOperation describes a virtual track built from other tracks of the file.
+//
+// A TrackOperation structure holds the TrackOperation element of a track, which
+// has no blocks of its own. Players assemble it from the tracks it references
+// by UID, for example the left and right eye views of a 3D video stored in two
+// tracks, or a track split into several tracks whose blocks are played in turn.
+type TrackOperation struct {
+	// CombinePlanes lists the tracks combined as planes of a single video
+	// track, such as the views of a 3D video.
+	CombinePlanes []TrackPlane
+	// JoinBlocks lists the UIDs of the tracks whose blocks are joined, in this
+	// order, into a single track.
+	JoinBlocks []uint64
+}
+
+// TrackPlane is a video plane combined into a track by a TrackOperation.
+type TrackPlane struct {
+	// UID is the UID of the track used as the plane.
+	UID uint64
+	// Type is the kind of plane. See the track plane type constants
+	// (TrackPlaneLeftEye, TrackPlaneRightEye, TrackPlaneBackground).
+	Type uint64
+}
+
+// ContentCompression contains the compression settings of a ContentEncoding.
+type ContentCompression struct {
+	// Algo is the compression algorithm. See the compression type constants
+	// (CompZlib, CompBzip, CompLZO1X, CompPrepend).
+	Algo uint64
+	// Settings contains algorithm-specific data. For header stripping
+	// (CompPrepend), these are the bytes removed from the start of every frame.
+	Settings []byte
+}
+
+// ContentEncryption contains the encryption settings of a ContentEncoding.
+type ContentEncryption struct {
+	// Algo is the encryption algorithm:
+	//     0 = not encrypted
+	//     1 = DES
+	//     2 = 3DES
+	//     3 = Twofish
+	//     4 = Blowfish
+	//     5 = AES
+	Algo uint64
+	// KeyID identifies the key used to encrypt the data.
+	KeyID []byte
+	// AESCipherMode is the AES cipher mode, only used when Algo is 5:
+	//     1 = AES-CTR
+	//     2 = AES-CBC
+	AESCipherMode uint64
 }
 
 // SegmentInfo contains file-level (segment) information about a Matroska stream.
@@ -347,14 +840,49 @@ type SegmentInfo struct {
 	// TimecodeScale is the timescale of any timecodes in the segment.
 	// This is used to convert timecodes to nanoseconds. The default is 1000000.
 	TimecodeScale uint64
-	// Duration is the file's duration in nanoseconds. May be 0 if unknown.
-	Duration uint64
-	// DateUTC is the date the file was created on, in nanoseconds since the Unix epoch.
-	// This can be used to determine when the file was created.
+	// Duration is the file's duration in TimecodeScale units. May be 0 if unknown.
+	// Multiply by TimecodeScale to get the duration in nanoseconds.
+	Duration float64
+	// DateUTC is the date the file was created on, in nanoseconds since
+	// 2001-01-01T00:00:00 UTC. Use DateUTCTime to get it as a time.Time.
 	DateUTC int64
 	// DateUTCValid indicates whether or not DateUTC can be considered valid.
 	// If false, the DateUTC value should not be used.
 	DateUTCValid bool
+	// TimecodeScaleInvalid indicates that the file declared a TimecodeScale of zero.
+	// In that case TimecodeScale is set to the default of 1000000 instead.
+	TimecodeScaleInvalid bool
+}
+
+// DurationNanos returns the duration of the segment as a time.Duration.
+//
+// Duration is stored in TimecodeScale units; this function multiplies it by
+// the timecode scale so that callers don't have to.
+//
+// Returns:
+//   - time.Duration: The duration of the segment, or 0 if the duration is unknown.
+func (si *SegmentInfo) DurationNanos() time.Duration {
+	if si.Duration <= 0 {
+		return 0
+	}
+	return time.Duration(si.Duration * float64(si.TimecodeScale))
+}
+
+// matroskaEpoch is the origin of Matroska dates, 2001-01-01T00:00:00 UTC.
+var matroskaEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DateUTCTime returns the date the file was created on as a time.Time.
+//
+// Matroska stores dates as nanoseconds since 2001-01-01T00:00:00 UTC. This
+// function applies that epoch offset so that callers don't have to.
+//
+// Returns:
+//   - time.Time: The creation date in UTC, or the zero time if DateUTCValid is false.
+func (si *SegmentInfo) DateUTCTime() time.Time {
+	if !si.DateUTCValid {
+		return time.Time{}
+	}
+	return matroskaEpoch.Add(time.Duration(si.DateUTC))
 }
 
 // Attachment contains information about a Matroska attachment.
@@ -500,7 +1028,7 @@ type Cue struct {
 	Block uint64
 	// Track is the track which this cue covers.
 	// This identifies which track the cue point belongs to.
-	Track uint8
+	Track uint64
 }
 
 // Target contains information about a tag's target.
@@ -548,3 +1076,335 @@ type Tag struct {
 	// These are the actual key-value metadata pairs.
 	SimpleTags []SimpleTag
 }
+
+// FLACStreamInfo contains the STREAMINFO metadata block of a FLAC track.
+//
+// A FLACStreamInfo structure holds the stream parameters stored in the CodecPrivate
+// of "A_FLAC" tracks, which are needed to decode the audio or to remux it into a
+// native .flac file.
+type FLACStreamInfo struct {
+	// MinBlockSize is the minimum block size, in samples, used in the stream.
+	MinBlockSize uint16
+	// MaxBlockSize is the maximum block size, in samples, used in the stream.
+	MaxBlockSize uint16
+	// MinFrameSize is the minimum frame size in bytes. 0 means the value is unknown.
+	MinFrameSize uint32
+	// MaxFrameSize is the maximum frame size in bytes. 0 means the value is unknown.
+	MaxFrameSize uint32
+	// SampleRate is the sample rate in Hz.
+	SampleRate uint32
+	// Channels is the number of channels.
+	Channels uint8
+	// BitsPerSample is the number of bits per sample.
+	BitsPerSample uint8
+	// TotalSamples is the total number of samples per channel. 0 means the value is unknown.
+	TotalSamples uint64
+	// MD5 is the MD5 signature of the unencoded audio data.
+	MD5 [16]byte
+	// Raw is the 34-byte body of the STREAMINFO block, as stored in the CodecPrivate.
+	// It can be written unchanged when remuxing to a native .flac file.
+	Raw []byte
+}
+
+// OpusHead contains the Opus identification header of an Opus track.
+//
+// An OpusHead structure holds the stream parameters stored in the CodecPrivate of
+// "A_OPUS" tracks, which are needed to decode the audio or to write a standalone
+// .opus file.
+type OpusHead struct {
+	// Version is the version of the identification header. Only the major
+	// version (the upper 4 bits) is checked for compatibility.
+	Version uint8
+	// Channels is the number of output channels.
+	Channels uint8
+	// PreSkip is the number of samples at 48 kHz to discard from the decoder
+	// output when starting playback.
+	PreSkip uint16
+	// InputSampleRate is the sample rate of the original input in Hz, for
+	// information only. Opus always decodes at 48 kHz.
+	InputSampleRate uint32
+	// OutputGain is the gain to apply to the decoded output, in Q7.8 dB.
+	OutputGain int16
+	// ChannelMappingFamily defines the order and semantic meaning of the channels.
+	ChannelMappingFamily uint8
+	// StreamCount is the number of Opus streams in each packet.
+	// Only set when ChannelMappingFamily is not 0.
+	StreamCount uint8
+	// CoupledCount is the number of streams that decode to two channels.
+	// Only set when ChannelMappingFamily is not 0.
+	CoupledCount uint8
+	// ChannelMapping maps each output channel to a decoded channel.
+	// Only set when ChannelMappingFamily is not 0.
+	ChannelMapping []byte
+}
+
+// PreSkipNanos returns the pre-skip of an Opus stream in nanoseconds.
+//
+// Matroska stores the same value in the track's CodecDelay, so this can be used
+// to fill in or check CodecDelay when remuxing.
+//
+// Returns:
+//   - uint64: The pre-skip in nanoseconds.
+func (h *OpusHead) PreSkipNanos() uint64 {
+	return uint64(h.PreSkip) * 1000000000 / 48000
+}
+
+// AV1Config contains the AV1 codec configuration record of an AV1 track.
+//
+// An AV1Config structure holds the stream parameters stored in the CodecPrivate
+// of "V_AV1" tracks, which are needed to write the av1C box of an MP4 file or
+// the header of an IVF file, and the sequence header OBU a decoder needs before
+// the first frame.
+type AV1Config struct {
+	// Version is the version of the configuration record, which is always 1.
+	Version uint8
+	// SeqProfile is the seq_profile of the sequence header: 0 for Main, 1 for
+	// High and 2 for Professional.
+	SeqProfile uint8
+	// SeqLevelIdx0 is the seq_level_idx[0] of the sequence header, the level of
+	// the first operating point.
+	SeqLevelIdx0 uint8
+	// SeqTier0 is the seq_tier[0] of the sequence header, 1 for the High tier.
+	SeqTier0 uint8
+	// HighBitdepth is the high_bitdepth flag of the color configuration.
+	HighBitdepth bool
+	// TwelveBit is the twelve_bit flag of the color configuration.
+	TwelveBit bool
+	// Monochrome is the mono_chrome flag of the color configuration.
+	Monochrome bool
+	// ChromaSubsamplingX is the subsampling_x flag of the color configuration.
+	ChromaSubsamplingX bool
+	// ChromaSubsamplingY is the subsampling_y flag of the color configuration.
+	ChromaSubsamplingY bool
+	// ChromaSamplePosition is the chroma_sample_position of the color
+	// configuration: 0 for unknown, 1 for vertical and 2 for colocated.
+	ChromaSamplePosition uint8
+	// InitialPresentationDelayPresent indicates whether
+	// InitialPresentationDelayMinusOne is set.
+	InitialPresentationDelayPresent bool
+	// InitialPresentationDelayMinusOne is the number of frames, minus one, to
+	// decode before presenting the first frame.
+	InitialPresentationDelayMinusOne uint8
+	// ConfigOBUs contains the OBUs following the 4-byte header, usually a
+	// sequence header OBU and optional metadata OBUs, in low overhead bitstream
+	// format. It is empty if the record has none.
+	ConfigOBUs []byte
+}
+
+// VP9Config contains the codec features of a VP9 track.
+//
+// A VP9Config structure holds the features stored in the CodecPrivate of
+// "V_VP9" tracks, which let a player configure its decoder without parsing the
+// first frame. Each feature is optional, as most VP9 tracks have no
+// CodecPrivate at all.
+type VP9Config struct {
+	// Profile is the VP9 profile, from 0 to 3. Only valid if HasProfile is true.
+	Profile uint8
+	// HasProfile indicates whether the CodecPrivate declares the profile.
+	HasProfile bool
+	// Level is the VP9 level, such as 10 for level 1 or 41 for level 4.1, or 0
+	// if it is not declared.
+	Level uint8
+	// BitDepth is the bit depth of the samples (8, 10 or 12), or 0 if it is not
+	// declared.
+	BitDepth uint8
+	// ChromaSubsampling is the chroma subsampling, only valid if
+	// HasChromaSubsampling is true:
+	//     0 = 4:2:0 with vertical chroma samples
+	//     1 = 4:2:0 with chroma samples colocated with luma samples
+	//     2 = 4:2:2
+	//     3 = 4:4:4
+	ChromaSubsampling uint8
+	// HasChromaSubsampling indicates whether the CodecPrivate declares the
+	// chroma subsampling.
+	HasChromaSubsampling bool
+}
+
+// BitDepth returns the bit depth of the samples, derived from the HighBitdepth
+// and TwelveBit flags.
+//
+// Returns:
+//   - int: The bit depth, which is 8, 10 or 12.
+func (c *AV1Config) BitDepth() int {
+	switch {
+	case c.HighBitdepth && c.TwelveBit:
+		return 12
+	case c.HighBitdepth:
+		return 10
+	default:
+		return 8
+	}
+}
+
+// ExtractOptions configures how Demuxer.ExtractTrack writes the packets of a track.
+//
+// Each conversion only applies to the tracks of the codec it is meant for, so
+// the same options can be used for every track of a file.
+type ExtractOptions struct {
+	// AnnexB converts the frames of H.264 and H.265 tracks from AVCC to Annex B
+	// format, producing a raw elementary stream.
+	AnnexB bool
+	// ADTS prepends an ADTS header to the frames of AAC tracks, producing a
+	// playable .aac stream.
+	ADTS bool
+	// SRT formats "S_TEXT/UTF8" subtitles as numbered SubRip entries.
+	SRT bool
+}
+
+// PGSSegment is a segment of a PGS (Presentation Graphic Stream) subtitle.
+//
+// The blocks of "S_HDMV/PGS" tracks hold one or more segments, which together
+// describe a display set: the composition, windows, palettes and bitmaps of
+// the subtitle shown at the block's timestamp. A .sup file stores each segment
+// with a "PG" header carrying its presentation and decoding timestamps.
+type PGSSegment struct {
+	// Type is the segment type. See the PGS segment type constants.
+	Type uint8
+	// Data is the payload of the segment, without its type and size.
+	Data []byte
+}
+
+// Metadata is the JSON document returned by Demuxer.MetadataJSON.
+//
+// The JSON field names are fixed by the struct tags, and every list is present
+// even when it is empty, so that the schema does not depend on the file.
+type Metadata struct {
+	// File describes the segment as a whole.
+	File MetadataFile `json:"file"`
+	// Tracks lists the tracks in the order they are declared.
+	Tracks []MetadataTrack `json:"tracks"`
+	// Chapters lists the top-level chapters, with their nested chapters.
+	Chapters []MetadataChapter `json:"chapters"`
+	// Tags lists the tags and the elements they apply to.
+	Tags []MetadataTag `json:"tags"`
+	// Attachments lists the attached files, without their data.
+	Attachments []MetadataAttachment `json:"attachments"`
+}
+
+// MetadataFile describes the segment information of a file in a Metadata document.
+type MetadataFile struct {
+	// Title is the title of the segment.
+	Title string `json:"title"`
+	// MuxingApp is the library that muxed the file.
+	MuxingApp string `json:"muxing_app"`
+	// WritingApp is the application that wrote the file.
+	WritingApp string `json:"writing_app"`
+	// SegmentUID is the segment UID as a hexadecimal string.
+	SegmentUID string `json:"segment_uid"`
+	// TimecodeScale is the number of nanoseconds per timestamp unit.
+	TimecodeScale uint64 `json:"timecode_scale"`
+	// DurationNs is the duration of the segment in nanoseconds.
+	DurationNs int64 `json:"duration_ns"`
+	// DateUTC is the creation date in RFC 3339 format, or empty if unknown.
+	DateUTC string `json:"date_utc"`
+}
+
+// MetadataTrack describes a track in a Metadata document.
+type MetadataTrack struct {
+	// Number is the track number, as found in Packet.Track.
+	Number uint64 `json:"number"`
+	// UID is the track UID.
+	UID uint64 `json:"uid"`
+	// Type is the name of the track type, such as "video".
+	Type string `json:"type"`
+	// CodecID is the codec identifier, such as "V_MPEG4/ISO/AVC".
+	CodecID string `json:"codec_id"`
+	// CodecName is the human-readable name of the codec.
+	CodecName string `json:"codec_name"`
+	// CodecPrivateSize is the size of the CodecPrivate in bytes.
+	CodecPrivateSize int `json:"codec_private_size"`
+	// Name is the name of the track.
+	Name string `json:"name"`
+	// Language is the ISO 639-2 language of the track.
+	Language string `json:"language"`
+	// LanguageIETF is the BCP 47 language of the track.
+	LanguageIETF string `json:"language_ietf"`
+	// Enabled, Default and Forced are the track flags.
+	Enabled bool `json:"enabled"`
+	Default bool `json:"default"`
+	Forced  bool `json:"forced"`
+	// DefaultDurationNs is the default duration of the track's frames in nanoseconds.
+	DefaultDurationNs uint64 `json:"default_duration_ns"`
+	// CodecDelayNs is the codec delay in nanoseconds.
+	CodecDelayNs uint64 `json:"codec_delay_ns"`
+	// SeekPreRollNs is the seek pre-roll in nanoseconds.
+	SeekPreRollNs uint64 `json:"seek_pre_roll_ns"`
+	// Video holds the video settings of video tracks, and is nil for other tracks.
+	Video *MetadataVideo `json:"video,omitempty"`
+	// Audio holds the audio settings of audio tracks, and is nil for other tracks.
+	Audio *MetadataAudio `json:"audio,omitempty"`
+}
+
+// MetadataVideo describes the video settings of a track in a Metadata document.
+type MetadataVideo struct {
+	PixelWidth    uint32 `json:"pixel_width"`
+	PixelHeight   uint32 `json:"pixel_height"`
+	DisplayWidth  uint32 `json:"display_width"`
+	DisplayHeight uint32 `json:"display_height"`
+	DisplayUnit   uint8  `json:"display_unit"`
+	Interlaced    bool   `json:"interlaced"`
+	StereoMode    uint8  `json:"stereo_mode"`
+}
+
+// MetadataAudio describes the audio settings of a track in a Metadata document.
+type MetadataAudio struct {
+	SamplingFrequency       float64 `json:"sampling_frequency"`
+	OutputSamplingFrequency float64 `json:"output_sampling_frequency"`
+	Channels                uint8   `json:"channels"`
+	BitDepth                uint8   `json:"bit_depth"`
+}
+
+// MetadataChapter describes a chapter in a Metadata document.
+type MetadataChapter struct {
+	// UID is the chapter UID.
+	UID uint64 `json:"uid"`
+	// StartNs and EndNs are the start and end times of the chapter in nanoseconds.
+	StartNs uint64 `json:"start_ns"`
+	EndNs   uint64 `json:"end_ns"`
+	// Hidden and Enabled are the chapter flags.
+	Hidden  bool `json:"hidden"`
+	Enabled bool `json:"enabled"`
+	// Names lists the titles of the chapter in different languages.
+	Names []MetadataChapterName `json:"names"`
+	// Children lists the nested chapters.
+	Children []MetadataChapter `json:"children"`
+}
+
+// MetadataChapterName describes a title of a chapter in a Metadata document.
+type MetadataChapterName struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Country  string `json:"country"`
+}
+
+// MetadataTag describes a tag in a Metadata document.
+type MetadataTag struct {
+	// Targets lists the elements the tag applies to. An empty list means the whole file.
+	Targets []MetadataTagTarget `json:"targets"`
+	// SimpleTags lists the name and value pairs of the tag.
+	SimpleTags []MetadataSimpleTag `json:"simple_tags"`
+}
+
+// MetadataTagTarget describes an element a tag applies to in a Metadata document.
+type MetadataTagTarget struct {
+	Type uint32 `json:"type"`
+	UID  uint64 `json:"uid"`
+}
+
+// MetadataSimpleTag describes a name and value pair of a tag in a Metadata document.
+type MetadataSimpleTag struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Language string `json:"language"`
+	Default  bool   `json:"default"`
+}
+
+// MetadataAttachment describes an attached file in a Metadata document.
+type MetadataAttachment struct {
+	UID         uint64 `json:"uid"`
+	Name        string `json:"name"`
+	MimeType    string `json:"mime_type"`
+	Description string `json:"description"`
+	// Size is the size of the attached file in bytes.
+	Size uint64 `json:"size"`
+}
@@ -4,6 +4,7 @@
 package matroska
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 )
@@ -65,3 +66,87 @@ func (f *fakeSeeker) Read(p []byte) (int, error) {
 func (f *fakeSeeker) Seek(offset int64, whence int) (int64, error) {
 	return -1, fmt.Errorf("this is a fake seeker")
 }
+
+// readBufferSize is the size of the buffer of a bufferedReadSeeker.
+const readBufferSize = 64 << 10
+
+// bufferedReadSeeker wraps an io.ReadSeeker with a bufio.Reader, so that the
+// small reads of the EBMLReader, such as the bytes of a VINT, do not each
+// result in a read from the source.
+//
+// Seeks forward within the buffered data discard it without touching the
+// source. Other seeks are passed to the source and empty the buffer. Seeks
+// relative to the current position are passed as such, so that sources that
+// only support those, such as fakeSeeker, behave as before.
+type bufferedReadSeeker struct {
+	r   io.ReadSeeker // The underlying reader
+	buf *bufio.Reader // The buffer over r
+	pos int64         // The position of the next byte returned by Read
+}
+
+// newBufferedReadSeeker creates a bufferedReadSeeker starting at the current
+// position of r, or at 0 if r cannot report it.
+//
+// Parameters:
+//   - r: The reader to buffer.
+//
+// Returns:
+//   - *bufferedReadSeeker: The buffered reader.
+func newBufferedReadSeeker(r io.ReadSeeker) *bufferedReadSeeker {
+	br := &bufferedReadSeeker{r: r, buf: bufio.NewReaderSize(r, readBufferSize)}
+	if pos, err := r.Seek(0, io.SeekCurrent); err == nil {
+		br.pos = pos
+	}
+	return br
+}
+
+// Read implements the io.Reader interface, reading from the buffer.
+//
+// Parameters:
+//   - p: The byte slice to read data into
+//
+// Returns:
+//   - int: The number of bytes read
+//   - error: Any error encountered during reading
+func (br *bufferedReadSeeker) Read(p []byte) (int, error) {
+	n, err := br.buf.Read(p)
+	br.pos += int64(n)
+	return n, err
+}
+
+// Seek implements the io.Seeker interface, discarding buffered data when the
+// new position is within it, and seeking the source otherwise.
+//
+// Parameters:
+//   - offset: The offset to seek to, relative to the whence parameter
+//   - whence: The reference point for the offset (0 = beginning, 1 = current, 2 = end)
+//
+// Returns:
+//   - int64: The new position relative to the beginning of the stream
+//   - error: An error if the source could not be seeked
+func (br *bufferedReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	skip := int64(-1)
+	switch whence {
+	case io.SeekStart:
+		skip = offset - br.pos
+	case io.SeekCurrent:
+		skip = offset
+	}
+	if skip >= 0 && skip <= int64(br.buf.Buffered()) {
+		n, _ := br.buf.Discard(int(skip))
+		br.pos += int64(n)
+		return br.pos, nil
+	}
+
+	if whence == io.SeekCurrent {
+		// The source is ahead of the position by the buffered data
+		offset -= int64(br.buf.Buffered())
+	}
+	pos, err := br.r.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	br.buf.Reset(br.r)
+	br.pos = pos
+	return pos, nil
+}
@@ -3,6 +3,7 @@ package matroska
 import (
 	"bytes"
 	"io"
+	"os"
 	"testing"
 )
 
@@ -117,3 +118,141 @@ func TestVintEncode_Length9(t *testing.T) {
 		t.Fatalf("parseVInt should fail for 9-byte encoding, got v=%#x n=%d", v, n)
 	}
 }
+
+// countingReadSeeker counts the reads from an io.ReadSeeker.
+type countingReadSeeker struct {
+	io.ReadSeeker
+	reads int
+}
+
+func (cr *countingReadSeeker) Read(p []byte) (int, error) {
+	cr.reads++
+	return cr.ReadSeeker.Read(p)
+}
+
+// TestBufferedReadSeeker checks that reads and seeks through the buffer
+// return the same data as the source.
+func TestBufferedReadSeeker(t *testing.T) {
+	data := make([]byte, 3*readBufferSize)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	source := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+	br := newBufferedReadSeeker(source)
+
+	steps := []struct {
+		offset int64
+		whence int
+		read   int
+	}{
+		{0, io.SeekStart, 10},
+		{5, io.SeekCurrent, 3},                  // Forward within the buffer
+		{100, io.SeekStart, 1},                  // Forward within the buffer
+		{50, io.SeekStart, 20},                  // Backwards
+		{2 * readBufferSize, io.SeekCurrent, 4}, // Forward past the buffer
+		{-10, io.SeekEnd, 10},
+		{0, io.SeekStart, readBufferSize + 5}, // Larger than the buffer
+	}
+	for i, step := range steps {
+		var expected int64
+		switch step.whence {
+		case io.SeekStart:
+			expected = step.offset
+		case io.SeekCurrent:
+			current, _ := br.Seek(0, io.SeekCurrent)
+			expected = current + step.offset
+		case io.SeekEnd:
+			expected = int64(len(data)) + step.offset
+		}
+		pos, err := br.Seek(step.offset, step.whence)
+		if err != nil {
+			t.Fatalf("Step %d: Seek() failed: %v", i, err)
+		}
+		if pos != expected {
+			t.Fatalf("Step %d: expected position %d, got %d", i, expected, pos)
+		}
+		buf := make([]byte, step.read)
+		if _, err = io.ReadFull(br, buf); err != nil {
+			t.Fatalf("Step %d: Read() failed: %v", i, err)
+		}
+		if !bytes.Equal(buf, data[pos:pos+int64(step.read)]) {
+			t.Errorf("Step %d: read data does not match the source at %d", i, pos)
+		}
+	}
+
+	t.Run("Small reads are buffered", func(t *testing.T) {
+		source.reads = 0
+		if _, err := br.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Seek() failed: %v", err)
+		}
+		b := make([]byte, 1)
+		for i := 0; i < 1000; i++ {
+			if _, err := br.Read(b); err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+		}
+		if source.reads != 1 {
+			t.Errorf("Expected 1 read from the source, got %d", source.reads)
+		}
+	})
+
+	t.Run("Relative seeks of a stream", func(t *testing.T) {
+		stream := newBufferedReadSeeker(&fakeSeeker{r: bytes.NewReader(data)})
+		b := make([]byte, 1)
+		if _, err := stream.Read(b); err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		if pos, err := stream.Seek(100, io.SeekCurrent); err != nil || pos != 101 {
+			t.Errorf("Expected a seek within the buffer to position 101, got %d, %v", pos, err)
+		}
+		if _, err := stream.Seek(readBufferSize, io.SeekCurrent); err == nil {
+			t.Error("Expected a seek past the buffer of a stream to fail")
+		}
+	})
+}
+
+// BenchmarkEBMLReader_ReadElementHeader walks the elements of a file and
+// reports the number of reads from the file, each of which is a system call,
+// with and without the buffer of NewEBMLReader.
+func BenchmarkEBMLReader_ReadElementHeader(b *testing.B) {
+	tracks, packets := poolTestFile()
+	file := createMuxedFile(b, tracks, packets).reader.(*os.File)
+	var err error
+
+	for _, bench := range []struct {
+		name      string
+		newReader func(io.ReadSeeker) *EBMLReader
+	}{
+		{"Unbuffered", func(r io.ReadSeeker) *EBMLReader { return &EBMLReader{r: r} }},
+		{"Buffered", NewEBMLReader},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			source := &countingReadSeeker{ReadSeeker: file}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err = source.Seek(0, io.SeekStart); err != nil {
+					b.Fatalf("Seek() failed: %v", err)
+				}
+				reader := bench.newReader(source)
+				for {
+					id, size, errReadHeader := reader.ReadElementHeader()
+					if errReadHeader == io.EOF {
+						break
+					}
+					if errReadHeader != nil {
+						b.Fatalf("ReadElementHeader() failed: %v", errReadHeader)
+					}
+					// Descend into the segment and its clusters
+					if id == IDSegment || id == IDCluster {
+						continue
+					}
+					if _, err = reader.Seek(int64(size), io.SeekCurrent); err != nil {
+						b.Fatalf("Seek() failed: %v", err)
+					}
+				}
+			}
+			b.ReportMetric(float64(source.reads)/float64(b.N), "reads/op")
+		})
+	}
+}
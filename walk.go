@@ -0,0 +1,158 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains Walk, which traverses the tree of EBML elements with a
+// visitor deciding which master elements to descend into.
+package matroska
+
+import (
+	"fmt"
+	"io"
+)
+
+// WalkFunc is called by EBMLReader.Walk for each element, after its header has
+// been read and before its data.
+//
+// Parameters:
+//   - id: The ID of the element.
+//   - size: The size of the element's data, or the unknown size
+//     ((1<<(7*8))-1) for elements such as live Segments and Clusters.
+//   - depth: The depth of the element, 0 for the elements at the level where
+//     the walk started.
+//
+// Returns:
+//   - bool: True to descend into the children of the element, which must then
+//     be a master element, or false to skip its data.
+//   - error: An error that stops the walk and is returned by Walk.
+type WalkFunc func(id uint32, size uint64, depth int) (descend bool, err error)
+
+// walkHeader is the header of an element read while walking the children of
+// an element of unknown size, which ends that element and belongs to one of
+// its ancestors.
+type walkHeader struct {
+	id   uint32
+	size uint64
+}
+
+// Walk traverses the tree of elements from the current position to the end of
+// the input, calling visitor for each element.
+//
+// The visitor decides whether to descend into each element. The children of
+// an element it descends into are visited next, at the following depth; the
+// data of the other elements is skipped without being read, so Walk can be
+// used to find a specific element, such as one the parser does not support,
+// without reading the clusters. The children of an element must lie within its
+// declared size, otherwise an error is returned.
+//
+// Elements of unknown size, such as the Segment and Clusters written by live
+// muxers, end at the end of their parent or at the first element that cannot
+// be their child: a top-level element (EBML header or Segment), or, for
+// elements other than the Segment, a child of the Segment such as the next
+// Cluster. As their end cannot be found without reading their children, the
+// visitor must descend into them.
+//
+// Example:
+//
+//	reader := matroska.NewEBMLReader(file)
+//	err := reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+//	    fmt.Printf("%s0x%X (%d bytes)\n", strings.Repeat("  ", depth), id, size)
+//	    return id == matroska.IDSegment || id == matroska.IDTracks, nil
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - visitor: The function called for each element.
+//
+// Returns:
+//   - error: The first error returned by visitor, an error wrapping
+//     ErrUnknownSizeUnsupported if the visitor does not descend into an
+//     element of unknown size, or an error if the input could not be read or
+//     an element overflows its parent.
+func (er *EBMLReader) Walk(visitor WalkFunc) error {
+	_, err := er.walk(visitor, 0, -1, 0, false)
+	return err
+}
+
+// walk visits the elements up to end, or to the end of the input if end is
+// negative, at the given depth.
+//
+// Parameters:
+//   - visitor: The function called for each element.
+//   - depth: The depth of the elements.
+//   - end: The position of the end of the parent, which is the end of its own
+//     parent if its size is unknown, or -1 for the end of the input.
+//   - parent: The ID of the parent, or 0 at the level where the walk started.
+//   - unknown: Whether the size of the parent is unknown.
+//
+// Returns:
+//   - *walkHeader: The header that ended a parent of unknown size, which
+//     belongs to an ancestor, or nil.
+//   - error: An error if the walk must stop.
+func (er *EBMLReader) walk(visitor WalkFunc, depth int, end int64, parent uint32, unknown bool) (*walkHeader, error) {
+	var pending *walkHeader
+	for pending != nil || end < 0 || er.pos < end {
+		header := pending
+		pending = nil
+		if header == nil {
+			id, size, err := er.ReadElementHeader()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			header = &walkHeader{id: id, size: size}
+		}
+		if unknown && endsUnknownSize(parent, header.id) {
+			return header, nil
+		}
+
+		// An element of unknown size ends at the latest with its parent
+		childUnknown := header.size == (1<<(7*8))-1
+		childEnd := end
+		if !childUnknown {
+			childEnd = er.pos + int64(header.size)
+			if end >= 0 && childEnd > end {
+				return nil, fmt.Errorf("element 0x%X at %d overflows its parent", header.id, er.pos)
+			}
+		}
+
+		descend, err := visitor(header.id, header.size, depth)
+		if err != nil {
+			return nil, err
+		}
+		if !descend {
+			if childUnknown {
+				return nil, fmt.Errorf("%w: cannot skip element 0x%X at %d", ErrUnknownSizeUnsupported, header.id, er.pos)
+			}
+			if _, err = er.Seek(childEnd, io.SeekStart); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if pending, err = er.walk(visitor, depth+1, childEnd, header.id, childUnknown); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// endsUnknownSize reports whether an element with the given ID ends its parent
+// of unknown size, as it cannot be its child.
+//
+// Parameters:
+//   - parent: The ID of the element of unknown size.
+//   - id: The ID of the element read in it.
+//
+// Returns:
+//   - bool: True if the element belongs to an ancestor of parent.
+func endsUnknownSize(parent, id uint32) bool {
+	switch id {
+	case IDEBMLHeader, IDSegment:
+		return true
+	case IDSeekHead, IDSegmentInfo, IDTracks, IDCues, IDChapters, IDTags, IDAttachments, IDCluster:
+		return parent != IDSegment
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package matroska
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEBMLReader_Walk(t *testing.T) {
+	mockFile, err := createMockMatroskaFile()
+	if err != nil {
+		t.Fatalf("Failed to create mock matroska file: %v", err)
+	}
+	masters := map[uint32]bool{IDEBMLHeader: true, IDSegment: true, IDSegmentInfo: true, IDTracks: true, IDCluster: true}
+
+	t.Run("Count elements", func(t *testing.T) {
+		counts := make(map[int]int)
+		var clusterChildren []uint32
+		inCluster := false
+		reader := NewEBMLReader(bytes.NewReader(mockFile))
+		err = reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+			counts[depth]++
+			if depth == 1 {
+				inCluster = id == IDCluster
+			} else if depth == 2 && inCluster {
+				clusterChildren = append(clusterChildren, id)
+			}
+			return masters[id], nil
+		})
+		if err != nil {
+			t.Fatalf("Walk() failed: %v", err)
+		}
+
+		// EBML header and Segment; DocType, SegmentInfo, Tracks and Cluster;
+		// Title, TimestampScale, TrackEntry, Timestamp and SimpleBlock
+		want := map[int]int{0: 2, 1: 4, 2: 5}
+		for depth, count := range want {
+			if counts[depth] != count {
+				t.Errorf("Expected %d elements at depth %d, got %d", count, depth, counts[depth])
+			}
+		}
+		if len(counts) != len(want) {
+			t.Errorf("Expected elements at %d depths, got %v", len(want), counts)
+		}
+		if len(clusterChildren) != 2 || clusterChildren[0] != IDTimestamp || clusterChildren[1] != IDSimpleBlock {
+			t.Errorf("Expected the Timestamp and SimpleBlock in the cluster, got %X", clusterChildren)
+		}
+	})
+
+	t.Run("Unknown-size clusters", func(t *testing.T) {
+		var segment, file bytes.Buffer
+		for i := 0; i < 2; i++ {
+			segment.Write([]byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+			putUIntElement(&segment, IDTimestamp, uint64(i))
+		}
+		putElement(&file, IDSegment, segment.Bytes())
+
+		var depths []int
+		reader := NewEBMLReader(bytes.NewReader(file.Bytes()))
+		err = reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+			depths = append(depths, depth)
+			return masters[id], nil
+		})
+		if err != nil {
+			t.Fatalf("Walk() failed: %v", err)
+		}
+		// Each cluster ends at the next one, and the last at the end of the segment
+		want := []int{0, 1, 2, 1, 2}
+		if len(depths) != len(want) {
+			t.Fatalf("Expected depths %v, got %v", want, depths)
+		}
+		for i := range want {
+			if depths[i] != want[i] {
+				t.Errorf("Expected depths %v, got %v", want, depths)
+				break
+			}
+		}
+	})
+
+	t.Run("Skipping an unknown size", func(t *testing.T) {
+		reader := NewEBMLReader(bytes.NewReader(mockFile))
+		err = reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+			return false, nil
+		})
+		if !errors.Is(err, ErrUnknownSizeUnsupported) {
+			t.Errorf("Expected ErrUnknownSizeUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("Visitor error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		visited := 0
+		reader := NewEBMLReader(bytes.NewReader(mockFile))
+		err = reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+			visited++
+			if id == IDTracks {
+				return false, errStop
+			}
+			return masters[id], nil
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("Expected the visitor error, got %v", err)
+		}
+		if visited != 7 {
+			t.Errorf("Expected the walk to stop at the 7th element, got %d", visited)
+		}
+	})
+
+	t.Run("Child overflowing its parent", func(t *testing.T) {
+		data := []byte{0x15, 0x49, 0xA9, 0x66, 0x83, 0x7B, 0xA9, 0x85, 'T', 'i', 't', 'l', 'e'}
+		reader := NewEBMLReader(bytes.NewReader(data))
+		err = reader.Walk(func(id uint32, size uint64, depth int) (bool, error) {
+			return masters[id], nil
+		})
+		if err == nil {
+			t.Error("Expected an error for a child overflowing its parent")
+		}
+	})
+}
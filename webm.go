@@ -0,0 +1,43 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the validation of the restrictions of WebM files, see
+// WithStrictWebM.
+package matroska
+
+import (
+	"fmt"
+	"strings"
+)
+
+// webmCodecs lists the codecs allowed in WebM files, other than the WebVTT
+// subtitle codecs, whose IDs start with webVTTCodecPrefix.
+var webmCodecs = map[string]bool{
+	"V_VP8":    true,
+	"V_VP9":    true,
+	"V_AV1":    true,
+	"A_OPUS":   true,
+	"A_VORBIS": true,
+}
+
+// webVTTCodecPrefix is the prefix of the codec IDs of the WebVTT subtitle
+// tracks allowed in WebM files, such as "D_WEBVTT/SUBTITLES".
+const webVTTCodecPrefix = "D_WEBVTT/"
+
+// validateWebM checks that the tracks of a WebM file only use the codecs
+// allowed by WebM: VP8, VP9 and AV1 video, Opus and Vorbis audio, and WebVTT
+// subtitles. Files of another DocType are not checked.
+//
+// Returns:
+//   - error: An error wrapping ErrInvalidWebM naming the first track with a
+//     codec that is not allowed.
+func (mp *MatroskaParser) validateWebM() error {
+	if mp.header == nil || mp.header.DocType != "webm" {
+		return nil
+	}
+	for _, track := range mp.tracks {
+		if webmCodecs[track.CodecID] || strings.HasPrefix(track.CodecID, webVTTCodecPrefix) {
+			continue
+		}
+		return fmt.Errorf("%w: track %d uses codec %q", ErrInvalidWebM, track.Number, track.CodecID)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package matroska
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// createDocTypeFile returns a file with the given DocType and a single track
+// using the given codec.
+func createDocTypeFile(t *testing.T, docType, codecID string) []byte {
+	t.Helper()
+	return buildTestFile(createEBMLHeader(docType, 4, 2), nil,
+		mockInfoElement(nil),
+		mockTracksElement(t, TypeVideo, codecID),
+	).data
+}
+
+func TestWithStrictWebM(t *testing.T) {
+	tests := []struct {
+		name    string
+		docType string
+		codecID string
+		strict  bool
+		wantErr bool
+	}{
+		{"WebM codec", "webm", "V_VP9", true, false},
+		{"WebVTT subtitles", "webm", "D_WEBVTT/SUBTITLES", true, false},
+		{"Disallowed codec", "webm", "V_MPEG4/ISO/AVC", true, true},
+		{"Disallowed codec without strict mode", "webm", "V_MPEG4/ISO/AVC", false, false},
+		{"Matroska file", "matroska", "V_MPEG4/ISO/AVC", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.strict {
+				opts = append(opts, WithStrictWebM())
+			}
+			data := createDocTypeFile(t, tt.docType, tt.codecID)
+			_, err := NewDemuxer(bytes.NewReader(data), opts...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWebM) {
+					t.Errorf("Expected ErrInvalidWebM, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("NewDemuxer() failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,516 @@
+// Package matroska provides utilities for working with Matroska/EBML format.
+// This file contains the Muxer, which writes packets and track metadata to a
+// new Matroska file.
+package matroska
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// muxerAppName is written as the MuxingApp and WritingApp of files created by the Muxer.
+const muxerAppName = "matroska-go"
+
+// maxClusterSize is the size in bytes after which the Muxer starts a new cluster.
+const maxClusterSize = 5 << 20
+
+// Muxer writes a Matroska file from track metadata and packets.
+//
+// A Muxer is used in four steps: the tracks are declared with AddTrack, the
+// file headers are written with WriteHeader, the packets are written in
+// presentation order with WritePacket, and the file is completed with
+// Finalize, which writes the remaining data and back-patches the sizes and
+// duration that were unknown when the headers were written.
+//
+// Packets are grouped into clusters by time. A new cluster is started when
+// a packet's timestamp no longer fits in a block's 16-bit relative timestamp,
+// when a keyframe of a video track is written, or when the current cluster
+// grows too large.
+//
+// Cues are written for the keyframes of video tracks, or of every track when
+// there is no video track, with at most one cue point per track and cluster.
+//
+// Example:
+//
+//	muxer := matroska.NewMuxer(out)
+//	videoTrack, err := muxer.AddTrack(&matroska.TrackInfo{Type: matroska.TypeVideo, CodecID: "V_VP9"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err = muxer.WriteHeader(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, frame := range frames {
+//	    packet := &matroska.Packet{Track: uint64(videoTrack), StartTime: frame.PTS, Data: frame.Data}
+//	    if err = muxer.WritePacket(packet); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+//	if err = muxer.Finalize(); err != nil {
+//	    log.Fatal(err)
+//	}
+type Muxer struct {
+	ew            *EBMLWriter
+	tracks        []*TrackInfo
+	timecodeScale uint64
+
+	headerWritten bool
+	finalized     bool
+
+	// Positions of the values back-patched by Finalize
+	segmentSizePos int64
+	durationPos    int64
+
+	// The cluster being written, kept in memory until it is complete
+	cluster           bytes.Buffer
+	clusterOpen       bool
+	clusterTimecode   uint64
+	lastTrackTimecode map[uint64]uint64
+
+	// Cue points of the written clusters, and those of the current cluster
+	// whose Position is set when the cluster is written
+	cues         []*Cue
+	clusterCues  []*Cue
+	cueAllTracks bool
+
+	// The end of the last packet written, in TimecodeScale units
+	endTimecode uint64
+}
+
+// NewMuxer creates a new Muxer writing to the given WriteSeeker.
+//
+// The WriteSeeker must support seeking so that Finalize can back-patch the
+// segment size and duration. Timestamps are written with a TimecodeScale of
+// 1,000,000, that is, with millisecond precision.
+//
+// Parameters:
+//   - w: The destination of the Matroska file.
+//
+// Returns:
+//   - *Muxer: The new Muxer.
+func NewMuxer(w io.WriteSeeker) *Muxer {
+	return &Muxer{
+		ew:                NewEBMLWriter(w),
+		timecodeScale:     1000000,
+		lastTrackTimecode: make(map[uint64]uint64),
+	}
+}
+
+// AddTrack declares a track to be written. Tracks must be added before
+// WriteHeader is called.
+//
+// The track is copied, and its Number is replaced by the number assigned by
+// the Muxer, which must be used as the Track of the packets written to it.
+// Content encodings are not written, as packets are expected to hold decoded
+// frames, such as those returned by Demuxer.ReadPacket.
+//
+// The track is written as enabled and default, which are the values of a
+// track without FlagEnabled and FlagDefault, whatever the Enabled and Default
+// fields of track, so that a TrackInfo built with only its type and codec
+// describes a playable track. Use SetTrackFlags to change them.
+//
+// Parameters:
+//   - track: The metadata of the track, such as its type, codec and CodecPrivate.
+//
+// Returns:
+//   - int: The number assigned to the track.
+//   - error: An error if the track is nil or the header was already written.
+func (m *Muxer) AddTrack(track *TrackInfo) (trackNum int, err error) {
+	if track == nil {
+		return 0, fmt.Errorf("track is nil")
+	}
+	if m.headerWritten {
+		return 0, fmt.Errorf("tracks cannot be added after the header is written")
+	}
+
+	info := *track
+	info.Number = uint64(len(m.tracks) + 1)
+	if info.UID == 0 {
+		info.UID = info.Number
+	}
+	info.Enabled, info.Default = true, true
+	m.tracks = append(m.tracks, &info)
+	return int(info.Number), nil
+}
+
+// SetTrackFlags sets the FlagEnabled and FlagDefault of a track added with
+// AddTrack. The flags must be set before WriteHeader is called.
+//
+// Example:
+//
+//	// Keep a commentary track, but do not play it by default
+//	commentary, err := muxer.AddTrack(audioTrack)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err = muxer.SetTrackFlags(commentary, true, false); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Parameters:
+//   - trackNum: The number of the track, as returned by AddTrack.
+//   - enabled: Whether the track is enabled and should be played.
+//   - isDefault: Whether the track is selected by default.
+//
+// Returns:
+//   - error: An error if the track is unknown or the header was already written.
+func (m *Muxer) SetTrackFlags(trackNum int, enabled, isDefault bool) error {
+	if m.headerWritten {
+		return fmt.Errorf("track flags cannot be set after the header is written")
+	}
+	if trackNum < 1 || trackNum > len(m.tracks) {
+		return fmt.Errorf("unknown track %d", trackNum)
+	}
+	track := m.tracks[trackNum-1]
+	track.Enabled, track.Default = enabled, isDefault
+	return nil
+}
+
+// WriteHeader writes the EBML header, and the start of the segment with its
+// SegmentInfo and Tracks elements.
+//
+// Returns:
+//   - error: An error if no track was added, the header was already written,
+//     or the data could not be written.
+func (m *Muxer) WriteHeader() error {
+	if m.headerWritten {
+		return fmt.Errorf("header already written")
+	}
+	if len(m.tracks) == 0 {
+		return fmt.Errorf("no tracks added")
+	}
+
+	var ebmlHeader bytes.Buffer
+	putUIntElement(&ebmlHeader, IDEBMLVersion, 1)
+	putUIntElement(&ebmlHeader, IDEBMLReadVersion, 1)
+	putUIntElement(&ebmlHeader, IDEBMLMaxIDLength, 4)
+	putUIntElement(&ebmlHeader, IDEBMLMaxSizeLength, 8)
+	putStringElement(&ebmlHeader, IDEBMLDocType, "matroska")
+	putUIntElement(&ebmlHeader, IDEBMLDocTypeVersion, 4)
+	putUIntElement(&ebmlHeader, IDEBMLDocTypeReadVersion, 2)
+
+	if err := m.ew.WriteBytes(IDEBMLHeader, ebmlHeader.Bytes()); err != nil {
+		return fmt.Errorf("failed to write EBML header: %w", err)
+	}
+
+	// The segment size is unknown until Finalize, which back-patches it
+	segmentSizePos, err := m.ew.StartMasterElement(IDSegment)
+	if err != nil {
+		return fmt.Errorf("failed to write segment header: %w", err)
+	}
+
+	var info bytes.Buffer
+	putUIntElement(&info, IDTimestampScale, m.timecodeScale)
+	putStringElement(&info, IDMuxingApp, muxerAppName)
+	putStringElement(&info, IDWritingApp, muxerAppName)
+	durationOffset := info.Len() + 3 // Past the ID and size of the Duration element
+	putFloatElement(&info, IDDuration, 0)
+	if err = m.ew.WriteElementHeader(IDSegmentInfo, uint64(info.Len())); err != nil {
+		return fmt.Errorf("failed to write segment info: %w", err)
+	}
+	durationPos := m.ew.Position() + int64(durationOffset)
+	if _, err = m.ew.Write(info.Bytes()); err != nil {
+		return fmt.Errorf("failed to write segment info: %w", err)
+	}
+
+	var tracks bytes.Buffer
+	for _, track := range m.tracks {
+		putElement(&tracks, IDTrackEntry, trackEntryData(track))
+	}
+	if err = m.ew.WriteBytes(IDTracks, tracks.Bytes()); err != nil {
+		return fmt.Errorf("failed to write tracks: %w", err)
+	}
+
+	m.cueAllTracks = true
+	for _, track := range m.tracks {
+		if track.Type == TypeVideo {
+			m.cueAllTracks = false
+		}
+	}
+
+	m.segmentSizePos = segmentSizePos
+	m.durationPos = durationPos
+	m.headerWritten = true
+	return nil
+}
+
+// WritePacket writes a packet to the current cluster, starting a new cluster
+// when needed.
+//
+// Packets must be written in increasing StartTime order within a cluster;
+// a packet whose timestamp precedes the current cluster starts a new one.
+// Packets whose duration (EndTime - StartTime) differs from the DefaultDuration
+// of their track are written as BlockGroups with a BlockDuration, and other
+// packets as SimpleBlocks. The KF, INVISIBLE and DISCARDABLE flags are kept,
+// except DISCARDABLE in a BlockGroup, which cannot store it.
+//
+// Parameters:
+//   - packet: The packet to write. Its Track must be a number returned by AddTrack.
+//
+// Returns:
+//   - error: An error if the header was not written, the track is unknown, or
+//     the data could not be written.
+func (m *Muxer) WritePacket(packet *Packet) error {
+	if !m.headerWritten {
+		return fmt.Errorf("header not written")
+	}
+	if m.finalized {
+		return fmt.Errorf("muxer already finalized")
+	}
+	if packet.Track == 0 || packet.Track > uint64(len(m.tracks)) {
+		return fmt.Errorf("unknown track %d", packet.Track)
+	}
+	track := m.tracks[packet.Track-1]
+	keyframe := packet.Flags&KF != 0
+
+	timecode := packet.StartTime / m.timecodeScale
+	newCluster := !m.clusterOpen ||
+		timecode < m.clusterTimecode ||
+		timecode-m.clusterTimecode > math.MaxInt16 ||
+		m.cluster.Len() >= maxClusterSize ||
+		(keyframe && track.Type == TypeVideo)
+	if newCluster {
+		if err := m.flushCluster(); err != nil {
+			return err
+		}
+		m.clusterOpen = true
+		m.clusterTimecode = timecode
+		putUIntElement(&m.cluster, IDTimestamp, timecode)
+	}
+
+	if keyframe && (m.cueAllTracks || track.Type == TypeVideo) && !m.hasClusterCue(packet.Track) {
+		m.clusterCues = append(m.clusterCues, &Cue{
+			Time:             timecode * m.timecodeScale,
+			RelativePosition: uint64(m.cluster.Len()),
+			Track:            packet.Track,
+		})
+	}
+
+	var block bytes.Buffer
+	block.Write(encodeVInt(packet.Track))
+	relative := int16(timecode - m.clusterTimecode)
+	_ = binary.Write(&block, binary.BigEndian, relative)
+
+	duration := uint64(0)
+	if packet.EndTime > packet.StartTime {
+		duration = packet.EndTime - packet.StartTime
+	}
+	invisible := byte(0x00)
+	if packet.Flags&INVISIBLE != 0 {
+		invisible = 0x08
+	}
+	if duration > 0 && duration != track.DefaultDuration {
+		block.WriteByte(invisible)
+		block.Write(packet.Data)
+
+		var group bytes.Buffer
+		putElement(&group, IDBlock, block.Bytes())
+		putUIntElement(&group, IDBlockDuration, duration/m.timecodeScale)
+		if previous, ok := m.lastTrackTimecode[packet.Track]; ok && !keyframe {
+			putIntElement(&group, IDReferenceBlock, int64(previous)-int64(timecode))
+		}
+		putElement(&m.cluster, IDBlockGroup, group.Bytes())
+	} else {
+		flags := invisible
+		if keyframe {
+			flags |= 0x80
+		}
+		if packet.Flags&DISCARDABLE != 0 {
+			flags |= 0x01
+		}
+		block.WriteByte(flags)
+		block.Write(packet.Data)
+		putElement(&m.cluster, IDSimpleBlock, block.Bytes())
+	}
+
+	m.lastTrackTimecode[packet.Track] = timecode
+	if end := (packet.StartTime + duration) / m.timecodeScale; end > m.endTimecode {
+		m.endTimecode = end
+	}
+	return nil
+}
+
+// Finalize writes the last cluster and the cues, and back-patches the segment
+// size and duration. The Muxer cannot be used after Finalize.
+//
+// Returns:
+//   - error: An error if the header was not written, or the data could not be
+//     written.
+func (m *Muxer) Finalize() error {
+	if !m.headerWritten {
+		return fmt.Errorf("header not written")
+	}
+	if m.finalized {
+		return fmt.Errorf("muxer already finalized")
+	}
+	if err := m.flushCluster(); err != nil {
+		return err
+	}
+	m.finalized = true
+
+	if len(m.cues) > 0 {
+		if err := m.ew.WriteBytes(IDCues, m.cuesData()); err != nil {
+			return fmt.Errorf("failed to write cues: %w", err)
+		}
+	}
+
+	if err := m.ew.WriteAt(encodeFloat(float64(m.endTimecode)), m.durationPos); err != nil {
+		return fmt.Errorf("failed to write duration: %w", err)
+	}
+	if err := m.ew.EndMasterElement(m.segmentSizePos); err != nil {
+		return fmt.Errorf("failed to write segment size: %w", err)
+	}
+	return nil
+}
+
+// flushCluster writes the current cluster, if any, to the output.
+//
+// Returns:
+//   - error: An error if the cluster could not be written.
+func (m *Muxer) flushCluster() error {
+	if !m.clusterOpen {
+		return nil
+	}
+	// Cue positions are relative to the start of the segment data
+	clusterPos := uint64(m.ew.Position() - m.segmentSizePos - int64(len(unknownSizeVInt)))
+	if err := m.ew.WriteBytes(IDCluster, m.cluster.Bytes()); err != nil {
+		return fmt.Errorf("failed to write cluster: %w", err)
+	}
+	for _, cue := range m.clusterCues {
+		cue.Position = clusterPos
+		m.cues = append(m.cues, cue)
+	}
+	m.clusterCues = m.clusterCues[:0]
+	m.cluster.Reset()
+	m.clusterOpen = false
+	return nil
+}
+
+// hasClusterCue reports whether the current cluster already has a cue point
+// for a track.
+//
+// Parameters:
+//   - trackNum: The number of the track.
+//
+// Returns:
+//   - bool: True if the track has a cue point in the current cluster.
+func (m *Muxer) hasClusterCue(trackNum uint64) bool {
+	for _, cue := range m.clusterCues {
+		if cue.Track == trackNum {
+			return true
+		}
+	}
+	return false
+}
+
+// cuesData builds the data of the Cues element from the cue points of the
+// written clusters.
+//
+// Returns:
+//   - []byte: The Cues data.
+func (m *Muxer) cuesData() []byte {
+	var cues bytes.Buffer
+	for _, cue := range m.cues {
+		var positions bytes.Buffer
+		putUIntElement(&positions, IDCueTrack, cue.Track)
+		putUIntElement(&positions, IDCueClusterPos, cue.Position)
+		putUIntElement(&positions, IDCueRelativePos, cue.RelativePosition)
+
+		var point bytes.Buffer
+		putUIntElement(&point, IDCueTime, cue.Time/m.timecodeScale)
+		putElement(&point, IDCueTrackPosition, positions.Bytes())
+		putElement(&cues, IDCuePoint, point.Bytes())
+	}
+	return cues.Bytes()
+}
+
+// trackEntryData builds the data of the TrackEntry element of a track.
+//
+// Parameters:
+//   - track: The track to describe.
+//
+// Returns:
+//   - []byte: The TrackEntry data.
+func trackEntryData(track *TrackInfo) []byte {
+	var entry bytes.Buffer
+	putUIntElement(&entry, IDTrackNum, track.Number)
+	putUIntElement(&entry, IDTrackUID, track.UID)
+	putUIntElement(&entry, IDTrackType, uint64(track.Type))
+	if !track.Enabled {
+		putUIntElement(&entry, IDFlagEnabled, 0)
+	}
+	if !track.Default {
+		putUIntElement(&entry, IDFlagDefault, 0)
+	}
+	if track.Forced {
+		putUIntElement(&entry, IDFlagForced, 1)
+	}
+	putUIntElement(&entry, IDFlagLacing, 0)
+	if track.DefaultDuration > 0 {
+		putUIntElement(&entry, IDDefaultDuration, track.DefaultDuration)
+	}
+	if track.CodecDelay > 0 {
+		putUIntElement(&entry, IDCodecDelay, track.CodecDelay)
+	}
+	if track.SeekPreRoll > 0 {
+		putUIntElement(&entry, IDSeekPreRoll, track.SeekPreRoll)
+	}
+	if track.Name != "" {
+		putStringElement(&entry, IDTrackName, track.Name)
+	}
+	if track.Language != "" {
+		putStringElement(&entry, IDLanguage, track.Language)
+	}
+	if track.LanguageIETF != "" {
+		putStringElement(&entry, IDLanguageIETF, track.LanguageIETF)
+	}
+	putStringElement(&entry, IDCodecID, track.CodecID)
+	if len(track.CodecPrivate) > 0 {
+		putElement(&entry, IDCodecPriv, track.CodecPrivate)
+	}
+	if track.CodecName != "" {
+		putStringElement(&entry, IDCodecName, track.CodecName)
+	}
+
+	switch track.Type {
+	case TypeVideo:
+		var video bytes.Buffer
+		if track.Video.Interlaced {
+			putUIntElement(&video, IDFlagInterlaced, 1)
+		}
+		putUIntElement(&video, IDPixelWidth, uint64(track.Video.PixelWidth))
+		putUIntElement(&video, IDPixelHeight, uint64(track.Video.PixelHeight))
+		if track.Video.DisplayWidth > 0 && track.Video.DisplayHeight > 0 {
+			putUIntElement(&video, IDDisplayWidth, uint64(track.Video.DisplayWidth))
+			putUIntElement(&video, IDDisplayHeight, uint64(track.Video.DisplayHeight))
+		}
+		if track.Video.DisplayUnit != 0 {
+			putUIntElement(&video, IDDisplayUnit, uint64(track.Video.DisplayUnit))
+		}
+		putElement(&entry, IDVideo, video.Bytes())
+	case TypeAudio:
+		var audio bytes.Buffer
+		putFloatElement(&audio, IDSamplingFrequency, track.Audio.SamplingFreq)
+		if track.Audio.OutputSamplingFreq > 0 && track.Audio.OutputSamplingFreq != track.Audio.SamplingFreq {
+			putFloatElement(&audio, IDOutputSamplingFrequency, track.Audio.OutputSamplingFreq)
+		}
+		putUIntElement(&audio, IDChannels, uint64(track.Audio.Channels))
+		if track.Audio.BitDepth > 0 {
+			putUIntElement(&audio, IDBitDepth, uint64(track.Audio.BitDepth))
+		}
+		putElement(&entry, IDAudio, audio.Bytes())
+	}
+
+	return entry.Bytes()
+}
+
+// boolToUInt converts a flag to its EBML unsigned integer value.
+func boolToUInt(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package matroska
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMuxer_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "muxed.mkv")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer out.Close()
+
+	muxer := NewMuxer(out)
+	video := &TrackInfo{
+		Type:            TypeVideo,
+		CodecID:         "V_VP9",
+		DefaultDuration: 40000000,
+		Language:        "und",
+	}
+	video.Video.PixelWidth = 320
+	video.Video.PixelHeight = 240
+	videoTrack, err := muxer.AddTrack(video)
+	if err != nil {
+		t.Fatalf("AddTrack() failed: %v", err)
+	}
+	subtitleTrack, err := muxer.AddTrack(&TrackInfo{
+		Type:         TypeSubtitle,
+		CodecID:      "S_TEXT/UTF8",
+		CodecPrivate: []byte{0x01, 0x02},
+		Name:         "English",
+		Language:     "eng",
+	})
+	if err != nil {
+		t.Fatalf("AddTrack() failed: %v", err)
+	}
+	if videoTrack != 1 || subtitleTrack != 2 {
+		t.Fatalf("Unexpected track numbers %d and %d", videoTrack, subtitleTrack)
+	}
+	if err = muxer.SetTrackFlags(subtitleTrack, true, false); err != nil {
+		t.Fatalf("SetTrackFlags() failed: %v", err)
+	}
+	if err = muxer.SetTrackFlags(3, true, true); err == nil {
+		t.Error("Expected an error setting the flags of an unknown track")
+	}
+
+	if err = muxer.WritePacket(&Packet{Track: 1}); err == nil {
+		t.Error("Expected an error writing a packet before the header")
+	}
+	if err = muxer.WriteHeader(); err != nil {
+		t.Fatalf("WriteHeader() failed: %v", err)
+	}
+	if _, err = muxer.AddTrack(video); err == nil {
+		t.Error("Expected an error adding a track after the header")
+	}
+	if err = muxer.SetTrackFlags(videoTrack, false, false); err == nil {
+		t.Error("Expected an error setting track flags after the header")
+	}
+
+	packets := []*Packet{
+		{Track: 1, StartTime: 0, EndTime: 40000000, Data: []byte{0x01}, Flags: KF},
+		{Track: 2, StartTime: 0, EndTime: 1500000000, Data: []byte("Hello")},
+		{Track: 1, StartTime: 40000000, EndTime: 80000000, Data: []byte{0x02}, Flags: INVISIBLE | DISCARDABLE},
+		{Track: 1, StartTime: 80000000, EndTime: 120000000, Data: []byte{0x03}, Flags: KF},
+		{Track: 1, StartTime: 40000000000, EndTime: 40040000000, Data: []byte{0x04}},
+	}
+	for _, packet := range packets {
+		if err = muxer.WritePacket(packet); err != nil {
+			t.Fatalf("WritePacket() failed: %v", err)
+		}
+	}
+	if err = muxer.WritePacket(&Packet{Track: 3}); err == nil {
+		t.Error("Expected an error writing a packet to an unknown track")
+	}
+	if err = muxer.Finalize(); err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+
+	if _, err = out.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	demuxer, err := NewDemuxer(out)
+	if err != nil {
+		t.Fatalf("NewDemuxer() failed: %v", err)
+	}
+
+	info, _ := demuxer.GetFileInfo()
+	if info.Duration != 40040 || info.MuxingApp != "matroska-go" {
+		t.Errorf("Unexpected file info: duration=%f muxingApp=%q", info.Duration, info.MuxingApp)
+	}
+
+	numTracks, _ := demuxer.GetNumTracks()
+	if numTracks != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", numTracks)
+	}
+	gotVideo, _ := demuxer.GetTrackInfo(0)
+	if gotVideo.CodecID != "V_VP9" || gotVideo.Video.PixelWidth != 320 || gotVideo.Video.PixelHeight != 240 || gotVideo.DefaultDuration != 40000000 ||
+		!gotVideo.Enabled || !gotVideo.Default {
+		t.Errorf("Unexpected video track: %+v", gotVideo)
+	}
+	gotSubtitle, _ := demuxer.GetTrackInfo(1)
+	if gotSubtitle.CodecID != "S_TEXT/UTF8" || gotSubtitle.Name != "English" || !gotSubtitle.Enabled || gotSubtitle.Default || !bytes.Equal(gotSubtitle.CodecPrivate, []byte{0x01, 0x02}) {
+		t.Errorf("Unexpected subtitle track: %+v", gotSubtitle)
+	}
+
+	for i, want := range packets {
+		got, err := demuxer.ReadPacket()
+		if err != nil {
+			t.Fatalf("ReadPacket() %d failed: %v", i, err)
+		}
+		if got.Track != want.Track || got.StartTime != want.StartTime || got.EndTime != want.EndTime || !bytes.Equal(got.Data, want.Data) {
+			t.Errorf("Packet %d = track %d [%d, %d] %x, want track %d [%d, %d] %x",
+				i, got.Track, got.StartTime, got.EndTime, got.Data, want.Track, want.StartTime, want.EndTime, want.Data)
+		}
+		if got.Flags != want.Flags && want.Track == 1 {
+			t.Errorf("Packet %d flags = 0x%X, want 0x%X", i, got.Flags, want.Flags)
+		}
+	}
+	if _, err = demuxer.ReadPacket(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}